@@ -0,0 +1,101 @@
+package pgxutil
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// AdvisoryLockKey identifies a PostgreSQL advisory lock. PostgreSQL has two independent key spaces for advisory locks:
+// a single int64 and a pair of int32. Create an AdvisoryLockKey with Int64AdvisoryLockKey or Int32PairAdvisoryLockKey.
+type AdvisoryLockKey struct {
+	key  int64
+	key1 int32
+	key2 int32
+	pair bool
+}
+
+// Int64AdvisoryLockKey returns the AdvisoryLockKey for the single int64 key.
+func Int64AdvisoryLockKey(key int64) AdvisoryLockKey {
+	return AdvisoryLockKey{key: key}
+}
+
+// Int32PairAdvisoryLockKey returns the AdvisoryLockKey for the pair of int32 keys.
+func Int32PairAdvisoryLockKey(key1, key2 int32) AdvisoryLockKey {
+	return AdvisoryLockKey{key1: key1, key2: key2, pair: true}
+}
+
+func (k AdvisoryLockKey) String() string {
+	if k.pair {
+		return fmt.Sprintf("(%d, %d)", k.key1, k.key2)
+	}
+	return fmt.Sprintf("%d", k.key)
+}
+
+// call returns the sql and arguments to call the advisory lock function fn with k.
+func (k AdvisoryLockKey) call(fn string) (string, []interface{}) {
+	if k.pair {
+		return "select " + fn + "($1, $2)", []interface{}{k.key1, k.key2}
+	}
+	return "select " + fn + "($1)", []interface{}{k.key}
+}
+
+// AcquireAdvisoryLock waits for and acquires the session level advisory lock key. The lock is held until it is released
+// with ReleaseAdvisoryLock on the same connection or the connection is closed. Do not pass a *pgxpool.Pool as db as
+// the lock would be acquired on an arbitrary connection.
+func AcquireAdvisoryLock(ctx context.Context, db Execer, key AdvisoryLockKey) error {
+	sql, args := key.call("pg_advisory_lock")
+	_, err := Exec(ctx, db, sql, args...)
+	return err
+}
+
+// TryAdvisoryLock is like AcquireAdvisoryLock except it does not wait. It returns true if the lock was acquired.
+func TryAdvisoryLock(ctx context.Context, db Queryer, key AdvisoryLockKey) (bool, error) {
+	sql, args := key.call("pg_try_advisory_lock")
+	return SelectBool(ctx, db, sql, args...)
+}
+
+// ReleaseAdvisoryLock releases the session level advisory lock key. An error is returned if the lock was not held by
+// the connection.
+func ReleaseAdvisoryLock(ctx context.Context, db Queryer, key AdvisoryLockKey) error {
+	sql, args := key.call("pg_advisory_unlock")
+	released, err := SelectBool(ctx, db, sql, args...)
+	if err != nil {
+		return err
+	}
+	if !released {
+		return fmt.Errorf("advisory lock %v was not held", key)
+	}
+
+	return nil
+}
+
+// AcquireAdvisoryXactLock waits for and acquires the transaction level advisory lock key. The lock is released when
+// the transaction ends.
+func AcquireAdvisoryXactLock(ctx context.Context, tx pgx.Tx, key AdvisoryLockKey) error {
+	sql, args := key.call("pg_advisory_xact_lock")
+	_, err := Exec(ctx, tx, sql, args...)
+	return err
+}
+
+// TryAdvisoryXactLock is like AcquireAdvisoryXactLock except it does not wait. It returns true if the lock was
+// acquired.
+func TryAdvisoryXactLock(ctx context.Context, tx pgx.Tx, key AdvisoryLockKey) (bool, error) {
+	sql, args := key.call("pg_try_advisory_xact_lock")
+	return SelectBool(ctx, tx, sql, args...)
+}
+
+// WithAdvisoryLock calls fn in a transaction while holding the advisory lock key. It waits for the lock if it is held
+// elsewhere. The lock is transaction level so it is always released when the transaction ends, even if the connection
+// is lost. db can safely be a *pgxpool.Pool. The transaction is committed or rolled back as described by WithTx.
+func WithAdvisoryLock(ctx context.Context, db Beginner, key AdvisoryLockKey, fn func(pgx.Tx) error) error {
+	return WithTx(ctx, db, func(tx pgx.Tx) error {
+		err := AcquireAdvisoryXactLock(ctx, tx, key)
+		if err != nil {
+			return err
+		}
+
+		return fn(tx)
+	})
+}
@@ -0,0 +1,127 @@
+package pgxutil_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgxutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdvisoryLock(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn1 := connectPG(t, ctx)
+	defer closeConn(t, conn1)
+	conn2 := connectPG(t, ctx)
+	defer closeConn(t, conn2)
+
+	keys := []pgxutil.AdvisoryLockKey{
+		pgxutil.Int64AdvisoryLockKey(7340001),
+		pgxutil.Int32PairAdvisoryLockKey(734, 1),
+	}
+	for i, key := range keys {
+		err := pgxutil.AcquireAdvisoryLock(ctx, conn1, key)
+		require.NoErrorf(t, err, "%d. %v", i, key)
+
+		acquired, err := pgxutil.TryAdvisoryLock(ctx, conn2, key)
+		require.NoErrorf(t, err, "%d. %v", i, key)
+		assert.Falsef(t, acquired, "%d. %v", i, key)
+
+		err = pgxutil.ReleaseAdvisoryLock(ctx, conn1, key)
+		require.NoErrorf(t, err, "%d. %v", i, key)
+
+		acquired, err = pgxutil.TryAdvisoryLock(ctx, conn2, key)
+		require.NoErrorf(t, err, "%d. %v", i, key)
+		assert.Truef(t, acquired, "%d. %v", i, key)
+
+		err = pgxutil.ReleaseAdvisoryLock(ctx, conn2, key)
+		require.NoErrorf(t, err, "%d. %v", i, key)
+	}
+
+	err := pgxutil.ReleaseAdvisoryLock(ctx, conn1, pgxutil.Int32PairAdvisoryLockKey(734, 1))
+	assert.EqualError(t, err, "advisory lock (734, 1) was not held")
+}
+
+func TestAcquireAdvisoryLockError(t *testing.T) {
+	t.Parallel()
+
+	// Like the other lock functions, AcquireAdvisoryLock reports a failed query as a QueryError.
+	db := &flakyDB{failures: 1, err: errors.New("connection reset")}
+	err := pgxutil.AcquireAdvisoryLock(context.Background(), db, pgxutil.Int64AdvisoryLockKey(42))
+	var queryErr *pgxutil.QueryError
+	require.True(t, errors.As(err, &queryErr))
+	assert.Equal(t, "select pg_advisory_lock($1)", queryErr.SQL)
+	assert.Equal(t, 1, db.attempts)
+}
+
+func TestAdvisoryXactLock(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn1 := connectPG(t, ctx)
+	defer closeConn(t, conn1)
+	conn2 := connectPG(t, ctx)
+	defer closeConn(t, conn2)
+
+	key := pgxutil.Int64AdvisoryLockKey(7340002)
+
+	tx, err := conn1.Begin(ctx)
+	require.NoError(t, err)
+	defer tx.Rollback(ctx)
+
+	err = pgxutil.AcquireAdvisoryXactLock(ctx, tx, key)
+	require.NoError(t, err)
+
+	acquired, err := pgxutil.TryAdvisoryLock(ctx, conn2, key)
+	require.NoError(t, err)
+	assert.False(t, acquired)
+
+	err = tx.Commit(ctx)
+	require.NoError(t, err)
+
+	tx, err = conn2.Begin(ctx)
+	require.NoError(t, err)
+	defer tx.Rollback(ctx)
+
+	acquired, err = pgxutil.TryAdvisoryXactLock(ctx, tx, key)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+}
+
+func TestWithAdvisoryLock(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn1 := connectPG(t, ctx)
+	defer closeConn(t, conn1)
+	conn2 := connectPG(t, ctx)
+	defer closeConn(t, conn2)
+
+	key := pgxutil.Int64AdvisoryLockKey(7340003)
+	called := false
+	err := pgxutil.WithAdvisoryLock(ctx, conn1, key, func(tx pgx.Tx) error {
+		called = true
+		acquired, err := pgxutil.TryAdvisoryLock(ctx, conn2, key)
+		require.NoError(t, err)
+		assert.False(t, acquired)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.True(t, called)
+
+	acquired, err := pgxutil.TryAdvisoryLock(ctx, conn2, key)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+}
@@ -0,0 +1,178 @@
+package pgxutil
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+)
+
+// BatchSender is the interface used by SendBatch. It is implemented by *pgx.Conn, pgx.Tx, and *pgxpool.Pool.
+type BatchSender interface {
+	SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
+}
+
+// Batch queues queries to be sent to the server in a single round trip with SendBatch. Each query has a handler that
+// receives its typed result. The zero value is ready to use.
+//
+// Options cannot be passed as the arguments of a queued query. SendBatch returns an error if they are. Options attached
+// to the ctx passed to SendBatch are ignored.
+//
+// Results in a batch are always read in the binary format. Selected values must be of a type pgx can scan into the
+// requested Go type. For example, QueueSelect[string] requires a text column.
+type Batch struct {
+	batch    pgx.Batch
+	handlers []func(ctx context.Context, br pgx.BatchResults) error
+	err      error
+}
+
+// Len returns the number of queued queries.
+func (b *Batch) Len() int {
+	return len(b.handlers)
+}
+
+func (b *Batch) queue(sql string, args []interface{}, handler func(ctx context.Context, br pgx.BatchResults) error) {
+	// The queries of a batch share one round trip, so Options cannot be applied to a single query. They are rejected
+	// rather than sent to PostgreSQL as parameters.
+	var err error
+	queryArgs := make([]interface{}, 0, len(args))
+	for _, arg := range args {
+		if _, ok := arg.(Options); ok {
+			err = fmt.Errorf("Options are not supported in a batch")
+			continue
+		}
+		queryArgs = append(queryArgs, arg)
+	}
+
+	if err == nil {
+		sql, queryArgs, err = rewriteNamedArgs(sql, queryArgs)
+	}
+	args = queryArgs
+	if err != nil && b.err == nil {
+		b.err = fmt.Errorf("batch query %d: %w", len(b.handlers), err)
+	}
+
+	b.batch.Queue(sql, args...)
+	b.handlers = append(b.handlers, handler)
+}
+
+// QueueExec queues a statement that does not return rows. fn is called with its command tag. fn may be nil.
+func (b *Batch) QueueExec(sql string, args []interface{}, fn func(pgconn.CommandTag) error) {
+	b.queue(sql, args, func(ctx context.Context, br pgx.BatchResults) error {
+		ct, err := br.Exec()
+		if err != nil {
+			return err
+		}
+
+		if fn == nil {
+			return nil
+		}
+		return fn(ct)
+	})
+}
+
+// QueueSelectInt64 queues a query that selects a single int64. It is like SelectInt64 except the value must be an
+// integer type.
+func (b *Batch) QueueSelectInt64(sql string, args []interface{}, fn func(int64) error) {
+	QueueSelect(b, sql, args, fn)
+}
+
+// QueueSelectString queues a query that selects a single string. It is like SelectString except the value must be a
+// text type.
+func (b *Batch) QueueSelectString(sql string, args []interface{}, fn func(string) error) {
+	QueueSelect(b, sql, args, fn)
+}
+
+// QueueSelectMap queues a query that selects a single row into a map. It is like SelectMap.
+func (b *Batch) QueueSelectMap(sql string, args []interface{}, fn func(map[string]interface{}) error) {
+	b.queue(sql, args, func(ctx context.Context, br pgx.BatchResults) error {
+		v, err := SelectMap(ctx, batchQueryer{br: br}, "")
+		if err != nil {
+			return err
+		}
+		return fn(v)
+	})
+}
+
+// QueueSelectAllMap queues a query that selects rows into maps. It is like SelectAllMap.
+func (b *Batch) QueueSelectAllMap(sql string, args []interface{}, fn func([]map[string]interface{}) error) {
+	b.queue(sql, args, func(ctx context.Context, br pgx.BatchResults) error {
+		v, err := SelectAllMap(ctx, batchQueryer{br: br}, "")
+		if err != nil {
+			return err
+		}
+		return fn(v)
+	})
+}
+
+// QueueSelectStruct queues a query that selects a single row into dst. It is like SelectStruct. dst is populated when
+// the batch is sent.
+func (b *Batch) QueueSelectStruct(dst interface{}, sql string, args []interface{}) {
+	b.queue(sql, args, func(ctx context.Context, br pgx.BatchResults) error {
+		return SelectStruct(ctx, batchQueryer{br: br}, dst, "")
+	})
+}
+
+// QueueSelectAllStruct queues a query that selects rows into dst. It is like SelectAllStruct. dst is populated when
+// the batch is sent.
+func (b *Batch) QueueSelectAllStruct(dst interface{}, sql string, args []interface{}) {
+	b.queue(sql, args, func(ctx context.Context, br pgx.BatchResults) error {
+		return SelectAllStruct(ctx, batchQueryer{br: br}, dst, "")
+	})
+}
+
+// QueueSelect queues a query on b that selects a single value of type T. It is like Select.
+func QueueSelect[T any](b *Batch, sql string, args []interface{}, fn func(T) error) {
+	b.queue(sql, args, func(ctx context.Context, br pgx.BatchResults) error {
+		v, err := Select[T](ctx, batchQueryer{br: br}, "")
+		if err != nil {
+			return err
+		}
+		return fn(v)
+	})
+}
+
+// QueueSelectAll queues a query on b that selects a column of type T. It is like SelectAll.
+func QueueSelectAll[T any](b *Batch, sql string, args []interface{}, fn func([]T) error) {
+	b.queue(sql, args, func(ctx context.Context, br pgx.BatchResults) error {
+		v, err := SelectAll[T](ctx, batchQueryer{br: br}, "")
+		if err != nil {
+			return err
+		}
+		return fn(v)
+	})
+}
+
+// SendBatch sends all queries queued on b in a single round trip and calls their handlers in order. It stops at and
+// returns the first error from a query or a handler.
+func SendBatch(ctx context.Context, db BatchSender, b *Batch) error {
+	if b.err != nil {
+		return b.err
+	}
+
+	// The handlers read the results with the Select functions, which would apply the Options of ctx to the empty sql
+	// they are given, so they are called without them.
+	handlerCtx := context.WithValue(ctx, optionsContextKey{}, Options{})
+
+	br := db.SendBatch(ctx, &b.batch)
+	for i, handler := range b.handlers {
+		err := handler(handlerCtx, br)
+		if err != nil {
+			br.Close()
+			return fmt.Errorf("batch query %d: %w", i, err)
+		}
+	}
+
+	return br.Close()
+}
+
+// batchQueryer adapts pgx.BatchResults to Queryer so the Select functions can read batch results. The sql and
+// arguments passed to Query are ignored as they were already queued.
+type batchQueryer struct {
+	br pgx.BatchResults
+}
+
+func (bq batchQueryer) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	return bq.br.Query()
+}
@@ -0,0 +1,174 @@
+package pgxutil_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgxutil"
+	"github.com/jackc/pgxutil/pgxutiltest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendBatch(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		_, err := tx.Exec(ctx, `create temporary table t (id serial primary key, name text, height int)`)
+		require.NoError(t, err)
+
+		type person struct {
+			ID     int32
+			Name   string
+			Height int32
+		}
+
+		var b pgxutil.Batch
+		var rowsAffected int64
+		var count int64
+		var name string
+		var heights []int32
+		var row map[string]interface{}
+		var people []person
+
+		b.QueueExec("insert into t (name, height) values ($1, $2), ($3, $4)", []interface{}{"Adam", 72, "Bill", 68}, func(ct pgconn.CommandTag) error {
+			rowsAffected = ct.RowsAffected()
+			return nil
+		})
+		b.QueueSelectInt64("select count(*) from t", nil, func(n int64) error {
+			count = n
+			return nil
+		})
+		b.QueueSelectString("select name from t where height = :height", []interface{}{pgxutil.NamedArgs{"height": 68}}, func(s string) error {
+			name = s
+			return nil
+		})
+		pgxutil.QueueSelectAll(&b, "select height from t order by id", nil, func(v []int32) error {
+			heights = v
+			return nil
+		})
+		b.QueueSelectMap("select name, height from t where id = $1", []interface{}{1}, func(m map[string]interface{}) error {
+			row = m
+			return nil
+		})
+		b.QueueSelectAllStruct(&people, "select id, name, height from t order by id", nil)
+		assert.Equal(t, 6, b.Len())
+
+		err = pgxutil.SendBatch(ctx, tx, &b)
+		require.NoError(t, err)
+
+		assert.EqualValues(t, 2, rowsAffected)
+		assert.EqualValues(t, 2, count)
+		assert.Equal(t, "Bill", name)
+		assert.Equal(t, []int32{72, 68}, heights)
+		assert.Equal(t, map[string]interface{}{"name": "Adam", "height": int32(72)}, row)
+		assert.Equal(t, []person{{1, "Adam", 72}, {2, "Bill", 68}}, people)
+	})
+}
+
+func TestSendBatchHandlerError(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		handlerErr := errors.New("handler failed")
+		called := false
+
+		var b pgxutil.Batch
+		b.QueueSelectInt64("select 1", nil, func(n int64) error {
+			return handlerErr
+		})
+		b.QueueSelectInt64("select 2", nil, func(n int64) error {
+			called = true
+			return nil
+		})
+
+		err := pgxutil.SendBatch(ctx, tx, &b)
+		assert.True(t, errors.Is(err, handlerErr))
+		assert.False(t, called)
+	})
+}
+
+func TestSendBatchNoRows(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		var b pgxutil.Batch
+		b.QueueSelectString("select 'foo' where false", nil, func(s string) error {
+			return nil
+		})
+
+		err := pgxutil.SendBatch(ctx, tx, &b)
+		assert.True(t, errors.Is(err, pgxutil.ErrNoRows))
+	})
+}
+
+func TestSendBatchOptions(t *testing.T) {
+	t.Parallel()
+
+	var b pgxutil.Batch
+	b.QueueExec("select 1", nil, nil)
+	b.QueueSelectInt64("select $1::int8", []interface{}{1, pgxutil.Options{ReadOnly: true}}, func(n int64) error {
+		return nil
+	})
+
+	err := pgxutil.SendBatch(context.Background(), nil, &b)
+	assert.EqualError(t, err, "batch query 1: Options are not supported in a batch")
+}
+
+// fakeBatchSender returns the results of the queries of a batch from FakeQueryer in order.
+type fakeBatchSender struct {
+	pgxutiltest.FakeQueryer
+}
+
+func (f *fakeBatchSender) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults {
+	return &fakeBatchResults{db: f}
+}
+
+type fakeBatchResults struct {
+	db *fakeBatchSender
+}
+
+func (br *fakeBatchResults) Exec() (pgconn.CommandTag, error) {
+	return pgconn.CommandTag("SELECT 1"), nil
+}
+
+func (br *fakeBatchResults) Query() (pgx.Rows, error) {
+	return br.db.Query(context.Background(), "batch")
+}
+
+func (br *fakeBatchResults) QueryRow() pgx.Row {
+	return nil
+}
+
+func (br *fakeBatchResults) Close() error {
+	return nil
+}
+
+func TestSendBatchContextOptions(t *testing.T) {
+	t.Parallel()
+
+	db := &fakeBatchSender{}
+	db.ExpectQuery("batch").ReturnRows(pgxutiltest.NewRows("n").AddRow(int64(1)))
+	db.ExpectQuery("batch").ReturnRows(pgxutiltest.NewRows("n").AddRow(int64(2)))
+
+	var n int64
+	var m map[string]interface{}
+	var b pgxutil.Batch
+	b.QueueSelectInt64("select 1", nil, func(v int64) error {
+		n = v
+		return nil
+	})
+	b.QueueSelectMap("select 2 as n", nil, func(v map[string]interface{}) error {
+		m = v
+		return nil
+	})
+
+	// The Options of ctx are not applied to the empty sql the results are read with.
+	ctx := pgxutil.WithOptions(context.Background(), pgxutil.Options{ReadOnly: true, Lock: pgxutil.LockForUpdate, Timeout: time.Second})
+	err := pgxutil.SendBatch(ctx, db, &b)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, n)
+	assert.Equal(t, map[string]interface{}{"n": int64(2)}, m)
+	require.NoError(t, db.ExpectationsWereMet())
+}
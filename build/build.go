@@ -0,0 +1,91 @@
+// Package build helps construct dynamic SQL with safely quoted identifiers and positional placeholders.
+package build
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// SanitizedSQL is a fragment of SQL that is safe to include in a query. Identifiers in it are quoted and values are
+// referenced by placeholders rather than interpolated.
+type SanitizedSQL string
+
+// QuoteIdentifier quotes an identifier. Multiple parts are joined with periods. e.g. QuoteIdentifier("public", "users")
+// returns "public"."users".
+func QuoteIdentifier(parts ...string) SanitizedSQL {
+	return SanitizedSQL(pgx.Identifier(parts).Sanitize())
+}
+
+// QuoteIdentifierList quotes each name and joins them with commas.
+func QuoteIdentifierList(names []string) SanitizedSQL {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = string(QuoteIdentifier(name))
+	}
+	return SanitizedSQL(strings.Join(quoted, ", "))
+}
+
+// Placeholders returns n comma separated placeholders starting at $start.
+func Placeholders(start, n int) SanitizedSQL {
+	sb := &strings.Builder{}
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteByte('$')
+		sb.WriteString(strconv.Itoa(start + i))
+	}
+	return SanitizedSQL(sb.String())
+}
+
+// SortedColumns returns the keys of values in sorted order and the corresponding values.
+func SortedColumns(values map[string]interface{}) ([]string, []interface{}) {
+	columns := make([]string, 0, len(values))
+	for k := range values {
+		columns = append(columns, k)
+	}
+	sort.Strings(columns)
+
+	args := make([]interface{}, len(columns))
+	for i, c := range columns {
+		args[i] = values[c]
+	}
+
+	return columns, args
+}
+
+// ColumnValues returns the quoted column list and placeholder list for inserting values. The placeholders start at
+// $start. The returned args are in the same order as the columns and placeholders.
+func ColumnValues(values map[string]interface{}, start int) (columns SanitizedSQL, placeholders SanitizedSQL, args []interface{}) {
+	columnNames, args := SortedColumns(values)
+	return QuoteIdentifierList(columnNames), Placeholders(start, len(columnNames)), args
+}
+
+// Assignments returns a comma separated list of column = placeholder pairs for values. e.g. "name" = $1. The
+// placeholders start at $start.
+func Assignments(values map[string]interface{}, start int) (SanitizedSQL, []interface{}) {
+	return joinedComparisons(values, start, ", ")
+}
+
+// Conditions returns column = placeholder pairs for values joined with and. e.g. "id" = $1 and "name" = $2. The
+// placeholders start at $start.
+func Conditions(values map[string]interface{}, start int) (SanitizedSQL, []interface{}) {
+	return joinedComparisons(values, start, " and ")
+}
+
+func joinedComparisons(values map[string]interface{}, start int, sep string) (SanitizedSQL, []interface{}) {
+	columnNames, args := SortedColumns(values)
+	sb := &strings.Builder{}
+	for i, c := range columnNames {
+		if i > 0 {
+			sb.WriteString(sep)
+		}
+		sb.WriteString(string(QuoteIdentifier(c)))
+		sb.WriteString(" = $")
+		sb.WriteString(strconv.Itoa(start + i))
+	}
+	return SanitizedSQL(sb.String()), args
+}
@@ -0,0 +1,51 @@
+package build_test
+
+import (
+	"testing"
+
+	"github.com/jackc/pgxutil/build"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuoteIdentifier(t *testing.T) {
+	tests := []struct {
+		parts  []string
+		result build.SanitizedSQL
+	}{
+		{[]string{"users"}, `"users"`},
+		{[]string{"public", "users"}, `"public"."users"`},
+		{[]string{`we"ird`}, `"we""ird"`},
+	}
+	for i, tt := range tests {
+		assert.Equalf(t, tt.result, build.QuoteIdentifier(tt.parts...), "%d", i)
+	}
+}
+
+func TestQuoteIdentifierList(t *testing.T) {
+	assert.Equal(t, build.SanitizedSQL(`"a", "b"`), build.QuoteIdentifierList([]string{"a", "b"}))
+	assert.Equal(t, build.SanitizedSQL(""), build.QuoteIdentifierList(nil))
+}
+
+func TestPlaceholders(t *testing.T) {
+	assert.Equal(t, build.SanitizedSQL("$3, $4, $5"), build.Placeholders(3, 3))
+	assert.Equal(t, build.SanitizedSQL(""), build.Placeholders(1, 0))
+}
+
+func TestColumnValues(t *testing.T) {
+	columns, placeholders, args := build.ColumnValues(map[string]interface{}{"name": "Adam", "height": 72}, 1)
+	assert.Equal(t, build.SanitizedSQL(`"height", "name"`), columns)
+	assert.Equal(t, build.SanitizedSQL("$1, $2"), placeholders)
+	assert.Equal(t, []interface{}{72, "Adam"}, args)
+}
+
+func TestAssignments(t *testing.T) {
+	sql, args := build.Assignments(map[string]interface{}{"name": "Adam", "height": 72}, 2)
+	assert.Equal(t, build.SanitizedSQL(`"height" = $2, "name" = $3`), sql)
+	assert.Equal(t, []interface{}{72, "Adam"}, args)
+}
+
+func TestConditions(t *testing.T) {
+	sql, args := build.Conditions(map[string]interface{}{"name": "Adam", "height": 72}, 1)
+	assert.Equal(t, build.SanitizedSQL(`"height" = $1 and "name" = $2`), sql)
+	assert.Equal(t, []interface{}{72, "Adam"}, args)
+}
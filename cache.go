@@ -0,0 +1,482 @@
+package pgxutil
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgproto3/v2"
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+)
+
+// DefaultCacheInvalidationChannel is the channel used by CachedDB.Listen and CreateCacheInvalidationTrigger when no
+// channel is given.
+const DefaultCacheInvalidationChannel = "pgxutil_cache_invalidation"
+
+// CachedDB is a Queryer that caches the results of queries in memory. Passing a CachedDB to the Select functions
+// returns the cached result of an earlier query with the same SQL and arguments until it expires, which is useful for
+// read-heavy lookups of data that changes slowly. A query is cached for Options.CacheTTL if it is set and otherwise for
+// TTL. Queries are sent to DB without caching if both are zero. Only read-only queries, as checked for
+// Options.ReadOnly, are cached, so statements such as the insert ... returning sent by Insert always run. Errors are not
+// cached.
+//
+// Results can be invalidated across processes with Listen, e.g. by triggers created with CreateCacheInvalidationTrigger.
+//
+// Arguments are compared by their type and their %#v formatting, so pointer arguments never match. Cached rows are
+// scanned with ConnInfo, as pgx does not expose the ConnInfo of the connection that read them. With Options.ZeroCopy
+// the returned []byte values share the memory of the cache and must not be modified.
+//
+// The zero value is not usable. DB must be set. The fields must not be changed after the CachedDB is first used.
+type CachedDB struct {
+	// DB receives the queries that are not cached.
+	DB Queryer
+
+	// TTL is how long results are cached when Options.CacheTTL is not set.
+	TTL time.Duration
+
+	// MaxEntries is the maximum number of cached results. When it is reached expired results are removed and, if that
+	// is not enough, new results are not cached. If zero 1000 is used.
+	MaxEntries int
+
+	// ConnInfo is used to scan cached rows. If nil the data types registered by pgtype.NewConnInfo are supported, which
+	// excludes types such as enums and composites registered on a connection.
+	ConnInfo *pgtype.ConnInfo
+
+	// Connect returns a new connection for Listen to listen on. It is only required for Listen.
+	Connect func(ctx context.Context) (*pgx.Conn, error)
+
+	// Channel is the channel Listen receives invalidations on. If empty DefaultCacheInvalidationChannel is used.
+	Channel string
+
+	// OnError is called with the error that caused Listen to reconnect. It is optional.
+	OnError func(err error)
+
+	mu       sync.Mutex
+	entries  map[string]map[string]*cachedResult
+	count    int
+	connInfo *pgtype.ConnInfo
+
+	// generation is incremented by every invalidation. A result read while an invalidation happened may be stale, so it
+	// is not cached.
+	generation uint64
+}
+
+// cachedResult is a query result read from DB. The variant of a cached query is identified by the pgx options such as
+// the requested result formats.
+type cachedResult struct {
+	fields     []pgproto3.FieldDescription
+	values     [][][]byte
+	commandTag pgconn.CommandTag
+	tags       []string
+	expiresAt  time.Time
+}
+
+// Query implements Queryer.
+func (c *CachedDB) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	opts := OptionsFromContext(ctx)
+	ttl := opts.CacheTTL
+	if ttl == 0 {
+		ttl = c.TTL
+	}
+	if ttl <= 0 || !isReadOnlySQL(sql) {
+		return c.DB.Query(ctx, sql, args...)
+	}
+
+	key, variant := cacheKey(sql, args)
+	if result, ok := c.get(key, variant); ok {
+		return c.newRows(result), nil
+	}
+
+	generation := c.currentGeneration()
+	rows, err := c.DB.Query(ctx, sql, args...)
+	if err != nil {
+		return rows, err
+	}
+
+	result := &cachedResult{fields: copyFieldDescriptions(rows.FieldDescriptions())}
+	for rows.Next() {
+		raw := rows.RawValues()
+		values := make([][]byte, len(raw))
+		for i, buf := range raw {
+			values[i] = copyBytes(buf, false)
+		}
+		result.values = append(result.values, values)
+	}
+	rows.Close()
+	if rows.Err() != nil {
+		return &cachedRows{err: rows.Err(), closed: true}, rows.Err()
+	}
+	result.commandTag = append(pgconn.CommandTag(nil), rows.CommandTag()...)
+	result.tags = opts.CacheTags
+	result.expiresAt = time.Now().Add(ttl)
+
+	c.put(key, variant, result, generation)
+
+	return c.newRows(result), nil
+}
+
+// Invalidate removes the cached results of sql with args. sql and args must be passed as they are to the Select
+// functions. NamedArgs and Options are accepted.
+func (c *CachedDB) Invalidate(sql string, args ...interface{}) error {
+	queryArgs := make([]interface{}, 0, len(args))
+	for _, arg := range args {
+		if _, ok := arg.(Options); !ok {
+			queryArgs = append(queryArgs, arg)
+		}
+	}
+
+	sql, queryArgs, err := rewriteNamedArgs(sql, queryArgs)
+	if err != nil {
+		return err
+	}
+
+	key, _ := cacheKey(sql, queryArgs)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	c.remove(key)
+
+	return nil
+}
+
+// InvalidateTag removes the cached results that have tag in Options.CacheTags.
+func (c *CachedDB) InvalidateTag(tag string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+
+	for key, variants := range c.entries {
+		for variant, result := range variants {
+			for _, t := range result.tags {
+				if t == tag {
+					delete(variants, variant)
+					c.count--
+					break
+				}
+			}
+		}
+		if len(variants) == 0 {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// InvalidateAll removes all cached results.
+func (c *CachedDB) InvalidateAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	c.entries = nil
+	c.count = 0
+}
+
+// Listen receives invalidations on Channel and removes the results they name until ctx is canceled. It always returns
+// a non-nil error. The payload of a notification is the tag to pass to InvalidateTag, either as is or as a JSON string
+// or array of strings as sent by Notify. An empty payload removes all results. All results are also removed each time
+// Listen connects as invalidations sent while it was not listening are lost. Connect must be set.
+func (c *CachedDB) Listen(ctx context.Context) error {
+	if c.Connect == nil {
+		return fmt.Errorf("CachedDB.Connect is nil")
+	}
+
+	channel := c.Channel
+	if channel == "" {
+		channel = DefaultCacheInvalidationChannel
+	}
+
+	listener := &Listener{
+		Connect:  c.Connect,
+		Channels: []string{channel},
+		Handler: func(ctx context.Context, notification *pgconn.Notification) error {
+			c.invalidatePayload(notification.Payload)
+			return nil
+		},
+		OnConnect: func(ctx context.Context, conn *pgx.Conn) error {
+			c.InvalidateAll()
+			return nil
+		},
+		OnError: c.OnError,
+	}
+
+	return listener.Listen(ctx)
+}
+
+// invalidatePayload removes the results named by the payload of an invalidation notification. A payload that cannot
+// be parsed removes all results.
+func (c *CachedDB) invalidatePayload(payload string) {
+	var tags []string
+	switch {
+	case payload == "":
+	case payload[0] == '[':
+		if json.Unmarshal([]byte(payload), &tags) != nil {
+			tags = nil
+		}
+	case payload[0] == '"':
+		var tag string
+		if json.Unmarshal([]byte(payload), &tag) == nil {
+			tags = []string{tag}
+		}
+	default:
+		tags = []string{payload}
+	}
+
+	if len(tags) == 0 {
+		c.InvalidateAll()
+		return
+	}
+	for _, tag := range tags {
+		c.InvalidateTag(tag)
+	}
+}
+
+// CreateCacheInvalidationTrigger creates a trigger on tableName that sends the name of the table, without the schema,
+// on channel after each statement that inserts, updates, deletes, or truncates rows. Pass the table name in
+// Options.CacheTags to have CachedDB.Listen remove the results that read the table when it changes. If channel is
+// empty DefaultCacheInvalidationChannel is used. The trigger function pgxutil_cache_invalidation_notify is created or
+// replaced too. tableName must be quoted if it requires quoting in SQL.
+func CreateCacheInvalidationTrigger(ctx context.Context, db Execer, tableName, channel string) error {
+	if channel == "" {
+		channel = DefaultCacheInvalidationChannel
+	}
+
+	return MultiExec(ctx, db, []string{
+		`create or replace function pgxutil_cache_invalidation_notify() returns trigger language plpgsql as $$
+begin
+	perform pg_notify(tg_argv[0], tg_table_name);
+	return null;
+end
+$$`,
+		fmt.Sprintf("drop trigger if exists pgxutil_cache_invalidation on %s", tableName),
+		fmt.Sprintf(`create trigger pgxutil_cache_invalidation
+	after insert or update or delete or truncate on %s
+	for each statement execute procedure pgxutil_cache_invalidation_notify(%s)`, tableName, quoteString(channel)),
+	})
+}
+
+func (c *CachedDB) get(key, variant string) (*cachedResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	result, ok := c.entries[key][variant]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(result.expiresAt) {
+		delete(c.entries[key], variant)
+		c.count--
+		if len(c.entries[key]) == 0 {
+			delete(c.entries, key)
+		}
+		return nil, false
+	}
+
+	return result, true
+}
+
+func (c *CachedDB) currentGeneration() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.generation
+}
+
+// put caches result unless an invalidation happened since generation was read before the query.
+func (c *CachedDB) put(key, variant string, result *cachedResult, generation uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.generation != generation {
+		return
+	}
+
+	if c.entries == nil {
+		c.entries = make(map[string]map[string]*cachedResult)
+	}
+
+	if _, ok := c.entries[key][variant]; !ok {
+		maxEntries := c.MaxEntries
+		if maxEntries == 0 {
+			maxEntries = 1000
+		}
+		if c.count >= maxEntries {
+			c.removeExpired()
+			if c.count >= maxEntries {
+				return
+			}
+		}
+		c.count++
+	}
+
+	if c.entries[key] == nil {
+		c.entries[key] = make(map[string]*cachedResult)
+	}
+	c.entries[key][variant] = result
+}
+
+// remove removes all variants of key. c.mu must be held.
+func (c *CachedDB) remove(key string) {
+	c.count -= len(c.entries[key])
+	delete(c.entries, key)
+}
+
+// removeExpired removes the expired results. c.mu must be held.
+func (c *CachedDB) removeExpired() {
+	now := time.Now()
+	for key, variants := range c.entries {
+		for variant, result := range variants {
+			if now.After(result.expiresAt) {
+				delete(variants, variant)
+				c.count--
+			}
+		}
+		if len(variants) == 0 {
+			delete(c.entries, key)
+		}
+	}
+}
+
+func (c *CachedDB) newRows(result *cachedResult) *cachedRows {
+	connInfo := c.ConnInfo
+	if connInfo == nil {
+		c.mu.Lock()
+		if c.connInfo == nil {
+			c.connInfo = pgtype.NewConnInfo()
+		}
+		connInfo = c.connInfo
+		c.mu.Unlock()
+	}
+
+	return &cachedRows{result: result, connInfo: connInfo, row: -1}
+}
+
+// cacheKey returns the key of sql and args and the variant for the pgx options in args.
+func cacheKey(sql string, args []interface{}) (key, variant string) {
+	var keyBuilder, variantBuilder strings.Builder
+	keyBuilder.WriteString(sql)
+	for _, arg := range args {
+		b := &keyBuilder
+		switch arg.(type) {
+		case pgx.QueryResultFormats, pgx.QueryResultFormatsByOID, pgx.QuerySimpleProtocol:
+			b = &variantBuilder
+		}
+		fmt.Fprintf(b, "\x00%T\x00%#v", arg, arg)
+	}
+
+	return keyBuilder.String(), variantBuilder.String()
+}
+
+// cachedRows implements pgx.Rows for a cachedResult.
+type cachedRows struct {
+	result   *cachedResult
+	connInfo *pgtype.ConnInfo
+	row      int
+	err      error
+	closed   bool
+}
+
+func (r *cachedRows) Close() {
+	r.closed = true
+}
+
+func (r *cachedRows) Err() error {
+	return r.err
+}
+
+func (r *cachedRows) CommandTag() pgconn.CommandTag {
+	if r.result == nil {
+		return nil
+	}
+	return r.result.commandTag
+}
+
+func (r *cachedRows) FieldDescriptions() []pgproto3.FieldDescription {
+	if r.result == nil {
+		return nil
+	}
+	return r.result.fields
+}
+
+func (r *cachedRows) Next() bool {
+	if r.closed {
+		return false
+	}
+
+	r.row++
+	if r.row >= len(r.result.values) {
+		r.Close()
+		return false
+	}
+
+	return true
+}
+
+func (r *cachedRows) Scan(dest ...interface{}) error {
+	err := pgx.ScanRow(r.connInfo, r.result.fields, r.result.values[r.row], dest...)
+	if err != nil {
+		r.err = err
+		r.Close()
+	}
+	return err
+}
+
+func (r *cachedRows) Values() ([]interface{}, error) {
+	if r.closed {
+		return nil, errors.New("rows is closed")
+	}
+
+	values := make([]interface{}, len(r.result.fields))
+	for i, buf := range r.result.values[r.row] {
+		if buf == nil {
+			continue
+		}
+
+		fd := r.result.fields[i]
+		dt, ok := r.connInfo.DataTypeForOID(fd.DataTypeOID)
+		if !ok {
+			if fd.Format == pgx.TextFormatCode {
+				values[i] = string(buf)
+			} else {
+				values[i] = copyBytes(buf, false)
+			}
+			continue
+		}
+
+		value := pgtype.NewValue(dt.Value)
+		err := decodeCachedValue(r.connInfo, value, fd.Format, buf)
+		if err != nil {
+			r.err = err
+			r.Close()
+			return nil, err
+		}
+		values[i] = value.Get()
+	}
+
+	return values, nil
+}
+
+func (r *cachedRows) RawValues() [][]byte {
+	return r.result.values[r.row]
+}
+
+func decodeCachedValue(ci *pgtype.ConnInfo, value pgtype.Value, format int16, buf []byte) error {
+	if format == pgx.BinaryFormatCode {
+		decoder, ok := value.(pgtype.BinaryDecoder)
+		if !ok {
+			return fmt.Errorf("%T cannot be decoded from the binary format", value)
+		}
+		return decoder.DecodeBinary(ci, buf)
+	}
+
+	decoder, ok := value.(pgtype.TextDecoder)
+	if !ok {
+		return fmt.Errorf("%T cannot be decoded from the text format", value)
+	}
+	return decoder.DecodeText(ci, buf)
+}
@@ -0,0 +1,295 @@
+package pgxutil_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgxutil"
+	"github.com/jackc/pgxutil/pgxutiltest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCachedDB(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	fake := &pgxutiltest.FakeQueryer{}
+	db := &pgxutil.CachedDB{DB: fake, TTL: time.Hour}
+
+	fake.ExpectQuery("select name from users where id = $1").WithArgs(int64(1)).ReturnRows(pgxutiltest.NewRows("name").AddRow("Alice"))
+	fake.ExpectQuery("select name from users where id = $1").WithArgs(int64(2)).ReturnRows(pgxutiltest.NewRows("name").AddRow("Bob"))
+	for i := 0; i < 2; i++ {
+		name, err := pgxutil.SelectString(ctx, db, "select name from users where id = $1", int64(1))
+		require.NoError(t, err)
+		assert.Equal(t, "Alice", name)
+
+		name, err = pgxutil.SelectString(ctx, db, "select name from users where id = :id", pgxutil.NamedArgs{"id": int64(2)})
+		require.NoError(t, err)
+		assert.Equal(t, "Bob", name)
+	}
+	require.NoError(t, fake.ExpectationsWereMet())
+
+	// Invalidate accepts the arguments as passed to the Select functions.
+	require.NoError(t, db.Invalidate("select name from users where id = :id", pgxutil.NamedArgs{"id": int64(2)}))
+	fake.ExpectQuery("select name from users where id = $1").WithArgs(int64(2)).ReturnRows(pgxutiltest.NewRows("name").AddRow("Robert"))
+	name, err := pgxutil.SelectString(ctx, db, "select name from users where id = $1", int64(2))
+	require.NoError(t, err)
+	assert.Equal(t, "Robert", name)
+	name, err = pgxutil.SelectString(ctx, db, "select name from users where id = $1", int64(1))
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", name)
+	require.NoError(t, fake.ExpectationsWereMet())
+
+	db.InvalidateAll()
+	fake.ExpectQuery("select name from users where id = $1").WithArgs(int64(1)).ReturnRows(pgxutiltest.NewRows("name").AddRow("Alicia"))
+	name, err = pgxutil.SelectString(ctx, db, "select name from users where id = $1", int64(1))
+	require.NoError(t, err)
+	assert.Equal(t, "Alicia", name)
+	require.NoError(t, fake.ExpectationsWereMet())
+}
+
+func TestCachedDBTTL(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	fake := &pgxutiltest.FakeQueryer{}
+	db := &pgxutil.CachedDB{DB: fake}
+
+	// Without a TTL queries are not cached.
+	fake.ExpectQuery("select 1").ReturnRows(pgxutiltest.NewRows("n").AddRow(int64(1)))
+	fake.ExpectQuery("select 1").ReturnRows(pgxutiltest.NewRows("n").AddRow(int64(1)))
+	for i := 0; i < 2; i++ {
+		_, err := pgxutil.SelectInt64(ctx, db, "select 1")
+		require.NoError(t, err)
+	}
+	require.NoError(t, fake.ExpectationsWereMet())
+
+	// A per-call TTL expires.
+	fake.ExpectQuery("select 2").ReturnRows(pgxutiltest.NewRows("n").AddRow(int64(2)))
+	fake.ExpectQuery("select 2").ReturnRows(pgxutiltest.NewRows("n").AddRow(int64(2)))
+	for i := 0; i < 2; i++ {
+		_, err := pgxutil.SelectInt64(ctx, db, "select 2", pgxutil.Options{CacheTTL: 10 * time.Millisecond})
+		require.NoError(t, err)
+		_, err = pgxutil.SelectInt64(pgxutil.WithOptions(ctx, pgxutil.Options{CacheTTL: 10 * time.Millisecond}), db, "select 2")
+		require.NoError(t, err)
+		time.Sleep(20 * time.Millisecond)
+	}
+	require.NoError(t, fake.ExpectationsWereMet())
+
+	// A negative TTL skips the cache.
+	db = &pgxutil.CachedDB{DB: fake, TTL: time.Hour}
+	fake.ExpectQuery("select 3").ReturnRows(pgxutiltest.NewRows("n").AddRow(int64(3)))
+	fake.ExpectQuery("select 3").ReturnRows(pgxutiltest.NewRows("n").AddRow(int64(3)))
+	for i := 0; i < 2; i++ {
+		_, err := pgxutil.SelectInt64(ctx, db, "select 3", pgxutil.Options{CacheTTL: -1})
+		require.NoError(t, err)
+	}
+	require.NoError(t, fake.ExpectationsWereMet())
+}
+
+func TestCachedDBRows(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	fake := &pgxutiltest.FakeQueryer{}
+	db := &pgxutil.CachedDB{DB: fake, TTL: time.Hour, MaxEntries: 1}
+
+	fake.ExpectQuery("select id, name from users").ReturnRows(pgxutiltest.NewRows("id", "name").AddRow(int32(1), "Alice").AddRow(int32(2), "Bob"))
+	for i := 0; i < 2; i++ {
+		users, err := pgxutil.SelectAllMap(ctx, db, "select id, name from users")
+		require.NoError(t, err)
+		assert.Equal(t, []map[string]interface{}{{"id": int32(1), "name": "Alice"}, {"id": int32(2), "name": "Bob"}}, users)
+
+		var structs []struct {
+			ID   int32
+			Name string
+		}
+		err = pgxutil.SelectAllStruct(ctx, db, &structs, "select id, name from users")
+		require.NoError(t, err)
+		require.Len(t, structs, 2)
+		assert.Equal(t, "Bob", structs[1].Name)
+	}
+	require.NoError(t, fake.ExpectationsWereMet())
+
+	// The cache is full so other queries are not cached.
+	fake.ExpectQuery("select 1").ReturnRows(pgxutiltest.NewRows("n").AddRow(int64(1)))
+	fake.ExpectQuery("select 1").ReturnRows(pgxutiltest.NewRows("n").AddRow(int64(1)))
+	for i := 0; i < 2; i++ {
+		_, err := pgxutil.SelectInt64(ctx, db, "select 1")
+		require.NoError(t, err)
+	}
+	require.NoError(t, fake.ExpectationsWereMet())
+}
+
+func TestCachedDBInvalidateTag(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	fake := &pgxutiltest.FakeQueryer{}
+	db := &pgxutil.CachedDB{DB: fake, TTL: time.Hour}
+	ctx = pgxutil.WithOptions(ctx, pgxutil.Options{CacheTags: []string{"users"}})
+
+	fake.ExpectQuery("select count(*) from users").ReturnRows(pgxutiltest.NewRows("count").AddRow(int64(1)))
+	fake.ExpectQuery("select count(*) from users join orders using (user_id)").ReturnRows(pgxutiltest.NewRows("count").AddRow(int64(2)))
+	fake.ExpectQuery("select count(*) from users").ReturnRows(pgxutiltest.NewRows("count").AddRow(int64(3)))
+	for i := 0; i < 2; i++ {
+		n, err := pgxutil.SelectInt64(ctx, db, "select count(*) from users")
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, n)
+
+		n, err = pgxutil.SelectInt64(ctx, db, "select count(*) from users join orders using (user_id)", pgxutil.Options{CacheTags: []string{"orders"}})
+		require.NoError(t, err)
+		assert.EqualValues(t, 2, n)
+	}
+
+	db.InvalidateTag("orders")
+	db.InvalidateTag("users")
+	fake.ExpectQuery("select count(*) from users join orders using (user_id)").ReturnRows(pgxutiltest.NewRows("count").AddRow(int64(4)))
+	n, err := pgxutil.SelectInt64(ctx, db, "select count(*) from users")
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, n)
+	n, err = pgxutil.SelectInt64(ctx, db, "select count(*) from users join orders using (user_id)")
+	require.NoError(t, err)
+	assert.EqualValues(t, 4, n)
+
+	require.NoError(t, fake.ExpectationsWereMet())
+}
+
+func TestCachedDBListen(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	conn := connectPG(t, ctx)
+	defer closeConn(t, conn)
+
+	const channel = "pgxutil_cached_db_listen"
+	err := pgxutil.MultiExec(ctx, conn, []string{
+		"create temporary table widgets (name text not null)",
+		"insert into widgets values ('a')",
+		"create temporary sequence widget_seq",
+	})
+	require.NoError(t, err)
+	err = pgxutil.CreateCacheInvalidationTrigger(ctx, conn, "widgets", channel)
+	require.NoError(t, err)
+
+	db := &pgxutil.CachedDB{
+		DB:  conn,
+		TTL: time.Hour,
+		Connect: func(ctx context.Context) (*pgx.Conn, error) {
+			return connectPG(t, ctx), nil
+		},
+		Channel: channel,
+	}
+
+	selectName := func() string {
+		name, err := pgxutil.SelectString(ctx, db, "select name from widgets", pgxutil.Options{CacheTags: []string{"widgets"}})
+		require.NoError(t, err)
+		return name
+	}
+	selectSeq := func() int64 {
+		n, err := pgxutil.SelectInt64(ctx, db, "select nextval('widget_seq')", pgxutil.Options{CacheTags: []string{"seq"}})
+		require.NoError(t, err)
+		return n
+	}
+
+	assert.Equal(t, "a", selectName())
+	assert.EqualValues(t, 1, selectSeq())
+
+	listenErr := make(chan error)
+	listenCtx, cancelListen := context.WithCancel(ctx)
+	go func() { listenErr <- db.Listen(listenCtx) }()
+
+	// The results are removed when Listen connects or when it receives the notification of the trigger.
+	_, err = conn.Exec(ctx, "update widgets set name = 'b'")
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return selectName() == "b" }, 5*time.Second, 10*time.Millisecond)
+
+	// Listen is listening now.
+	_, err = conn.Exec(ctx, "update widgets set name = 'c'")
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return selectName() == "c" }, 5*time.Second, 10*time.Millisecond)
+
+	seq := selectSeq()
+	assert.Equal(t, seq, selectSeq())
+	err = pgxutil.Notify(ctx, conn, channel, "seq")
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return selectSeq() == seq+1 }, 5*time.Second, 10*time.Millisecond)
+
+	cancelListen()
+	assert.ErrorIs(t, <-listenErr, context.Canceled)
+}
+
+func TestCachedDBWrites(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	fake := &pgxutiltest.FakeQueryer{}
+	db := &pgxutil.CachedDB{DB: fake, TTL: time.Hour}
+
+	const sql = "insert into users (name) values ($1) returning id"
+	fake.ExpectQuery(sql).WithArgs("Alice").ReturnRows(pgxutiltest.NewRows("id").AddRow(int64(1)))
+	fake.ExpectQuery(sql).WithArgs("Alice").ReturnRows(pgxutiltest.NewRows("id").AddRow(int64(2)))
+	for i := 1; i <= 2; i++ {
+		id, err := pgxutil.SelectInt64(ctx, db, sql, "Alice")
+		require.NoError(t, err)
+		assert.EqualValues(t, i, id)
+	}
+	require.NoError(t, fake.ExpectationsWereMet())
+}
+
+func TestCachedDBLock(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	fake := &pgxutiltest.FakeQueryer{}
+	db := &pgxutil.CachedDB{DB: fake, TTL: time.Hour}
+
+	// Each locking select must reach the database to take its lock.
+	const sql = "select name from users where id = $1\nfor share"
+	fake.ExpectQuery(sql).WithArgs(int64(1)).ReturnRows(pgxutiltest.NewRows("name").AddRow("Alice"))
+	fake.ExpectQuery(sql).WithArgs(int64(1)).ReturnRows(pgxutiltest.NewRows("name").AddRow("Alice"))
+	for i := 0; i < 2; i++ {
+		name, err := pgxutil.SelectString(ctx, db, "select name from users where id = $1", int64(1), pgxutil.Options{Lock: pgxutil.LockForShare})
+		require.NoError(t, err)
+		assert.Equal(t, "Alice", name)
+	}
+	require.NoError(t, fake.ExpectationsWereMet())
+}
+
+// invalidatingQueryer invalidates db while each query is in flight.
+type invalidatingQueryer struct {
+	*pgxutiltest.FakeQueryer
+	db *pgxutil.CachedDB
+}
+
+func (q *invalidatingQueryer) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	q.db.InvalidateTag("widgets")
+	return q.FakeQueryer.Query(ctx, sql, args...)
+}
+
+func TestCachedDBInvalidateInFlight(t *testing.T) {
+	t.Parallel()
+
+	fake := &pgxutiltest.FakeQueryer{}
+	fake.ExpectQuery("select name from widgets").ReturnRows(pgxutiltest.NewRows("name").AddRow("a"))
+	fake.ExpectQuery("select name from widgets").ReturnRows(pgxutiltest.NewRows("name").AddRow("b"))
+
+	db := &pgxutil.CachedDB{TTL: time.Hour}
+	db.DB = &invalidatingQueryer{FakeQueryer: fake, db: db}
+
+	// The result read while it was invalidated is not cached, so the second query reads the new name.
+	ctx := context.Background()
+	opts := pgxutil.Options{CacheTags: []string{"widgets"}}
+	name, err := pgxutil.SelectString(ctx, db, "select name from widgets", opts)
+	require.NoError(t, err)
+	assert.Equal(t, "a", name)
+	name, err = pgxutil.SelectString(ctx, db, "select name from widgets", opts)
+	require.NoError(t, err)
+	assert.Equal(t, "b", name)
+	require.NoError(t, fake.ExpectationsWereMet())
+}
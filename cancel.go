@@ -0,0 +1,80 @@
+package pgxutil
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+)
+
+// withCancelRequest calls fn with a context that carries the values of ctx but is not canceled with it. When ctx is
+// done before fn returns a cancel request is sent for the connection of db and fn is given grace to return before its
+// context is canceled too. This lets PostgreSQL end the query with a query_canceled error while the connection is
+// still usable instead of pgx interrupting the connection mid-query, which closes it.
+func withCancelRequest(ctx context.Context, db interface{}, grace time.Duration, fn func(ctx context.Context) error) error {
+	pgConn, ok := cancelPgConn(db)
+	if !ok {
+		return fmt.Errorf("%T does not implement PgConn or Conn, which is required for Options.CancelGracePeriod", db)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	fnCtx, cancelFn := context.WithCancel(detachedContext{parent: ctx})
+	defer cancelFn()
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+
+		select {
+		case <-done:
+			return
+		case <-ctx.Done():
+		}
+
+		graceCtx, cancel := context.WithTimeout(context.Background(), grace)
+		defer cancel()
+
+		// A failed cancel request is not reported. fn is still interrupted when grace expires.
+		pgConn.CancelRequest(graceCtx)
+
+		select {
+		case <-done:
+		case <-graceCtx.Done():
+			cancelFn()
+		}
+	}()
+
+	err := fn(fnCtx)
+	close(done)
+	<-stopped
+
+	return err
+}
+
+// cancelPgConn returns the connection a cancel request must be sent for to cancel a query of db.
+func cancelPgConn(db interface{}) (*pgconn.PgConn, bool) {
+	switch db := db.(type) {
+	case PgConner:
+		return db.PgConn(), true
+	case interface{ Conn() *pgx.Conn }:
+		return db.Conn().PgConn(), true
+	default:
+		return nil, false
+	}
+}
+
+// detachedContext is a context with the values of parent that is never done.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool)         { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}               { return nil }
+func (detachedContext) Err() error                          { return nil }
+func (c detachedContext) Value(key interface{}) interface{} { return c.parent.Value(key) }
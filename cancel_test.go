@@ -0,0 +1,58 @@
+package pgxutil_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgxutil"
+	"github.com/jackc/pgxutil/pgxutiltest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOptionsCancelGracePeriod(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn := connectPG(t, ctx)
+	defer closeConn(t, conn)
+
+	opts := pgxutil.Options{Timeout: 50 * time.Millisecond, CancelGracePeriod: 2 * time.Second}
+
+	_, err := pgxutil.SelectString(ctx, conn, "select 'ok' from pg_sleep(1)", opts)
+	var pgErr *pgconn.PgError
+	require.True(t, errors.As(err, &pgErr))
+	assert.Equal(t, "57014", pgErr.Code)
+	assert.False(t, conn.IsClosed())
+
+	_, err = pgxutil.Exec(pgxutil.WithOptions(ctx, opts), conn, "select pg_sleep(1)")
+	require.True(t, errors.As(err, &pgErr))
+	assert.Equal(t, "57014", pgErr.Code)
+	assert.False(t, conn.IsClosed())
+
+	tx, err := conn.Begin(ctx)
+	require.NoError(t, err)
+	defer tx.Rollback(ctx)
+
+	_, err = pgxutil.SelectString(ctx, tx, "select 'ok' from pg_sleep(1)", opts, pgxutil.Options{StatementTimeout: time.Minute})
+	require.True(t, errors.As(err, &pgErr))
+	assert.Equal(t, "57014", pgErr.Code)
+	assert.False(t, conn.IsClosed())
+
+	s, err := pgxutil.SelectString(ctx, conn, "select 'ok'")
+	require.NoError(t, err)
+	assert.Equal(t, "ok", s)
+}
+
+func TestOptionsCancelGracePeriodRequiresPgConn(t *testing.T) {
+	t.Parallel()
+
+	db := &pgxutiltest.FakeQueryer{}
+	_, err := pgxutil.SelectString(context.Background(), db, "select 'ok'", pgxutil.Options{CancelGracePeriod: time.Second})
+	require.EqualError(t, err, "*pgxutiltest.FakeQueryer does not implement PgConn or Conn, which is required for Options.CancelGracePeriod")
+}
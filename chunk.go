@@ -0,0 +1,111 @@
+package pgxutil
+
+import (
+	"context"
+	"fmt"
+)
+
+// ChunkOptions configures SelectInt64Chunked and SelectMapChunked.
+type ChunkOptions struct {
+	// Size is the maximum number of rows per chunk.
+	Size int
+
+	// OrderBy are the result columns used to select chunks with keyset pagination as described by PageOptions.OrderBy.
+	// For SelectInt64Chunked it must be the name of the selected column. If empty, chunks are selected with limit and
+	// offset and sql should have an order by clause that gives the rows a stable order. Keyset pagination should be
+	// preferred for large results because each offset query must read and discard all the preceding rows.
+	OrderBy []string
+
+	// Desc orders the rows in descending instead of ascending order when OrderBy is set.
+	Desc bool
+}
+
+// SelectInt64Chunked selects a column of int64 in chunks of up to opts.Size values and calls fn with each chunk. sql is
+// used as a subquery and is run once per chunk so the amount of memory used does not depend on the size of the result.
+// The chunks are separate queries so they only see a consistent snapshot if db is a transaction with the repeatable
+// read or serializable isolation level. If fn returns an error no more chunks are selected and the error is returned.
+// The values are converted as described by SelectInt64. fn may retain the chunk.
+func SelectInt64Chunked(ctx context.Context, db Queryer, opts ChunkOptions, fn func([]int64) error, sql string, args ...interface{}) error {
+	if len(opts.OrderBy) > 1 {
+		return fmt.Errorf("order by must have one column for SelectInt64Chunked")
+	}
+
+	var after []interface{}
+	return selectChunks(opts, sql, args, func(chunkSQL string, chunkArgs []interface{}) (int, error) {
+		if len(opts.OrderBy) > 0 {
+			var err error
+			chunkSQL, chunkArgs, err = buildKeysetSQL(sql, args, opts.OrderBy, opts.Desc, after, opts.Size, false)
+			if err != nil {
+				return 0, err
+			}
+		}
+
+		chunk, err := SelectAllInt64(ctx, db, chunkSQL, chunkArgs...)
+		if err != nil {
+			return 0, err
+		}
+		if len(chunk) == 0 {
+			return 0, nil
+		}
+
+		after = []interface{}{chunk[len(chunk)-1]}
+		return len(chunk), fn(chunk)
+	})
+}
+
+// SelectMapChunked is like SelectInt64Chunked except each row is selected into a map as described by SelectAllMap.
+func SelectMapChunked(ctx context.Context, db Queryer, opts ChunkOptions, fn func([]map[string]interface{}) error, sql string, args ...interface{}) error {
+	var after []interface{}
+	return selectChunks(opts, sql, args, func(chunkSQL string, chunkArgs []interface{}) (int, error) {
+		if len(opts.OrderBy) > 0 {
+			var err error
+			chunkSQL, chunkArgs, err = buildKeysetSQL(sql, args, opts.OrderBy, opts.Desc, after, opts.Size, false)
+			if err != nil {
+				return 0, err
+			}
+		}
+
+		chunk, err := SelectAllMap(ctx, db, chunkSQL, chunkArgs...)
+		if err != nil {
+			return 0, err
+		}
+		if len(chunk) == 0 {
+			return 0, nil
+		}
+
+		lastRow := chunk[len(chunk)-1]
+		after = make([]interface{}, len(opts.OrderBy))
+		for i, c := range opts.OrderBy {
+			v, ok := lastRow[c]
+			if !ok {
+				return 0, fmt.Errorf("order by column %s not found in result", c)
+			}
+			after[i] = v
+		}
+
+		return len(chunk), fn(chunk)
+	})
+}
+
+// selectChunks validates opts and calls selectChunk until a chunk has fewer than opts.Size rows. selectChunk is given
+// the limit and offset query for the next chunk. It must build its own query when opts.OrderBy is set.
+func selectChunks(opts ChunkOptions, sql string, args []interface{}, selectChunk func(chunkSQL string, chunkArgs []interface{}) (int, error)) error {
+	if opts.Size < 1 {
+		return fmt.Errorf("size must be at least 1")
+	}
+
+	for offset := 0; ; offset += opts.Size {
+		var chunkSQL string
+		if len(opts.OrderBy) == 0 {
+			chunkSQL = fmt.Sprintf("select * from (%s) pgxutil_chunk limit %d offset %d", sql, opts.Size, offset)
+		}
+
+		n, err := selectChunk(chunkSQL, args)
+		if err != nil {
+			return err
+		}
+		if n < opts.Size {
+			return nil
+		}
+	}
+}
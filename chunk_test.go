@@ -0,0 +1,91 @@
+package pgxutil_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgxutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectInt64Chunked(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		for _, tt := range []struct {
+			name   string
+			opts   pgxutil.ChunkOptions
+			sql    string
+			chunks [][]int64
+		}{
+			{
+				name:   "offset",
+				opts:   pgxutil.ChunkOptions{Size: 3},
+				sql:    "select n from generate_series(1, $1::int) n order by n",
+				chunks: [][]int64{{1, 2, 3}, {4, 5, 6}, {7}},
+			},
+			{
+				name:   "keyset",
+				opts:   pgxutil.ChunkOptions{Size: 3, OrderBy: []string{"id"}},
+				sql:    "select n as id from generate_series(1, $1::int) n",
+				chunks: [][]int64{{1, 2, 3}, {4, 5, 6}, {7}},
+			},
+			{
+				name:   "keyset desc",
+				opts:   pgxutil.ChunkOptions{Size: 3, OrderBy: []string{"id"}, Desc: true},
+				sql:    "select n as id from generate_series(1, $1::int) n",
+				chunks: [][]int64{{7, 6, 5}, {4, 3, 2}, {1}},
+			},
+		} {
+			var chunks [][]int64
+			err := pgxutil.SelectInt64Chunked(ctx, tx, tt.opts, func(chunk []int64) error {
+				chunks = append(chunks, chunk)
+				return nil
+			}, tt.sql, 7)
+			require.NoErrorf(t, err, tt.name)
+			assert.Equalf(t, tt.chunks, chunks, tt.name)
+		}
+
+		count := 0
+		err := pgxutil.SelectInt64Chunked(ctx, tx, pgxutil.ChunkOptions{Size: 3, OrderBy: []string{"n"}}, func(chunk []int64) error {
+			count++
+			return nil
+		}, "select n from generate_series(1, 6) n")
+		require.NoError(t, err)
+		assert.Equal(t, 2, count)
+
+		errStop := errors.New("stop")
+		err = pgxutil.SelectInt64Chunked(ctx, tx, pgxutil.ChunkOptions{Size: 2}, func(chunk []int64) error {
+			return errStop
+		}, "select n from generate_series(1, 6) n order by n")
+		assert.Equal(t, errStop, err)
+
+		err = pgxutil.SelectInt64Chunked(ctx, tx, pgxutil.ChunkOptions{}, func(chunk []int64) error { return nil }, "select 1")
+		assert.EqualError(t, err, "size must be at least 1")
+	})
+}
+
+func TestSelectMapChunked(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		var ids []int32
+		chunkCount := 0
+		err := pgxutil.SelectMapChunked(ctx, tx, pgxutil.ChunkOptions{Size: 2, OrderBy: []string{"grp", "id"}}, func(chunk []map[string]interface{}) error {
+			chunkCount++
+			for _, row := range chunk {
+				ids = append(ids, row["id"].(int32))
+			}
+			return nil
+		}, "select n as id, n % 3 as grp from generate_series(1, 7) n")
+		require.NoError(t, err)
+		assert.Equal(t, []int32{3, 6, 1, 4, 7, 2, 5}, ids)
+		assert.Equal(t, 4, chunkCount)
+
+		err = pgxutil.SelectMapChunked(ctx, tx, pgxutil.ChunkOptions{Size: 2, OrderBy: []string{"missing"}}, func(chunk []map[string]interface{}) error {
+			return nil
+		}, "select n as id from generate_series(1, 7) n")
+		assert.Error(t, err)
+	})
+}
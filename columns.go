@@ -0,0 +1,139 @@
+package pgxutil
+
+import (
+	"context"
+
+	"github.com/jackc/pgproto3/v2"
+	"github.com/jackc/pgx/v4"
+)
+
+// ColumnInfo describes a column of a result set.
+type ColumnInfo struct {
+	// Name is the column name.
+	Name string
+
+	// DataTypeOID is the OID of the column's data type.
+	DataTypeOID uint32
+
+	// DataTypeName is the name of the data type including any modifier as formatted by PostgreSQL. e.g. integer or
+	// character varying(20).
+	DataTypeName string
+
+	// TableOID and TableAttributeNumber identify the table column the result column was selected from. They are zero if
+	// the result column is not a simple reference to a table column.
+	TableOID             uint32
+	TableAttributeNumber uint16
+
+	// Nullable is false if the column is a table column with a not null constraint. Otherwise, it is true as the value
+	// may be null.
+	Nullable bool
+}
+
+// QueryDescription describes the parameters and result columns of a query.
+type QueryDescription struct {
+	// ParamOIDs are the OIDs of the data types PostgreSQL inferred for the parameters $1, $2, etc.
+	ParamOIDs []uint32
+
+	// Fields describe the result columns. They are empty if the query does not return rows.
+	Fields []pgproto3.FieldDescription
+}
+
+// DescribeQuery parses sql and returns its parameter and result column descriptions without executing it. An error is
+// returned if sql is not valid. Use tx.Conn() to describe a query in a transaction.
+func DescribeQuery(ctx context.Context, db PgConner, sql string) (*QueryDescription, error) {
+	sd, err := db.PgConn().Prepare(ctx, "", sql, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &QueryDescription{ParamOIDs: sd.ParamOIDs, Fields: copyFieldDescriptions(sd.Fields)}, nil
+}
+
+// SelectMapWithTypes is like SelectMap except it also returns a description of each column. The column descriptions
+// are read from the system catalogs with an additional query.
+func SelectMapWithTypes(ctx context.Context, db Queryer, sql string, args ...interface{}) (map[string]interface{}, []ColumnInfo, error) {
+	v, columns, err := SelectAllMapWithTypes(ctx, db, sql, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(v) == 0 {
+		return nil, nil, ErrNoRows
+	}
+	if len(v) > 1 {
+		return nil, nil, ErrTooManyRows
+	}
+
+	return v[0], columns, nil
+}
+
+// SelectAllMapWithTypes is like SelectAllMap except it also returns a description of each column. The column
+// descriptions are returned even if there are no rows. They are read from the system catalogs with an additional query.
+func SelectAllMapWithTypes(ctx context.Context, db Queryer, sql string, args ...interface{}) ([]map[string]interface{}, []ColumnInfo, error) {
+	var v []map[string]interface{}
+	var scanner mapRowScanner
+	fieldDescriptions, err := selectRowsFields(ctx, db, sql, args, func(rows pgx.Rows) error {
+		m := make(map[string]interface{}, len(rows.FieldDescriptions()))
+		err := scanner.scan(rows, m)
+		if err != nil {
+			return err
+		}
+		v = append(v, m)
+
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	columns, err := describeColumns(ctx, db, fieldDescriptions)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return v, columns, nil
+}
+
+// describeColumns returns the ColumnInfo for fieldDescriptions. Type names and nullability are read from the system
+// catalogs.
+func describeColumns(ctx context.Context, db Queryer, fieldDescriptions []pgproto3.FieldDescription) ([]ColumnInfo, error) {
+	columns := make([]ColumnInfo, len(fieldDescriptions))
+	if len(fieldDescriptions) == 0 {
+		return columns, nil
+	}
+
+	typeOIDs := make([]int64, len(fieldDescriptions))
+	typeModifiers := make([]int32, len(fieldDescriptions))
+	tableOIDs := make([]int64, len(fieldDescriptions))
+	attributeNumbers := make([]int16, len(fieldDescriptions))
+	for i, fd := range fieldDescriptions {
+		columns[i] = ColumnInfo{
+			Name:                 string(fd.Name),
+			DataTypeOID:          fd.DataTypeOID,
+			TableOID:             fd.TableOID,
+			TableAttributeNumber: fd.TableAttributeNumber,
+		}
+		typeOIDs[i] = int64(fd.DataTypeOID)
+		typeModifiers[i] = fd.TypeModifier
+		tableOIDs[i] = int64(fd.TableOID)
+		attributeNumbers[i] = int16(fd.TableAttributeNumber)
+	}
+
+	i := 0
+	_, err := queryRows(ctx, db, `select format_type(c.type_oid::oid, c.type_modifier),
+	coalesce((select not a.attnotnull from pg_attribute a where a.attrelid = c.table_oid::oid and a.attnum = c.attnum), true)
+from unnest($1::int8[], $2::int4[], $3::int8[], $4::int2[]) with ordinality as c(type_oid, type_modifier, table_oid, attnum, n)
+order by c.n`,
+		[]interface{}{typeOIDs, typeModifiers, tableOIDs, attributeNumbers},
+		func(rows pgx.Rows) error {
+			err := rows.Scan(&columns[i].DataTypeName, &columns[i].Nullable)
+			i++
+			return err
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return columns, nil
+}
@@ -0,0 +1,102 @@
+package pgxutil_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgxutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectMapWithTypes(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		_, err := tx.Exec(ctx, `create temporary table t (id serial primary key, name varchar(20) not null, height int)`)
+		require.NoError(t, err)
+		_, err = tx.Exec(ctx, `insert into t (name, height) values ('Adam', 72)`)
+		require.NoError(t, err)
+
+		m, columns, err := pgxutil.SelectMapWithTypes(ctx, tx, "select name, height, height * 2 as double_height from t")
+		require.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"name": "Adam", "height": int32(72), "double_height": int32(144)}, m)
+		require.Len(t, columns, 3)
+
+		assert.Equal(t, "name", columns[0].Name)
+		assert.EqualValues(t, pgtype.VarcharOID, columns[0].DataTypeOID)
+		assert.Equal(t, "character varying(20)", columns[0].DataTypeName)
+		assert.NotZero(t, columns[0].TableOID)
+		assert.EqualValues(t, 2, columns[0].TableAttributeNumber)
+		assert.False(t, columns[0].Nullable)
+
+		assert.Equal(t, "height", columns[1].Name)
+		assert.Equal(t, "integer", columns[1].DataTypeName)
+		assert.True(t, columns[1].Nullable)
+
+		assert.Equal(t, "double_height", columns[2].Name)
+		assert.Equal(t, "integer", columns[2].DataTypeName)
+		assert.Zero(t, columns[2].TableOID)
+		assert.True(t, columns[2].Nullable)
+
+		_, _, err = pgxutil.SelectMapWithTypes(ctx, tx, "select * from t where false")
+		assert.ErrorIs(t, err, pgxutil.ErrNoRows)
+	})
+}
+
+func TestSelectAllMapWithTypes(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		rows, columns, err := pgxutil.SelectAllMapWithTypes(ctx, tx, "select n, n::text as s from generate_series(1, 2) n")
+		require.NoError(t, err)
+		assert.Equal(t, []map[string]interface{}{{"n": int32(1), "s": "1"}, {"n": int32(2), "s": "2"}}, rows)
+		require.Len(t, columns, 2)
+		assert.Equal(t, "integer", columns[0].DataTypeName)
+		assert.Equal(t, "text", columns[1].DataTypeName)
+
+		rows, columns, err = pgxutil.SelectAllMapWithTypes(ctx, tx, "select 1::int8 as n where false")
+		require.NoError(t, err)
+		assert.Empty(t, rows)
+		require.Len(t, columns, 1)
+		assert.Equal(t, "n", columns[0].Name)
+		assert.Equal(t, "bigint", columns[0].DataTypeName)
+	})
+}
+
+func TestDescribeQuery(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn := connectPG(t, ctx)
+	defer closeConn(t, conn)
+
+	_, err := conn.Exec(ctx, `create temporary table t (id serial primary key, name text)`)
+	require.NoError(t, err)
+
+	description, err := pgxutil.DescribeQuery(ctx, conn, "insert into t (name) values ($1) returning id, name, $2::int8 as n")
+	require.NoError(t, err)
+	assert.Equal(t, []uint32{pgtype.TextOID, pgtype.Int8OID}, description.ParamOIDs)
+	require.Len(t, description.Fields, 3)
+	assert.Equal(t, "id", string(description.Fields[0].Name))
+	assert.EqualValues(t, pgtype.Int4OID, description.Fields[0].DataTypeOID)
+	assert.Equal(t, "name", string(description.Fields[1].Name))
+	assert.EqualValues(t, pgtype.TextOID, description.Fields[1].DataTypeOID)
+	assert.Equal(t, "n", string(description.Fields[2].Name))
+
+	// The query was not executed.
+	n, err := pgxutil.SelectInt64(ctx, conn, "select count(*) from t")
+	require.NoError(t, err)
+	assert.EqualValues(t, 0, n)
+
+	description, err = pgxutil.DescribeQuery(ctx, conn, "delete from t")
+	require.NoError(t, err)
+	assert.Empty(t, description.ParamOIDs)
+	assert.Empty(t, description.Fields)
+
+	_, err = pgxutil.DescribeQuery(ctx, conn, "select * from missing")
+	require.Error(t, err)
+}
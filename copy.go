@@ -0,0 +1,294 @@
+package pgxutil
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgxutil/build"
+)
+
+// PgConner is the interface used by functions that require the underlying *pgconn.PgConn. It is implemented by
+// *pgx.Conn. Use tx.Conn() to pass a pgx.Tx and Acquire to use a *pgxpool.Pool.
+type PgConner interface {
+	PgConn() *pgconn.PgConn
+}
+
+// CopyFromStructs copies rows into tableName with the copy protocol and returns the number of rows copied. rows must
+// be a slice of structs or pointers to structs. Each exported field is copied to the column named by its db tag or, for
+// untagged fields, the field name converted to snake case. Fields tagged db:"-" are skipped. The rows are streamed to
+// the server rather than copied into an intermediate buffer. tableName may be schema qualified and quoted as in SQL.
+func CopyFromStructs(ctx context.Context, db CopyFromer, tableName string, rows interface{}) (int64, error) {
+	sliceValue := reflect.ValueOf(rows)
+	if sliceValue.Kind() != reflect.Slice {
+		return 0, fmt.Errorf("rows not a slice")
+	}
+
+	elemType := sliceValue.Type().Elem()
+	if elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+	if elemType.Kind() != reflect.Struct {
+		return 0, fmt.Errorf("rows not a slice of structs or pointers to structs")
+	}
+
+	columnNames, fieldIndexes := structColumns(elemType)
+	if len(columnNames) == 0 {
+		return 0, fmt.Errorf("%v has no fields to copy", elemType)
+	}
+
+	table, err := parseTableName(tableName)
+	if err != nil {
+		return 0, err
+	}
+
+	src := &structCopyFromSource{rows: sliceValue, fieldIndexes: fieldIndexes, i: -1}
+	return db.CopyFrom(ctx, table, columnNames, src)
+}
+
+// structColumns returns the column names and field indexes of the exported fields of structType. The column name is
+// the db tag or the snake case field name. Fields tagged db:"-" are skipped.
+func structColumns(structType reflect.Type) ([]string, [][]int) {
+	fields := exportedStructFields(structType, nil)
+	columnNames := make([]string, 0, len(fields))
+	fieldIndexes := make([][]int, 0, len(fields))
+	for _, sf := range fields {
+		name, ok := structFieldColumnName(sf.StructField)
+		if !ok {
+			continue
+		}
+		columnNames = append(columnNames, name)
+		fieldIndexes = append(fieldIndexes, sf.index)
+	}
+
+	return columnNames, fieldIndexes
+}
+
+// structFieldColumnName returns the column name of sf. It is the name in the db tag or the snake case field name. ok
+// is false if sf is tagged db:"-".
+func structFieldColumnName(sf reflect.StructField) (string, bool) {
+	name, _, _ := dbTag(sf)
+	switch name {
+	case "-":
+		return "", false
+	case "":
+		return snakeCase(sf.Name), true
+	default:
+		return name, true
+	}
+}
+
+// snakeCase converts a Go identifier such as UserID or CreatedAt to user_id or created_at.
+func snakeCase(s string) string {
+	runes := []rune(s)
+	sb := &strings.Builder{}
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) ||
+				(i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))) {
+				sb.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// structCopyFromSource implements pgx.CopyFromSource for a slice of structs or pointers to structs.
+type structCopyFromSource struct {
+	rows         reflect.Value
+	fieldIndexes [][]int
+	i            int
+	err          error
+}
+
+func (s *structCopyFromSource) Next() bool {
+	s.i++
+	return s.i < s.rows.Len()
+}
+
+func (s *structCopyFromSource) Values() ([]interface{}, error) {
+	row := s.rows.Index(s.i)
+	if row.Kind() == reflect.Ptr {
+		if row.IsNil() {
+			s.err = fmt.Errorf("row %d is nil", s.i)
+			return nil, s.err
+		}
+		row = row.Elem()
+	}
+
+	values := make([]interface{}, len(s.fieldIndexes))
+	for i, index := range s.fieldIndexes {
+		if fv, ok := fieldByIndexNoAlloc(row, index); ok {
+			values[i] = fv.Interface()
+		}
+	}
+
+	return values, nil
+}
+
+func (s *structCopyFromSource) Err() error {
+	return s.err
+}
+
+// ExportCSV writes the result of sql to w as CSV with a header row and returns the number of rows written. It uses
+// copy to stdout so the result is streamed to w without being buffered. As copy does not accept parameters, args are
+// quoted as literals and interpolated into sql. NamedArgs may be used in place of positional arguments.
+func ExportCSV(ctx context.Context, db PgConner, w io.Writer, sql string, args ...interface{}) (int64, error) {
+	sql, err := interpolateArgs(sql, args)
+	if err != nil {
+		return 0, err
+	}
+
+	ct, err := db.PgConn().CopyTo(ctx, w, "copy ("+sql+") to stdout with (format csv, header)")
+	if err != nil {
+		return 0, err
+	}
+
+	return ct.RowsAffected(), nil
+}
+
+// interpolateArgs returns sql with its placeholders replaced with args quoted as literals. It is used for statements
+// such as copy that cannot take parameters.
+func interpolateArgs(sql string, args []interface{}) (string, error) {
+	sql, args, err := rewriteNamedArgs(sql, args)
+	if err != nil {
+		return "", err
+	}
+
+	values := make([]interface{}, 0, len(args))
+	for _, arg := range args {
+		switch arg.(type) {
+		case pgx.QueryResultFormats, pgx.QueryResultFormatsByOID, pgx.QuerySimpleProtocol:
+		default:
+			values = append(values, arg)
+		}
+	}
+
+	if len(values) == 0 {
+		return sql, nil
+	}
+
+	ci := pgtype.NewConnInfo()
+	return rewritePlaceholders(sql, func(placeholder string) (string, error) {
+		if placeholder[0] != '$' {
+			return placeholder, nil
+		}
+
+		n, err := strconv.Atoi(placeholder[1:])
+		if err != nil || n < 1 || n > len(values) {
+			return "", fmt.Errorf("no argument for placeholder %s", placeholder)
+		}
+
+		return quoteLiteral(ci, values[n-1])
+	})
+}
+
+// quoteLiteral returns the text format of arg as a quoted SQL literal. nil values are returned as null.
+func quoteLiteral(ci *pgtype.ConnInfo, arg interface{}) (string, error) {
+	if valuer, ok := arg.(driver.Valuer); ok {
+		v, err := valuer.Value()
+		if err != nil {
+			return "", err
+		}
+		arg = v
+	}
+
+	if arg == nil {
+		return "null", nil
+	}
+
+	encoder, ok := arg.(pgtype.TextEncoder)
+	if !ok {
+		dt, ok := ci.DataTypeForValue(arg)
+		if !ok {
+			return "", fmt.Errorf("cannot encode argument of type %T", arg)
+		}
+		value := pgtype.NewValue(dt.Value)
+		err := value.Set(arg)
+		if err != nil {
+			return "", err
+		}
+		encoder, ok = value.(pgtype.TextEncoder)
+		if !ok {
+			return "", fmt.Errorf("cannot encode argument of type %T", arg)
+		}
+	}
+
+	buf, err := encoder.EncodeText(ci, nil)
+	if err != nil {
+		return "", err
+	}
+	if buf == nil {
+		return "null", nil
+	}
+
+	return quoteString(string(buf)), nil
+}
+
+// CSVOptions controls how ImportCSV reads CSV data.
+type CSVOptions struct {
+	// Columns are the columns in the order they appear in the CSV data. If nil all columns of the table are used in
+	// table order.
+	Columns []string
+
+	// Header is true if the first line of the CSV data is a header that should be skipped.
+	Header bool
+
+	// Delimiter separates the values in a line. If zero a comma is used.
+	Delimiter rune
+
+	// Null is the string that represents a null value. If empty an unquoted empty value is null.
+	Null string
+}
+
+// ImportCSV reads CSV data from r into tableName with copy from stdin and returns the number of rows copied. The
+// names in opts.Columns are quoted as column names. tableName is used as is.
+func ImportCSV(ctx context.Context, db PgConner, tableName string, r io.Reader, opts CSVOptions) (int64, error) {
+	sb := &strings.Builder{}
+	sb.WriteString("copy ")
+	sb.WriteString(tableName)
+	if len(opts.Columns) > 0 {
+		sb.WriteString(" (")
+		sb.WriteString(string(build.QuoteIdentifierList(opts.Columns)))
+		sb.WriteByte(')')
+	}
+	sb.WriteString(" from stdin with (format csv")
+	if opts.Header {
+		sb.WriteString(", header true")
+	}
+	if opts.Delimiter != 0 {
+		sb.WriteString(", delimiter ")
+		sb.WriteString(quoteString(string(opts.Delimiter)))
+	}
+	if opts.Null != "" {
+		sb.WriteString(", null ")
+		sb.WriteString(quoteString(opts.Null))
+	}
+	sb.WriteByte(')')
+
+	ct, err := db.PgConn().CopyFrom(ctx, r, sb.String())
+	if err != nil {
+		return 0, err
+	}
+
+	return ct.RowsAffected(), nil
+}
+
+func quoteString(s string) string {
+	if strings.Contains(s, `\`) {
+		// Backslashes are escapes in an ordinary literal when standard_conforming_strings is off, so a literal with one is
+		// written as an escape string literal, whose meaning does not depend on the setting.
+		return "E'" + strings.ReplaceAll(strings.ReplaceAll(s, `\`, `\\`), "'", "''") + "'"
+	}
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
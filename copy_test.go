@@ -0,0 +1,161 @@
+package pgxutil_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgxutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCopyFromStructs(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		_, err := tx.Exec(ctx, `create temporary table t (id serial primary key, name text, height_inches int, note text)`)
+		require.NoError(t, err)
+
+		type person struct {
+			ID           int32 `db:"-"`
+			Name         string
+			HeightInches int32
+			Comment      *string `db:"note"`
+		}
+
+		note := "tall"
+		n, err := pgxutil.CopyFromStructs(ctx, tx, "t", []person{
+			{Name: "Adam", HeightInches: 72, Comment: &note},
+			{Name: "Bill", HeightInches: 68},
+		})
+		require.NoError(t, err)
+		assert.EqualValues(t, 2, n)
+
+		n, err = pgxutil.CopyFromStructs(ctx, tx, "t", []*person{{Name: "Charlie", HeightInches: 70}})
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, n)
+
+		rows, err := pgxutil.SelectAllStringMap(ctx, tx, "select name, height_inches, coalesce(note, '') as note from t order by id")
+		require.NoError(t, err)
+		assert.Equal(t, []map[string]string{
+			{"name": "Adam", "height_inches": "72", "note": "tall"},
+			{"name": "Bill", "height_inches": "68", "note": ""},
+			{"name": "Charlie", "height_inches": "70", "note": ""},
+		}, rows)
+	})
+}
+
+func TestCopyFromStructsTableName(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		_, err := tx.Exec(ctx, `create temporary table "Widgets.v2" (name text)`)
+		require.NoError(t, err)
+
+		type widget struct {
+			Name string
+		}
+
+		n, err := pgxutil.CopyFromStructs(ctx, tx, `PG_TEMP."Widgets.v2"`, []widget{{Name: "widget"}})
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, n)
+
+		_, err = pgxutil.CopyFromStructs(ctx, tx, `pg_temp."Widgets`, []widget{{Name: "widget"}})
+		assert.Error(t, err)
+	})
+}
+
+func TestCopyFromStructsErrors(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		_, err := tx.Exec(ctx, `create temporary table t (id serial primary key, name text)`)
+		require.NoError(t, err)
+
+		type person struct {
+			Name string
+		}
+
+		_, err = pgxutil.CopyFromStructs(ctx, tx, "t", person{Name: "Adam"})
+		assert.EqualError(t, err, "rows not a slice")
+
+		_, err = pgxutil.CopyFromStructs(ctx, tx, "t", []string{"Adam"})
+		assert.EqualError(t, err, "rows not a slice of structs or pointers to structs")
+
+		_, err = pgxutil.CopyFromStructs(ctx, tx, "t", []*person{{Name: "Adam"}, nil})
+		assert.Error(t, err)
+	})
+}
+
+func TestExportCSV(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		_, err := tx.Exec(ctx, `create temporary table t (id serial primary key, name text, height int)`)
+		require.NoError(t, err)
+		_, err = tx.Exec(ctx, `insert into t (name, height) values ('Adam', 72), ('Bill, Jr.', 68), ('Charlie', null)`)
+		require.NoError(t, err)
+
+		buf := &bytes.Buffer{}
+		n, err := pgxutil.ExportCSV(ctx, tx.Conn(), buf, "select name, height from t order by id")
+		require.NoError(t, err)
+		assert.EqualValues(t, 3, n)
+		assert.Equal(t, "name,height\nAdam,72\n\"Bill, Jr.\",68\nCharlie,\n", buf.String())
+
+		buf.Reset()
+		n, err = pgxutil.ExportCSV(ctx, tx.Conn(), buf, "select name from t where height = $1 or name = $2 order by id", 72, "O'Brien")
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, n)
+		assert.Equal(t, "name\nAdam\n", buf.String())
+
+		buf.Reset()
+		n, err = pgxutil.ExportCSV(ctx, tx.Conn(), buf, "select name from t where height < :height", pgxutil.NamedArgs{"height": 70})
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, n)
+		assert.Equal(t, "name\n\"Bill, Jr.\"\n", buf.String())
+
+		_, err = pgxutil.ExportCSV(ctx, tx.Conn(), buf, "select $2::text", "foo")
+		assert.EqualError(t, err, "no argument for placeholder $2")
+
+		// A backslash cannot end the literal of an argument when standard_conforming_strings is off.
+		_, err = tx.Exec(ctx, "set local standard_conforming_strings = off")
+		require.NoError(t, err)
+		buf.Reset()
+		n, err = pgxutil.ExportCSV(ctx, tx.Conn(), buf, "select $1::text as s", `\' union select 'injected' --`)
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, n)
+		assert.Equal(t, "s\n\\' union select 'injected' --\n", buf.String())
+	})
+}
+
+func TestImportCSV(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		_, err := tx.Exec(ctx, `create temporary table t (id serial primary key, name text, height int)`)
+		require.NoError(t, err)
+
+		n, err := pgxutil.ImportCSV(ctx, tx.Conn(), "t", strings.NewReader("name,height\nAdam,72\n\"Bill, Jr.\",68\n"), pgxutil.CSVOptions{
+			Columns: []string{"name", "height"},
+			Header:  true,
+		})
+		require.NoError(t, err)
+		assert.EqualValues(t, 2, n)
+
+		n, err = pgxutil.ImportCSV(ctx, tx.Conn(), "t", strings.NewReader("10;Charlie;NA\n"), pgxutil.CSVOptions{
+			Delimiter: ';',
+			Null:      "NA",
+		})
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, n)
+
+		rows, err := pgxutil.SelectAllStringMap(ctx, tx, "select name, coalesce(height, -1) as height from t order by id")
+		require.NoError(t, err)
+		assert.Equal(t, []map[string]string{
+			{"name": "Adam", "height": "72"},
+			{"name": "Bill, Jr.", "height": "68"},
+			{"name": "Charlie", "height": "-1"},
+		}, rows)
+
+		_, err = pgxutil.ImportCSV(ctx, tx.Conn(), "t", strings.NewReader("not a number\n"), pgxutil.CSVOptions{Columns: []string{"height"}})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,65 @@
+package pgxutil
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/jackc/pgx/v4"
+)
+
+var cursorCount int64
+
+// Cursor reads the results of a query in batches with a PostgreSQL cursor. A Cursor can only be used in the
+// transaction in which it was created.
+type Cursor struct {
+	tx        pgx.Tx
+	name      string
+	batchSize int
+}
+
+// SelectCursor declares a cursor for sql in tx. Each call to the Fetch methods of the returned Cursor reads up to
+// batchSize rows. The cursor should be closed when it is no longer needed. It is also closed when tx ends. args may
+// include NamedArgs and Options as for the Select functions. Options.StatementTimeout does not apply as the query is
+// run by the fetches, which take their Options from their own ctx.
+func SelectCursor(ctx context.Context, tx pgx.Tx, batchSize int, sql string, args ...interface{}) (*Cursor, error) {
+	if batchSize < 1 {
+		return nil, fmt.Errorf("batchSize must be at least 1")
+	}
+
+	ctx, cancel, sql, args, err := prepareQuery(ctx, sql, args)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	name := "pgxutil_cursor_" + strconv.FormatInt(atomic.AddInt64(&cursorCount, 1), 10)
+	_, err = cancelableExec(ctx, tx, "declare "+name+" no scroll cursor for "+sql, args)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Cursor{tx: tx, name: name, batchSize: batchSize}, nil
+}
+
+func (c *Cursor) fetchSQL() string {
+	return "fetch forward " + strconv.Itoa(c.batchSize) + " from " + c.name
+}
+
+// Fetch reads the next batch of rows into a map slice. An empty result means all rows have been read.
+func (c *Cursor) Fetch(ctx context.Context) ([]map[string]interface{}, error) {
+	return SelectAllMap(ctx, c.tx, c.fetchSQL())
+}
+
+// FetchStruct reads the next batch of rows into dst. dst must be a pointer to a slice of struct or pointer to struct.
+// The values are mapped the same way as SelectAllStruct. An empty result means all rows have been read.
+func (c *Cursor) FetchStruct(ctx context.Context, dst interface{}) error {
+	return SelectAllStruct(ctx, c.tx, dst, c.fetchSQL())
+}
+
+// Close closes the cursor.
+func (c *Cursor) Close(ctx context.Context) error {
+	_, err := c.tx.Exec(ctx, "close "+c.name)
+	return err
+}
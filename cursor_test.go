@@ -0,0 +1,72 @@
+package pgxutil_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgxutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursorFetch(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		cursor, err := pgxutil.SelectCursor(ctx, tx, 2, "select n from generate_series(1, $1::int) n", 5)
+		require.NoError(t, err)
+
+		var batchSizes []int
+		for {
+			rows, err := cursor.Fetch(ctx)
+			require.NoError(t, err)
+			if len(rows) == 0 {
+				break
+			}
+			batchSizes = append(batchSizes, len(rows))
+		}
+		assert.Equal(t, []int{2, 2, 1}, batchSizes)
+
+		require.NoError(t, cursor.Close(ctx))
+	})
+}
+
+func TestCursorNamedArgs(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		// NamedArgs are rewritten and Options are applied rather than sent as arguments.
+		cursor, err := pgxutil.SelectCursor(ctx, tx, 10, "select n from generate_series(1, :count::int) n", pgxutil.NamedArgs{"count": 3}, pgxutil.Options{ReadOnly: true})
+		require.NoError(t, err)
+		defer cursor.Close(ctx)
+
+		rows, err := cursor.Fetch(ctx)
+		require.NoError(t, err)
+		assert.Len(t, rows, 3)
+
+		_, err = pgxutil.SelectCursor(ctx, tx, 10, "insert into t values (1) returning *", pgxutil.Options{ReadOnly: true})
+		assert.ErrorIs(t, err, pgxutil.ErrNotReadOnly)
+	})
+}
+
+func TestCursorFetchStruct(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		type row struct {
+			N int32 `db:"n"`
+		}
+
+		cursor, err := pgxutil.SelectCursor(ctx, tx, 3, "select n from generate_series(1, 4) n")
+		require.NoError(t, err)
+		defer cursor.Close(ctx)
+
+		var batch []row
+		require.NoError(t, cursor.FetchStruct(ctx, &batch))
+		assert.Equal(t, []row{{1}, {2}, {3}}, batch)
+
+		require.NoError(t, cursor.FetchStruct(ctx, &batch))
+		assert.Equal(t, []row{{4}}, batch)
+
+		require.NoError(t, cursor.FetchStruct(ctx, &batch))
+		assert.Len(t, batch, 0)
+	})
+}
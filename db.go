@@ -0,0 +1,729 @@
+package pgxutil
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+)
+
+// DB wraps a Handle such as *pgxpool.Pool, *pgx.Conn, or pgx.Tx and exposes the pgxutil functions as methods, e.g.
+// db.SelectInt64(ctx, sql) instead of pgxutil.SelectInt64(ctx, db, sql). Each method behaves exactly like the function
+// of the same name called with the wrapped handle.
+//
+// DB itself implements Handle so it can be passed to the generic functions such as Select and wrapped by middleware
+// such as HookedDB. Because the SendBatch method implements BatchSender, the SendBatch function for a *Batch is the
+// Send method. Functions that require a pgx.Tx, such as SelectCursor, are not methods.
+type DB struct {
+	db Handle
+}
+
+// NewDB returns db wrapped in a DB.
+func NewDB(db Handle) *DB {
+	return &DB{db: db}
+}
+
+// WithHook returns a new DB whose queries are observed by hook as described by HookedDB.
+func (d *DB) WithHook(hook QueryHook) *DB {
+	return NewDB(NewHookedDB(d.db, hook))
+}
+
+// Query implements Queryer.
+func (d *DB) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	return d.db.Query(ctx, sql, args...)
+}
+
+// Exec implements Execer. Like Query, it sends sql and args to the wrapped handle as is, so the Exec function and the
+// other functions that execute statements do not apply Options or rewrite NamedArgs twice. Use the Exec function with
+// a DB for NamedArgs and Options.
+func (d *DB) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	return d.db.Exec(ctx, sql, args...)
+}
+
+// Begin implements Beginner.
+func (d *DB) Begin(ctx context.Context) (pgx.Tx, error) {
+	return d.db.Begin(ctx)
+}
+
+// BeginTx implements TxBeginner. It returns an error if the wrapped handle does not implement TxBeginner.
+func (d *DB) BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error) {
+	txBeginner, ok := d.db.(TxBeginner)
+	if !ok {
+		return nil, fmt.Errorf("%T does not implement BeginTx", d.db)
+	}
+
+	return txBeginner.BeginTx(ctx, txOptions)
+}
+
+// CopyFrom implements CopyFromer.
+func (d *DB) CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error) {
+	return d.db.CopyFrom(ctx, tableName, columnNames, rowSrc)
+}
+
+// SendBatch implements BatchSender.
+func (d *DB) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults {
+	return d.db.SendBatch(ctx, b)
+}
+
+// Send is like the SendBatch function.
+func (d *DB) Send(ctx context.Context, b *Batch) error {
+	return SendBatch(ctx, d.db, b)
+}
+
+// AcquireAdvisoryLock is like the AcquireAdvisoryLock function.
+func (d *DB) AcquireAdvisoryLock(ctx context.Context, key AdvisoryLockKey) error {
+	return AcquireAdvisoryLock(ctx, d.db, key)
+}
+
+// TryAdvisoryLock is like the TryAdvisoryLock function.
+func (d *DB) TryAdvisoryLock(ctx context.Context, key AdvisoryLockKey) (bool, error) {
+	return TryAdvisoryLock(ctx, d.db, key)
+}
+
+// ReleaseAdvisoryLock is like the ReleaseAdvisoryLock function.
+func (d *DB) ReleaseAdvisoryLock(ctx context.Context, key AdvisoryLockKey) error {
+	return ReleaseAdvisoryLock(ctx, d.db, key)
+}
+
+// WithAdvisoryLock is like the WithAdvisoryLock function.
+func (d *DB) WithAdvisoryLock(ctx context.Context, key AdvisoryLockKey, fn func(pgx.Tx) error) error {
+	return WithAdvisoryLock(ctx, d.db, key, fn)
+}
+
+// CreateCacheInvalidationTrigger is like the CreateCacheInvalidationTrigger function.
+func (d *DB) CreateCacheInvalidationTrigger(ctx context.Context, tableName, channel string) error {
+	return CreateCacheInvalidationTrigger(ctx, d.db, tableName, channel)
+}
+
+// SelectInt64Chunked is like the SelectInt64Chunked function.
+func (d *DB) SelectInt64Chunked(ctx context.Context, opts ChunkOptions, fn func([]int64) error, sql string, args ...interface{}) error {
+	return SelectInt64Chunked(ctx, d.db, opts, fn, sql, args...)
+}
+
+// SelectMapChunked is like the SelectMapChunked function.
+func (d *DB) SelectMapChunked(ctx context.Context, opts ChunkOptions, fn func([]map[string]interface{}) error, sql string, args ...interface{}) error {
+	return SelectMapChunked(ctx, d.db, opts, fn, sql, args...)
+}
+
+// DescribeQuery is like the DescribeQuery function.
+func (d *DB) DescribeQuery(ctx context.Context, sql string) (*QueryDescription, error) {
+	db, ok := d.db.(PgConner)
+	if !ok {
+		return nil, fmt.Errorf("%T does not implement PgConn", d.db)
+	}
+
+	return DescribeQuery(ctx, db, sql)
+}
+
+// SelectMapWithTypes is like the SelectMapWithTypes function.
+func (d *DB) SelectMapWithTypes(ctx context.Context, sql string, args ...interface{}) (map[string]interface{}, []ColumnInfo, error) {
+	return SelectMapWithTypes(ctx, d.db, sql, args...)
+}
+
+// SelectAllMapWithTypes is like the SelectAllMapWithTypes function.
+func (d *DB) SelectAllMapWithTypes(ctx context.Context, sql string, args ...interface{}) ([]map[string]interface{}, []ColumnInfo, error) {
+	return SelectAllMapWithTypes(ctx, d.db, sql, args...)
+}
+
+// CopyFromStructs is like the CopyFromStructs function.
+func (d *DB) CopyFromStructs(ctx context.Context, tableName string, rows interface{}) (int64, error) {
+	return CopyFromStructs(ctx, d.db, tableName, rows)
+}
+
+// ExportCSV is like the ExportCSV function.
+func (d *DB) ExportCSV(ctx context.Context, w io.Writer, sql string, args ...interface{}) (int64, error) {
+	db, ok := d.db.(PgConner)
+	if !ok {
+		return 0, fmt.Errorf("%T does not implement PgConn", d.db)
+	}
+
+	return ExportCSV(ctx, db, w, sql, args...)
+}
+
+// ImportCSV is like the ImportCSV function.
+func (d *DB) ImportCSV(ctx context.Context, tableName string, r io.Reader, opts CSVOptions) (int64, error) {
+	db, ok := d.db.(PgConner)
+	if !ok {
+		return 0, fmt.Errorf("%T does not implement PgConn", d.db)
+	}
+
+	return ImportCSV(ctx, db, tableName, r, opts)
+}
+
+// SelectJSONRows is like the SelectJSONRows function.
+func (d *DB) SelectJSONRows(ctx context.Context, w io.Writer, sql string, args ...interface{}) (int64, error) {
+	return SelectJSONRows(ctx, d.db, w, sql, args...)
+}
+
+// SelectCSV is like the SelectCSV function.
+func (d *DB) SelectCSV(ctx context.Context, w io.Writer, opts SelectCSVOptions, sql string, args ...interface{}) (int64, error) {
+	return SelectCSV(ctx, d.db, w, opts, sql, args...)
+}
+
+// ExecOne is like the ExecOne function.
+func (d *DB) ExecOne(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	return ExecOne(ctx, d.db, sql, args...)
+}
+
+// ExecAtLeastOne is like the ExecAtLeastOne function.
+func (d *DB) ExecAtLeastOne(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	return ExecAtLeastOne(ctx, d.db, sql, args...)
+}
+
+// MultiExec is like the MultiExec function.
+func (d *DB) MultiExec(ctx context.Context, sqls []string) error {
+	return MultiExec(ctx, d.db, sqls)
+}
+
+// Explain is like the Explain function.
+func (d *DB) Explain(ctx context.Context, opts ExplainOptions, sql string, args ...interface{}) (*ExplainResult, error) {
+	return Explain(ctx, d.db, opts, sql, args...)
+}
+
+// SelectPoint is like the SelectPoint function.
+func (d *DB) SelectPoint(ctx context.Context, sql string, args ...interface{}) (Point, error) {
+	return SelectPoint(ctx, d.db, sql, args...)
+}
+
+// SelectPointPtr is like the SelectPointPtr function.
+func (d *DB) SelectPointPtr(ctx context.Context, sql string, args ...interface{}) (*Point, error) {
+	return SelectPointPtr(ctx, d.db, sql, args...)
+}
+
+// SelectAllPoint is like the SelectAllPoint function.
+func (d *DB) SelectAllPoint(ctx context.Context, sql string, args ...interface{}) ([]Point, error) {
+	return SelectAllPoint(ctx, d.db, sql, args...)
+}
+
+// SelectBox is like the SelectBox function.
+func (d *DB) SelectBox(ctx context.Context, sql string, args ...interface{}) (Box, error) {
+	return SelectBox(ctx, d.db, sql, args...)
+}
+
+// SelectPolygon is like the SelectPolygon function.
+func (d *DB) SelectPolygon(ctx context.Context, sql string, args ...interface{}) (Polygon, error) {
+	return SelectPolygon(ctx, d.db, sql, args...)
+}
+
+// SelectGeometry is like the SelectGeometry function.
+func (d *DB) SelectGeometry(ctx context.Context, sql string, args ...interface{}) ([]byte, error) {
+	return SelectGeometry(ctx, d.db, sql, args...)
+}
+
+// HealthCheck is like the HealthCheck function.
+func (d *DB) HealthCheck(ctx context.Context) error {
+	return HealthCheck(ctx, d.db)
+}
+
+// CheckReplication is like the CheckReplication function.
+func (d *DB) CheckReplication(ctx context.Context) (*ReplicationStatus, error) {
+	return CheckReplication(ctx, d.db)
+}
+
+// Notify is like the Notify function.
+func (d *DB) Notify(ctx context.Context, channel string, payload interface{}) error {
+	return Notify(ctx, d.db, channel, payload)
+}
+
+// RefreshMaterializedView is like the RefreshMaterializedView function.
+func (d *DB) RefreshMaterializedView(ctx context.Context, name string, concurrently bool) error {
+	return RefreshMaterializedView(ctx, d.db, name, concurrently)
+}
+
+// SelectPage is like the SelectPage function.
+func (d *DB) SelectPage(ctx context.Context, sql string, args []interface{}, opts PageOptions) ([]map[string]interface{}, string, error) {
+	return SelectPage(ctx, d.db, sql, args, opts)
+}
+
+// SelectString is like the SelectString function.
+func (d *DB) SelectString(ctx context.Context, sql string, args ...interface{}) (string, error) {
+	return SelectString(ctx, d.db, sql, args...)
+}
+
+// SelectStringPtr is like the SelectStringPtr function.
+func (d *DB) SelectStringPtr(ctx context.Context, sql string, args ...interface{}) (*string, error) {
+	return SelectStringPtr(ctx, d.db, sql, args...)
+}
+
+// SelectStringOr is like the SelectStringOr function.
+func (d *DB) SelectStringOr(ctx context.Context, defaultValue string, sql string, args ...interface{}) (string, error) {
+	return SelectStringOr(ctx, d.db, defaultValue, sql, args...)
+}
+
+// SelectStringAs is like the SelectStringAs function.
+func (d *DB) SelectStringAs(ctx context.Context, typeName string, sql string, args ...interface{}) (string, error) {
+	return SelectStringAs(ctx, d.db, typeName, sql, args...)
+}
+
+// SelectAllString is like the SelectAllString function.
+func (d *DB) SelectAllString(ctx context.Context, sql string, args ...interface{}) ([]string, error) {
+	return SelectAllString(ctx, d.db, sql, args...)
+}
+
+// SelectByteSlice is like the SelectByteSlice function.
+func (d *DB) SelectByteSlice(ctx context.Context, sql string, args ...interface{}) ([]byte, error) {
+	return SelectByteSlice(ctx, d.db, sql, args...)
+}
+
+// SelectAllByteSlice is like the SelectAllByteSlice function.
+func (d *DB) SelectAllByteSlice(ctx context.Context, sql string, args ...interface{}) ([][]byte, error) {
+	return SelectAllByteSlice(ctx, d.db, sql, args...)
+}
+
+// SelectJSON is like the SelectJSON function.
+func (d *DB) SelectJSON(ctx context.Context, sql string, args ...interface{}) (json.RawMessage, error) {
+	return SelectJSON(ctx, d.db, sql, args...)
+}
+
+// SelectJSONUnmarshal is like the SelectJSONUnmarshal function.
+func (d *DB) SelectJSONUnmarshal(ctx context.Context, dst interface{}, sql string, args ...interface{}) error {
+	return SelectJSONUnmarshal(ctx, d.db, dst, sql, args...)
+}
+
+// SelectXML is like the SelectXML function.
+func (d *DB) SelectXML(ctx context.Context, sql string, args ...interface{}) ([]byte, error) {
+	return SelectXML(ctx, d.db, sql, args...)
+}
+
+// SelectXMLUnmarshal is like the SelectXMLUnmarshal function.
+func (d *DB) SelectXMLUnmarshal(ctx context.Context, dst interface{}, sql string, args ...interface{}) error {
+	return SelectXMLUnmarshal(ctx, d.db, dst, sql, args...)
+}
+
+// SelectBool is like the SelectBool function.
+func (d *DB) SelectBool(ctx context.Context, sql string, args ...interface{}) (bool, error) {
+	return SelectBool(ctx, d.db, sql, args...)
+}
+
+// SelectBoolPtr is like the SelectBoolPtr function.
+func (d *DB) SelectBoolPtr(ctx context.Context, sql string, args ...interface{}) (*bool, error) {
+	return SelectBoolPtr(ctx, d.db, sql, args...)
+}
+
+// SelectAllBool is like the SelectAllBool function.
+func (d *DB) SelectAllBool(ctx context.Context, sql string, args ...interface{}) ([]bool, error) {
+	return SelectAllBool(ctx, d.db, sql, args...)
+}
+
+// SelectExists is like the SelectExists function.
+func (d *DB) SelectExists(ctx context.Context, sql string, args ...interface{}) (bool, error) {
+	return SelectExists(ctx, d.db, sql, args...)
+}
+
+// SelectInt64 is like the SelectInt64 function.
+func (d *DB) SelectInt64(ctx context.Context, sql string, args ...interface{}) (int64, error) {
+	return SelectInt64(ctx, d.db, sql, args...)
+}
+
+// SelectInt64Ptr is like the SelectInt64Ptr function.
+func (d *DB) SelectInt64Ptr(ctx context.Context, sql string, args ...interface{}) (*int64, error) {
+	return SelectInt64Ptr(ctx, d.db, sql, args...)
+}
+
+// SelectInt64Or is like the SelectInt64Or function.
+func (d *DB) SelectInt64Or(ctx context.Context, defaultValue int64, sql string, args ...interface{}) (int64, error) {
+	return SelectInt64Or(ctx, d.db, defaultValue, sql, args...)
+}
+
+// SelectCount is like the SelectCount function.
+func (d *DB) SelectCount(ctx context.Context, tableName string, whereArgs map[string]interface{}) (int64, error) {
+	return SelectCount(ctx, d.db, tableName, whereArgs)
+}
+
+// SelectCountSQL is like the SelectCountSQL function.
+func (d *DB) SelectCountSQL(ctx context.Context, sql string, args ...interface{}) (int64, error) {
+	return SelectCountSQL(ctx, d.db, sql, args...)
+}
+
+// SelectAllInt64 is like the SelectAllInt64 function.
+func (d *DB) SelectAllInt64(ctx context.Context, sql string, args ...interface{}) ([]int64, error) {
+	return SelectAllInt64(ctx, d.db, sql, args...)
+}
+
+// SelectInt32 is like the SelectInt32 function.
+func (d *DB) SelectInt32(ctx context.Context, sql string, args ...interface{}) (int32, error) {
+	return SelectInt32(ctx, d.db, sql, args...)
+}
+
+// SelectInt32Ptr is like the SelectInt32Ptr function.
+func (d *DB) SelectInt32Ptr(ctx context.Context, sql string, args ...interface{}) (*int32, error) {
+	return SelectInt32Ptr(ctx, d.db, sql, args...)
+}
+
+// SelectInt32Or is like the SelectInt32Or function.
+func (d *DB) SelectInt32Or(ctx context.Context, defaultValue int32, sql string, args ...interface{}) (int32, error) {
+	return SelectInt32Or(ctx, d.db, defaultValue, sql, args...)
+}
+
+// SelectAllInt32 is like the SelectAllInt32 function.
+func (d *DB) SelectAllInt32(ctx context.Context, sql string, args ...interface{}) ([]int32, error) {
+	return SelectAllInt32(ctx, d.db, sql, args...)
+}
+
+// SelectInt16 is like the SelectInt16 function.
+func (d *DB) SelectInt16(ctx context.Context, sql string, args ...interface{}) (int16, error) {
+	return SelectInt16(ctx, d.db, sql, args...)
+}
+
+// SelectInt16Ptr is like the SelectInt16Ptr function.
+func (d *DB) SelectInt16Ptr(ctx context.Context, sql string, args ...interface{}) (*int16, error) {
+	return SelectInt16Ptr(ctx, d.db, sql, args...)
+}
+
+// SelectInt16Or is like the SelectInt16Or function.
+func (d *DB) SelectInt16Or(ctx context.Context, defaultValue int16, sql string, args ...interface{}) (int16, error) {
+	return SelectInt16Or(ctx, d.db, defaultValue, sql, args...)
+}
+
+// SelectAllInt16 is like the SelectAllInt16 function.
+func (d *DB) SelectAllInt16(ctx context.Context, sql string, args ...interface{}) ([]int16, error) {
+	return SelectAllInt16(ctx, d.db, sql, args...)
+}
+
+// SelectBigInt is like the SelectBigInt function.
+func (d *DB) SelectBigInt(ctx context.Context, sql string, args ...interface{}) (*big.Int, error) {
+	return SelectBigInt(ctx, d.db, sql, args...)
+}
+
+// SelectBigIntPtr is like the SelectBigIntPtr function.
+func (d *DB) SelectBigIntPtr(ctx context.Context, sql string, args ...interface{}) (*big.Int, error) {
+	return SelectBigIntPtr(ctx, d.db, sql, args...)
+}
+
+// SelectAllBigInt is like the SelectAllBigInt function.
+func (d *DB) SelectAllBigInt(ctx context.Context, sql string, args ...interface{}) ([]*big.Int, error) {
+	return SelectAllBigInt(ctx, d.db, sql, args...)
+}
+
+// SelectFloat64 is like the SelectFloat64 function.
+func (d *DB) SelectFloat64(ctx context.Context, sql string, args ...interface{}) (float64, error) {
+	return SelectFloat64(ctx, d.db, sql, args...)
+}
+
+// SelectFloat64Ptr is like the SelectFloat64Ptr function.
+func (d *DB) SelectFloat64Ptr(ctx context.Context, sql string, args ...interface{}) (*float64, error) {
+	return SelectFloat64Ptr(ctx, d.db, sql, args...)
+}
+
+// SelectAllFloat64 is like the SelectAllFloat64 function.
+func (d *DB) SelectAllFloat64(ctx context.Context, sql string, args ...interface{}) ([]float64, error) {
+	return SelectAllFloat64(ctx, d.db, sql, args...)
+}
+
+// SelectUUID is like the SelectUUID function.
+func (d *DB) SelectUUID(ctx context.Context, sql string, args ...interface{}) (uuid.UUID, error) {
+	return SelectUUID(ctx, d.db, sql, args...)
+}
+
+// SelectUUIDPtr is like the SelectUUIDPtr function.
+func (d *DB) SelectUUIDPtr(ctx context.Context, sql string, args ...interface{}) (*uuid.UUID, error) {
+	return SelectUUIDPtr(ctx, d.db, sql, args...)
+}
+
+// SelectAllUUID is like the SelectAllUUID function.
+func (d *DB) SelectAllUUID(ctx context.Context, sql string, args ...interface{}) ([]uuid.UUID, error) {
+	return SelectAllUUID(ctx, d.db, sql, args...)
+}
+
+// SelectInt64Array is like the SelectInt64Array function.
+func (d *DB) SelectInt64Array(ctx context.Context, sql string, args ...interface{}) ([]int64, error) {
+	return SelectInt64Array(ctx, d.db, sql, args...)
+}
+
+// SelectStringArray is like the SelectStringArray function.
+func (d *DB) SelectStringArray(ctx context.Context, sql string, args ...interface{}) ([]string, error) {
+	return SelectStringArray(ctx, d.db, sql, args...)
+}
+
+// SelectStringArray2D is like the SelectStringArray2D function.
+func (d *DB) SelectStringArray2D(ctx context.Context, sql string, args ...interface{}) ([][]string, error) {
+	return SelectStringArray2D(ctx, d.db, sql, args...)
+}
+
+// SelectHstore is like the SelectHstore function.
+func (d *DB) SelectHstore(ctx context.Context, sql string, args ...interface{}) (map[string]*string, error) {
+	return SelectHstore(ctx, d.db, sql, args...)
+}
+
+// SelectAllHstore is like the SelectAllHstore function.
+func (d *DB) SelectAllHstore(ctx context.Context, sql string, args ...interface{}) ([]map[string]*string, error) {
+	return SelectAllHstore(ctx, d.db, sql, args...)
+}
+
+// SelectIPNet is like the SelectIPNet function.
+func (d *DB) SelectIPNet(ctx context.Context, sql string, args ...interface{}) (*net.IPNet, error) {
+	return SelectIPNet(ctx, d.db, sql, args...)
+}
+
+// SelectAllIPNet is like the SelectAllIPNet function.
+func (d *DB) SelectAllIPNet(ctx context.Context, sql string, args ...interface{}) ([]*net.IPNet, error) {
+	return SelectAllIPNet(ctx, d.db, sql, args...)
+}
+
+// SelectIP is like the SelectIP function.
+func (d *DB) SelectIP(ctx context.Context, sql string, args ...interface{}) (net.IP, error) {
+	return SelectIP(ctx, d.db, sql, args...)
+}
+
+// SelectAllIP is like the SelectAllIP function.
+func (d *DB) SelectAllIP(ctx context.Context, sql string, args ...interface{}) ([]net.IP, error) {
+	return SelectAllIP(ctx, d.db, sql, args...)
+}
+
+// SelectTime is like the SelectTime function.
+func (d *DB) SelectTime(ctx context.Context, sql string, args ...interface{}) (time.Time, error) {
+	return SelectTime(ctx, d.db, sql, args...)
+}
+
+// SelectTimePtr is like the SelectTimePtr function.
+func (d *DB) SelectTimePtr(ctx context.Context, sql string, args ...interface{}) (*time.Time, error) {
+	return SelectTimePtr(ctx, d.db, sql, args...)
+}
+
+// SelectAllTime is like the SelectAllTime function.
+func (d *DB) SelectAllTime(ctx context.Context, sql string, args ...interface{}) ([]time.Time, error) {
+	return SelectAllTime(ctx, d.db, sql, args...)
+}
+
+// SelectTimeWithOptions is like the SelectTimeWithOptions function.
+func (d *DB) SelectTimeWithOptions(ctx context.Context, opts TimeOptions, sql string, args ...interface{}) (time.Time, error) {
+	return SelectTimeWithOptions(ctx, d.db, opts, sql, args...)
+}
+
+// SelectAllTimeWithOptions is like the SelectAllTimeWithOptions function.
+func (d *DB) SelectAllTimeWithOptions(ctx context.Context, opts TimeOptions, sql string, args ...interface{}) ([]time.Time, error) {
+	return SelectAllTimeWithOptions(ctx, d.db, opts, sql, args...)
+}
+
+// SelectInterval is like the SelectInterval function.
+func (d *DB) SelectInterval(ctx context.Context, sql string, args ...interface{}) (pgtype.Interval, error) {
+	return SelectInterval(ctx, d.db, sql, args...)
+}
+
+// SelectAllInterval is like the SelectAllInterval function.
+func (d *DB) SelectAllInterval(ctx context.Context, sql string, args ...interface{}) ([]pgtype.Interval, error) {
+	return SelectAllInterval(ctx, d.db, sql, args...)
+}
+
+// SelectDuration is like the SelectDuration function.
+func (d *DB) SelectDuration(ctx context.Context, sql string, args ...interface{}) (time.Duration, error) {
+	return SelectDuration(ctx, d.db, sql, args...)
+}
+
+// SelectAllDuration is like the SelectAllDuration function.
+func (d *DB) SelectAllDuration(ctx context.Context, sql string, args ...interface{}) ([]time.Duration, error) {
+	return SelectAllDuration(ctx, d.db, sql, args...)
+}
+
+// SelectValue is like the SelectValue function.
+func (d *DB) SelectValue(ctx context.Context, sql string, args ...interface{}) (interface{}, error) {
+	return SelectValue(ctx, d.db, sql, args...)
+}
+
+// SelectValueOr is like the SelectValueOr function.
+func (d *DB) SelectValueOr(ctx context.Context, defaultValue interface{}, sql string, args ...interface{}) (interface{}, error) {
+	return SelectValueOr(ctx, d.db, defaultValue, sql, args...)
+}
+
+// SelectAllValue is like the SelectAllValue function.
+func (d *DB) SelectAllValue(ctx context.Context, sql string, args ...interface{}) ([]interface{}, error) {
+	return SelectAllValue(ctx, d.db, sql, args...)
+}
+
+// SelectMap is like the SelectMap function.
+func (d *DB) SelectMap(ctx context.Context, sql string, args ...interface{}) (map[string]interface{}, error) {
+	return SelectMap(ctx, d.db, sql, args...)
+}
+
+// SelectAllMap is like the SelectAllMap function.
+func (d *DB) SelectAllMap(ctx context.Context, sql string, args ...interface{}) ([]map[string]interface{}, error) {
+	return SelectAllMap(ctx, d.db, sql, args...)
+}
+
+// SelectAppendMap is like the SelectAppendMap function.
+func (d *DB) SelectAppendMap(ctx context.Context, dst []map[string]interface{}, sql string, args ...interface{}) ([]map[string]interface{}, error) {
+	return SelectAppendMap(ctx, d.db, dst, sql, args...)
+}
+
+// SelectMapForEach is like the SelectMapForEach function.
+func (d *DB) SelectMapForEach(ctx context.Context, fn func(map[string]interface{}) error, sql string, args ...interface{}) error {
+	return SelectMapForEach(ctx, d.db, fn, sql, args...)
+}
+
+// SelectMapForEachReuse is like the SelectMapForEachReuse function.
+func (d *DB) SelectMapForEachReuse(ctx context.Context, fn func(map[string]interface{}) error, sql string, args ...interface{}) error {
+	return SelectMapForEachReuse(ctx, d.db, fn, sql, args...)
+}
+
+// SelectStringMap is like the SelectStringMap function.
+func (d *DB) SelectStringMap(ctx context.Context, sql string, args ...interface{}) (map[string]string, error) {
+	return SelectStringMap(ctx, d.db, sql, args...)
+}
+
+// SelectAllStringMap is like the SelectAllStringMap function.
+func (d *DB) SelectAllStringMap(ctx context.Context, sql string, args ...interface{}) ([]map[string]string, error) {
+	return SelectAllStringMap(ctx, d.db, sql, args...)
+}
+
+// SelectRowScan is like the SelectRowScan function.
+func (d *DB) SelectRowScan(ctx context.Context, sql string, args []interface{}, dest ...interface{}) error {
+	return SelectRowScan(ctx, d.db, sql, args, dest...)
+}
+
+// SelectStruct is like the SelectStruct function.
+func (d *DB) SelectStruct(ctx context.Context, dst interface{}, sql string, args ...interface{}) error {
+	return SelectStruct(ctx, d.db, dst, sql, args...)
+}
+
+// SelectStructStrict is like the SelectStructStrict function.
+func (d *DB) SelectStructStrict(ctx context.Context, dst interface{}, sql string, args ...interface{}) error {
+	return SelectStructStrict(ctx, d.db, dst, sql, args...)
+}
+
+// SelectAllStruct is like the SelectAllStruct function.
+func (d *DB) SelectAllStruct(ctx context.Context, dst interface{}, sql string, args ...interface{}) error {
+	return SelectAllStruct(ctx, d.db, dst, sql, args...)
+}
+
+// SelectAllStructStrict is like the SelectAllStructStrict function.
+func (d *DB) SelectAllStructStrict(ctx context.Context, dst interface{}, sql string, args ...interface{}) error {
+	return SelectAllStructStrict(ctx, d.db, dst, sql, args...)
+}
+
+// SelectComposite is like the SelectComposite function.
+func (d *DB) SelectComposite(ctx context.Context, dst interface{}, sql string, args ...interface{}) error {
+	return SelectComposite(ctx, d.db, dst, sql, args...)
+}
+
+// Insert is like the Insert function.
+func (d *DB) Insert(ctx context.Context, tableName string, values map[string]interface{}) (map[string]interface{}, error) {
+	return Insert(ctx, d.db, tableName, values)
+}
+
+// InsertReturning is like the InsertReturning function.
+func (d *DB) InsertReturning(ctx context.Context, tableName string, values map[string]interface{}, returning string) (map[string]interface{}, error) {
+	return InsertReturning(ctx, d.db, tableName, values, returning)
+}
+
+// InsertReturningStruct is like the InsertReturningStruct function.
+func (d *DB) InsertReturningStruct(ctx context.Context, dst interface{}, tableName string, values map[string]interface{}) error {
+	return InsertReturningStruct(ctx, d.db, dst, tableName, values)
+}
+
+// Upsert is like the Upsert function.
+func (d *DB) Upsert(ctx context.Context, tableName string, values map[string]interface{}, conflictColumns []string) (map[string]interface{}, error) {
+	return Upsert(ctx, d.db, tableName, values, conflictColumns)
+}
+
+// InsertOnConflictDoNothing is like the InsertOnConflictDoNothing function.
+func (d *DB) InsertOnConflictDoNothing(ctx context.Context, tableName string, values map[string]interface{}, conflictColumns []string) (int64, error) {
+	return InsertOnConflictDoNothing(ctx, d.db, tableName, values, conflictColumns)
+}
+
+// InsertRows is like the InsertRows function.
+func (d *DB) InsertRows(ctx context.Context, tableName string, rows []map[string]interface{}) (int64, error) {
+	return InsertRows(ctx, d.db, tableName, rows)
+}
+
+// Update is like the Update function.
+func (d *DB) Update(ctx context.Context, tableName string, setValues, whereArgs map[string]interface{}) (int64, error) {
+	return Update(ctx, d.db, tableName, setValues, whereArgs)
+}
+
+// UpdateReturning is like the UpdateReturning function.
+func (d *DB) UpdateReturning(ctx context.Context, tableName string, setValues, whereArgs map[string]interface{}) ([]map[string]interface{}, error) {
+	return UpdateReturning(ctx, d.db, tableName, setValues, whereArgs)
+}
+
+// UpdateVersioned is like the UpdateVersioned function.
+func (d *DB) UpdateVersioned(ctx context.Context, tableName string, setValues, whereArgs map[string]interface{}, versionColumn string, version interface{}) (int64, error) {
+	return UpdateVersioned(ctx, d.db, tableName, setValues, whereArgs, versionColumn, version)
+}
+
+// Delete is like the Delete function.
+func (d *DB) Delete(ctx context.Context, tableName string, whereArgs map[string]interface{}) (int64, error) {
+	return Delete(ctx, d.db, tableName, whereArgs)
+}
+
+// DeleteOne is like the DeleteOne function.
+func (d *DB) DeleteOne(ctx context.Context, tableName string, whereArgs map[string]interface{}) error {
+	return DeleteOne(ctx, d.db, tableName, whereArgs)
+}
+
+// WithReadOnlyTx is like the WithReadOnlyTx function.
+func (d *DB) WithReadOnlyTx(ctx context.Context, fn func(pgx.Tx) error) error {
+	return WithReadOnlyTx(ctx, d.db, fn)
+}
+
+// InsertStruct is like the InsertStruct function.
+func (d *DB) InsertStruct(ctx context.Context, tableName string, record interface{}) error {
+	return InsertStruct(ctx, d.db, tableName, record)
+}
+
+// UpdateStruct is like the UpdateStruct function.
+func (d *DB) UpdateStruct(ctx context.Context, tableName string, record interface{}, whereColumns []string) (int64, error) {
+	return UpdateStruct(ctx, d.db, tableName, record, whereColumns)
+}
+
+// UpdateStructChanges is like the UpdateStructChanges function.
+func (d *DB) UpdateStructChanges(ctx context.Context, tableName string, record interface{}, whereColumns []string, changes map[string]interface{}) (int64, error) {
+	return UpdateStructChanges(ctx, d.db, tableName, record, whereColumns, changes)
+}
+
+// Get is like the Get function.
+func (d *DB) Get(ctx context.Context, tableName string, dest interface{}, pk interface{}) error {
+	return Get(ctx, d.db, tableName, dest, pk)
+}
+
+// ListTables is like the ListTables function.
+func (d *DB) ListTables(ctx context.Context) ([]Table, error) {
+	return ListTables(ctx, d.db)
+}
+
+// TableExists is like the TableExists function.
+func (d *DB) TableExists(ctx context.Context, tableName string) (bool, error) {
+	return TableExists(ctx, d.db, tableName)
+}
+
+// ListColumns is like the ListColumns function.
+func (d *DB) ListColumns(ctx context.Context, tableName string) ([]Column, error) {
+	return ListColumns(ctx, d.db, tableName)
+}
+
+// ColumnExists is like the ColumnExists function.
+func (d *DB) ColumnExists(ctx context.Context, tableName, columnName string) (bool, error) {
+	return ColumnExists(ctx, d.db, tableName, columnName)
+}
+
+// ListIndexes is like the ListIndexes function.
+func (d *DB) ListIndexes(ctx context.Context, tableName string) ([]Index, error) {
+	return ListIndexes(ctx, d.db, tableName)
+}
+
+// ListTableStats is like the ListTableStats function.
+func (d *DB) ListTableStats(ctx context.Context) ([]TableStats, error) {
+	return ListTableStats(ctx, d.db)
+}
+
+// GetTableStats is like the GetTableStats function.
+func (d *DB) GetTableStats(ctx context.Context, tableName string) (*TableStats, error) {
+	return GetTableStats(ctx, d.db, tableName)
+}
+
+// WithTx is like the WithTx function.
+func (d *DB) WithTx(ctx context.Context, fn func(pgx.Tx) error) error {
+	return WithTx(ctx, d.db, fn)
+}
+
+// WithTxRetry is like the WithTxRetry function.
+func (d *DB) WithTxRetry(ctx context.Context, txOptions pgx.TxOptions, maxAttempts int, fn func(pgx.Tx) error) error {
+	return WithTxRetry(ctx, d, txOptions, maxAttempts, fn)
+}
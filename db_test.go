@@ -0,0 +1,112 @@
+package pgxutil_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgxutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDB(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		db := pgxutil.NewDB(tx)
+
+		_, err := db.Exec(ctx, `create temporary table t (id serial primary key, name text)`)
+		require.NoError(t, err)
+
+		_, err = db.Insert(ctx, "t", map[string]interface{}{"name": "Adam"})
+		require.NoError(t, err)
+
+		n, err := db.Update(ctx, "t", map[string]interface{}{"name": "Bill"}, map[string]interface{}{"id": 1})
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, n)
+
+		name, err := db.SelectString(ctx, "select name from t where id = :id", pgxutil.NamedArgs{"id": 1})
+		require.NoError(t, err)
+		assert.Equal(t, "Bill", name)
+
+		count, err := pgxutil.Select[int64](ctx, db, "select count(*) from t")
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, count)
+
+		err = db.WithTx(ctx, func(tx pgx.Tx) error {
+			_, err := pgxutil.NewDB(tx).Delete(ctx, "t", map[string]interface{}{"id": 1})
+			return err
+		})
+		require.NoError(t, err)
+
+		exists, err := db.SelectExists(ctx, "select 1 from t")
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+}
+
+func TestDBSend(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		db := pgxutil.NewDB(tx)
+
+		var n int64
+		var s string
+		b := &pgxutil.Batch{}
+		b.QueueSelectInt64("select 42", nil, func(v int64) error { n = v; return nil })
+		b.QueueSelectString("select 'foo'", nil, func(v string) error { s = v; return nil })
+		err := db.Send(ctx, b)
+		require.NoError(t, err)
+		assert.EqualValues(t, 42, n)
+		assert.Equal(t, "foo", s)
+	})
+}
+
+func TestDBWithHook(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		hook := &recordingHook{}
+		db := pgxutil.NewDB(tx).WithHook(hook)
+
+		n, err := db.SelectInt64(ctx, "select 1")
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, n)
+
+		_, err = db.Exec(ctx, "select 2")
+		require.NoError(t, err)
+
+		require.Len(t, hook.after, 2)
+		assert.Equal(t, "SelectInt64", hook.after[0].Function)
+		assert.Equal(t, "select 1", hook.after[0].SQL)
+		assert.Equal(t, "select 2", hook.after[1].SQL)
+	})
+}
+
+func TestDBPgConnRequired(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		db := pgxutil.NewDB(tx)
+
+		_, err := db.DescribeQuery(ctx, "select 1")
+		require.EqualError(t, err, "*pgx.dbTx does not implement PgConn")
+
+		desc, err := pgxutil.NewDB(tx.Conn()).DescribeQuery(ctx, "select $1::int4")
+		require.NoError(t, err)
+		assert.Equal(t, []uint32{23}, desc.ParamOIDs)
+	})
+}
+
+func TestDBExecPassesThrough(t *testing.T) {
+	t.Parallel()
+
+	handle := &flakyDB{failures: 1, err: errors.New("failed")}
+	db := pgxutil.NewDB(handle)
+
+	_, err := pgxutil.Exec(context.Background(), db, "update t set n = :n", pgxutil.NamedArgs{"n": 1})
+	require.EqualError(t, err, `query "update t set n = $1": failed`)
+
+	_, err = db.Exec(context.Background(), "update t set n = 1")
+	require.NoError(t, err)
+	assert.Equal(t, 2, handle.attempts)
+}
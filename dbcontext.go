@@ -0,0 +1,51 @@
+package pgxutil
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v4"
+)
+
+type dbContextKey struct{}
+
+// NewContext returns a copy of ctx that carries db. Code that is passed the context can get db with FromContext
+// without knowing whether it is a pool or a transaction. This lets a service layer decide the transaction boundaries
+// of repositories that only receive a context:
+//
+//	err := pgxutil.WithContextTx(ctx, func(ctx context.Context) error {
+//		err := users.Create(ctx, user) // uses pgxutil.FromContext(ctx)
+//		if err != nil {
+//			return err
+//		}
+//		return audit.Record(ctx, "user created")
+//	})
+func NewContext(ctx context.Context, db Handle) context.Context {
+	d, ok := db.(*DB)
+	if !ok {
+		d = NewDB(db)
+	}
+	return context.WithValue(ctx, dbContextKey{}, d)
+}
+
+// FromContext returns the handle attached to ctx with NewContext wrapped in a DB. Its methods are the pgxutil
+// functions without the db argument. ok is false if ctx does not carry a handle.
+func FromContext(ctx context.Context) (db *DB, ok bool) {
+	db, ok = ctx.Value(dbContextKey{}).(*DB)
+	return db, ok
+}
+
+// WithContextTx begins a transaction on the handle attached to ctx with NewContext and calls fn with a copy of ctx that
+// carries the transaction instead. The transaction is committed or rolled back as described by WithTx. If the handle
+// is already a transaction a savepoint is used, so WithContextTx calls can be nested. An error is returned if ctx does
+// not carry a handle.
+func WithContextTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	db, ok := FromContext(ctx)
+	if !ok {
+		return fmt.Errorf("context does not carry a Handle, which is required for WithContextTx")
+	}
+
+	return WithTx(ctx, db, func(tx pgx.Tx) error {
+		return fn(NewContext(ctx, tx))
+	})
+}
@@ -0,0 +1,64 @@
+package pgxutil_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgxutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextDB(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		_, err := tx.Exec(ctx, "create temporary table t (id int primary key)")
+		require.NoError(t, err)
+
+		ctx = pgxutil.NewContext(ctx, tx)
+
+		insert := func(ctx context.Context, id int) error {
+			db, ok := pgxutil.FromContext(ctx)
+			require.True(t, ok)
+			_, err := db.Exec(ctx, "insert into t values ($1)", id)
+			return err
+		}
+
+		err = pgxutil.WithContextTx(ctx, func(ctx context.Context) error {
+			require.NoError(t, insert(ctx, 1))
+
+			// The nested transaction is a savepoint that is rolled back on its own.
+			err := pgxutil.WithContextTx(ctx, func(ctx context.Context) error {
+				require.NoError(t, insert(ctx, 2))
+				return errors.New("rollback")
+			})
+			require.EqualError(t, err, "rollback")
+
+			return insert(ctx, 3)
+		})
+		require.NoError(t, err)
+
+		db, ok := pgxutil.FromContext(ctx)
+		require.True(t, ok)
+		ids, err := db.SelectAllInt64(ctx, "select id from t order by id")
+		require.NoError(t, err)
+		assert.Equal(t, []int64{1, 3}, ids)
+	})
+}
+
+func TestContextDBMissing(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	_, ok := pgxutil.FromContext(ctx)
+	assert.False(t, ok)
+
+	err := pgxutil.WithContextTx(ctx, func(ctx context.Context) error {
+		t.Error("fn was called")
+		return nil
+	})
+	require.EqualError(t, err, "context does not carry a Handle, which is required for WithContextTx")
+}
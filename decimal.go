@@ -0,0 +1,45 @@
+//go:build !pgxutil_nodecimal
+
+// The functions in this file use github.com/shopspring/decimal. Build with the pgxutil_nodecimal tag to omit them, so
+// the decimal package is not compiled in, in projects that use another decimal type with SelectNumeric. The module is
+// still required by go.mod, as pgtype requires it too.
+
+package pgxutil
+
+import (
+	"context"
+
+	"github.com/shopspring/decimal"
+)
+
+// SelectDecimal selects a single decimal.Decimal. Any PostgreSQL value representable as an decimal can be selected.
+// An error will be returned if no rows are found or a null value is found.
+func SelectDecimal(ctx context.Context, db Queryer, sql string, args ...interface{}) (decimal.Decimal, error) {
+	return SelectNumeric(ctx, db, decimal.NewFromString, sql, args...)
+}
+
+// SelectDecimalPtr is like SelectDecimal except nil is returned if a null value is found.
+func SelectDecimalPtr(ctx context.Context, db Queryer, sql string, args ...interface{}) (*decimal.Decimal, error) {
+	return SelectNumericPtr(ctx, db, decimal.NewFromString, sql, args...)
+}
+
+// SelectAllDecimal selects a column of decimal.Decimal. Any PostgreSQL value representable as an decimal can be
+// selected. An error will be returned if a null value is found.
+func SelectAllDecimal(ctx context.Context, db Queryer, sql string, args ...interface{}) ([]decimal.Decimal, error) {
+	return SelectAllNumeric(ctx, db, decimal.NewFromString, sql, args...)
+}
+
+// SelectDecimal is like the SelectDecimal function.
+func (d *DB) SelectDecimal(ctx context.Context, sql string, args ...interface{}) (decimal.Decimal, error) {
+	return SelectDecimal(ctx, d.db, sql, args...)
+}
+
+// SelectDecimalPtr is like the SelectDecimalPtr function.
+func (d *DB) SelectDecimalPtr(ctx context.Context, sql string, args ...interface{}) (*decimal.Decimal, error) {
+	return SelectDecimalPtr(ctx, d.db, sql, args...)
+}
+
+// SelectAllDecimal is like the SelectAllDecimal function.
+func (d *DB) SelectAllDecimal(ctx context.Context, sql string, args ...interface{}) ([]decimal.Decimal, error) {
+	return SelectAllDecimal(ctx, d.db, sql, args...)
+}
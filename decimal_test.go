@@ -0,0 +1,80 @@
+//go:build !pgxutil_nodecimal
+
+package pgxutil_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgxutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectDecimal(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		tests := []struct {
+			sql    string
+			result string
+		}{
+			{"select 1.2345::numeric", "1.2345"},
+			{"select 1.2345::float8", "1.2345"},
+			{"select 1.23::float4", "1.23"},
+			{"select 99999999999::bigint", "99999999999"},
+			{"select 42::smallint", "42"},
+		}
+		for i, tt := range tests {
+			v, err := pgxutil.SelectDecimal(ctx, tx, tt.sql)
+			assert.NoErrorf(t, err, "%d. %s", i, tt.sql)
+			assert.Equalf(t, tt.result, v.String(), "%d. %s", i, tt.sql)
+		}
+	})
+}
+
+func TestSelectAllDecimal(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		tests := []struct {
+			sql    string
+			result []string
+		}{
+			{"select n + 0.5 from generate_series(1,2) n", []string{"1.5", "2.5"}},
+			{"select 42.0 where false", nil},
+		}
+		for i, tt := range tests {
+			v, err := pgxutil.SelectAllDecimal(ctx, tx, tt.sql)
+			assert.NoErrorf(t, err, "%d. %s", i, tt.sql)
+			if assert.Equalf(t, len(tt.result), len(v), "%d. %s", i, tt.sql) {
+				for j := range v {
+					assert.Equalf(t, tt.result[j], v[j].String(), "%d. %s - %d", i, tt.sql, j)
+				}
+			}
+		}
+	})
+}
+
+func TestSelectDecimalPtr(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		d, err := pgxutil.SelectDecimalPtr(ctx, tx, "select 1.5::numeric")
+		assert.NoError(t, err)
+		if assert.NotNil(t, d) {
+			assert.Equal(t, "1.5", d.String())
+		}
+
+		d, err = pgxutil.SelectDecimalPtr(ctx, tx, "select null::numeric")
+		assert.NoError(t, err)
+		assert.Nil(t, d)
+	})
+}
+
+func TestOptionsResultFormatDecimal(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		d, err := pgxutil.SelectDecimal(ctx, tx, "select 1.5", pgxutil.Options{ResultFormat: pgxutil.BinaryResultFormat})
+		require.NoError(t, err)
+		assert.Equal(t, "1.5", d.String())
+	})
+}
@@ -0,0 +1,148 @@
+package pgxutil
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// Elector elects a single leader among processes that campaign with the same key, e.g. to run a background worker on
+// only one replica of a service. The leader is the process whose connection holds the session level advisory lock key.
+//
+// Leadership is lost when the connection is lost. The Elector finds out with a ping every CheckInterval, so if the
+// database drops the connection another process can be elected up to CheckInterval before the old leader resigns.
+// Work that must never overlap should also take a lock of its own, e.g. with WithAdvisoryLock.
+type Elector struct {
+	// Connect returns a new connection to hold the lock on. It is required. The Elector closes the connection when it is
+	// done with it. It must not be a connection that is used for anything else.
+	Connect func(ctx context.Context) (*pgx.Conn, error)
+
+	// Key is the advisory lock that elects the leader.
+	Key AdvisoryLockKey
+
+	// RetryInterval is how often a process that is not the leader tries to take the lock or reconnect. If zero 5 seconds
+	// is used.
+	RetryInterval time.Duration
+
+	// CheckInterval is how often the leader checks that its connection is still alive. A check that does not complete
+	// within CheckInterval fails, so a connection that stopped responding is not held indefinitely. If zero 5 seconds is
+	// used.
+	CheckInterval time.Duration
+
+	// OnChange is called with true when the process becomes the leader and with false when it stops being the leader. It
+	// is optional. It is called from the goroutine running Campaign, which waits for it to return.
+	OnChange func(leader bool)
+
+	// OnError is called with the errors that caused the Elector to reconnect. It is optional.
+	OnError func(err error)
+
+	leader int32
+}
+
+// IsLeader returns true if the process is currently the leader. It is safe to call from any goroutine.
+func (e *Elector) IsLeader() bool {
+	return atomic.LoadInt32(&e.leader) == 1
+}
+
+// Campaign campaigns for leadership until ctx is canceled. When ctx is canceled the leader resigns by closing its
+// connection, which releases the lock. It always returns a non-nil error.
+func (e *Elector) Campaign(ctx context.Context) error {
+	if e.Connect == nil {
+		return fmt.Errorf("Elector.Connect is nil")
+	}
+
+	for {
+		err := e.campaign(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if e.OnError != nil {
+			e.OnError(err)
+		}
+
+		err = e.sleep(ctx, e.retryInterval())
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// campaign connects, takes the lock when it is free, and holds it until an error occurs or ctx is canceled.
+func (e *Elector) campaign(ctx context.Context) error {
+	conn, err := e.Connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	for {
+		acquired, err := TryAdvisoryLock(ctx, conn, e.Key)
+		if err != nil {
+			return err
+		}
+		if acquired {
+			break
+		}
+
+		err = e.sleep(ctx, e.retryInterval())
+		if err != nil {
+			return err
+		}
+	}
+
+	e.setLeader(true)
+	defer e.setLeader(false)
+
+	for {
+		err := e.sleep(ctx, e.checkInterval())
+		if err != nil {
+			return err
+		}
+
+		pingCtx, cancel := context.WithTimeout(ctx, e.checkInterval())
+		err = conn.Ping(pingCtx)
+		cancel()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (e *Elector) setLeader(leader bool) {
+	var v int32
+	if leader {
+		v = 1
+	}
+	atomic.StoreInt32(&e.leader, v)
+
+	if e.OnChange != nil {
+		e.OnChange(leader)
+	}
+}
+
+func (e *Elector) sleep(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
+
+func (e *Elector) retryInterval() time.Duration {
+	if e.RetryInterval == 0 {
+		return 5 * time.Second
+	}
+	return e.RetryInterval
+}
+
+func (e *Elector) checkInterval() time.Duration {
+	if e.CheckInterval == 0 {
+		return 5 * time.Second
+	}
+	return e.CheckInterval
+}
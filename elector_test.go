@@ -0,0 +1,77 @@
+package pgxutil_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgxutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestElector(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	adminConn := connectPG(t, ctx)
+	defer closeConn(t, adminConn)
+
+	newElector := func(changes chan bool, pids chan uint32) *pgxutil.Elector {
+		return &pgxutil.Elector{
+			Connect: func(ctx context.Context) (*pgx.Conn, error) {
+				conn := connectPG(t, ctx)
+				pids <- conn.PgConn().PID()
+				return conn, nil
+			},
+			Key:           pgxutil.Int32PairAdvisoryLockKey(84, 1),
+			RetryInterval: 10 * time.Millisecond,
+			CheckInterval: 10 * time.Millisecond,
+			OnChange:      func(leader bool) { changes <- leader },
+		}
+	}
+
+	changes1 := make(chan bool, 10)
+	pids1 := make(chan uint32, 10)
+	e1 := newElector(changes1, pids1)
+	ctx1, cancel1 := context.WithCancel(ctx)
+	err1 := make(chan error)
+	go func() { err1 <- e1.Campaign(ctx1) }()
+
+	<-pids1
+	require.True(t, <-changes1)
+	assert.True(t, e1.IsLeader())
+
+	changes2 := make(chan bool, 10)
+	pids2 := make(chan uint32, 10)
+	e2 := newElector(changes2, pids2)
+	ctx2, cancel2 := context.WithCancel(ctx)
+	err2 := make(chan error)
+	go func() { err2 <- e2.Campaign(ctx2) }()
+
+	pid2 := <-pids2
+	time.Sleep(50 * time.Millisecond)
+	assert.False(t, e2.IsLeader())
+
+	cancel1()
+	assert.True(t, errors.Is(<-err1, context.Canceled))
+	assert.False(t, <-changes1)
+	assert.False(t, e1.IsLeader())
+
+	require.True(t, <-changes2)
+	assert.True(t, e2.IsLeader())
+
+	// Losing the connection resigns and campaigns again on a new connection.
+	_, err := adminConn.Exec(ctx, "select pg_terminate_backend($1)", pid2)
+	require.NoError(t, err)
+	assert.False(t, <-changes2)
+	assert.NotEqual(t, pid2, <-pids2)
+	assert.True(t, <-changes2)
+
+	cancel2()
+	assert.True(t, errors.Is(<-err2, context.Canceled))
+}
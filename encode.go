@@ -0,0 +1,220 @@
+package pgxutil
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/jackc/pgproto3/v2"
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+)
+
+// jsonRowsResultFormats reads timestamps in the binary format so they can be formatted independently of the session's
+// DateStyle and TimeZone. All other values are read in the text format.
+var jsonRowsResultFormats = pgx.QueryResultFormatsByOID{
+	pgtype.TimestamptzOID: pgx.BinaryFormatCode,
+	pgtype.TimestampOID:   pgx.BinaryFormatCode,
+}
+
+// SelectJSONRows selects rows and writes them to w as a JSON array of objects. Each row is written as soon as it is
+// read so large result sets use constant memory. It returns the number of rows written. Nothing is written if the query
+// fails before returning any rows. If it fails later the output is incomplete.
+//
+// Booleans, integers, finite floats, json, and jsonb are written as their JSON equivalent. timestamptz values are
+// written as RFC 3339 strings in UTC and timestamp values as RFC 3339 strings without a time zone offset. All other
+// values, including numeric, are written as strings in the PostgreSQL text format so no precision is lost.
+func SelectJSONRows(ctx context.Context, db Queryer, w io.Writer, sql string, args ...interface{}) (int64, error) {
+	args = append([]interface{}{jsonRowsResultFormats}, args...)
+
+	var n int64
+	buf := &bytes.Buffer{}
+	buf.WriteByte('[')
+	err := selectRows(ctx, db, sql, args, func(rows pgx.Rows) error {
+		if n > 0 {
+			buf.WriteByte(',')
+		}
+
+		fieldDescriptions := rows.FieldDescriptions()
+		buf.WriteByte('{')
+		for i, raw := range rows.RawValues() {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			err := appendJSONString(buf, string(fieldDescriptions[i].Name))
+			if err != nil {
+				return err
+			}
+			buf.WriteByte(':')
+			err = appendJSONValue(buf, fieldDescriptions[i], raw)
+			if err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+
+		_, err := w.Write(buf.Bytes())
+		buf.Reset()
+		if err != nil {
+			return err
+		}
+		n++
+
+		return nil
+	})
+	if err != nil {
+		return n, err
+	}
+
+	buf.WriteByte(']')
+	_, err = w.Write(buf.Bytes())
+	return n, err
+}
+
+func appendJSONString(buf *bytes.Buffer, s string) error {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	buf.Write(b)
+	return nil
+}
+
+// appendJSONValue appends the JSON representation of a value as described by SelectJSONRows.
+func appendJSONValue(buf *bytes.Buffer, fd pgproto3.FieldDescription, raw []byte) error {
+	if raw == nil {
+		buf.WriteString("null")
+		return nil
+	}
+
+	if fd.Format == pgx.BinaryFormatCode {
+		switch fd.DataTypeOID {
+		case pgtype.TimestamptzOID:
+			var ts pgtype.Timestamptz
+			err := ts.DecodeBinary(nil, raw)
+			if err != nil {
+				return err
+			}
+			return appendJSONTime(buf, ts.Time.UTC(), ts.InfinityModifier, time.RFC3339Nano)
+		case pgtype.TimestampOID:
+			var ts pgtype.Timestamp
+			err := ts.DecodeBinary(nil, raw)
+			if err != nil {
+				return err
+			}
+			return appendJSONTime(buf, ts.Time, ts.InfinityModifier, "2006-01-02T15:04:05.999999999")
+		default:
+			return fmt.Errorf("cannot convert binary value of column %s to JSON", fd.Name)
+		}
+	}
+
+	switch fd.DataTypeOID {
+	case pgtype.BoolOID:
+		if string(raw) == "t" {
+			buf.WriteString("true")
+		} else {
+			buf.WriteString("false")
+		}
+		return nil
+	case pgtype.Int2OID, pgtype.Int4OID, pgtype.Int8OID, pgtype.OIDOID, pgtype.JSONOID, pgtype.JSONBOID:
+		buf.Write(raw)
+		return nil
+	case pgtype.Float4OID, pgtype.Float8OID:
+		switch string(raw) {
+		case "NaN", "Infinity", "-Infinity":
+		default:
+			buf.Write(raw)
+			return nil
+		}
+	}
+
+	return appendJSONString(buf, string(raw))
+}
+
+func appendJSONTime(buf *bytes.Buffer, t time.Time, infinityModifier pgtype.InfinityModifier, layout string) error {
+	switch infinityModifier {
+	case pgtype.Infinity:
+		return appendJSONString(buf, "infinity")
+	case pgtype.NegativeInfinity:
+		return appendJSONString(buf, "-infinity")
+	default:
+		return appendJSONString(buf, t.Format(layout))
+	}
+}
+
+// SelectCSVOptions controls how SelectCSV writes CSV data.
+type SelectCSVOptions struct {
+	// Delimiter separates the values in a line. If zero a comma is used.
+	Delimiter rune
+
+	// Null is written for null values. If empty null values are written as empty values.
+	Null string
+
+	// OmitHeader skips the header line of column names.
+	OmitHeader bool
+}
+
+// SelectCSV selects rows and writes them to w as CSV data with encoding/csv. The first line is a header of the column
+// names unless opts.OmitHeader is set. Values are written in the PostgreSQL text format. It returns the number of rows
+// written, not counting the header. If an error occurs the lines written before it are still flushed to w. Unlike
+// ExportCSV it works with any Queryer and supports NamedArgs and Options.
+func SelectCSV(ctx context.Context, db Queryer, w io.Writer, opts SelectCSVOptions, sql string, args ...interface{}) (int64, error) {
+	args = append([]interface{}{pgx.QueryResultFormats{pgx.TextFormatCode}}, args...)
+
+	cw := csv.NewWriter(w)
+	if opts.Delimiter != 0 {
+		cw.Comma = opts.Delimiter
+	}
+
+	writeHeader := func(fieldDescriptions []pgproto3.FieldDescription) error {
+		header := make([]string, len(fieldDescriptions))
+		for i, fd := range fieldDescriptions {
+			header[i] = string(fd.Name)
+		}
+		return cw.Write(header)
+	}
+
+	var n int64
+	var record []string
+	fieldDescriptions, err := selectRowsFields(ctx, db, sql, args, func(rows pgx.Rows) error {
+		if n == 0 && !opts.OmitHeader {
+			err := writeHeader(rows.FieldDescriptions())
+			if err != nil {
+				return err
+			}
+		}
+
+		values := rows.RawValues()
+		if record == nil {
+			record = make([]string, len(values))
+		}
+		for i, raw := range values {
+			if raw == nil {
+				record[i] = opts.Null
+			} else {
+				record[i] = string(raw)
+			}
+		}
+		n++
+
+		return cw.Write(record)
+	})
+	if err != nil {
+		cw.Flush()
+		return n, err
+	}
+
+	if n == 0 && !opts.OmitHeader {
+		err := writeHeader(fieldDescriptions)
+		if err != nil {
+			return n, err
+		}
+	}
+
+	cw.Flush()
+	return n, cw.Error()
+}
@@ -0,0 +1,87 @@
+package pgxutil_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgxutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectJSONRows(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		buf := &bytes.Buffer{}
+		n, err := pgxutil.SelectJSONRows(ctx, tx, buf, `select
+	n,
+	n % 2 = 0 as even,
+	(n / 4.0)::numeric(10, 2) as quarter,
+	n * 1.5::float8 as float,
+	'NaN'::float8 as nan,
+	'{"a": [1, 2]}'::jsonb as doc,
+	'<b> & "quoted"' as text,
+	null::text as missing,
+	'2020-01-02 03:04:05.5+00'::timestamptz as tstz,
+	'2020-01-02 03:04:05'::timestamp as ts,
+	'2020-01-02'::date as date,
+	'infinity'::timestamptz as forever
+from generate_series(1, 2) n`)
+		require.NoError(t, err)
+		assert.EqualValues(t, 2, n)
+		assert.JSONEq(t, `[
+	{"n": 1, "even": false, "quarter": "0.25", "float": 1.5, "nan": "NaN", "doc": {"a": [1, 2]}, "text": "<b> & \"quoted\"", "missing": null,
+		"tstz": "2020-01-02T03:04:05.5Z", "ts": "2020-01-02T03:04:05", "date": "2020-01-02", "forever": "infinity"},
+	{"n": 2, "even": true, "quarter": "0.50", "float": 3, "nan": "NaN", "doc": {"a": [1, 2]}, "text": "<b> & \"quoted\"", "missing": null,
+		"tstz": "2020-01-02T03:04:05.5Z", "ts": "2020-01-02T03:04:05", "date": "2020-01-02", "forever": "infinity"}
+]`, buf.String())
+
+		buf.Reset()
+		n, err = pgxutil.SelectJSONRows(ctx, tx, buf, "select 1 as n where false")
+		require.NoError(t, err)
+		assert.EqualValues(t, 0, n)
+		assert.Equal(t, "[]", buf.String())
+
+		buf.Reset()
+		_, err = pgxutil.SelectJSONRows(ctx, tx, buf, "select :n::int8 as n", pgxutil.NamedArgs{"n": 42})
+		require.NoError(t, err)
+		assert.Equal(t, `[{"n":42}]`, buf.String())
+
+		buf.Reset()
+		_, err = pgxutil.SelectJSONRows(ctx, tx, buf, "select 1/0")
+		require.Error(t, err)
+		assert.Empty(t, buf.String())
+	})
+}
+
+func TestSelectCSV(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		buf := &bytes.Buffer{}
+		n, err := pgxutil.SelectCSV(ctx, tx, buf, pgxutil.SelectCSVOptions{}, `select n, 'a,"b"' as s, nullif(n, 2) as maybe from generate_series(1, 2) n`)
+		require.NoError(t, err)
+		assert.EqualValues(t, 2, n)
+		assert.Equal(t, "n,s,maybe\n1,\"a,\"\"b\"\"\",1\n2,\"a,\"\"b\"\"\",\n", buf.String())
+
+		buf.Reset()
+		n, err = pgxutil.SelectCSV(ctx, tx, buf, pgxutil.SelectCSVOptions{Delimiter: ';', Null: `\N`, OmitHeader: true}, `select :n::int4 as n, null::text as s`, pgxutil.NamedArgs{"n": 7})
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, n)
+		assert.Equal(t, "7;\\N\n", buf.String())
+
+		buf.Reset()
+		n, err = pgxutil.SelectCSV(ctx, tx, buf, pgxutil.SelectCSVOptions{}, `select 1 as a, 2 as b where false`)
+		require.NoError(t, err)
+		assert.EqualValues(t, 0, n)
+		assert.Equal(t, "a,b\n", buf.String())
+
+		// The rows read before the error are written.
+		buf.Reset()
+		n, err = pgxutil.SelectCSV(ctx, tx, buf, pgxutil.SelectCSVOptions{}, `select 1 / (2 - n) as n from generate_series(1, 3) n`)
+		require.Error(t, err)
+		assert.EqualValues(t, 1, n)
+		assert.Equal(t, "n\n1\n", buf.String())
+	})
+}
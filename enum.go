@@ -0,0 +1,110 @@
+package pgxutil
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// UnknownEnumLabelError is returned by SelectEnum and SelectAllEnum when a selected label is not one of the allowed
+// labels. This usually means a label was added to the enum type in the database without adding the matching Go
+// constant.
+type UnknownEnumLabelError struct {
+	Label string
+}
+
+func (e *UnknownEnumLabelError) Error() string {
+	return fmt.Sprintf("unknown enum label %q", e.Label)
+}
+
+// SelectEnum selects a single enum label as a T. If the label is not in allowed an *UnknownEnumLabelError is returned.
+// Use CheckEnum to find labels missing from allowed before they are selected. An error will be returned if no rows are
+// found or a null value is found.
+func SelectEnum[T ~string](ctx context.Context, db Queryer, allowed []T, sql string, args ...interface{}) (T, error) {
+	args = append([]interface{}{pgx.QueryResultFormats{pgx.TextFormatCode}}, args...)
+	var label string
+	err := selectOneValueNotNull(ctx, db, sql, args, func(rows pgx.Rows) error {
+		label = string(rows.RawValues()[0])
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	err = checkEnumLabels(allowed, []string{label})
+	if err != nil {
+		return "", err
+	}
+
+	return T(label), nil
+}
+
+// SelectAllEnum selects a column of enum labels as T. The labels are checked as described by SelectEnum. An error will
+// be returned if a null value is found.
+func SelectAllEnum[T ~string](ctx context.Context, db Queryer, allowed []T, sql string, args ...interface{}) ([]T, error) {
+	args = append([]interface{}{pgx.QueryResultFormats{pgx.TextFormatCode}}, args...)
+	var labels []string
+	err := selectColumnNotNull(ctx, db, sql, args, func(rows pgx.Rows) error {
+		labels = append(labels, string(rows.RawValues()[0]))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkEnumLabels(allowed, labels)
+	if err != nil {
+		return nil, err
+	}
+
+	v := make([]T, len(labels))
+	for i := range labels {
+		v[i] = T(labels[i])
+	}
+	return v, nil
+}
+
+// CheckEnum checks that the labels of the enum type typeName in pg_enum are exactly allowed, e.g. at startup so a label
+// added to the database without the matching Go constant is found before it is selected. If the type has a label that
+// is not in allowed an *UnknownEnumLabelError is returned. If allowed has a label the type does not have, or typeName
+// is not an enum type, an error is returned too. typeName is resolved as a regtype, so it may be schema qualified.
+func CheckEnum[T ~string](ctx context.Context, db Queryer, typeName string, allowed []T) error {
+	labels, err := SelectAllString(ctx, db, "select enumlabel from pg_enum where enumtypid = $1::regtype order by enumsortorder", typeName)
+	if err != nil {
+		return err
+	}
+
+	err = checkEnumLabels(allowed, labels)
+	if err != nil {
+		return err
+	}
+
+	known := make(map[string]struct{}, len(labels))
+	for _, l := range labels {
+		known[l] = struct{}{}
+	}
+	for _, a := range allowed {
+		if _, ok := known[string(a)]; !ok {
+			return fmt.Errorf("enum label %q is not a label of %s", a, typeName)
+		}
+	}
+
+	return nil
+}
+
+// checkEnumLabels returns an *UnknownEnumLabelError for the first of labels that is not in allowed.
+func checkEnumLabels[T ~string](allowed []T, labels []string) error {
+	known := make(map[string]struct{}, len(allowed))
+	for _, a := range allowed {
+		known[string(a)] = struct{}{}
+	}
+
+	for _, l := range labels {
+		if _, ok := known[l]; !ok {
+			return &UnknownEnumLabelError{Label: l}
+		}
+	}
+
+	return nil
+}
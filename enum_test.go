@@ -0,0 +1,71 @@
+package pgxutil_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgxutil"
+	"github.com/jackc/pgxutil/pgxutiltest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mood string
+
+const (
+	moodHappy mood = "happy"
+	moodSad   mood = "sad"
+)
+
+var moods = []mood{moodHappy, moodSad}
+
+func TestSelectEnum(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	db := &pgxutiltest.FakeQueryer{}
+
+	db.ExpectQuery("select mood from people").ReturnRows(pgxutiltest.NewRows("mood").AddRow("sad"))
+	m, err := pgxutil.SelectEnum(ctx, db, moods, "select mood from people")
+	require.NoError(t, err)
+	assert.Equal(t, moodSad, m)
+
+	db.ExpectQuery("select mood from people").ReturnRows(pgxutiltest.NewRows("mood").AddRow("ecstatic"))
+	_, err = pgxutil.SelectEnum(ctx, db, moods, "select mood from people")
+	var labelErr *pgxutil.UnknownEnumLabelError
+	require.True(t, errors.As(err, &labelErr))
+	assert.Equal(t, "ecstatic", labelErr.Label)
+	assert.EqualError(t, err, `unknown enum label "ecstatic"`)
+
+	db.ExpectQuery("select mood from people").ReturnRows(pgxutiltest.NewRows("mood").AddRow("happy").AddRow("sad"))
+	all, err := pgxutil.SelectAllEnum(ctx, db, moods, "select mood from people")
+	require.NoError(t, err)
+	assert.Equal(t, []mood{moodHappy, moodSad}, all)
+
+	require.NoError(t, db.ExpectationsWereMet())
+}
+
+func TestCheckEnum(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		_, err := tx.Exec(ctx, "create type pg_temp.mood as enum ('happy', 'sad')")
+		require.NoError(t, err)
+
+		require.NoError(t, pgxutil.CheckEnum(ctx, tx, "pg_temp.mood", moods))
+
+		// The Go constants lag behind the database.
+		err = pgxutil.CheckEnum(ctx, tx, "pg_temp.mood", []mood{moodHappy})
+		var labelErr *pgxutil.UnknownEnumLabelError
+		require.True(t, errors.As(err, &labelErr))
+		assert.Equal(t, "sad", labelErr.Label)
+
+		err = pgxutil.CheckEnum(ctx, tx, "pg_temp.mood", []mood{moodHappy, moodSad, "ecstatic"})
+		assert.EqualError(t, err, `enum label "ecstatic" is not a label of pg_temp.mood`)
+
+		// A text type has no enum labels.
+		err = pgxutil.CheckEnum(ctx, tx, "text", moods)
+		assert.Error(t, err)
+	})
+}
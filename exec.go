@@ -0,0 +1,99 @@
+package pgxutil
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+)
+
+// Exec executes sql with args. NamedArgs may be used in place of positional arguments. Options may be passed as an
+// argument or attached to ctx.
+func Exec(ctx context.Context, db Execer, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	ctx, cancel, sql, args, err := prepareQuery(ctx, sql, args)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	if timeout := OptionsFromContext(ctx).StatementTimeout; timeout != 0 {
+		var ct pgconn.CommandTag
+		err := withStatementTimeout(ctx, db, timeout, func(tx pgx.Tx) error {
+			var err error
+			ct, err = cancelableExec(ctx, tx, sql, args)
+			return err
+		})
+		return ct, err
+	}
+
+	return cancelableExec(ctx, db, sql, args)
+}
+
+// cancelableExec calls db.Exec with Options.CancelGracePeriod applied. An error is returned as a *QueryError.
+func cancelableExec(ctx context.Context, db Execer, sql string, args []interface{}) (pgconn.CommandTag, error) {
+	exec := func(ctx context.Context) (pgconn.CommandTag, error) {
+		ct, err := db.Exec(ctx, sql, args...)
+		if err != nil {
+			return ct, newQueryError(ctx, sql, args, err)
+		}
+		return ct, nil
+	}
+
+	grace := OptionsFromContext(ctx).CancelGracePeriod
+	if grace == 0 {
+		return exec(ctx)
+	}
+
+	var ct pgconn.CommandTag
+	err := withCancelRequest(ctx, db, grace, func(ctx context.Context) error {
+		var err error
+		ct, err = exec(ctx)
+		return err
+	})
+	return ct, err
+}
+
+// ExecOne is like Exec except an error is returned if the statement did not affect exactly one row. The statement is
+// not rolled back. Use a transaction if the change must be undone.
+func ExecOne(ctx context.Context, db Execer, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	ct, err := Exec(ctx, db, sql, args...)
+	if err != nil {
+		return ct, err
+	}
+
+	if n := ct.RowsAffected(); n != 1 {
+		return ct, fmt.Errorf("expected 1 row to be affected, but %d rows were affected", n)
+	}
+
+	return ct, nil
+}
+
+// ExecAtLeastOne is like Exec except an error is returned if the statement did not affect any rows. The statement is
+// not rolled back. Use a transaction if the change must be undone.
+func ExecAtLeastOne(ctx context.Context, db Execer, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	ct, err := Exec(ctx, db, sql, args...)
+	if err != nil {
+		return ct, err
+	}
+
+	if ct.RowsAffected() == 0 {
+		return ct, fmt.Errorf("expected at least 1 row to be affected, but 0 rows were affected")
+	}
+
+	return ct, nil
+}
+
+// MultiExec executes each statement in sqls in order. It stops at and returns the first error. Each statement is sent
+// without arguments so it may itself contain multiple semicolon separated statements. MultiExec does not start a
+// transaction. Pass a pgx.Tx as db if the statements must be applied atomically.
+func MultiExec(ctx context.Context, db Execer, sqls []string) error {
+	for i, sql := range sqls {
+		_, err := db.Exec(ctx, sql)
+		if err != nil {
+			return fmt.Errorf("statement %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package pgxutil_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgxutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExec(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		_, err := tx.Exec(ctx, `create temporary table t (id serial primary key, name text, height int)`)
+		require.NoError(t, err)
+
+		ct, err := pgxutil.Exec(ctx, tx, "insert into t (name, height) values ($1, $2), ($3, $4)", "Adam", 72, "Bill", 68)
+		require.NoError(t, err)
+		assert.EqualValues(t, 2, ct.RowsAffected())
+
+		ct, err = pgxutil.Exec(ctx, tx, "update t set height = :height where name = :name", pgxutil.NamedArgs{"name": "Adam", "height": 73})
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, ct.RowsAffected())
+	})
+}
+
+func TestExecOne(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		_, err := tx.Exec(ctx, `create temporary table t (id serial primary key, name text, height int)`)
+		require.NoError(t, err)
+		_, err = tx.Exec(ctx, `insert into t (name, height) values ('Adam', 72), ('Bill', 68), ('Charlie', 68)`)
+		require.NoError(t, err)
+
+		ct, err := pgxutil.ExecOne(ctx, tx, "update t set height = 73 where name = $1", "Adam")
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, ct.RowsAffected())
+
+		_, err = pgxutil.ExecOne(ctx, tx, "update t set height = 69 where height = $1", 68)
+		assert.EqualError(t, err, "expected 1 row to be affected, but 2 rows were affected")
+
+		_, err = pgxutil.ExecOne(ctx, tx, "delete from t where name = $1", "Zed")
+		assert.EqualError(t, err, "expected 1 row to be affected, but 0 rows were affected")
+	})
+}
+
+func TestExecAtLeastOne(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		_, err := tx.Exec(ctx, `create temporary table t (id serial primary key, name text, height int)`)
+		require.NoError(t, err)
+		_, err = tx.Exec(ctx, `insert into t (name, height) values ('Adam', 72), ('Bill', 68), ('Charlie', 68)`)
+		require.NoError(t, err)
+
+		ct, err := pgxutil.ExecAtLeastOne(ctx, tx, "update t set height = 69 where height = $1", 68)
+		require.NoError(t, err)
+		assert.EqualValues(t, 2, ct.RowsAffected())
+
+		_, err = pgxutil.ExecAtLeastOne(ctx, tx, "delete from t where name = $1", "Zed")
+		assert.EqualError(t, err, "expected at least 1 row to be affected, but 0 rows were affected")
+	})
+}
+
+func TestMultiExec(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		err := pgxutil.MultiExec(ctx, tx, []string{
+			`create temporary table t (id serial primary key, name text, height int)`,
+			`insert into t (name, height) values ('Adam', 72); insert into t (name, height) values ('Bill', 68)`,
+		})
+		require.NoError(t, err)
+
+		n, err := pgxutil.SelectInt64(ctx, tx, "select count(*) from t")
+		require.NoError(t, err)
+		assert.EqualValues(t, 2, n)
+
+		err = pgxutil.MultiExec(ctx, tx, []string{"select 1", "select * from missing_table"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "statement 1: ")
+	})
+}
@@ -0,0 +1,132 @@
+package pgxutil
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// ExplainOptions control the explain statement sent by Explain.
+type ExplainOptions struct {
+	// Analyze runs the statement and adds the actual times and row counts to the plan. The statement really runs, so
+	// explain a statement that changes data in a transaction that is rolled back.
+	Analyze bool
+
+	// Buffers adds the buffer usage of each node to the plan. It is mostly useful with Analyze.
+	Buffers bool
+
+	// FormatJSON requests the plan in the JSON format and parses it into ExplainResult.Plan instead of returning the
+	// lines of the text format in ExplainResult.Lines.
+	FormatJSON bool
+}
+
+// ExplainResult is the result of Explain.
+type ExplainResult struct {
+	// Lines are the lines of the text format plan. It is nil if ExplainOptions.FormatJSON is set.
+	Lines []string
+
+	// Plan is the root node of the plan. It is nil unless ExplainOptions.FormatJSON is set.
+	Plan *Plan
+
+	// PlanningTime and ExecutionTime are only set if ExplainOptions.FormatJSON and ExplainOptions.Analyze are set.
+	PlanningTime  time.Duration
+	ExecutionTime time.Duration
+}
+
+// Plan is a node of a JSON format plan. Only the common properties are parsed. Properties that do not apply to a node
+// or that were not requested are zero.
+type Plan struct {
+	// NodeType is the kind of node, e.g. Seq Scan, Index Scan, Index Only Scan, Hash Join, or Sort.
+	NodeType string `json:"Node Type"`
+
+	RelationName string `json:"Relation Name"`
+	Alias        string `json:"Alias"`
+	IndexName    string `json:"Index Name"`
+	IndexCond    string `json:"Index Cond"`
+	JoinType     string `json:"Join Type"`
+	Filter       string `json:"Filter"`
+
+	StartupCost float64 `json:"Startup Cost"`
+	TotalCost   float64 `json:"Total Cost"`
+	PlanRows    float64 `json:"Plan Rows"`
+	PlanWidth   int     `json:"Plan Width"`
+
+	// The actual properties are only present with ExplainOptions.Analyze. Times are in milliseconds.
+	ActualStartupTime float64 `json:"Actual Startup Time"`
+	ActualTotalTime   float64 `json:"Actual Total Time"`
+	ActualRows        float64 `json:"Actual Rows"`
+	ActualLoops       float64 `json:"Actual Loops"`
+
+	// The block counts are only present with ExplainOptions.Buffers.
+	SharedHitBlocks  int64 `json:"Shared Hit Blocks"`
+	SharedReadBlocks int64 `json:"Shared Read Blocks"`
+
+	// Plans are the child nodes.
+	Plans []*Plan `json:"Plans"`
+}
+
+// Nodes returns p and all of its descendants in depth-first order.
+func (p *Plan) Nodes() []*Plan {
+	nodes := []*Plan{p}
+	for _, child := range p.Plans {
+		nodes = append(nodes, child.Nodes()...)
+	}
+	return nodes
+}
+
+// UsesIndex returns true if any node of p scans the index indexName. e.g. a test can check that a query does not
+// fall back to a sequential scan.
+func (p *Plan) UsesIndex(indexName string) bool {
+	for _, node := range p.Nodes() {
+		if node.IndexName == indexName {
+			return true
+		}
+	}
+	return false
+}
+
+// Explain returns the plan of sql with args as described by opts. sql is not run unless opts.Analyze is set.
+func Explain(ctx context.Context, db Queryer, opts ExplainOptions, sql string, args ...interface{}) (*ExplainResult, error) {
+	var explainOpts []string
+	if opts.Analyze {
+		explainOpts = append(explainOpts, "analyze")
+	}
+	if opts.Buffers {
+		explainOpts = append(explainOpts, "buffers")
+	}
+	if opts.FormatJSON {
+		explainOpts = append(explainOpts, "format json")
+	}
+
+	explainSQL := "explain " + sql
+	if len(explainOpts) > 0 {
+		explainSQL = "explain (" + strings.Join(explainOpts, ", ") + ") " + sql
+	}
+
+	if !opts.FormatJSON {
+		lines, err := SelectAllString(ctx, db, explainSQL, args...)
+		if err != nil {
+			return nil, err
+		}
+		return &ExplainResult{Lines: lines}, nil
+	}
+
+	var explained []struct {
+		Plan          *Plan   `json:"Plan"`
+		PlanningTime  float64 `json:"Planning Time"`
+		ExecutionTime float64 `json:"Execution Time"`
+	}
+	err := SelectJSONUnmarshal(ctx, db, &explained, explainSQL, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ExplainResult{}
+	if len(explained) > 0 {
+		result.Plan = explained[0].Plan
+		result.PlanningTime = time.Duration(explained[0].PlanningTime * float64(time.Millisecond))
+		result.ExecutionTime = time.Duration(explained[0].ExecutionTime * float64(time.Millisecond))
+	}
+
+	return result, nil
+}
@@ -0,0 +1,79 @@
+package pgxutil_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgxutil"
+	"github.com/jackc/pgxutil/pgxutiltest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExplain(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		err := pgxutil.MultiExec(ctx, tx, []string{
+			"create temporary table t (id int primary key, name text)",
+			"insert into t select n, n::text from generate_series(1, 1000) n",
+			"analyze t",
+		})
+		require.NoError(t, err)
+
+		result, err := pgxutil.Explain(ctx, tx, pgxutil.ExplainOptions{}, "select * from t where id = $1", 42)
+		require.NoError(t, err)
+		require.NotEmpty(t, result.Lines)
+		assert.Contains(t, result.Lines[0], "Index Scan using t_pkey on t")
+		assert.Nil(t, result.Plan)
+
+		result, err = pgxutil.Explain(ctx, tx, pgxutil.ExplainOptions{Analyze: true, Buffers: true, FormatJSON: true}, "select * from t where id = $1", 42)
+		require.NoError(t, err)
+		assert.Nil(t, result.Lines)
+		require.NotNil(t, result.Plan)
+		assert.True(t, result.Plan.UsesIndex("t_pkey"))
+		assert.EqualValues(t, 1, result.Plan.ActualRows)
+		assert.Greater(t, result.ExecutionTime, time.Duration(0))
+
+		result, err = pgxutil.Explain(ctx, tx, pgxutil.ExplainOptions{FormatJSON: true}, "select * from t where name = $1", "42")
+		require.NoError(t, err)
+		assert.False(t, result.Plan.UsesIndex("t_pkey"))
+		assert.Equal(t, "Seq Scan", result.Plan.NodeType)
+	})
+}
+
+func TestExplainJSONPlan(t *testing.T) {
+	t.Parallel()
+
+	db := &pgxutiltest.FakeQueryer{}
+	db.ExpectQuery("explain (analyze, format json) select * from a join b using (id)").ReturnRows(pgxutiltest.NewRows("QUERY PLAN").AddRow(`[
+  {
+    "Plan": {
+      "Node Type": "Nested Loop",
+      "Join Type": "Inner",
+      "Actual Rows": 1,
+      "Plans": [
+        {"Node Type": "Seq Scan", "Relation Name": "a", "Alias": "a", "Actual Rows": 1},
+        {"Node Type": "Index Scan", "Relation Name": "b", "Alias": "b", "Index Name": "b_pkey", "Index Cond": "(id = a.id)", "Actual Rows": 1}
+      ]
+    },
+    "Planning Time": 0.5,
+    "Execution Time": 1.25
+  }
+]`))
+
+	result, err := pgxutil.Explain(context.Background(), db, pgxutil.ExplainOptions{Analyze: true, FormatJSON: true}, "select * from a join b using (id)")
+	require.NoError(t, err)
+	require.NoError(t, db.ExpectationsWereMet())
+
+	nodes := result.Plan.Nodes()
+	require.Len(t, nodes, 3)
+	assert.Equal(t, "Nested Loop", nodes[0].NodeType)
+	assert.Equal(t, "Seq Scan", nodes[1].NodeType)
+	assert.Equal(t, "(id = a.id)", nodes[2].IndexCond)
+	assert.True(t, result.Plan.UsesIndex("b_pkey"))
+	assert.False(t, result.Plan.UsesIndex("a_pkey"))
+	assert.Equal(t, 500*time.Microsecond, result.PlanningTime)
+	assert.Equal(t, 1250*time.Microsecond, result.ExecutionTime)
+}
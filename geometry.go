@@ -0,0 +1,146 @@
+package pgxutil
+
+import (
+	"context"
+	"encoding/hex"
+	"strings"
+
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+)
+
+// Point is a PostgreSQL point.
+type Point struct {
+	X float64
+	Y float64
+}
+
+// Box is a PostgreSQL box. PostgreSQL stores a box as its upper right and lower left corners regardless of the corners
+// it was created with.
+type Box struct {
+	UpperRight Point
+	LowerLeft  Point
+}
+
+// Polygon is a PostgreSQL polygon. The last point is connected to the first.
+type Polygon []Point
+
+// SelectPoint selects a single point. An error will be returned if no rows are found or a null value is found.
+func SelectPoint(ctx context.Context, db Queryer, sql string, args ...interface{}) (Point, error) {
+	v, err := Select[pgtype.Point](ctx, db, sql, args...)
+	return Point(v.P), err
+}
+
+// SelectPointPtr is like SelectPoint except nil is returned if a null value is found.
+func SelectPointPtr(ctx context.Context, db Queryer, sql string, args ...interface{}) (*Point, error) {
+	return selectOneValuePtr(ctx, db, sql, args, func(rows pgx.Rows) (Point, error) {
+		var v pgtype.Point
+		err := rows.Scan(&v)
+		return Point(v.P), err
+	})
+}
+
+// SelectAllPoint selects a column of points. An error will be returned if a null value is found.
+func SelectAllPoint(ctx context.Context, db Queryer, sql string, args ...interface{}) ([]Point, error) {
+	v := makeRowSlice[Point](ctx, args)
+	err := selectColumnNotNull(ctx, db, sql, args, func(rows pgx.Rows) error {
+		var p pgtype.Point
+		err := rows.Scan(&p)
+		if err != nil {
+			return err
+		}
+		v = append(v, Point(p.P))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// SelectBox selects a single box. An error will be returned if no rows are found or a null value is found.
+func SelectBox(ctx context.Context, db Queryer, sql string, args ...interface{}) (Box, error) {
+	v, err := Select[pgtype.Box](ctx, db, sql, args...)
+	return Box{UpperRight: Point(v.P[0]), LowerLeft: Point(v.P[1])}, err
+}
+
+// SelectPolygon selects a single polygon. An error will be returned if no rows are found or a null value is found.
+func SelectPolygon(ctx context.Context, db Queryer, sql string, args ...interface{}) (Polygon, error) {
+	v, err := Select[pgtype.Polygon](ctx, db, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	p := make(Polygon, len(v.P))
+	for i := range v.P {
+		p[i] = Point(v.P[i])
+	}
+	return p, nil
+}
+
+// SelectGeometry selects a single PostGIS geometry or geography as the EWKB bytes PostgreSQL outputs for it. The value
+// is read in the text format so the types do not need to be registered with pgx. A bytea such as the result of
+// ST_AsBinary can also be selected. An error will be returned if no rows are found or a null value is found.
+func SelectGeometry(ctx context.Context, db Queryer, sql string, args ...interface{}) ([]byte, error) {
+	args = append([]interface{}{pgx.QueryResultFormats{pgx.TextFormatCode}}, args...)
+	var v []byte
+	err := selectOneValueNotNull(ctx, db, sql, args, func(rows pgx.Rows) error {
+		var err error
+		v, err = decodeHexGeometry(rows.RawValues()[0])
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// SelectGeometryAs selects a single PostGIS geometry or geography as a T created by decode from the EWKB bytes
+// described by SelectGeometry. This allows selecting into the geometry type of any library without pgxutil depending
+// on it. e.g. for github.com/paulmach/orb:
+//
+//	g, err := SelectGeometryAs(ctx, db, ewkb.Unmarshal, "select boundary from regions where id = $1", id)
+//
+// An error will be returned if no rows are found or a null value is found.
+func SelectGeometryAs[T any](ctx context.Context, db Queryer, decode func([]byte) (T, error), sql string, args ...interface{}) (T, error) {
+	buf, err := SelectGeometry(ctx, db, sql, args...)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return decode(buf)
+}
+
+// SelectAllGeometryAs selects a column of T created by decode from the EWKB bytes of each value as described by
+// SelectGeometryAs. An error will be returned if a null value is found.
+func SelectAllGeometryAs[T any](ctx context.Context, db Queryer, decode func([]byte) (T, error), sql string, args ...interface{}) ([]T, error) {
+	args = append([]interface{}{pgx.QueryResultFormats{pgx.TextFormatCode}}, args...)
+	v := makeRowSlice[T](ctx, args)
+	err := selectColumnNotNull(ctx, db, sql, args, func(rows pgx.Rows) error {
+		buf, err := decodeHexGeometry(rows.RawValues()[0])
+		if err != nil {
+			return err
+		}
+		t, err := decode(buf)
+		if err != nil {
+			return err
+		}
+		v = append(v, t)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// decodeHexGeometry decodes the text format of a geometry, which is hex encoded EWKB, or of a bytea, which is hex
+// encoded with a \x prefix.
+func decodeHexGeometry(src []byte) ([]byte, error) {
+	s := strings.TrimPrefix(string(src), `\x`)
+	return hex.DecodeString(s)
+}
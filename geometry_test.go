@@ -0,0 +1,88 @@
+package pgxutil_test
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgxutil"
+	"github.com/jackc/pgxutil/pgxutiltest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectPoint(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		p, err := pgxutil.SelectPoint(ctx, tx, "select point(1.5, -2)")
+		require.NoError(t, err)
+		assert.Equal(t, pgxutil.Point{X: 1.5, Y: -2}, p)
+
+		pp, err := pgxutil.SelectPointPtr(ctx, tx, "select null::point")
+		require.NoError(t, err)
+		assert.Nil(t, pp)
+
+		points, err := pgxutil.SelectAllPoint(ctx, tx, "select point(n, n * 2) from generate_series(1, 2) n")
+		require.NoError(t, err)
+		assert.Equal(t, []pgxutil.Point{{X: 1, Y: 2}, {X: 2, Y: 4}}, points)
+	})
+}
+
+func TestSelectBox(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		b, err := pgxutil.SelectBox(ctx, tx, "select box(point(0, 0), point(2, 3))")
+		require.NoError(t, err)
+		assert.Equal(t, pgxutil.Box{UpperRight: pgxutil.Point{X: 2, Y: 3}, LowerLeft: pgxutil.Point{X: 0, Y: 0}}, b)
+	})
+}
+
+func TestSelectPolygon(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		p, err := pgxutil.SelectPolygon(ctx, tx, "select '((0,0),(1,0),(0,1))'::polygon")
+		require.NoError(t, err)
+		assert.Equal(t, pgxutil.Polygon{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 0, Y: 1}}, p)
+	})
+}
+
+// decodeWKBPoint decodes a little endian WKB point. It stands in for the decoder of a geometry library.
+func decodeWKBPoint(b []byte) (pgxutil.Point, error) {
+	if len(b) != 21 || b[0] != 1 || binary.LittleEndian.Uint32(b[1:]) != 1 {
+		return pgxutil.Point{}, fmt.Errorf("not a little endian WKB point")
+	}
+	return pgxutil.Point{
+		X: math.Float64frombits(binary.LittleEndian.Uint64(b[5:])),
+		Y: math.Float64frombits(binary.LittleEndian.Uint64(b[13:])),
+	}, nil
+}
+
+func TestSelectGeometryAs(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	// POINT(1 2) as output by PostGIS for a geometry and for ST_AsBinary.
+	const wkb = "0101000000000000000000F03F0000000000000040"
+
+	db := &pgxutiltest.FakeQueryer{}
+	db.ExpectQuery("select location").ReturnRows(pgxutiltest.NewRows("location").AddRow(wkb))
+	p, err := pgxutil.SelectGeometryAs(ctx, db, decodeWKBPoint, "select location")
+	require.NoError(t, err)
+	assert.Equal(t, pgxutil.Point{X: 1, Y: 2}, p)
+
+	db.ExpectQuery("select st_asbinary(location)").ReturnRows(pgxutiltest.NewRows("st_asbinary").AddRow(`\x` + wkb))
+	buf, err := pgxutil.SelectGeometry(ctx, db, "select st_asbinary(location)")
+	require.NoError(t, err)
+	assert.Len(t, buf, 21)
+
+	db.ExpectQuery("select location").ReturnRows(pgxutiltest.NewRows("location").AddRow(wkb).AddRow(wkb))
+	points, err := pgxutil.SelectAllGeometryAs(ctx, db, decodeWKBPoint, "select location")
+	require.NoError(t, err)
+	assert.Equal(t, []pgxutil.Point{{X: 1, Y: 2}, {X: 1, Y: 2}}, points)
+
+	require.NoError(t, db.ExpectationsWereMet())
+}
@@ -1,13 +1,27 @@
 module github.com/jackc/pgxutil
 
-go 1.14
+go 1.18
 
 require (
 	github.com/gofrs/uuid v3.2.0+incompatible
 	github.com/jackc/pgconn v1.6.1
-	github.com/jackc/pgsql v0.0.0-20200214204435-ebb58a15ff4a
+	github.com/jackc/pgproto3/v2 v2.0.2
 	github.com/jackc/pgtype v1.4.0
 	github.com/jackc/pgx/v4 v4.7.1
 	github.com/shopspring/decimal v1.2.0
-	github.com/stretchr/testify v1.5.1
+	github.com/stretchr/testify v1.8.2
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/jackc/chunkreader/v2 v2.0.1 // indirect
+	github.com/jackc/pgio v1.0.0 // indirect
+	github.com/jackc/pgpassfile v1.0.0 // indirect
+	github.com/jackc/pgservicefile v0.0.0-20200307190119-3430c5407db8 // indirect
+	github.com/jackc/puddle v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/crypto v0.0.0-20200622213623-75b288015ac9 // indirect
+	golang.org/x/text v0.3.7 // indirect
+	golang.org/x/xerrors v0.0.0-20200804184101-5ec99f83aff1 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -0,0 +1,83 @@
+package pgxutil
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+)
+
+// HealthCheckTimeout limits the time HealthCheck and CheckReplication wait for the server. A shorter deadline of the
+// context takes precedence.
+const HealthCheckTimeout = 5 * time.Second
+
+// ReplicationStatus describes whether a server is a replica and how far behind the primary it is.
+type ReplicationStatus struct {
+	// InRecovery is true if the server is a standby replaying WAL from a primary, pg_is_in_recovery().
+	InRecovery bool
+
+	// ReadOnly is true if the server does not accept writes, i.e. it is in recovery or default_transaction_read_only
+	// is on.
+	ReadOnly bool
+
+	// Lag is the time since the last transaction replayed by a replica was committed on the primary. It is zero for a
+	// primary and for a replica that has replayed all the WAL it received, as an idle primary sends no transactions
+	// to measure against.
+	Lag time.Duration
+
+	// LagKnown is false if the server is a replica that has not replayed any transaction since it started, so Lag
+	// could not be measured.
+	LagKnown bool
+}
+
+// HealthCheck returns an error if db cannot run select 1 within HealthCheckTimeout. It is meant for liveness and
+// readiness probes.
+func HealthCheck(ctx context.Context, db Queryer) error {
+	ctx, cancel := context.WithTimeout(ctx, HealthCheckTimeout)
+	defer cancel()
+
+	n, err := SelectInt64(ctx, db, "select 1")
+	if err != nil {
+		return err
+	}
+	if n != 1 {
+		return fmt.Errorf("health check returned %d instead of 1", n)
+	}
+
+	return nil
+}
+
+// CheckReplication returns the replication status of the server of db within HealthCheckTimeout. e.g. a readiness
+// probe of a service that reads from a replica can fail when Lag is too high, and one that writes can fail when
+// ReadOnly is true after a failover left it connected to a replica.
+func CheckReplication(ctx context.Context, db Queryer) (*ReplicationStatus, error) {
+	ctx, cancel := context.WithTimeout(ctx, HealthCheckTimeout)
+	defer cancel()
+
+	var status ReplicationStatus
+	var lag pgtype.Float8
+	err := selectRows(ctx, db, `select pg_is_in_recovery(),
+	pg_is_in_recovery() or current_setting('default_transaction_read_only')::bool,
+	case
+		when not pg_is_in_recovery() then 0
+		when pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() then 0
+		else extract(epoch from now() - pg_last_xact_replay_timestamp())
+	end::float8`,
+		nil,
+		func(rows pgx.Rows) error {
+			return rows.Scan(&status.InRecovery, &status.ReadOnly, &lag)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if lag.Status == pgtype.Present {
+		status.Lag = time.Duration(lag.Float * float64(time.Second))
+		status.LagKnown = true
+	}
+
+	return &status, nil
+}
@@ -0,0 +1,41 @@
+package pgxutil_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgxutil"
+	"github.com/jackc/pgxutil/pgxutiltest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthCheck(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		require.NoError(t, pgxutil.HealthCheck(ctx, tx))
+	})
+
+	db := &pgxutiltest.FakeQueryer{}
+	errRefused := errors.New("connection refused")
+	db.ExpectQuery("select 1").ReturnError(errRefused)
+	require.ErrorIs(t, pgxutil.HealthCheck(context.Background(), db), errRefused)
+}
+
+func TestCheckReplication(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		status, err := pgxutil.CheckReplication(ctx, tx)
+		require.NoError(t, err)
+		assert.Equal(t, &pgxutil.ReplicationStatus{LagKnown: true}, status)
+
+		_, err = tx.Exec(ctx, "set local default_transaction_read_only = on")
+		require.NoError(t, err)
+
+		status, err = pgxutil.CheckReplication(ctx, tx)
+		require.NoError(t, err)
+		assert.Equal(t, &pgxutil.ReplicationStatus{ReadOnly: true, LagKnown: true}, status)
+	})
+}
@@ -0,0 +1,383 @@
+package pgxutil
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"runtime"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgxutil/build"
+)
+
+// Handle is the interface of a database handle that can be wrapped by NewHookedDB. It is implemented by *pgx.Conn,
+// pgx.Tx, and *pgxpool.Pool.
+type Handle interface {
+	Queryer
+	Execer
+	Beginner
+	CopyFromer
+	BatchSender
+}
+
+// QueryHookData describes a query observed by a QueryHook.
+type QueryHookData struct {
+	// SQL and Args are the query as sent to PostgreSQL. NamedArgs have already been rewritten to positional arguments.
+	SQL  string
+	Args []interface{}
+
+	// Function is the name of the exported pgxutil function that sent the query such as SelectInt64 or Insert. It is
+	// empty when the query was sent directly through the HookedDB.
+	Function string
+
+	// Attributes are the Options.Attributes of the query.
+	Attributes map[string]string
+
+	// The remaining fields are only set for AfterQuery.
+
+	// Duration is the time from sending the query until it finished. For queries that return rows this includes the
+	// time spent reading the rows.
+	Duration time.Duration
+
+	// CommandTag is the command tag returned by PostgreSQL.
+	CommandTag pgconn.CommandTag
+
+	// Rows is the number of rows read from a query that returns rows.
+	Rows int64
+
+	// Err is the error the query failed with, if any.
+	Err error
+}
+
+// QueryHook observes the queries sent through a HookedDB.
+type QueryHook interface {
+	// BeforeQuery is called before a query is sent. The returned context is used for the query and passed to
+	// AfterQuery.
+	BeforeQuery(ctx context.Context, data *QueryHookData) context.Context
+
+	// AfterQuery is called when a query finishes. For queries that return rows that is when the rows are closed.
+	AfterQuery(ctx context.Context, data *QueryHookData)
+}
+
+// HookedDB wraps a Handle and calls a QueryHook for each query, statement, and copy sent through it. Transactions
+// begun through a HookedDB are also hooked. Batches are passed through without calling the hook. Use
+// pgxutil.SelectInt64(ctx, pgxutil.NewHookedDB(db, hook), sql) to hook a single call.
+type HookedDB struct {
+	db   Handle
+	hook QueryHook
+}
+
+// NewHookedDB returns db wrapped so hook is called for every query sent through it. Wrapping a HookedDB with another
+// hook calls the outer hook's BeforeQuery first and AfterQuery last.
+func NewHookedDB(db Handle, hook QueryHook) *HookedDB {
+	return &HookedDB{db: db, hook: hook}
+}
+
+// Query implements Queryer.
+func (h *HookedDB) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	return hookedQuery(ctx, h.db, h.hook, sql, args)
+}
+
+// QueryRow is like pgx.Conn.QueryRow.
+func (h *HookedDB) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	rows, err := h.Query(ctx, sql, args...)
+	return &hookedRow{rows: rows, err: err}
+}
+
+// Exec implements Execer.
+func (h *HookedDB) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	return hookedExec(ctx, h.db, h.hook, sql, args)
+}
+
+// CopyFrom implements CopyFromer.
+func (h *HookedDB) CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error) {
+	return hookedCopyFrom(ctx, h.db, h.hook, tableName, columnNames, rowSrc)
+}
+
+// SendBatch implements BatchSender. The hook is not called.
+func (h *HookedDB) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults {
+	return h.db.SendBatch(ctx, b)
+}
+
+// Begin implements Beginner. The returned transaction is hooked.
+func (h *HookedDB) Begin(ctx context.Context) (pgx.Tx, error) {
+	tx, err := h.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &hookedTx{Tx: tx, hook: h.hook}, nil
+}
+
+// BeginTx implements TxBeginner. An error is returned if the wrapped Handle does not implement TxBeginner. The
+// returned transaction is hooked.
+func (h *HookedDB) BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error) {
+	txBeginner, ok := h.db.(TxBeginner)
+	if !ok {
+		return nil, fmt.Errorf("%T does not implement BeginTx", h.db)
+	}
+
+	tx, err := txBeginner.BeginTx(ctx, txOptions)
+	if err != nil {
+		return nil, err
+	}
+	return &hookedTx{Tx: tx, hook: h.hook}, nil
+}
+
+// hookedTx is a pgx.Tx that calls hook for each query.
+type hookedTx struct {
+	pgx.Tx
+	hook QueryHook
+}
+
+func (tx *hookedTx) Begin(ctx context.Context) (pgx.Tx, error) {
+	nested, err := tx.Tx.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &hookedTx{Tx: nested, hook: tx.hook}, nil
+}
+
+func (tx *hookedTx) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	return hookedQuery(ctx, tx.Tx, tx.hook, sql, args)
+}
+
+func (tx *hookedTx) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	rows, err := tx.Query(ctx, sql, args...)
+	return &hookedRow{rows: rows, err: err}
+}
+
+func (tx *hookedTx) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	return hookedExec(ctx, tx.Tx, tx.hook, sql, args)
+}
+
+func (tx *hookedTx) CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error) {
+	return hookedCopyFrom(ctx, tx.Tx, tx.hook, tableName, columnNames, rowSrc)
+}
+
+func hookedQuery(ctx context.Context, db Queryer, hook QueryHook, sql string, args []interface{}) (pgx.Rows, error) {
+	data := &QueryHookData{SQL: sql, Args: args, Function: helperName(), Attributes: OptionsFromContext(ctx).Attributes}
+	ctx = hook.BeforeQuery(ctx, data)
+	startTime := time.Now()
+
+	rows, err := db.Query(ctx, sql, args...)
+	hr := &hookedRows{Rows: rows, ctx: ctx, hook: hook, data: data, startTime: startTime}
+	if err != nil {
+		hr.finish(err)
+		return rows, err
+	}
+
+	return hr, nil
+}
+
+func hookedExec(ctx context.Context, db Execer, hook QueryHook, sql string, args []interface{}) (pgconn.CommandTag, error) {
+	data := &QueryHookData{SQL: sql, Args: args, Function: helperName(), Attributes: OptionsFromContext(ctx).Attributes}
+	ctx = hook.BeforeQuery(ctx, data)
+	startTime := time.Now()
+
+	ct, err := db.Exec(ctx, sql, args...)
+
+	data.Duration = time.Since(startTime)
+	data.CommandTag = ct
+	data.Err = err
+	hook.AfterQuery(ctx, data)
+
+	return ct, err
+}
+
+func hookedCopyFrom(ctx context.Context, db CopyFromer, hook QueryHook, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error) {
+	data := &QueryHookData{
+		SQL:        fmt.Sprintf("copy %s (%s) from stdin", tableName.Sanitize(), build.QuoteIdentifierList(columnNames)),
+		Function:   helperName(),
+		Attributes: OptionsFromContext(ctx).Attributes,
+	}
+	ctx = hook.BeforeQuery(ctx, data)
+	startTime := time.Now()
+
+	n, err := db.CopyFrom(ctx, tableName, columnNames, rowSrc)
+
+	data.Duration = time.Since(startTime)
+	data.CommandTag = pgconn.CommandTag(fmt.Sprintf("COPY %d", n))
+	data.Rows = n
+	data.Err = err
+	hook.AfterQuery(ctx, data)
+
+	return n, err
+}
+
+// helperName returns the name of the exported pgxutil function that was called from outside pgxutil to send the query,
+// or an empty string if there is none. Functions that call back into the caller such as WithTx are skipped so a query
+// sent by the callback is reported for the function the callback called.
+func helperName() string {
+	const prefix = "github.com/jackc/pgxutil."
+
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	name := ""
+	for {
+		frame, more := frames.Next()
+		if strings.HasPrefix(frame.Function, prefix) {
+			fn := frame.Function[len(prefix):]
+			if i := strings.IndexAny(fn, ".["); i >= 0 {
+				fn = fn[:i]
+			}
+			if fn != "" && unicode.IsUpper(rune(fn[0])) {
+				name = fn
+			}
+		} else {
+			return name
+		}
+		if !more {
+			break
+		}
+	}
+
+	return name
+}
+
+// hookedRows calls AfterQuery when the rows are closed or all rows have been read.
+type hookedRows struct {
+	pgx.Rows
+	ctx       context.Context
+	hook      QueryHook
+	data      *QueryHookData
+	startTime time.Time
+	finished  bool
+}
+
+func (r *hookedRows) Next() bool {
+	if r.Rows.Next() {
+		r.data.Rows++
+		return true
+	}
+
+	r.finish(r.Rows.Err())
+	return false
+}
+
+func (r *hookedRows) Close() {
+	r.Rows.Close()
+	r.finish(r.Rows.Err())
+}
+
+func (r *hookedRows) finish(err error) {
+	if r.finished {
+		return
+	}
+	r.finished = true
+
+	r.data.Duration = time.Since(r.startTime)
+	if r.Rows != nil {
+		r.data.CommandTag = r.Rows.CommandTag()
+	}
+	r.data.Err = err
+	r.hook.AfterQuery(r.ctx, r.data)
+}
+
+// hookedRow implements pgx.Row for QueryRow.
+type hookedRow struct {
+	rows pgx.Rows
+	err  error
+}
+
+func (r *hookedRow) Scan(dest ...interface{}) error {
+	if r.err != nil {
+		return r.err
+	}
+	defer r.rows.Close()
+
+	if !r.rows.Next() {
+		if r.rows.Err() != nil {
+			return r.rows.Err()
+		}
+		return pgx.ErrNoRows
+	}
+
+	err := r.rows.Scan(dest...)
+	if err != nil {
+		return err
+	}
+	r.rows.Close()
+
+	return r.rows.Err()
+}
+
+// SlowQueryHook is a QueryHook that logs each query that takes longer than Threshold.
+type SlowQueryHook struct {
+	// Threshold is the duration a query must exceed to be logged.
+	Threshold time.Duration
+
+	// MaxArgLen is the length arguments are truncated to in the log message. If zero 64 is used.
+	MaxArgLen int
+
+	// Log is called for each slow query with a message including the duration, function, SQL, and truncated arguments.
+	// The arguments are omitted from both the message and data if Options.RedactArgs is set. data can be used for
+	// structured logging. If nil the message is written with the standard log package.
+	Log func(ctx context.Context, msg string, data *QueryHookData)
+}
+
+// BeforeQuery implements QueryHook.
+func (h *SlowQueryHook) BeforeQuery(ctx context.Context, data *QueryHookData) context.Context {
+	return ctx
+}
+
+// AfterQuery implements QueryHook.
+func (h *SlowQueryHook) AfterQuery(ctx context.Context, data *QueryHookData) {
+	if data.Duration <= h.Threshold {
+		return
+	}
+
+	sb := &strings.Builder{}
+	fmt.Fprintf(sb, "slow query: %v", data.Duration)
+	if data.Function != "" {
+		fmt.Fprintf(sb, " in %s", data.Function)
+	}
+	fmt.Fprintf(sb, ": %s", data.SQL)
+	if OptionsFromContext(ctx).RedactArgs {
+		redacted := *data
+		redacted.Args = nil
+		data = &redacted
+	}
+	if args := truncatedArgs(data.Args, h.MaxArgLen); len(args) > 0 {
+		fmt.Fprintf(sb, " args: [%s]", strings.Join(args, ", "))
+	}
+
+	if h.Log == nil {
+		log.Print(sb.String())
+		return
+	}
+	h.Log(ctx, sb.String(), data)
+}
+
+// truncatedArgs formats args for logging. Each argument is truncated to maxLen bytes. pgx query options are omitted.
+func truncatedArgs(args []interface{}, maxLen int) []string {
+	if maxLen <= 0 {
+		maxLen = 64
+	}
+
+	formatted := make([]string, 0, len(args))
+	for _, arg := range args {
+		switch arg.(type) {
+		case pgx.QueryResultFormats, pgx.QueryResultFormatsByOID, pgx.QuerySimpleProtocol:
+			continue
+		}
+
+		s := fmt.Sprintf("%v", arg)
+		if len(s) > maxLen {
+			cut := maxLen
+			for cut > 0 && !utf8.RuneStart(s[cut]) {
+				cut--
+			}
+			s = s[:cut] + "..."
+		}
+		formatted = append(formatted, s)
+	}
+
+	return formatted
+}
@@ -0,0 +1,142 @@
+package pgxutil_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgxutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type hookContextKey struct{}
+
+type recordingHook struct {
+	before []string
+	after  []pgxutil.QueryHookData
+}
+
+func (h *recordingHook) BeforeQuery(ctx context.Context, data *pgxutil.QueryHookData) context.Context {
+	h.before = append(h.before, data.SQL)
+	return context.WithValue(ctx, hookContextKey{}, data.SQL)
+}
+
+func (h *recordingHook) AfterQuery(ctx context.Context, data *pgxutil.QueryHookData) {
+	if ctx.Value(hookContextKey{}) != data.SQL {
+		panic("context from BeforeQuery not passed to AfterQuery")
+	}
+	h.after = append(h.after, *data)
+}
+
+func TestHookedDB(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		hook := &recordingHook{}
+		db := pgxutil.NewHookedDB(tx, hook)
+
+		_, err := db.Exec(ctx, `create temporary table t (id serial primary key, name text)`)
+		require.NoError(t, err)
+
+		_, err = pgxutil.InsertRows(ctx, db, "t", []map[string]interface{}{{"name": "Adam"}, {"name": "Bill"}})
+		require.NoError(t, err)
+
+		names, err := pgxutil.SelectAllString(ctx, db, "select name from t where id > :id order by id", pgxutil.NamedArgs{"id": 0})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Adam", "Bill"}, names)
+
+		_, err = pgxutil.SelectInt64(ctx, db, "select 1/0")
+		require.Error(t, err)
+
+		var n int64
+		err = db.QueryRow(ctx, "select count(*) from t").Scan(&n)
+		require.NoError(t, err)
+		assert.EqualValues(t, 2, n)
+
+		require.Len(t, hook.after, 5)
+		assert.Equal(t, hook.before, []string{hook.after[0].SQL, hook.after[1].SQL, hook.after[2].SQL, hook.after[3].SQL, hook.after[4].SQL})
+
+		assert.Equal(t, "CREATE TABLE", string(hook.after[0].CommandTag))
+		assert.Equal(t, "", hook.after[0].Function)
+
+		assert.Equal(t, `insert into t ("name") values ($1), ($2)`, hook.after[1].SQL)
+		assert.Equal(t, []interface{}{"Adam", "Bill"}, hook.after[1].Args)
+		assert.EqualValues(t, 2, hook.after[1].CommandTag.RowsAffected())
+		assert.Equal(t, "InsertRows", hook.after[1].Function)
+
+		assert.Equal(t, "select name from t where id > $1 order by id", hook.after[2].SQL)
+		assert.EqualValues(t, 2, hook.after[2].Rows)
+		assert.Equal(t, "SelectAllString", hook.after[2].Function)
+		assert.Equal(t, "SELECT 2", string(hook.after[2].CommandTag))
+		assert.NoError(t, hook.after[2].Err)
+		assert.True(t, hook.after[2].Duration > 0)
+
+		assert.Equal(t, "select 1/0", hook.after[3].SQL)
+		assert.Error(t, hook.after[3].Err)
+		assert.Equal(t, "SelectInt64", hook.after[3].Function)
+	})
+}
+
+func TestHookedDBTx(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		hook := &recordingHook{}
+		db := pgxutil.NewHookedDB(tx, hook)
+
+		err := pgxutil.WithTx(ctx, db, func(tx pgx.Tx) error {
+			_, err := pgxutil.SelectInt64(ctx, tx, "select 1")
+			if err != nil {
+				return err
+			}
+
+			_, err = tx.Exec(ctx, "select 3")
+			if err != nil {
+				return err
+			}
+
+			return pgxutil.WithTx(ctx, tx, func(tx pgx.Tx) error {
+				_, err := pgxutil.SelectInt64(ctx, tx, "select 2")
+				return err
+			})
+		})
+		require.NoError(t, err)
+
+		require.Len(t, hook.after, 3)
+		assert.Equal(t, "select 1", hook.after[0].SQL)
+		assert.Equal(t, "SelectInt64", hook.after[0].Function)
+		assert.Equal(t, "select 3", hook.after[1].SQL)
+		assert.Equal(t, "", hook.after[1].Function)
+		assert.Equal(t, "select 2", hook.after[2].SQL)
+		assert.Equal(t, "SelectInt64", hook.after[2].Function)
+	})
+}
+
+func TestSlowQueryHook(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		var messages []string
+		hook := &pgxutil.SlowQueryHook{
+			Threshold: 50 * time.Millisecond,
+			MaxArgLen: 5,
+			Log: func(ctx context.Context, msg string, data *pgxutil.QueryHookData) {
+				messages = append(messages, msg)
+			},
+		}
+		db := pgxutil.NewHookedDB(tx, hook)
+
+		_, err := pgxutil.SelectString(ctx, db, "select $1::text", "fast")
+		require.NoError(t, err)
+		assert.Empty(t, messages)
+
+		_, err = pgxutil.SelectString(ctx, db, "select $1::text from pg_sleep(0.1)", "abcdefghij")
+		require.NoError(t, err)
+		require.Len(t, messages, 1)
+		assert.Regexp(t, `^slow query: \S+ in SelectString: select \$1::text from pg_sleep\(0\.1\) args: \[abcde\.\.\.\]$`, messages[0])
+
+		_, err = pgxutil.SelectString(ctx, db, "select $1::text from pg_sleep(0.1)", "secret", pgxutil.Options{RedactArgs: true})
+		require.NoError(t, err)
+		require.Len(t, messages, 2)
+		assert.Regexp(t, `^slow query: \S+ in SelectString: select \$1::text from pg_sleep\(0\.1\)$`, messages[1])
+	})
+}
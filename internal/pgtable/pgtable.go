@@ -0,0 +1,35 @@
+// Package pgtable holds the helpers shared by the packages that keep their state in a table of their own, such as
+// queue, outbox, ratelimit, and kv.
+package pgtable
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgxutil"
+)
+
+// Name returns tableName or, if it is empty, defaultName.
+func Name(tableName, defaultName string) string {
+	if tableName == "" {
+		return defaultName
+	}
+	return tableName
+}
+
+// Create creates tableName with columns if it does not exist and then executes statements, e.g. to create indexes.
+// columns is the column list of the create table statement without the parentheses.
+func Create(ctx context.Context, db pgxutil.Execer, tableName, columns string, statements ...string) error {
+	return pgxutil.MultiExec(ctx, db, append([]string{
+		fmt.Sprintf("create table if not exists %s (%s)", tableName, columns),
+	}, statements...))
+}
+
+var indexNameReplacer = strings.NewReplacer(".", "_", `"`, "")
+
+// IndexName returns the quoted name of an index of tableName that ends in suffix. tableName may be qualified or quoted.
+func IndexName(tableName, suffix string) string {
+	return pgx.Identifier{indexNameReplacer.Replace(tableName) + "_" + suffix}.Sanitize()
+}
@@ -0,0 +1,16 @@
+package pgtable_test
+
+import (
+	"testing"
+
+	"github.com/jackc/pgxutil/internal/pgtable"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIndexName(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, `"queue_run_at_idx"`, pgtable.IndexName("queue", "run_at_idx"))
+	assert.Equal(t, `"pg_temp_queue_run_at_idx"`, pgtable.IndexName("pg_temp.queue", "run_at_idx"))
+	assert.Equal(t, `"my jobs_run_at_idx"`, pgtable.IndexName(`"my jobs"`, "run_at_idx"))
+}
@@ -0,0 +1,26 @@
+// Package testdb connects the tests of the pgxutil packages to the database named by the TEST_DATABASE environment
+// variable.
+package testdb
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/stretchr/testify/require"
+)
+
+// ConnString returns the connection string of the test database.
+func ConnString() string {
+	return fmt.Sprintf("database=%s", os.Getenv("TEST_DATABASE"))
+}
+
+// Connect connects to the test database. The connection is closed when the test finishes.
+func Connect(t testing.TB, ctx context.Context) *pgx.Conn {
+	conn, err := pgx.Connect(ctx, ConnString())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close(context.Background()) })
+	return conn
+}
@@ -0,0 +1,112 @@
+// Package kv is a small key-value store in a PostgreSQL table for things like feature flags and configuration.
+//
+// Values are stored as JSON. A key can have a time to live. Expiry is lazy: expired keys are treated as absent and are
+// replaced when they are set again, but their rows remain until DeleteExpired removes them.
+package kv
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgxutil"
+	"github.com/jackc/pgxutil/internal/pgtable"
+)
+
+// DefaultTableName is the table used when a table name is not set.
+const DefaultTableName = "kv_store"
+
+// CreateTable creates the table of keys if it does not exist. If tableName is empty DefaultTableName is used.
+func CreateTable(ctx context.Context, db pgxutil.Execer, tableName string) error {
+	return pgtable.Create(ctx, db, pgtable.Name(tableName, DefaultTableName), `
+	key text primary key,
+	value jsonb not null,
+	expires_at timestamptz
+`)
+}
+
+// Store reads and writes keys in a table. The zero value uses DefaultTableName.
+type Store struct {
+	// TableName is the table of keys. If empty DefaultTableName is used.
+	TableName string
+}
+
+// Get unmarshals the JSON value of key into dst. It returns pgxutil.ErrNoRows if key is not set or has expired.
+func (s *Store) Get(ctx context.Context, db pgxutil.Queryer, key string, dst interface{}) error {
+	return pgxutil.SelectJSONUnmarshal(ctx, db, dst,
+		fmt.Sprintf("select value from %s where key = $1 and (expires_at is null or expires_at > now())", s.tableName()),
+		key,
+	)
+}
+
+// Set sets key to value marshaled to JSON. If ttl is greater than zero key expires after ttl. Otherwise it never
+// expires. The expiration time is computed with the local clock.
+func (s *Store) Set(ctx context.Context, db pgxutil.Queryer, key string, value interface{}, ttl time.Duration) error {
+	values, err := s.values(key, value, ttl)
+	if err != nil {
+		return err
+	}
+
+	_, err = pgxutil.Upsert(ctx, db, s.tableName(), values, []string{"key"})
+	return err
+}
+
+// SetNX is like Set except key is only set if it is not already set or has expired. It returns true if key was set.
+// Concurrent callers never both succeed, so SetNX with a ttl can serve as a simple lease.
+func (s *Store) SetNX(ctx context.Context, db pgxutil.Execer, key string, value interface{}, ttl time.Duration) (bool, error) {
+	values, err := s.values(key, value, ttl)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = pgxutil.Exec(ctx, db, fmt.Sprintf("delete from %s where key = $1 and expires_at <= now()", s.tableName()), key)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := pgxutil.InsertOnConflictDoNothing(ctx, db, s.tableName(), values, []string{"key"})
+	if err != nil {
+		return false, err
+	}
+
+	return n == 1, nil
+}
+
+// Delete deletes key. It returns true if key was set and had not expired.
+func (s *Store) Delete(ctx context.Context, db pgxutil.Queryer, key string) (bool, error) {
+	live, err := pgxutil.SelectAllBool(ctx, db,
+		fmt.Sprintf("delete from %s where key = $1 returning expires_at is null or expires_at > now()", s.tableName()),
+		key,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return len(live) == 1 && live[0], nil
+}
+
+// DeleteExpired deletes all expired keys and returns the number of keys deleted. Call it periodically if keys are set
+// with a ttl and not overwritten.
+func (s *Store) DeleteExpired(ctx context.Context, db pgxutil.Execer) (int64, error) {
+	ct, err := pgxutil.Exec(ctx, db, fmt.Sprintf("delete from %s where expires_at <= now()", s.tableName()))
+	return ct.RowsAffected(), err
+}
+
+func (s *Store) values(key string, value interface{}, ttl time.Duration) (map[string]interface{}, error) {
+	buf, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var expiresAt interface{}
+	if ttl > 0 {
+		expiresAt = time.Now().Add(ttl)
+	}
+
+	return map[string]interface{}{"key": key, "value": string(buf), "expires_at": expiresAt}, nil
+}
+
+func (s *Store) tableName() string {
+	return pgtable.Name(s.TableName, DefaultTableName)
+}
@@ -0,0 +1,111 @@
+package kv_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgxutil"
+	"github.com/jackc/pgxutil/internal/testdb"
+	"github.com/jackc/pgxutil/kv"
+	"github.com/jackc/pgxutil/pgxutiltest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	conn := testdb.Connect(t, ctx)
+
+	s := &kv.Store{TableName: "pg_temp.kv"}
+	require.NoError(t, kv.CreateTable(ctx, conn, s.TableName))
+
+	type flag struct {
+		Enabled bool
+		Percent int
+	}
+
+	var f flag
+	err := s.Get(ctx, conn, "new_ui", &f)
+	assert.True(t, errors.Is(err, pgxutil.ErrNoRows))
+
+	require.NoError(t, s.Set(ctx, conn, "new_ui", flag{Enabled: true, Percent: 10}, 0))
+	require.NoError(t, s.Get(ctx, conn, "new_ui", &f))
+	assert.Equal(t, flag{Enabled: true, Percent: 10}, f)
+
+	require.NoError(t, s.Set(ctx, conn, "new_ui", flag{Enabled: true, Percent: 50}, 0))
+	require.NoError(t, s.Get(ctx, conn, "new_ui", &f))
+	assert.Equal(t, 50, f.Percent)
+
+	deleted, err := s.Delete(ctx, conn, "new_ui")
+	require.NoError(t, err)
+	assert.True(t, deleted)
+
+	deleted, err = s.Delete(ctx, conn, "new_ui")
+	require.NoError(t, err)
+	assert.False(t, deleted)
+}
+
+func TestStoreTTL(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	conn := testdb.Connect(t, ctx)
+
+	s := &kv.Store{TableName: "pg_temp.kv"}
+	require.NoError(t, kv.CreateTable(ctx, conn, s.TableName))
+
+	ok, err := s.SetNX(ctx, conn, "lease", "worker-1", time.Hour)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = s.SetNX(ctx, conn, "lease", "worker-2", time.Hour)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	var holder string
+	require.NoError(t, s.Get(ctx, conn, "lease", &holder))
+	assert.Equal(t, "worker-1", holder)
+
+	// Expire the lease.
+	_, err = pgxutil.Exec(ctx, conn, "update pg_temp.kv set expires_at = now() - '1 second'::interval")
+	require.NoError(t, err)
+
+	err = s.Get(ctx, conn, "lease", &holder)
+	assert.True(t, errors.Is(err, pgxutil.ErrNoRows))
+
+	ok, err = s.SetNX(ctx, conn, "lease", "worker-2", time.Hour)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	require.NoError(t, s.Get(ctx, conn, "lease", &holder))
+	assert.Equal(t, "worker-2", holder)
+
+	require.NoError(t, s.Set(ctx, conn, "stale", 1, time.Hour))
+	_, err = pgxutil.Exec(ctx, conn, "update pg_temp.kv set expires_at = now() - '1 second'::interval where key = 'stale'")
+	require.NoError(t, err)
+
+	n, err := s.DeleteExpired(ctx, conn)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, n)
+}
+
+func TestStoreGetJSONB(t *testing.T) {
+	t.Parallel()
+
+	db := &pgxutiltest.FakeQueryer{}
+	db.ExpectQuery("select value from kv_store where key = $1 and (expires_at is null or expires_at > now())").
+		WithArgs("config").
+		ReturnRows(pgxutiltest.NewRows("value").Types(pgtype.JSONBOID).AddRow(map[string]interface{}{"retries": 3}))
+
+	var config struct {
+		Retries int `json:"retries"`
+	}
+	err := (&kv.Store{}).Get(context.Background(), db, "config", &config)
+	require.NoError(t, err)
+	assert.Equal(t, 3, config.Retries)
+	require.NoError(t, db.ExpectationsWereMet())
+}
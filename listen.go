@@ -0,0 +1,138 @@
+package pgxutil
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+)
+
+// maxNotifyPayloadLen is the maximum length in bytes of a notification payload in the default PostgreSQL configuration.
+const maxNotifyPayloadLen = 7999
+
+// Notify sends a notification on channel with payload marshaled as JSON. An error is returned if the JSON is longer
+// than the 7999 byte limit PostgreSQL imposes on payloads. If db is a pgx.Tx the notification is delivered when the
+// transaction commits.
+func Notify(ctx context.Context, db Execer, channel string, payload interface{}) error {
+	buf, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	if len(buf) > maxNotifyPayloadLen {
+		return fmt.Errorf("notification payload is %d bytes, but the limit is %d bytes", len(buf), maxNotifyPayloadLen)
+	}
+
+	_, err = db.Exec(ctx, "select pg_notify($1, $2)", channel, string(buf))
+	return err
+}
+
+// Listener listens for notifications on one or more channels and passes them to a handler. When the connection is
+// lost it reconnects with a randomized, increasing delay and listens again. Notifications sent while the Listener is
+// reconnecting are not received. Use OnConnect to catch up on anything that may have been missed.
+type Listener struct {
+	// Connect returns a new connection to listen on. It is required. The Listener closes the connection when it is done
+	// with it.
+	Connect func(ctx context.Context) (*pgx.Conn, error)
+
+	// Channels are the channels to listen on.
+	Channels []string
+
+	// Handler is called for each notification. It is required. If it returns an error Listen returns that error.
+	Handler func(ctx context.Context, notification *pgconn.Notification) error
+
+	// OnConnect is called each time a connection is established and listening on Channels. It is optional. If it returns
+	// an error the connection is closed and retried.
+	OnConnect func(ctx context.Context, conn *pgx.Conn) error
+
+	// OnError is called with the error that caused the Listener to reconnect. It is optional.
+	OnError func(err error)
+}
+
+// listenerHandlerError wraps an error returned by Listener.Handler so it is not retried.
+type listenerHandlerError struct {
+	err error
+}
+
+func (e *listenerHandlerError) Error() string {
+	return e.err.Error()
+}
+
+// Listen listens for notifications until ctx is canceled or l.Handler returns an error. It always returns a non-nil
+// error.
+func (l *Listener) Listen(ctx context.Context) error {
+	if l.Connect == nil {
+		return fmt.Errorf("Listener.Connect is nil")
+	}
+	if l.Handler == nil {
+		return fmt.Errorf("Listener.Handler is nil")
+	}
+
+	attempt := 0
+	for {
+		connected, err := l.listen(ctx)
+
+		var handlerErr *listenerHandlerError
+		if errors.As(err, &handlerErr) {
+			return handlerErr.err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if l.OnError != nil {
+			l.OnError(err)
+		}
+
+		if connected {
+			attempt = 0
+		}
+		attempt++
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(retryDelay(attempt)):
+		}
+	}
+}
+
+// listen connects, listens, and handles notifications until an error occurs. connected is true if the connection was
+// successfully established.
+func (l *Listener) listen(ctx context.Context) (connected bool, err error) {
+	conn, err := l.Connect(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close(context.Background())
+
+	for _, channel := range l.Channels {
+		_, err := conn.Exec(ctx, "listen "+pgx.Identifier{channel}.Sanitize())
+		if err != nil {
+			return false, err
+		}
+	}
+
+	if l.OnConnect != nil {
+		err := l.OnConnect(ctx, conn)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return true, err
+		}
+
+		err = l.Handler(ctx, notification)
+		if err != nil {
+			return true, &listenerHandlerError{err: err}
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package pgxutil_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgxutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListener(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	notifyConn := connectPG(t, ctx)
+	defer closeConn(t, notifyConn)
+
+	connected := make(chan uint32, 2)
+	notifications := make(chan *pgconn.Notification)
+	listener := &pgxutil.Listener{
+		Connect: func(ctx context.Context) (*pgx.Conn, error) {
+			return connectPG(t, ctx), nil
+		},
+		Channels: []string{"pgxutil_listener_a", "pgxutil listener b"},
+		Handler: func(ctx context.Context, n *pgconn.Notification) error {
+			notifications <- n
+			return nil
+		},
+		OnConnect: func(ctx context.Context, conn *pgx.Conn) error {
+			connected <- conn.PgConn().PID()
+			return nil
+		},
+	}
+
+	listenErr := make(chan error)
+	listenCtx, cancelListen := context.WithCancel(ctx)
+	go func() { listenErr <- listener.Listen(listenCtx) }()
+
+	pid := <-connected
+
+	_, err := notifyConn.Exec(ctx, `select pg_notify('pgxutil_listener_a', 'hello')`)
+	require.NoError(t, err)
+	n := <-notifications
+	assert.Equal(t, "pgxutil_listener_a", n.Channel)
+	assert.Equal(t, "hello", n.Payload)
+
+	_, err = notifyConn.Exec(ctx, `select pg_terminate_backend($1)`, pid)
+	require.NoError(t, err)
+	newPID := <-connected
+	assert.NotEqual(t, pid, newPID)
+
+	_, err = notifyConn.Exec(ctx, `select pg_notify('pgxutil listener b', 'again')`)
+	require.NoError(t, err)
+	n = <-notifications
+	assert.Equal(t, "pgxutil listener b", n.Channel)
+	assert.Equal(t, "again", n.Payload)
+
+	cancelListen()
+	assert.True(t, errors.Is(<-listenErr, context.Canceled))
+}
+
+func TestListenerHandlerError(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	notifyConn := connectPG(t, ctx)
+	defer closeConn(t, notifyConn)
+
+	handlerErr := errors.New("handler failed")
+	connected := make(chan struct{})
+	listener := &pgxutil.Listener{
+		Connect: func(ctx context.Context) (*pgx.Conn, error) {
+			return connectPG(t, ctx), nil
+		},
+		Channels: []string{"pgxutil_listener_error"},
+		Handler: func(ctx context.Context, n *pgconn.Notification) error {
+			return handlerErr
+		},
+		OnConnect: func(ctx context.Context, conn *pgx.Conn) error {
+			close(connected)
+			return nil
+		},
+	}
+
+	listenErr := make(chan error)
+	go func() { listenErr <- listener.Listen(ctx) }()
+	<-connected
+
+	_, err := notifyConn.Exec(ctx, `select pg_notify('pgxutil_listener_error', '')`)
+	require.NoError(t, err)
+	assert.Equal(t, handlerErr, <-listenErr)
+}
+
+func TestNotify(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	listenConn := connectPG(t, ctx)
+	defer closeConn(t, listenConn)
+	notifyConn := connectPG(t, ctx)
+	defer closeConn(t, notifyConn)
+
+	_, err := listenConn.Exec(ctx, `listen "pgxutil notify"`)
+	require.NoError(t, err)
+
+	type event struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+
+	err = pgxutil.Notify(ctx, notifyConn, "pgxutil notify", event{ID: 1, Name: "O'Brien"})
+	require.NoError(t, err)
+
+	n, err := listenConn.WaitForNotification(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "pgxutil notify", n.Channel)
+
+	var e event
+	err = json.Unmarshal([]byte(n.Payload), &e)
+	require.NoError(t, err)
+	assert.Equal(t, event{ID: 1, Name: "O'Brien"}, e)
+
+	err = pgxutil.Notify(ctx, notifyConn, "pgxutil notify", strings.Repeat("x", 8000))
+	assert.EqualError(t, err, "notification payload is 8002 bytes, but the limit is 7999 bytes")
+}
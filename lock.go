@@ -0,0 +1,82 @@
+package pgxutil
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrLockNotAvailable matches, with errors.Is, the error of a query that failed with lock_not_available (55P03), e.g.
+// because Options.LockWait is LockNoWait and a row was already locked.
+var ErrLockNotAvailable = errors.New("lock not available")
+
+// LockStrength is the strength of the row-level lock requested by Options.Lock.
+type LockStrength int8
+
+const (
+	// NoLock does not lock the selected rows.
+	NoLock LockStrength = iota
+
+	// LockForUpdate adds for update.
+	LockForUpdate
+
+	// LockForNoKeyUpdate adds for no key update.
+	LockForNoKeyUpdate
+
+	// LockForShare adds for share.
+	LockForShare
+
+	// LockForKeyShare adds for key share.
+	LockForKeyShare
+)
+
+// LockWait is what a query does when a row it locks with Options.Lock is already locked.
+type LockWait int8
+
+const (
+	// LockWaitDefault waits for the lock.
+	LockWaitDefault LockWait = iota
+
+	// LockNoWait adds nowait. The query fails with an error matching ErrLockNotAvailable.
+	LockNoWait
+
+	// LockSkipLocked adds skip locked. Locked rows are omitted from the result. This is useful for job queues where
+	// each worker should take rows no other worker has taken.
+	LockSkipLocked
+)
+
+// addLockClause appends the row-level locking clause for strength and wait to sql. A trailing semicolon is removed and
+// the clause is placed on its own line so it is not hidden by a trailing comment.
+func addLockClause(sql string, strength LockStrength, wait LockWait) (string, error) {
+	if keyword := strings.ToLower(leadingKeyword(sql)); keyword != "select" && keyword != "with" {
+		return "", fmt.Errorf("row-level locks require a select statement")
+	}
+
+	var clause string
+	switch strength {
+	case LockForUpdate:
+		clause = "for update"
+	case LockForNoKeyUpdate:
+		clause = "for no key update"
+	case LockForShare:
+		clause = "for share"
+	case LockForKeyShare:
+		clause = "for key share"
+	default:
+		return "", fmt.Errorf("invalid lock strength %d", strength)
+	}
+
+	switch wait {
+	case LockWaitDefault:
+	case LockNoWait:
+		clause += " nowait"
+	case LockSkipLocked:
+		clause += " skip locked"
+	default:
+		return "", fmt.Errorf("invalid lock wait %d", wait)
+	}
+
+	sql = strings.TrimRight(sql, " \t\r\n;")
+
+	return sql + "\n" + clause, nil
+}
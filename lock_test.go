@@ -0,0 +1,80 @@
+package pgxutil_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgxutil"
+	"github.com/jackc/pgxutil/pgxutiltest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOptionsLockSQL(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	for _, tt := range []struct {
+		sql      string
+		opts     pgxutil.Options
+		expected string
+	}{
+		{"select id from jobs", pgxutil.Options{Lock: pgxutil.LockForUpdate}, "select id from jobs for update"},
+		{"select id from jobs;", pgxutil.Options{Lock: pgxutil.LockForNoKeyUpdate, LockWait: pgxutil.LockNoWait}, "select id from jobs for no key update nowait"},
+		{"select id from jobs -- comment", pgxutil.Options{Lock: pgxutil.LockForShare, LockWait: pgxutil.LockSkipLocked}, "select id from jobs -- comment for share skip locked"},
+		{"with j as (select id from jobs) select id from j", pgxutil.Options{Lock: pgxutil.LockForKeyShare}, "with j as (select id from jobs) select id from j for key share"},
+	} {
+		db := &pgxutiltest.FakeQueryer{}
+		db.ExpectQuery(tt.expected).ReturnRows(pgxutiltest.NewRows("id"))
+		_, err := pgxutil.SelectAllInt64(ctx, db, tt.sql, tt.opts)
+		require.NoError(t, err, tt.sql)
+	}
+
+	_, err := pgxutil.SelectAllInt64(ctx, &pgxutiltest.FakeQueryer{}, "delete from jobs returning id", pgxutil.Options{Lock: pgxutil.LockForUpdate})
+	require.EqualError(t, err, "row-level locks require a select statement")
+}
+
+func TestOptionsLock(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn1 := connectPG(t, ctx)
+	defer closeConn(t, conn1)
+	conn2 := connectPG(t, ctx)
+	defer closeConn(t, conn2)
+
+	tableName := fmt.Sprintf("pgxutil_lock_%d", time.Now().UnixNano())
+	_, err := conn1.Exec(ctx, fmt.Sprintf("create table %s (id int primary key)", tableName))
+	require.NoError(t, err)
+	defer conn1.Exec(context.Background(), fmt.Sprintf("drop table %s", tableName))
+	_, err = conn1.Exec(ctx, fmt.Sprintf("insert into %s select generate_series(1, 3)", tableName))
+	require.NoError(t, err)
+
+	tx1, err := conn1.Begin(ctx)
+	require.NoError(t, err)
+	defer tx1.Rollback(ctx)
+
+	ids, err := pgxutil.SelectAllInt64(ctx, tx1, fmt.Sprintf("select id from %s where id = 1", tableName), pgxutil.Options{Lock: pgxutil.LockForUpdate})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1}, ids)
+
+	err = pgxutil.WithTx(ctx, conn2, func(tx pgx.Tx) error {
+		ids, err := pgxutil.SelectAllInt64(ctx, tx, fmt.Sprintf("select id from %s order by id", tableName), pgxutil.Options{Lock: pgxutil.LockForUpdate, LockWait: pgxutil.LockSkipLocked})
+		require.NoError(t, err)
+		assert.Equal(t, []int64{2, 3}, ids)
+		return nil
+	})
+	require.NoError(t, err)
+
+	err = pgxutil.WithTx(ctx, conn2, func(tx pgx.Tx) error {
+		_, err := pgxutil.SelectAllInt64(ctx, tx, fmt.Sprintf("select id from %s", tableName), pgxutil.Options{Lock: pgxutil.LockForShare, LockWait: pgxutil.LockNoWait})
+		return err
+	})
+	assert.True(t, errors.Is(err, pgxutil.ErrLockNotAvailable))
+}
@@ -0,0 +1,144 @@
+package pgxutil
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// materializedViewLockNamespace is the first key of the pairs returned by DefaultMaterializedViewLockKey.
+const materializedViewLockNamespace = 1_935_108_953
+
+// DefaultMaterializedViewLockKey returns the advisory lock used by MaterializedViewRefresher for the view name when
+// LockKey is nil. It is a pair of int32 keys: a fixed namespace and the 32-bit FNV-1a hash of name, so each view has its
+// own lock and different views may be refreshed at the same time.
+func DefaultMaterializedViewLockKey(name string) AdvisoryLockKey {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	return Int32PairAdvisoryLockKey(materializedViewLockNamespace, int32(h.Sum32()))
+}
+
+// RefreshMaterializedView refreshes the materialized view name. If concurrently is true the view can still be read
+// while it is refreshed. That requires a unique index on the view and that it was already populated. name must be
+// quoted if it requires quoting in SQL.
+func RefreshMaterializedView(ctx context.Context, db Execer, name string, concurrently bool) error {
+	sql := "refresh materialized view " + name
+	if concurrently {
+		sql = "refresh materialized view concurrently " + name
+	}
+
+	_, err := Exec(ctx, db, sql)
+	return err
+}
+
+// MaterializedView is a view refreshed by MaterializedViewRefresher.
+type MaterializedView struct {
+	// Name is the name of the view. It must be quoted if it requires quoting in SQL.
+	Name string
+
+	// Interval is how often the view is refreshed. It must be positive.
+	Interval time.Duration
+
+	// Concurrently refreshes the view without blocking reads as described by RefreshMaterializedView.
+	Concurrently bool
+}
+
+// MaterializedViewRefresher refreshes materialized views on intervals. All the processes that refresh the same views
+// should use the same LockKey. Each refresh runs in a transaction that takes the transaction level advisory lock of
+// the view without waiting, so only one process refreshes a view at a time. A refresh is skipped if another process is
+// refreshing the view. Each process still refreshes on its own schedule, so run the MaterializedViewRefresher under an
+// Elector to refresh each view only once per interval.
+type MaterializedViewRefresher struct {
+	// DB is used to refresh the views. It is required.
+	DB Beginner
+
+	// Views are the views to refresh. The first refresh of each view is one Interval after Run starts.
+	Views []MaterializedView
+
+	// LockKey returns the advisory lock that is held while the view name is refreshed. If nil
+	// DefaultMaterializedViewLockKey is used.
+	LockKey func(name string) AdvisoryLockKey
+
+	// OnError is called with the errors of refreshes. It is optional. A failed refresh is retried at the next interval.
+	OnError func(err error)
+}
+
+// Run refreshes the views until ctx is canceled. It always returns a non-nil error.
+func (r *MaterializedViewRefresher) Run(ctx context.Context) error {
+	if r.DB == nil {
+		return fmt.Errorf("MaterializedViewRefresher.DB is nil")
+	}
+
+	next := make([]time.Time, len(r.Views))
+	for i, view := range r.Views {
+		if view.Interval <= 0 {
+			return fmt.Errorf("interval of materialized view %s must be positive", view.Name)
+		}
+		next[i] = time.Now().Add(view.Interval)
+	}
+
+	for {
+		var wait time.Duration = -1
+		now := time.Now()
+		for i, view := range r.Views {
+			if !next[i].After(now) {
+				_, err := r.Refresh(ctx, view)
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				if err != nil && r.OnError != nil {
+					r.OnError(err)
+				}
+				next[i] = time.Now().Add(view.Interval)
+			}
+
+			if d := time.Until(next[i]); wait < 0 || d < wait {
+				wait = d
+			}
+		}
+
+		if wait < 0 {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// Refresh refreshes view now while holding its lock. It returns false without refreshing if another process holds the
+// lock.
+func (r *MaterializedViewRefresher) Refresh(ctx context.Context, view MaterializedView) (refreshed bool, err error) {
+	err = WithTx(ctx, r.DB, func(tx pgx.Tx) error {
+		locked, err := TryAdvisoryXactLock(ctx, tx, r.lockKey(view.Name))
+		if err != nil || !locked {
+			return err
+		}
+
+		err = RefreshMaterializedView(ctx, tx, view.Name, view.Concurrently)
+		if err != nil {
+			return err
+		}
+		refreshed = true
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("refresh materialized view %s: %w", view.Name, err)
+	}
+
+	return refreshed, nil
+}
+
+func (r *MaterializedViewRefresher) lockKey(name string) AdvisoryLockKey {
+	if r.LockKey == nil {
+		return DefaultMaterializedViewLockKey(name)
+	}
+	return r.LockKey(name)
+}
@@ -0,0 +1,150 @@
+package pgxutil_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgxutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createWidgetCounts(t testing.TB, ctx context.Context, db pgxutil.Execer, schema string) {
+	err := pgxutil.MultiExec(ctx, db, []string{
+		"create schema " + schema,
+		"create table " + schema + ".widgets (id int primary key)",
+		"insert into " + schema + ".widgets values (1)",
+		"create materialized view " + schema + ".widget_counts as select 1 as id, count(*) from " + schema + ".widgets",
+		"create unique index on " + schema + ".widget_counts (id)",
+	})
+	require.NoError(t, err)
+}
+
+func TestRefreshMaterializedView(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		createWidgetCounts(t, ctx, tx, "pgxutil_matview")
+
+		_, err := tx.Exec(ctx, "insert into pgxutil_matview.widgets values (2)")
+		require.NoError(t, err)
+		err = pgxutil.RefreshMaterializedView(ctx, tx, "pgxutil_matview.widget_counts", false)
+		require.NoError(t, err)
+		n, err := pgxutil.SelectInt64(ctx, tx, "select count from pgxutil_matview.widget_counts")
+		require.NoError(t, err)
+		assert.EqualValues(t, 2, n)
+
+		_, err = tx.Exec(ctx, "insert into pgxutil_matview.widgets values (3)")
+		require.NoError(t, err)
+		err = pgxutil.RefreshMaterializedView(ctx, tx, "pgxutil_matview.widget_counts", true)
+		require.NoError(t, err)
+		n, err = pgxutil.SelectInt64(ctx, tx, "select count from pgxutil_matview.widget_counts")
+		require.NoError(t, err)
+		assert.EqualValues(t, 3, n)
+	})
+}
+
+func TestMaterializedViewRefresherRefresh(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	lockConn := connectPG(t, ctx)
+	defer closeConn(t, lockConn)
+
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		createWidgetCounts(t, ctx, tx, "pgxutil_matview_refresher")
+
+		r := &pgxutil.MaterializedViewRefresher{DB: tx}
+		view := pgxutil.MaterializedView{Name: "pgxutil_matview_refresher.widget_counts", Interval: time.Minute}
+		key := pgxutil.DefaultMaterializedViewLockKey(view.Name)
+
+		_, err := tx.Exec(ctx, "insert into pgxutil_matview_refresher.widgets values (2)")
+		require.NoError(t, err)
+		refreshed, err := r.Refresh(ctx, view)
+		require.NoError(t, err)
+		assert.True(t, refreshed)
+		n, err := pgxutil.SelectInt64(ctx, tx, "select count from pgxutil_matview_refresher.widget_counts")
+		require.NoError(t, err)
+		assert.EqualValues(t, 2, n)
+
+		// Another process is refreshing.
+		require.NoError(t, pgxutil.AcquireAdvisoryLock(ctx, lockConn, key))
+		_, err = tx.Exec(ctx, "insert into pgxutil_matview_refresher.widgets values (3)")
+		require.NoError(t, err)
+		refreshed, err = r.Refresh(ctx, view)
+		require.NoError(t, err)
+		assert.False(t, refreshed)
+		n, err = pgxutil.SelectInt64(ctx, tx, "select count from pgxutil_matview_refresher.widget_counts")
+		require.NoError(t, err)
+		assert.EqualValues(t, 2, n)
+
+		// Another process refreshing a different view does not block this one.
+		otherKey := pgxutil.DefaultMaterializedViewLockKey("pgxutil_matview_refresher.other")
+		assert.NotEqual(t, key, otherKey)
+		require.NoError(t, pgxutil.ReleaseAdvisoryLock(ctx, lockConn, key))
+		require.NoError(t, pgxutil.AcquireAdvisoryLock(ctx, lockConn, otherKey))
+		refreshed, err = r.Refresh(ctx, view)
+		require.NoError(t, err)
+		assert.True(t, refreshed)
+		require.NoError(t, pgxutil.ReleaseAdvisoryLock(ctx, lockConn, otherKey))
+
+		_, err = r.Refresh(ctx, pgxutil.MaterializedView{Name: "pgxutil_matview_refresher.missing"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "refresh materialized view pgxutil_matview_refresher.missing: ")
+	})
+}
+
+func TestMaterializedViewRefresherRun(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	conn := connectPG(t, ctx)
+	defer closeConn(t, conn)
+
+	createWidgetCounts(t, ctx, conn, "pgxutil_matview_run")
+	t.Cleanup(func() {
+		_, err := pgxutil.Exec(context.Background(), conn, "drop schema pgxutil_matview_run cascade")
+		assert.NoError(t, err)
+	})
+
+	refreshConn := connectPG(t, ctx)
+	defer closeConn(t, refreshConn)
+
+	r := &pgxutil.MaterializedViewRefresher{
+		DB:      refreshConn,
+		Views:   []pgxutil.MaterializedView{{Name: "pgxutil_matview_run.widget_counts", Interval: 10 * time.Millisecond, Concurrently: true}},
+		LockKey: func(string) pgxutil.AdvisoryLockKey { return pgxutil.Int64AdvisoryLockKey(8_304_112_965_157_386_261) },
+		OnError: func(err error) { t.Error(err) },
+	}
+
+	runErr := make(chan error)
+	runCtx, cancelRun := context.WithCancel(ctx)
+	go func() { runErr <- r.Run(runCtx) }()
+
+	_, err := conn.Exec(ctx, "insert into pgxutil_matview_run.widgets values (2)")
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		n, err := pgxutil.SelectInt64(ctx, conn, "select count from pgxutil_matview_run.widget_counts")
+		require.NoError(t, err)
+		return n == 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cancelRun()
+	assert.True(t, errors.Is(<-runErr, context.Canceled))
+}
+
+func TestMaterializedViewRefresherRunInvalidInterval(t *testing.T) {
+	t.Parallel()
+
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		r := &pgxutil.MaterializedViewRefresher{DB: tx, Views: []pgxutil.MaterializedView{{Name: "widget_counts"}}}
+		err := r.Run(ctx)
+		require.EqualError(t, err, "interval of materialized view widget_counts must be positive")
+	})
+}
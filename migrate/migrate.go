@@ -0,0 +1,164 @@
+// Package migrate applies ordered schema migrations to a PostgreSQL database.
+//
+// Applied versions are tracked in a table, schema_migrations by default. Each migration is applied in its own
+// transaction together with the record of its version so a failed migration leaves no trace. A transaction level
+// advisory lock is held while a migration is applied so concurrent runners, e.g. several instances of a service
+// starting at once, apply each migration exactly once.
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgxutil"
+)
+
+// DefaultLockKey is the advisory lock key used when Migrator.LockKey is not set.
+var DefaultLockKey = pgxutil.Int64AdvisoryLockKey(7_476_109_522_683_251_731)
+
+// Migration is a single schema change. Exactly one of SQL and Func must be set.
+type Migration struct {
+	// Version orders the migrations. It must be positive and unique. Versions do not need to be consecutive, e.g. a
+	// timestamp such as 20200102150405 can be used.
+	Version int64
+
+	// Name describes the migration. It is recorded with the version.
+	Name string
+
+	// SQL is executed with the simple protocol so it may contain multiple statements.
+	SQL string
+
+	// Func is called to apply the migration in Go.
+	Func func(ctx context.Context, tx pgx.Tx) error
+}
+
+// Migrator applies registered migrations. The zero value is ready to use.
+type Migrator struct {
+	// TableName is the table that records applied versions. It is created if it does not exist. If empty
+	// schema_migrations is used. It may be schema qualified.
+	TableName string
+
+	// LockKey is the advisory lock held while a migration is applied. If zero DefaultLockKey is used.
+	LockKey pgxutil.AdvisoryLockKey
+
+	migrations []Migration
+}
+
+// Add registers a migration that executes sql.
+func (m *Migrator) Add(version int64, name, sql string) {
+	m.migrations = append(m.migrations, Migration{Version: version, Name: name, SQL: sql})
+}
+
+// AddFunc registers a migration that calls fn.
+func (m *Migrator) AddFunc(version int64, name string, fn func(ctx context.Context, tx pgx.Tx) error) {
+	m.migrations = append(m.migrations, Migration{Version: version, Name: name, Func: fn})
+}
+
+// Migrations returns the registered migrations ordered by version. An error is returned if any migration is invalid.
+func (m *Migrator) Migrations() ([]Migration, error) {
+	migrations := make([]Migration, len(m.migrations))
+	copy(migrations, m.migrations)
+	sort.SliceStable(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	for i, mig := range migrations {
+		if mig.Version <= 0 {
+			return nil, fmt.Errorf("migration %q has invalid version %d", mig.Name, mig.Version)
+		}
+		if i > 0 && migrations[i-1].Version == mig.Version {
+			return nil, fmt.Errorf("duplicate migration version %d", mig.Version)
+		}
+		if (mig.SQL == "") == (mig.Func == nil) {
+			return nil, fmt.Errorf("migration %d must have exactly one of SQL and Func", mig.Version)
+		}
+	}
+
+	return migrations, nil
+}
+
+// Migrate applies the migrations that have not been applied yet in version order and returns the number applied. It
+// stops at the first migration that fails. Applied migrations are not rolled back. Migrations with versions lower than
+// the highest applied version are still applied if they have not been.
+func (m *Migrator) Migrate(ctx context.Context, db pgxutil.Beginner) (int, error) {
+	migrations, err := m.Migrations()
+	if err != nil {
+		return 0, err
+	}
+
+	err = pgxutil.WithAdvisoryLock(ctx, db, m.lockKey(), func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, fmt.Sprintf(`create table if not exists %s (
+	version bigint primary key,
+	name text not null,
+	applied_at timestamptz not null default now()
+)`, m.tableName()))
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	applied := 0
+	for _, mig := range migrations {
+		var ok bool
+		err := pgxutil.WithAdvisoryLock(ctx, db, m.lockKey(), func(tx pgx.Tx) error {
+			var err error
+			ok, err = m.apply(ctx, tx, mig)
+			return err
+		})
+		if err != nil {
+			return applied, fmt.Errorf("migration %d %s: %w", mig.Version, mig.Name, err)
+		}
+		if ok {
+			applied++
+		}
+	}
+
+	return applied, nil
+}
+
+// apply applies mig in tx unless it has already been applied. It returns true if it was applied.
+func (m *Migrator) apply(ctx context.Context, tx pgx.Tx, mig Migration) (bool, error) {
+	exists, err := pgxutil.SelectExists(ctx, tx, fmt.Sprintf("select 1 from %s where version = $1", m.tableName()), mig.Version)
+	if err != nil {
+		return false, err
+	}
+	if exists {
+		return false, nil
+	}
+
+	if mig.Func != nil {
+		err = mig.Func(ctx, tx)
+	} else {
+		_, err = tx.Exec(ctx, mig.SQL)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	_, err = tx.Exec(ctx, fmt.Sprintf("insert into %s (version, name) values ($1, $2)", m.tableName()), mig.Version, mig.Name)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// AppliedVersions returns the applied versions in order. It returns an error if the table of versions does not exist.
+func (m *Migrator) AppliedVersions(ctx context.Context, db pgxutil.Queryer) ([]int64, error) {
+	return pgxutil.SelectAllInt64(ctx, db, fmt.Sprintf("select version from %s order by version", m.tableName()))
+}
+
+func (m *Migrator) tableName() string {
+	if m.TableName == "" {
+		return "schema_migrations"
+	}
+	return m.TableName
+}
+
+func (m *Migrator) lockKey() pgxutil.AdvisoryLockKey {
+	if m.LockKey == (pgxutil.AdvisoryLockKey{}) {
+		return DefaultLockKey
+	}
+	return m.LockKey
+}
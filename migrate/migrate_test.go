@@ -0,0 +1,162 @@
+package migrate_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/jackc/pgxutil"
+	"github.com/jackc/pgxutil/internal/testdb"
+	"github.com/jackc/pgxutil/migrate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrate(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	conn := testdb.Connect(t, ctx)
+
+	m := &migrate.Migrator{TableName: "pg_temp.schema_migrations"}
+	m.Add(2, "add widgets.name", "alter table pg_temp.widgets add column name text; insert into pg_temp.widgets values (1, 'foo');")
+	m.Add(1, "create widgets", "create table pg_temp.widgets (id int primary key)")
+	m.AddFunc(3, "rename foo", func(ctx context.Context, tx pgx.Tx) error {
+		_, err := pgxutil.Update(ctx, tx, "pg_temp.widgets", map[string]interface{}{"name": "bar"}, map[string]interface{}{"name": "foo"})
+		return err
+	})
+
+	n, err := m.Migrate(ctx, conn)
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	name, err := pgxutil.SelectString(ctx, conn, "select name from pg_temp.widgets where id = 1")
+	require.NoError(t, err)
+	assert.Equal(t, "bar", name)
+
+	versions, err := m.AppliedVersions(ctx, conn)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1, 2, 3}, versions)
+
+	n, err = m.Migrate(ctx, conn)
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	m.Add(4, "add widgets.created_at", "alter table pg_temp.widgets add column created_at timestamptz")
+	n, err = m.Migrate(ctx, conn)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+}
+
+func TestMigrateFailure(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	conn := testdb.Connect(t, ctx)
+
+	m := &migrate.Migrator{TableName: "pg_temp.schema_migrations"}
+	m.Add(1, "create widgets", "create table pg_temp.widgets (id int primary key)")
+	m.AddFunc(2, "fail", func(ctx context.Context, tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, "create table pg_temp.gadgets (id int primary key)")
+		require.NoError(t, err)
+		return errors.New("boom")
+	})
+	m.Add(3, "never", "create table pg_temp.never (id int)")
+
+	n, err := m.Migrate(ctx, conn)
+	require.EqualError(t, err, "migration 2 fail: boom")
+	assert.Equal(t, 1, n)
+
+	versions, err := m.AppliedVersions(ctx, conn)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1}, versions)
+
+	exists, err := pgxutil.SelectExists(ctx, conn, "select 1 from pg_class where relname = 'gadgets' and relpersistence = 't'")
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestMigrateConcurrent(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	pool, err := pgxpool.Connect(ctx, testdb.ConnString())
+	require.NoError(t, err)
+	defer pool.Close()
+
+	suffix := time.Now().UnixNano()
+	tableName := fmt.Sprintf("schema_migrations_%d", suffix)
+	widgets := fmt.Sprintf("widgets_%d", suffix)
+	defer func() {
+		_, err := pool.Exec(ctx, fmt.Sprintf("drop table if exists %s, %s", tableName, widgets))
+		require.NoError(t, err)
+	}()
+
+	m := &migrate.Migrator{TableName: tableName, LockKey: pgxutil.Int64AdvisoryLockKey(suffix)}
+	m.Add(1, "create widgets", fmt.Sprintf("create table %s (id int primary key)", widgets))
+	m.Add(2, "insert widget", fmt.Sprintf("insert into %s values (1)", widgets))
+
+	var wg sync.WaitGroup
+	applied := make([]int, 4)
+	errs := make([]error, 4)
+	for i := range applied {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			applied[i], errs[i] = m.Migrate(ctx, pool)
+		}(i)
+	}
+	wg.Wait()
+
+	total := 0
+	for i := range applied {
+		require.NoError(t, errs[i])
+		total += applied[i]
+	}
+	assert.Equal(t, 2, total)
+
+	versions, err := m.AppliedVersions(ctx, pool)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1, 2}, versions)
+}
+
+func TestMigrationsInvalid(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name string
+		add  func(m *migrate.Migrator)
+		err  string
+	}{
+		{
+			name: "duplicate version",
+			add: func(m *migrate.Migrator) {
+				m.Add(1, "a", "select 1")
+				m.Add(1, "b", "select 2")
+			},
+			err: "duplicate migration version 1",
+		},
+		{
+			name: "invalid version",
+			add:  func(m *migrate.Migrator) { m.Add(0, "a", "select 1") },
+			err:  `migration "a" has invalid version 0`,
+		},
+		{
+			name: "no SQL or Func",
+			add:  func(m *migrate.Migrator) { m.Add(1, "a", "") },
+			err:  "migration 1 must have exactly one of SQL and Func",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &migrate.Migrator{}
+			tt.add(m)
+			_, err := m.Migrations()
+			require.EqualError(t, err, tt.err)
+		})
+	}
+}
@@ -0,0 +1,245 @@
+package pgxutil
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// NamedArgs binds :name placeholders in a query to values. It can be passed as the only query argument to any Select
+// function. e.g. SelectValue(ctx, db, "select * from users where id = :id", NamedArgs{"id": 1}). A placeholder is
+// matched to a key exactly or, failing that, ignoring case and underscores.
+type NamedArgs map[string]interface{}
+
+// NamedArgsFromStruct returns NamedArgs with an entry for each exported field of the struct or pointer to struct src.
+// The key is the field's db tag or, for untagged fields, the field name. Fields tagged db:"-" are skipped.
+func NamedArgsFromStruct(src interface{}) (NamedArgs, error) {
+	v := reflect.ValueOf(src)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("src not a struct or pointer to struct")
+	}
+
+	fields := exportedStructFields(v.Type(), nil)
+	namedArgs := make(NamedArgs, len(fields))
+	for _, sf := range fields {
+		name := sf.Name
+		if tagName, _, ok := dbTag(sf.StructField); ok {
+			if tagName == "-" {
+				continue
+			}
+			if tagName != "" {
+				name = tagName
+			}
+		}
+
+		fv, ok := fieldByIndexNoAlloc(v, sf.index)
+		if !ok {
+			namedArgs[name] = nil
+			continue
+		}
+		namedArgs[name] = fv.Interface()
+	}
+
+	return namedArgs, nil
+}
+
+// fieldByIndexNoAlloc is like reflect.Value.FieldByIndex except it returns false instead of panicking when a nil
+// embedded struct pointer is traversed.
+func fieldByIndexNoAlloc(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, fieldIndex := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(fieldIndex)
+	}
+
+	return v, true
+}
+
+func (na NamedArgs) lookup(name string) (interface{}, bool) {
+	if v, ok := na[name]; ok {
+		return v, true
+	}
+
+	normalizedName := strings.ReplaceAll(name, "_", "")
+	for k, v := range na {
+		if strings.EqualFold(strings.ReplaceAll(k, "_", ""), normalizedName) {
+			return v, true
+		}
+	}
+
+	return nil, false
+}
+
+// rewriteNamedArgs rewrites sql and args if args contains NamedArgs. The :name placeholders in sql are replaced with
+// positional placeholders and the NamedArgs are replaced with the bound values. pgx query options such as
+// pgx.QueryResultFormats are preserved. If args does not contain NamedArgs sql and args are returned unchanged.
+func rewriteNamedArgs(sql string, args []interface{}) (string, []interface{}, error) {
+	var namedArgs NamedArgs
+	var newArgs []interface{}
+	positionalCount := 0
+	for _, arg := range args {
+		switch arg := arg.(type) {
+		case NamedArgs:
+			if namedArgs != nil {
+				return "", nil, fmt.Errorf("multiple NamedArgs")
+			}
+			namedArgs = arg
+		case pgx.QueryResultFormats, pgx.QueryResultFormatsByOID, pgx.QuerySimpleProtocol, defaultResultFormats:
+			newArgs = append(newArgs, arg)
+		default:
+			positionalCount++
+		}
+	}
+
+	if namedArgs == nil {
+		return sql, args, nil
+	}
+
+	if positionalCount > 0 {
+		return "", nil, fmt.Errorf("NamedArgs cannot be combined with positional arguments")
+	}
+
+	placeholders := make(map[string]int)
+	sql, err := rewritePlaceholders(sql, func(placeholder string) (string, error) {
+		if placeholder[0] != ':' {
+			return placeholder, nil
+		}
+
+		name := placeholder[1:]
+		n, ok := placeholders[name]
+		if !ok {
+			v, ok := namedArgs.lookup(name)
+			if !ok {
+				return "", fmt.Errorf("no value for named argument %s", name)
+			}
+			newArgs = append(newArgs, v)
+			n = len(placeholders) + 1
+			placeholders[name] = n
+		}
+		return "$" + strconv.Itoa(n), nil
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	return sql, newArgs, nil
+}
+
+// rewritePlaceholders returns sql with each :name and $n placeholder replaced with the result of replace. Quoted
+// strings, quoted identifiers, dollar quoted strings, comments, and :: casts are copied unchanged.
+func rewritePlaceholders(sql string, replace func(placeholder string) (string, error)) (string, error) {
+	sb := &strings.Builder{}
+
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		switch {
+		case c == '\'' || c == '"':
+			end := skipQuoted(sql, i, c)
+			sb.WriteString(sql[i:end])
+			i = end
+		case c == '-' && i+1 < len(sql) && sql[i+1] == '-':
+			end := strings.IndexByte(sql[i:], '\n')
+			if end == -1 {
+				end = len(sql)
+			} else {
+				end += i
+			}
+			sb.WriteString(sql[i:end])
+			i = end
+		case c == '/' && i+1 < len(sql) && sql[i+1] == '*':
+			end := strings.Index(sql[i+2:], "*/")
+			if end == -1 {
+				end = len(sql)
+			} else {
+				end += i + 4
+			}
+			sb.WriteString(sql[i:end])
+			i = end
+		case c == '$' && i+1 < len(sql) && sql[i+1] >= '0' && sql[i+1] <= '9':
+			end := i + 2
+			for end < len(sql) && sql[end] >= '0' && sql[end] <= '9' {
+				end++
+			}
+			s, err := replace(sql[i:end])
+			if err != nil {
+				return "", err
+			}
+			sb.WriteString(s)
+			i = end
+		case c == '$':
+			end := skipDollarQuoted(sql, i)
+			sb.WriteString(sql[i:end])
+			i = end
+		case c == ':' && i+1 < len(sql) && sql[i+1] == ':':
+			sb.WriteString("::")
+			i += 2
+		case c == ':' && i+1 < len(sql) && isIdentStart(sql[i+1]):
+			end := i + 2
+			for end < len(sql) && isIdentChar(sql[end]) {
+				end++
+			}
+			s, err := replace(sql[i:end])
+			if err != nil {
+				return "", err
+			}
+			sb.WriteString(s)
+			i = end
+		default:
+			sb.WriteByte(c)
+			i++
+		}
+	}
+
+	return sb.String(), nil
+}
+
+// skipQuoted returns the index after the quoted string or identifier starting at sql[start].
+func skipQuoted(sql string, start int, quote byte) int {
+	for i := start + 1; i < len(sql); i++ {
+		if sql[i] == quote {
+			if i+1 < len(sql) && sql[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(sql)
+}
+
+// skipDollarQuoted returns the index after the dollar quoted string starting at sql[start]. If sql[start] does not
+// start a dollar quoted string the index after the '$' is returned.
+func skipDollarQuoted(sql string, start int) int {
+	end := start + 1
+	for end < len(sql) && isIdentChar(sql[end]) && !(end == start+1 && sql[end] >= '0' && sql[end] <= '9') {
+		end++
+	}
+	if end >= len(sql) || sql[end] != '$' {
+		return start + 1
+	}
+
+	tag := sql[start : end+1]
+	close := strings.Index(sql[end+1:], tag)
+	if close == -1 {
+		return len(sql)
+	}
+	return end + 1 + close + len(tag)
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentChar(c byte) bool {
+	return isIdentStart(c) || (c >= '0' && c <= '9')
+}
@@ -0,0 +1,57 @@
+package pgxutil_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgxutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNamedArgs(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		tests := []struct {
+			sql    string
+			args   pgxutil.NamedArgs
+			result string
+		}{
+			{"select :a::text || :b::text || :a::text", pgxutil.NamedArgs{"a": "x", "b": "y"}, "xyx"},
+			{"select ':a' || :a::text", pgxutil.NamedArgs{"a": "x"}, ":ax"},
+			{`select $$:a$$ || :a::text -- :b`, pgxutil.NamedArgs{"a": "x"}, ":ax"},
+			{`select /* :b */ :first_name::text`, pgxutil.NamedArgs{"FirstName": "Adam"}, "Adam"},
+		}
+		for i, tt := range tests {
+			v, err := pgxutil.SelectString(ctx, tx, tt.sql, tt.args)
+			assert.NoErrorf(t, err, "%d. %s", i, tt.sql)
+			assert.Equalf(t, tt.result, v, "%d. %s", i, tt.sql)
+		}
+
+		_, err := pgxutil.SelectString(ctx, tx, "select :a::text", pgxutil.NamedArgs{})
+		assert.EqualError(t, err, "no value for named argument a")
+
+		_, err = pgxutil.SelectString(ctx, tx, "select :a::text", pgxutil.NamedArgs{"a": "x"}, 1)
+		assert.EqualError(t, err, "NamedArgs cannot be combined with positional arguments")
+	})
+}
+
+func TestNamedArgsFromStruct(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		type person struct {
+			Name    string `db:"name"`
+			Height  int32
+			Ignored string `db:"-"`
+		}
+
+		args, err := pgxutil.NamedArgsFromStruct(&person{Name: "Adam", Height: 72, Ignored: "x"})
+		require.NoError(t, err)
+		assert.Equal(t, pgxutil.NamedArgs{"name": "Adam", "Height": int32(72)}, args)
+
+		m, err := pgxutil.SelectStringMap(ctx, tx, "select :name::text as name, :height::int as height", args)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"name": "Adam", "height": "72"}, m)
+	})
+}
@@ -0,0 +1,370 @@
+package pgxutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// ErrNotReadOnly is returned instead of sending a statement that is not read-only when Options.ReadOnly is set.
+var ErrNotReadOnly = errors.New("statement is not read-only")
+
+// Options control how the Select functions, Exec, and the functions built on them send a query. Options can be
+// attached to a context with WithOptions or passed as an argument. e.g. SelectInt64(ctx, db, sql,
+// Options{Timeout: time.Second}, 42). Options passed as an argument are merged with and take precedence over Options
+// in the context.
+type Options struct {
+	// Timeout limits the time a query may take including reading its rows. Zero means no timeout.
+	Timeout time.Duration
+
+	// StatementTimeout sets statement_timeout for just the query. PostgreSQL cancels the query if it runs longer. It is
+	// rounded up to whole milliseconds. Zero means the connection's statement_timeout is used. The query is run in a
+	// transaction, or a savepoint if db is a pgx.Tx, with the setting applied by set_config with is_local true. db must
+	// implement Beginner.
+	StatementTimeout time.Duration
+
+	// CancelGracePeriod changes how a query is stopped when its context is done, e.g. because Timeout expired. By
+	// default pgx interrupts the connection, which closes it. If CancelGracePeriod is set a PostgreSQL cancel request
+	// is sent instead and the query is given CancelGracePeriod to end with a query_canceled error, which is returned,
+	// before the connection is interrupted. db must implement PgConner or have a Conn method like pgx.Tx. Use Acquire
+	// with a *pgxpool.Pool.
+	CancelGracePeriod time.Duration
+
+	// SimpleProtocol sends the query with the simple protocol. See pgx.QuerySimpleProtocol.
+	SimpleProtocol bool
+
+	// ReadOnly causes ErrNotReadOnly to be returned instead of sending a statement that does not begin with select,
+	// with, values, table, or show, a with statement that contains insert, update, delete, or merge, or a statement
+	// that locks rows, e.g. with Options.Lock. This is a client side check meant to catch mistakes. Use ReadOnlyDB or WithReadOnlyTx to have PostgreSQL enforce it.
+	ReadOnly bool
+
+	// RetrySafe marks a query as safe to send again even if it may already have run, e.g. because it is read-only or
+	// idempotent. RetryDB only retries such queries after connection errors that leave it unknown whether they ran.
+	RetrySafe bool
+
+	// Route overrides how ReplicaRouter chooses between the primary and the replicas for a query. e.g. RoutePrimary
+	// reads a row from the primary right after writing it, before the replicas have caught up.
+	Route Route
+
+	// CacheTTL sets how long CachedDB caches the result of the query, overriding CachedDB.TTL. A negative CacheTTL
+	// sends the query without using the cache.
+	CacheTTL time.Duration
+
+	// CacheTags are associated with the result cached by CachedDB, e.g. the names of the tables the query reads.
+	// CachedDB.InvalidateTag removes the cached results that have a tag. Tags passed as an argument are added to those
+	// attached to the context.
+	CacheTags []string
+
+	// Lock adds a row-level locking clause such as for update to the end of the query, which must be a select
+	// statement. It applies to every table in the from clause. Write the clause in the query to lock only some tables.
+	// Locks are held until the end of the transaction so db should be a pgx.Tx. Lock should be passed as an argument
+	// rather than attached to a context as it makes any other statement fail.
+	Lock LockStrength
+
+	// LockWait adds nowait or skip locked to the clause added for Lock.
+	LockWait LockWait
+
+	// ResultFormat requests every result value in the text or binary format. By default pgx requests the binary format
+	// for the data types it can decode. The format is only visible with functions that return undecoded values such as
+	// SelectByteSlice. Functions that require a particular format, such as SelectDecimal, ignore it.
+	ResultFormat ResultFormat
+
+	// ExpectedRows is a hint of the number of rows a query returns. Functions that return every row, such as SelectAll,
+	// SelectAllValue, SelectAllMap, and SelectAllStringMap, preallocate their result for that many rows. It does not
+	// limit the rows returned. Zero means no hint.
+	ExpectedRows int
+
+	// ZeroCopy skips copying []byte values returned by SelectByteSlice, SelectValue, SelectMap, SelectMapForEach, and
+	// SelectMapForEachReuse out of the buffer pgx reads rows into. The buffer is reused for later rows and queries so
+	// such a value is only valid until the next row is read or the connection is used again. Functions that return
+	// several rows, such as SelectAllByteSlice, always copy.
+	ZeroCopy bool
+
+	// RedactArgs leaves QueryError.Args empty so the arguments of a failed query, which may contain sensitive data,
+	// are not exposed with the error. SlowQueryHook also omits them from its log.
+	RedactArgs bool
+
+	// Attributes are passed to QueryHooks in QueryHookData.Attributes. otelpgxutil records them as span attributes.
+	Attributes map[string]string
+}
+
+// ResultFormat is the format of result values requested by Options.ResultFormat.
+type ResultFormat int8
+
+const (
+	// DefaultResultFormat uses the format chosen by pgx or the function sending the query.
+	DefaultResultFormat ResultFormat = iota
+
+	// TextResultFormat requests the text format.
+	TextResultFormat
+
+	// BinaryResultFormat requests the binary format.
+	BinaryResultFormat
+)
+
+// merge returns o with the fields set in other applied.
+func (o Options) merge(other Options) Options {
+	if other.Timeout != 0 {
+		o.Timeout = other.Timeout
+	}
+	if other.StatementTimeout != 0 {
+		o.StatementTimeout = other.StatementTimeout
+	}
+	if other.CancelGracePeriod != 0 {
+		o.CancelGracePeriod = other.CancelGracePeriod
+	}
+	if other.Route != RouteAuto {
+		o.Route = other.Route
+	}
+	if other.CacheTTL != 0 {
+		o.CacheTTL = other.CacheTTL
+	}
+	if other.Lock != NoLock {
+		o.Lock = other.Lock
+	}
+	if other.LockWait != LockWaitDefault {
+		o.LockWait = other.LockWait
+	}
+	if other.ResultFormat != DefaultResultFormat {
+		o.ResultFormat = other.ResultFormat
+	}
+	if other.ExpectedRows != 0 {
+		o.ExpectedRows = other.ExpectedRows
+	}
+	o.SimpleProtocol = o.SimpleProtocol || other.SimpleProtocol
+	o.ReadOnly = o.ReadOnly || other.ReadOnly
+	o.RetrySafe = o.RetrySafe || other.RetrySafe
+	o.RedactArgs = o.RedactArgs || other.RedactArgs
+	o.ZeroCopy = o.ZeroCopy || other.ZeroCopy
+
+	if len(other.CacheTags) > 0 {
+		o.CacheTags = append(append([]string(nil), o.CacheTags...), other.CacheTags...)
+	}
+
+	if len(other.Attributes) > 0 {
+		attributes := make(map[string]string, len(o.Attributes)+len(other.Attributes))
+		for k, v := range o.Attributes {
+			attributes[k] = v
+		}
+		for k, v := range other.Attributes {
+			attributes[k] = v
+		}
+		o.Attributes = attributes
+	}
+
+	return o
+}
+
+type optionsContextKey struct{}
+
+// WithOptions returns a copy of ctx with opts merged into any Options already attached to ctx.
+func WithOptions(ctx context.Context, opts Options) context.Context {
+	return context.WithValue(ctx, optionsContextKey{}, OptionsFromContext(ctx).merge(opts))
+}
+
+// OptionsFromContext returns the Options attached to ctx with WithOptions.
+func OptionsFromContext(ctx context.Context) Options {
+	opts, _ := ctx.Value(optionsContextKey{}).(Options)
+	return opts
+}
+
+// WithStatementTimeout returns a copy of ctx with Options.StatementTimeout set to timeout. Queries sent by the Select
+// functions and Exec with ctx are canceled by PostgreSQL if they run longer than timeout.
+func WithStatementTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return WithOptions(ctx, Options{StatementTimeout: timeout})
+}
+
+// withStatementTimeout calls fn in a transaction on db with statement_timeout set to timeout. The previous
+// statement_timeout is always restored when fn succeeds because the transaction may be a savepoint of a transaction
+// that is not visible through db, e.g. a pgx.Tx wrapped by a DB or a HookedDB. If fn fails the setting is rolled back
+// with the transaction or savepoint.
+func withStatementTimeout(ctx context.Context, db interface{}, timeout time.Duration, fn func(tx pgx.Tx) error) error {
+	beginner, ok := db.(Beginner)
+	if !ok {
+		return fmt.Errorf("%T does not implement Begin, which is required for Options.StatementTimeout", db)
+	}
+
+	// statement_timeout is in milliseconds and 0 disables it, so a shorter timeout is rounded up rather than down.
+	milliseconds := int64((timeout + time.Millisecond - 1) / time.Millisecond)
+
+	return WithTx(ctx, beginner, func(tx pgx.Tx) error {
+		var previous, ignored string
+		err := tx.QueryRow(ctx,
+			"select current_setting('statement_timeout'), set_config('statement_timeout', $1, true)",
+			strconv.FormatInt(milliseconds, 10),
+		).Scan(&previous, &ignored)
+		if err != nil {
+			return err
+		}
+
+		err = fn(tx)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, "select set_config('statement_timeout', $1, true)", previous)
+		return err
+	})
+}
+
+// queryOptions returns the Options in ctx merged with any Options in args.
+func queryOptions(ctx context.Context, args []interface{}) Options {
+	opts := OptionsFromContext(ctx)
+	for _, arg := range args {
+		if arg, ok := arg.(Options); ok {
+			opts = opts.merge(arg)
+		}
+	}
+	return opts
+}
+
+// makeRowSlice returns a slice with capacity for the number of rows hinted by Options.ExpectedRows. It returns nil if
+// there is no hint.
+func makeRowSlice[T any](ctx context.Context, args []interface{}) []T {
+	if n := queryOptions(ctx, args).ExpectedRows; n > 0 {
+		return make([]T, 0, n)
+	}
+	return nil
+}
+
+// prepareQuery applies the Options in ctx and args and rewrites NamedArgs. The returned context carries the merged
+// Options. cancel must be called when the query is finished.
+func prepareQuery(ctx context.Context, sql string, args []interface{}) (_ context.Context, cancel context.CancelFunc, _ string, _ []interface{}, err error) {
+	opts := queryOptions(ctx, args)
+	var optsInArgs bool
+	for _, arg := range args {
+		if _, ok := arg.(Options); ok {
+			optsInArgs = true
+		}
+	}
+	if optsInArgs {
+		queryArgs := make([]interface{}, 0, len(args))
+		for _, arg := range args {
+			if _, ok := arg.(Options); !ok {
+				queryArgs = append(queryArgs, arg)
+			}
+		}
+		args = queryArgs
+		ctx = context.WithValue(ctx, optionsContextKey{}, opts)
+	}
+
+	sql, args, err = rewriteNamedArgs(sql, args)
+	if err != nil {
+		return nil, nil, "", nil, err
+	}
+
+	if opts.Lock != NoLock {
+		sql, err = addLockClause(sql, opts.Lock, opts.LockWait)
+		if err != nil {
+			return nil, nil, "", nil, err
+		}
+	}
+
+	if opts.ReadOnly && !isReadOnlySQL(sql) {
+		return nil, nil, "", nil, ErrNotReadOnly
+	}
+
+	if opts.SimpleProtocol {
+		args = append([]interface{}{pgx.QuerySimpleProtocol(true)}, args...)
+	}
+
+	cancel = func() {}
+	if opts.Timeout != 0 {
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+	}
+
+	return ctx, cancel, sql, args, nil
+}
+
+// defaultResultFormats is used instead of pgx.QueryResultFormats by functions whose result format may be changed with
+// Options.ResultFormat.
+type defaultResultFormats pgx.QueryResultFormats
+
+// applyResultFormat converts any defaultResultFormats in args to pgx.QueryResultFormats. If format is set they are
+// removed and format is requested instead. It is placed before the other pgx options in args so the formats required
+// by a function take precedence.
+func applyResultFormat(args []interface{}, format ResultFormat) []interface{} {
+	hasDefault := false
+	for _, arg := range args {
+		if _, ok := arg.(defaultResultFormats); ok {
+			hasDefault = true
+		}
+	}
+	if !hasDefault && format == DefaultResultFormat {
+		return args
+	}
+
+	newArgs := make([]interface{}, 0, len(args)+1)
+	switch format {
+	case TextResultFormat:
+		newArgs = append(newArgs, pgx.QueryResultFormats{pgx.TextFormatCode})
+	case BinaryResultFormat:
+		newArgs = append(newArgs, pgx.QueryResultFormats{pgx.BinaryFormatCode})
+	}
+	for _, arg := range args {
+		if formats, ok := arg.(defaultResultFormats); ok {
+			if format == DefaultResultFormat {
+				newArgs = append(newArgs, pgx.QueryResultFormats(formats))
+			}
+			continue
+		}
+		newArgs = append(newArgs, arg)
+	}
+
+	return newArgs
+}
+
+var dataModifyingKeywordRegexp = regexp.MustCompile(`(?i)\b(insert|update|delete|merge)\b`)
+
+// rowLockingClauseRegexp matches the row-level locking clauses added by Options.Lock. PostgreSQL refuses them in a
+// read-only transaction.
+var rowLockingClauseRegexp = regexp.MustCompile(`(?i)\bfor\s+(update|no\s+key\s+update|share|key\s+share)\b`)
+
+// isReadOnlySQL reports whether sql appears to be a read-only statement as described by Options.ReadOnly.
+func isReadOnlySQL(sql string) bool {
+	switch strings.ToLower(leadingKeyword(sql)) {
+	case "select", "values", "table":
+		return !rowLockingClauseRegexp.MatchString(sql)
+	case "show":
+		return true
+	case "with":
+		return !dataModifyingKeywordRegexp.MatchString(sql) && !rowLockingClauseRegexp.MatchString(sql)
+	default:
+		return false
+	}
+}
+
+// leadingKeyword returns the first word of sql skipping whitespace, comments, and opening parentheses.
+func leadingKeyword(sql string) string {
+	for {
+		sql = strings.TrimLeft(sql, " \t\r\n(")
+		switch {
+		case strings.HasPrefix(sql, "--"):
+			i := strings.IndexByte(sql, '\n')
+			if i < 0 {
+				return ""
+			}
+			sql = sql[i+1:]
+		case strings.HasPrefix(sql, "/*"):
+			i := strings.Index(sql, "*/")
+			if i < 0 {
+				return ""
+			}
+			sql = sql[i+2:]
+		default:
+			end := strings.IndexFunc(sql, func(r rune) bool {
+				return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r == '_')
+			})
+			if end < 0 {
+				return sql
+			}
+			return sql[:end]
+		}
+	}
+}
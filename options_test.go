@@ -0,0 +1,227 @@
+package pgxutil_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgxutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithOptions(t *testing.T) {
+	t.Parallel()
+
+	ctx := pgxutil.WithOptions(context.Background(), pgxutil.Options{Timeout: time.Second, Attributes: map[string]string{"a": "1", "b": "2"}, CacheTags: []string{"users"}})
+	ctx = pgxutil.WithOptions(ctx, pgxutil.Options{ReadOnly: true, Attributes: map[string]string{"b": "3"}, CacheTags: []string{"orders"}})
+
+	opts := pgxutil.OptionsFromContext(ctx)
+	assert.Equal(t, time.Second, opts.Timeout)
+	assert.True(t, opts.ReadOnly)
+	assert.False(t, opts.SimpleProtocol)
+	assert.Equal(t, map[string]string{"a": "1", "b": "3"}, opts.Attributes)
+	assert.Equal(t, []string{"users", "orders"}, opts.CacheTags)
+
+	assert.Equal(t, pgxutil.Options{}, pgxutil.OptionsFromContext(context.Background()))
+}
+
+func TestOptionsTimeout(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		_, err := pgxutil.SelectString(ctx, tx, "select 'ok' from pg_sleep(1)", pgxutil.Options{Timeout: 50 * time.Millisecond})
+		require.Error(t, err)
+	})
+
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		ctx = pgxutil.WithOptions(ctx, pgxutil.Options{Timeout: 50 * time.Millisecond})
+		_, err := pgxutil.Exec(ctx, tx, "select pg_sleep(1)")
+		require.Error(t, err)
+	})
+}
+
+func TestOptionsSimpleProtocol(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		n, err := pgxutil.SelectInt64(ctx, tx, "select $1::int8", pgxutil.Options{SimpleProtocol: true}, 42)
+		require.NoError(t, err)
+		assert.EqualValues(t, 42, n)
+
+		s, err := pgxutil.SelectString(ctx, tx, "select :name::text", pgxutil.NamedArgs{"name": "Adam"}, pgxutil.Options{SimpleProtocol: true})
+		require.NoError(t, err)
+		assert.Equal(t, "Adam", s)
+	})
+}
+
+func TestOptionsReadOnly(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		_, err := tx.Exec(ctx, `create temporary table t (id int)`)
+		require.NoError(t, err)
+
+		ctx = pgxutil.WithOptions(ctx, pgxutil.Options{ReadOnly: true})
+
+		for i, sql := range []string{
+			"select 1",
+			"  -- comment\n/* comment */ (select 1)",
+			"with x as (select 1) select * from x",
+			"values (1)",
+		} {
+			_, err := pgxutil.SelectValue(ctx, tx, sql)
+			assert.NoErrorf(t, err, "%d. %s", i, sql)
+		}
+
+		for i, sql := range []string{
+			"insert into t values (1)",
+			"with x as (delete from t returning *) select * from x",
+			"create table u (id int)",
+			"select * from t for update",
+			"with x as (select * from t for share) select * from x",
+		} {
+			_, err := pgxutil.Exec(ctx, tx, sql)
+			assert.Truef(t, errors.Is(err, pgxutil.ErrNotReadOnly), "%d. %s", i, sql)
+		}
+
+		_, err = pgxutil.SelectAllValue(ctx, tx, "select * from t", pgxutil.Options{Lock: pgxutil.LockForUpdate, LockWait: pgxutil.LockSkipLocked})
+		assert.True(t, errors.Is(err, pgxutil.ErrNotReadOnly))
+	})
+}
+
+func TestOptionsAttributes(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		hook := &recordingHook{}
+		db := pgxutil.NewHookedDB(tx, hook)
+
+		ctx = pgxutil.WithOptions(ctx, pgxutil.Options{Attributes: map[string]string{"route": "/users"}})
+		_, err := pgxutil.SelectInt64(ctx, db, "select 1", pgxutil.Options{Attributes: map[string]string{"user": "adam"}})
+		require.NoError(t, err)
+		require.Len(t, hook.after, 1)
+		assert.Equal(t, map[string]string{"route": "/users", "user": "adam"}, hook.after[0].Attributes)
+	})
+}
+
+func TestOptionsResultFormat(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		b, err := pgxutil.SelectByteSlice(ctx, tx, "select 42::int4")
+		require.NoError(t, err)
+		assert.Equal(t, []byte{0, 0, 0, 42}, b)
+
+		b, err = pgxutil.SelectByteSlice(ctx, tx, "select 42::int4", pgxutil.Options{ResultFormat: pgxutil.TextResultFormat})
+		require.NoError(t, err)
+		assert.Equal(t, []byte("42"), b)
+
+		textCtx := pgxutil.WithOptions(ctx, pgxutil.Options{ResultFormat: pgxutil.TextResultFormat})
+		bs, err := pgxutil.SelectAllByteSlice(textCtx, tx, "select n::int4 from generate_series(1, :n) n", pgxutil.NamedArgs{"n": 2})
+		require.NoError(t, err)
+		assert.Equal(t, [][]byte{[]byte("1"), []byte("2")}, bs)
+
+		n, err := pgxutil.SelectInt64(textCtx, tx, "select 42")
+		require.NoError(t, err)
+		assert.EqualValues(t, 42, n)
+
+		m, err := pgxutil.SelectMap(ctx, tx, "select 42::int4 as n", pgxutil.Options{ResultFormat: pgxutil.BinaryResultFormat})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"n": int32(42)}, m)
+	})
+}
+
+func TestOptionsExpectedRows(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		ns, err := pgxutil.SelectAll[int32](ctx, tx, "select n from generate_series(1,3) n", pgxutil.Options{ExpectedRows: 100})
+		require.NoError(t, err)
+		assert.Equal(t, []int32{1, 2, 3}, ns)
+		assert.Equal(t, 100, cap(ns))
+
+		ctx = pgxutil.WithOptions(ctx, pgxutil.Options{ExpectedRows: 50})
+		ms, err := pgxutil.SelectAllMap(ctx, tx, "select n from generate_series(1,3) n")
+		require.NoError(t, err)
+		assert.Len(t, ms, 3)
+		assert.Equal(t, 50, cap(ms))
+
+		ss, err := pgxutil.SelectAllStringMap(ctx, tx, "select n from generate_series(1,3) n")
+		require.NoError(t, err)
+		assert.Len(t, ss, 3)
+		assert.Equal(t, 50, cap(ss))
+	})
+}
+
+func TestOptionsZeroCopy(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		b, err := pgxutil.SelectByteSlice(ctx, tx, "select '\\x0102'::bytea", pgxutil.Options{ZeroCopy: true})
+		require.NoError(t, err)
+		assert.Equal(t, []byte{1, 2}, b)
+
+		var rows [][]byte
+		err = pgxutil.SelectMapForEach(ctx, tx, func(m map[string]interface{}) error {
+			rows = append(rows, append([]byte(nil), m["b"].([]byte)...))
+			return nil
+		}, "select decode(lpad(to_hex(n), 2, '0'), 'hex') as b from generate_series(1, 3) n", pgxutil.Options{ZeroCopy: true})
+		require.NoError(t, err)
+		assert.Equal(t, [][]byte{{1}, {2}, {3}}, rows)
+	})
+}
+
+func TestWithStatementTimeout(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn := connectPG(t, ctx)
+	defer closeConn(t, conn)
+
+	timeoutCtx := pgxutil.WithStatementTimeout(ctx, 50*time.Millisecond)
+
+	_, err := pgxutil.SelectString(timeoutCtx, conn, "select 'ok' from pg_sleep(1)")
+	var pgErr *pgconn.PgError
+	require.True(t, errors.As(err, &pgErr))
+	assert.Equal(t, "57014", pgErr.Code)
+
+	_, err = pgxutil.Exec(timeoutCtx, conn, "select pg_sleep(1)")
+	require.True(t, errors.As(err, &pgErr))
+	assert.Equal(t, "57014", pgErr.Code)
+
+	s, err := pgxutil.SelectString(timeoutCtx, conn, "select current_setting('statement_timeout')")
+	require.NoError(t, err)
+	assert.Equal(t, "50ms", s)
+
+	s, err = pgxutil.SelectString(ctx, conn, "select current_setting('statement_timeout')")
+	require.NoError(t, err)
+	assert.Equal(t, "0", s)
+
+	// A timeout shorter than a millisecond is rounded up instead of disabling statement_timeout.
+	_, err = pgxutil.SelectString(pgxutil.WithStatementTimeout(ctx, time.Microsecond), conn, "select 'ok' from pg_sleep(1)")
+	require.True(t, errors.As(err, &pgErr))
+	assert.Equal(t, "57014", pgErr.Code)
+
+	tx, err := conn.Begin(ctx)
+	require.NoError(t, err)
+	defer tx.Rollback(ctx)
+
+	_, err = pgxutil.SelectString(timeoutCtx, tx, "select 'ok' from pg_sleep(1)")
+	require.True(t, errors.As(err, &pgErr))
+	assert.Equal(t, "57014", pgErr.Code)
+
+	s, err = pgxutil.SelectString(timeoutCtx, tx, "select current_setting('statement_timeout')")
+	require.NoError(t, err)
+	assert.Equal(t, "50ms", s)
+
+	s, err = pgxutil.SelectString(ctx, tx, "select current_setting('statement_timeout')")
+	require.NoError(t, err)
+	assert.Equal(t, "0", s)
+
+	// The setting does not leak when the transaction is wrapped.
+	_, err = pgxutil.SelectString(timeoutCtx, pgxutil.NewDB(tx), "select current_setting('statement_timeout')")
+	require.NoError(t, err)
+
+	s, err = pgxutil.SelectString(ctx, tx, "select current_setting('statement_timeout')")
+	require.NoError(t, err)
+	assert.Equal(t, "0", s)
+}
@@ -0,0 +1,86 @@
+// Package otelpgxutil provides OpenTelemetry tracing for pgxutil. It is a separate module so the main pgxutil package does
+// not require OpenTelemetry.
+package otelpgxutil
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jackc/pgxutil"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/jackc/pgxutil/otelpgxutil"
+
+// Hook is a pgxutil.QueryHook that records a client span for each query. The span is a child of any span in the
+// query's context and is in the context passed to PostgreSQL and AfterQuery. pgxutil.Options.Attributes are recorded as
+// span attributes.
+type Hook struct {
+	tracer trace.Tracer
+}
+
+// NewHook returns a Hook that creates spans with tracerProvider. If tracerProvider is nil the global TracerProvider is
+// used.
+func NewHook(tracerProvider trace.TracerProvider) *Hook {
+	if tracerProvider == nil {
+		tracerProvider = otel.GetTracerProvider()
+	}
+
+	return &Hook{tracer: tracerProvider.Tracer(instrumentationName)}
+}
+
+// Wrap returns db wrapped so a span is recorded for each query sent through it. If tracerProvider is nil the global
+// TracerProvider is used.
+func Wrap(db pgxutil.Handle, tracerProvider trace.TracerProvider) *pgxutil.HookedDB {
+	return pgxutil.NewHookedDB(db, NewHook(tracerProvider))
+}
+
+// BeforeQuery implements pgxutil.QueryHook.
+func (h *Hook) BeforeQuery(ctx context.Context, data *pgxutil.QueryHookData) context.Context {
+	operation := sqlOperation(data.SQL)
+	attrs := []attribute.KeyValue{
+		attribute.String("db.system", "postgresql"),
+		attribute.String("db.operation", operation),
+		attribute.String("db.statement", data.SQL),
+	}
+	for k, v := range data.Attributes {
+		attrs = append(attrs, attribute.String(k, v))
+	}
+
+	ctx, _ = h.tracer.Start(ctx, operation, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+	return ctx
+}
+
+// AfterQuery implements pgxutil.QueryHook.
+func (h *Hook) AfterQuery(ctx context.Context, data *pgxutil.QueryHookData) {
+	span := trace.SpanFromContext(ctx)
+	if data.CommandTag != nil {
+		span.SetAttributes(attribute.Int64("db.rows_affected", data.CommandTag.RowsAffected()))
+	}
+	if data.Err != nil {
+		span.RecordError(data.Err)
+		span.SetStatus(codes.Error, data.Err.Error())
+	}
+	span.End()
+}
+
+// sqlOperation returns the first keyword of sql in upper case. e.g. SELECT or INSERT.
+func sqlOperation(sql string) string {
+	fields := strings.Fields(sql)
+	if len(fields) == 0 {
+		return "QUERY"
+	}
+
+	operation := fields[0]
+	if i := strings.IndexAny(operation, "(;"); i >= 0 {
+		operation = operation[:i]
+	}
+	if operation == "" {
+		return "QUERY"
+	}
+
+	return strings.ToUpper(operation)
+}
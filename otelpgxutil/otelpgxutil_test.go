@@ -0,0 +1,100 @@
+package otelpgxutil_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgxutil"
+	"github.com/jackc/pgxutil/otelpgxutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newTracerProvider() (*sdktrace.TracerProvider, *tracetest.SpanRecorder) {
+	recorder := tracetest.NewSpanRecorder()
+	return sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)), recorder
+}
+
+func spanAttributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	m := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes() {
+		m[kv.Key] = kv.Value
+	}
+	return m
+}
+
+func TestHook(t *testing.T) {
+	t.Parallel()
+
+	tp, recorder := newTracerProvider()
+	hook := otelpgxutil.NewHook(tp)
+
+	parentCtx, parent := tp.Tracer("test").Start(context.Background(), "parent")
+
+	data := &pgxutil.QueryHookData{SQL: "update t set name = $1", Args: []interface{}{"Adam"}, Attributes: map[string]string{"app.route": "/users"}}
+	ctx := hook.BeforeQuery(parentCtx, data)
+	assert.True(t, trace.SpanFromContext(ctx).SpanContext().IsValid())
+	assert.NotEqual(t, parent.SpanContext().SpanID(), trace.SpanFromContext(ctx).SpanContext().SpanID())
+	data.CommandTag = pgconn.CommandTag("UPDATE 3")
+	hook.AfterQuery(ctx, data)
+
+	data = &pgxutil.QueryHookData{SQL: "select 1/0"}
+	ctx = hook.BeforeQuery(parentCtx, data)
+	data.Err = errors.New("division by zero")
+	hook.AfterQuery(ctx, data)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+
+	assert.Equal(t, "UPDATE", spans[0].Name())
+	assert.Equal(t, trace.SpanKindClient, spans[0].SpanKind())
+	assert.Equal(t, parent.SpanContext().SpanID(), spans[0].Parent().SpanID())
+	attrs := spanAttributes(spans[0])
+	assert.Equal(t, "postgresql", attrs["db.system"].AsString())
+	assert.Equal(t, "update t set name = $1", attrs["db.statement"].AsString())
+	assert.EqualValues(t, 3, attrs["db.rows_affected"].AsInt64())
+	assert.Equal(t, "/users", attrs["app.route"].AsString())
+
+	assert.Equal(t, "SELECT", spans[1].Name())
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+	assert.Equal(t, "division by zero", spans[1].Status().Description)
+}
+
+func TestWrap(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, err := pgx.Connect(ctx, fmt.Sprintf("database=%s", os.Getenv("TEST_DATABASE")))
+	require.NoError(t, err)
+	defer conn.Close(ctx)
+
+	tp, recorder := newTracerProvider()
+	db := otelpgxutil.Wrap(conn, tp)
+
+	n, err := pgxutil.SelectInt64(ctx, db, "select 42")
+	require.NoError(t, err)
+	assert.EqualValues(t, 42, n)
+
+	_, err = pgxutil.Exec(ctx, db, "select * from pgxutil_missing_table")
+	require.Error(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "SELECT", spans[0].Name())
+	assert.Equal(t, "select 42", spanAttributes(spans[0])["db.statement"].AsString())
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+}
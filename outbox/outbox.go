@@ -0,0 +1,234 @@
+// Package outbox implements the transactional outbox pattern for reliably publishing events.
+//
+// Write stores a message in the outbox table in the same transaction as the change it describes, so the message exists
+// if and only if the change is committed. A Relay reads the outbox and passes each message to a handler that publishes
+// it, e.g. to a message broker, and deletes the message once the handler succeeds. Delivery is at least once: if the
+// relay stops after the handler succeeds but before the message is deleted it is handled again, so consumers must
+// tolerate duplicates.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgxutil"
+	"github.com/jackc/pgxutil/internal/pgtable"
+)
+
+// DefaultTableName is the table used when a table name is not set.
+const DefaultTableName = "outbox_messages"
+
+// Message is a message read from the outbox.
+type Message struct {
+	ID    int64  `db:"id"`
+	Topic string `db:"topic"`
+
+	// Payload is the JSON the message was written with.
+	Payload json.RawMessage `db:"payload"`
+
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// CreateTable creates the outbox table if it does not exist. If tableName is empty DefaultTableName is used.
+func CreateTable(ctx context.Context, db pgxutil.Execer, tableName string) error {
+	return pgtable.Create(ctx, db, pgtable.Name(tableName, DefaultTableName), `
+	id bigserial primary key,
+	topic text not null,
+	payload jsonb not null,
+	created_at timestamptz not null default now()
+`)
+}
+
+// WriteOptions configures WriteWithOptions.
+type WriteOptions struct {
+	// TableName is the outbox table. If empty DefaultTableName is used.
+	TableName string
+
+	// Channel is notified when the transaction commits so a Relay listening on it handles the message without waiting
+	// for its next poll. If empty no notification is sent.
+	Channel string
+}
+
+// Write stores a message with payload marshaled to JSON in the outbox in tx and returns its ID.
+func Write(ctx context.Context, tx pgx.Tx, topic string, payload interface{}) (int64, error) {
+	return WriteWithOptions(ctx, tx, topic, payload, WriteOptions{})
+}
+
+// WriteWithOptions is like Write except the message is written according to opts.
+func WriteWithOptions(ctx context.Context, tx pgx.Tx, topic string, payload interface{}, opts WriteOptions) (int64, error) {
+	buf, err := json.Marshal(payload)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := pgxutil.SelectInt64(ctx, tx,
+		fmt.Sprintf("insert into %s (topic, payload) values ($1, $2::jsonb) returning id", pgtable.Name(opts.TableName, DefaultTableName)),
+		topic, string(buf),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	if opts.Channel != "" {
+		err := pgxutil.Notify(ctx, tx, opts.Channel, id)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return id, nil
+}
+
+// Relay passes the messages in the outbox to Handler in ID order. Messages are locked while they are handled so
+// multiple relays can run at once, but then messages are only ordered within each relay. The zero value is not usable.
+// DB and Handler must be set. The fields must not be changed while the Relay is running.
+type Relay struct {
+	// DB is used to read and delete messages. It is usually a *pgxpool.Pool.
+	DB pgxutil.Handle
+
+	// TableName is the outbox table. If empty DefaultTableName is used.
+	TableName string
+
+	// Handler is called with each message. If it returns an error the message is kept and it and the messages after it
+	// are handled again later.
+	Handler func(ctx context.Context, msg *Message) error
+
+	// BatchSize is the maximum number of messages read at once. If zero 100 is used.
+	BatchSize int
+
+	// PollInterval is how long Run waits before reading the outbox again when it was empty or an error occurred. If
+	// zero 1 second is used.
+	PollInterval time.Duration
+
+	// Connect and Channel are optional. If both are set Run listens on Channel with a connection from Connect and reads
+	// the outbox as soon as a notification arrives. Write messages with the same WriteOptions.Channel. PollInterval
+	// still applies in case a notification is missed.
+	Connect func(ctx context.Context) (*pgx.Conn, error)
+	Channel string
+
+	// OnError is called with errors Run encounters. It is optional.
+	OnError func(err error)
+}
+
+// Run relays messages until ctx is canceled. Errors do not stop Run. They are passed to OnError and the outbox is read
+// again after PollInterval. Run always returns ctx.Err().
+func (r *Relay) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	wake := make(chan struct{}, 1)
+	signal := func() {
+		select {
+		case wake <- struct{}{}:
+		default:
+		}
+	}
+
+	if r.Connect != nil && r.Channel != "" {
+		listener := &pgxutil.Listener{
+			Connect:  r.Connect,
+			Channels: []string{r.Channel},
+			Handler: func(ctx context.Context, notification *pgconn.Notification) error {
+				signal()
+				return nil
+			},
+			// Messages written while not listening were not notified.
+			OnConnect: func(ctx context.Context, conn *pgx.Conn) error {
+				signal()
+				return nil
+			},
+			OnError: r.OnError,
+		}
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			listener.Listen(ctx)
+		}()
+		defer func() {
+			cancel()
+			<-done
+		}()
+	}
+
+	for {
+		n, err := r.Poll(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil && r.OnError != nil {
+			r.OnError(err)
+		}
+
+		if err == nil && n > 0 {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-wake:
+		case <-time.After(r.pollInterval()):
+		}
+	}
+}
+
+// Poll reads one batch of messages, passes them to Handler, and returns the number of messages successfully handled and
+// deleted. If Handler returns an error Poll stops and returns it. Messages handled before the error are still deleted.
+func (r *Relay) Poll(ctx context.Context) (int, error) {
+	var handled []int64
+	var handlerErr error
+
+	err := pgxutil.WithTx(ctx, r.DB, func(tx pgx.Tx) error {
+		var messages []Message
+		err := pgxutil.SelectAllStruct(ctx, tx, &messages,
+			fmt.Sprintf("select id, topic, payload, created_at from %s order by id limit $1", r.tableName()),
+			r.batchSize(), pgxutil.Options{Lock: pgxutil.LockForUpdate, LockWait: pgxutil.LockSkipLocked},
+		)
+		if err != nil {
+			return err
+		}
+
+		for i := range messages {
+			handlerErr = r.Handler(ctx, &messages[i])
+			if handlerErr != nil {
+				break
+			}
+			handled = append(handled, messages[i].ID)
+		}
+
+		if len(handled) == 0 {
+			return nil
+		}
+
+		_, err = pgxutil.Exec(ctx, tx, fmt.Sprintf("delete from %s where id = any($1)", r.tableName()), handled)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return len(handled), handlerErr
+}
+
+func (r *Relay) tableName() string {
+	return pgtable.Name(r.TableName, DefaultTableName)
+}
+
+func (r *Relay) batchSize() int {
+	if r.BatchSize == 0 {
+		return 100
+	}
+	return r.BatchSize
+}
+
+func (r *Relay) pollInterval() time.Duration {
+	if r.PollInterval == 0 {
+		return time.Second
+	}
+	return r.PollInterval
+}
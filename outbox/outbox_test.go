@@ -0,0 +1,141 @@
+package outbox_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgxutil"
+	"github.com/jackc/pgxutil/internal/testdb"
+	"github.com/jackc/pgxutil/outbox"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteIsTransactional(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	conn := testdb.Connect(t, ctx)
+
+	tableName := "pg_temp.outbox"
+	require.NoError(t, outbox.CreateTable(ctx, conn, tableName))
+
+	err := pgxutil.WithTx(ctx, conn, func(tx pgx.Tx) error {
+		_, err := outbox.WriteWithOptions(ctx, tx, "user.created", map[string]int{"id": 1}, outbox.WriteOptions{TableName: tableName})
+		require.NoError(t, err)
+		return errors.New("rollback")
+	})
+	require.EqualError(t, err, "rollback")
+
+	n, err := pgxutil.SelectInt64(ctx, conn, "select count(*) from pg_temp.outbox")
+	require.NoError(t, err)
+	assert.EqualValues(t, 0, n)
+}
+
+func TestRelayPoll(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	conn := testdb.Connect(t, ctx)
+
+	tableName := "pg_temp.outbox"
+	require.NoError(t, outbox.CreateTable(ctx, conn, tableName))
+
+	err := pgxutil.WithTx(ctx, conn, func(tx pgx.Tx) error {
+		for _, id := range []int{1, 2, 3} {
+			_, err := outbox.WriteWithOptions(ctx, tx, "user.created", map[string]int{"id": id}, outbox.WriteOptions{TableName: tableName})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	var published []string
+	fail := true
+	r := &outbox.Relay{
+		DB:        conn,
+		TableName: tableName,
+		Handler: func(ctx context.Context, msg *outbox.Message) error {
+			if fail && len(published) == 2 {
+				return errors.New("broker unavailable")
+			}
+			assert.Equal(t, "user.created", msg.Topic)
+			published = append(published, string(msg.Payload))
+			return nil
+		},
+	}
+
+	n, err := r.Poll(ctx)
+	require.EqualError(t, err, "broker unavailable")
+	assert.Equal(t, 2, n)
+
+	fail = false
+	n, err = r.Poll(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, []string{`{"id": 1}`, `{"id": 2}`, `{"id": 3}`}, published)
+
+	n, err = r.Poll(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+}
+
+func TestRelayRunListens(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	conn := testdb.Connect(t, ctx)
+
+	// The relay uses its own connection so the table cannot be temporary.
+	tableName := "pgxutil_outbox_test"
+	require.NoError(t, outbox.CreateTable(ctx, conn, tableName))
+	t.Cleanup(func() {
+		_, err := pgxutil.Exec(context.Background(), conn, "drop table "+tableName)
+		assert.NoError(t, err)
+	})
+
+	published := make(chan *outbox.Message, 1)
+	r := &outbox.Relay{
+		DB:           testdb.Connect(t, ctx),
+		TableName:    tableName,
+		PollInterval: time.Hour,
+		Connect: func(ctx context.Context) (*pgx.Conn, error) {
+			return pgx.Connect(ctx, testdb.ConnString())
+		},
+		Channel: "pgxutil_outbox_test",
+		Handler: func(ctx context.Context, msg *outbox.Message) error {
+			published <- msg
+			return nil
+		},
+		OnError: func(err error) { t.Error(err) },
+	}
+
+	runErr := make(chan error, 1)
+	go func() { runErr <- r.Run(ctx) }()
+
+	// PollInterval is too long for the test so the message is only relayed in time if the relay is woken by the
+	// notification or by connecting the listener.
+	var id int64
+	err := pgxutil.WithTx(ctx, conn, func(tx pgx.Tx) error {
+		var err error
+		id, err = outbox.WriteWithOptions(ctx, tx, "user.created", 1, outbox.WriteOptions{TableName: tableName, Channel: r.Channel})
+		return err
+	})
+	require.NoError(t, err)
+
+	select {
+	case msg := <-published:
+		assert.Equal(t, id, msg.ID)
+	case <-ctx.Done():
+		t.Fatal("message was not relayed")
+	}
+
+	cancel()
+	assert.ErrorIs(t, <-runErr, context.Canceled)
+}
@@ -0,0 +1,195 @@
+package pgxutil
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// PageOptions configures SelectPage.
+type PageOptions struct {
+	// After is the cursor returned by the previous call to SelectPage. If empty the first page is selected.
+	After string
+
+	// Limit is the maximum number of rows per page.
+	Limit int
+
+	// OrderBy are the result columns the rows are ordered by. Together they must uniquely identify a row and must not
+	// be null.
+	OrderBy []string
+
+	// Desc orders the rows in descending instead of ascending order.
+	Desc bool
+}
+
+// SelectPage selects a page of rows from sql using keyset pagination. sql is used as a subquery. It should not have its
+// own order by or limit clause. The returned cursor can be passed as PageOptions.After to select the next page. It is
+// empty when there are no more rows.
+func SelectPage(ctx context.Context, db Queryer, sql string, args []interface{}, opts PageOptions) ([]map[string]interface{}, string, error) {
+	if opts.Limit < 1 {
+		return nil, "", fmt.Errorf("limit must be at least 1")
+	}
+	if len(opts.OrderBy) == 0 {
+		return nil, "", fmt.Errorf("order by must not be empty")
+	}
+
+	var afterValues []interface{}
+	if opts.After != "" {
+		var err error
+		afterValues, err = decodePageCursor(opts.After, len(opts.OrderBy))
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	keysetSQL, queryArgs, err := buildKeysetSQL(sql, args, opts.OrderBy, opts.Desc, afterValues, opts.Limit+1, true)
+	if err != nil {
+		return nil, "", err
+	}
+
+	rows, err := SelectAllMap(ctx, db, keysetSQL, queryArgs...)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// The text of the order by values is selected as extra columns so the cursor can hold values of any type, e.g. uuid
+	// or numeric, in a form that can be bound as an argument of the next query.
+	lastValues := make([]string, len(opts.OrderBy))
+	for i, row := range rows {
+		for j, c := range opts.OrderBy {
+			key := pageKeyColumn(j)
+			if i == opts.Limit-1 {
+				v, ok := row[key].(string)
+				if !ok {
+					return nil, "", fmt.Errorf("order by column %s is null", c)
+				}
+				lastValues[j] = v
+			}
+			delete(row, key)
+		}
+	}
+
+	if len(rows) <= opts.Limit {
+		return rows, "", nil
+	}
+
+	cursor, err := encodePageCursor(lastValues)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return rows[:opts.Limit], cursor, nil
+}
+
+// pageKeyColumn returns the name of the column SelectPage selects the text of the ith order by value into.
+func pageKeyColumn(i int) string {
+	return "pgxutil_page_key_" + strconv.Itoa(i)
+}
+
+// buildKeysetSQL returns sql wrapped as a subquery that selects up to limit rows ordered by orderBy after the row whose
+// orderBy values are afterValues, and the arguments for it. If afterValues is nil the first rows are selected. NamedArgs
+// in args are rewritten first so the placeholders of afterValues follow the positional arguments of sql. Options are
+// kept for the wrapped query. If selectKeyText is true the text of each orderBy value is also selected into the column
+// named by pageKeyColumn.
+func buildKeysetSQL(sql string, args []interface{}, orderBy []string, desc bool, afterValues []interface{}, limit int, selectKeyText bool) (string, []interface{}, error) {
+	var optionArgs []interface{}
+	sqlArgs := make([]interface{}, 0, len(args))
+	for _, arg := range args {
+		if _, ok := arg.(Options); ok {
+			optionArgs = append(optionArgs, arg)
+		} else {
+			sqlArgs = append(sqlArgs, arg)
+		}
+	}
+
+	sql, sqlArgs, err := rewriteNamedArgs(sql, sqlArgs)
+	if err != nil {
+		return "", nil, err
+	}
+
+	positionalCount := 0
+	for _, arg := range sqlArgs {
+		switch arg.(type) {
+		case pgx.QueryResultFormats, pgx.QueryResultFormatsByOID, pgx.QuerySimpleProtocol, defaultResultFormats:
+		default:
+			positionalCount++
+		}
+	}
+
+	direction, comparison := "asc", ">"
+	if desc {
+		direction, comparison = "desc", "<"
+	}
+
+	columns := make([]string, len(orderBy))
+	orderTerms := make([]string, len(orderBy))
+	for i, c := range orderBy {
+		columns[i] = pgx.Identifier{c}.Sanitize()
+		orderTerms[i] = columns[i] + " " + direction
+	}
+	columnList := strings.Join(columns, ", ")
+
+	queryArgs := append(make([]interface{}, 0, len(args)+len(afterValues)), sqlArgs...)
+
+	sb := &strings.Builder{}
+	sb.WriteString("select *")
+	if selectKeyText {
+		for i, c := range columns {
+			fmt.Fprintf(sb, ", %s::text as %s", c, pageKeyColumn(i))
+		}
+	}
+	sb.WriteString(" from (")
+	sb.WriteString(sql)
+	sb.WriteString(") pgxutil_page")
+
+	if afterValues != nil {
+		placeholders := make([]string, len(afterValues))
+		for i, v := range afterValues {
+			queryArgs = append(queryArgs, v)
+			placeholders[i] = "$" + strconv.Itoa(positionalCount+i+1)
+		}
+		fmt.Fprintf(sb, " where (%s) %s (%s)", columnList, comparison, strings.Join(placeholders, ", "))
+	}
+
+	fmt.Fprintf(sb, " order by %s limit %d", strings.Join(orderTerms, ", "), limit)
+
+	return sb.String(), append(queryArgs, optionArgs...), nil
+}
+
+func encodePageCursor(values []string) (string, error) {
+	buf, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// decodePageCursor decodes a cursor created by encodePageCursor. The values are the text of the order by values, which
+// PostgreSQL parses as the types of the order by columns.
+func decodePageCursor(cursor string, columnCount int) ([]interface{}, error) {
+	buf, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor: %v", err)
+	}
+
+	var values []string
+	err = json.Unmarshal(buf, &values)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor: %v", err)
+	}
+	if len(values) != columnCount {
+		return nil, fmt.Errorf("invalid cursor: got %d values, but order by has %d columns", len(values), columnCount)
+	}
+
+	afterValues := make([]interface{}, len(values))
+	for i, v := range values {
+		afterValues[i] = v
+	}
+
+	return afterValues, nil
+}
@@ -0,0 +1,154 @@
+package pgxutil_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgxutil"
+	"github.com/jackc/pgxutil/pgxutiltest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectPage(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		sql := "select n as id, n % 3 as grp from generate_series(1, $1::int) n"
+
+		for _, desc := range []bool{false, true} {
+			opts := pgxutil.PageOptions{Limit: 2, OrderBy: []string{"grp", "id"}, Desc: desc}
+
+			var ids []int32
+			pageCount := 0
+			for {
+				rows, cursor, err := pgxutil.SelectPage(ctx, tx, sql, []interface{}{7}, opts)
+				require.NoError(t, err)
+				pageCount++
+				for _, row := range rows {
+					ids = append(ids, row["id"].(int32))
+				}
+				if cursor == "" {
+					break
+				}
+				opts.After = cursor
+			}
+
+			if desc {
+				assert.Equal(t, []int32{5, 2, 7, 4, 1, 6, 3}, ids)
+			} else {
+				assert.Equal(t, []int32{3, 6, 1, 4, 7, 2, 5}, ids)
+			}
+			assert.Equal(t, 4, pageCount)
+		}
+
+		// The cursor holds the text of the keys, so keys whose values are not JSON friendly can be paged too.
+		for _, key := range []string{
+			"('00000000-0000-0000-0000-00000000000' || n)::uuid",
+			"(n + 0.5)::numeric",
+			"'2020-01-01 00:00:00+00'::timestamptz + n * interval '1.000001 second'",
+		} {
+			sql := fmt.Sprintf("select n as id, %s as k from generate_series(1, 7) n", key)
+			opts := pgxutil.PageOptions{Limit: 2, OrderBy: []string{"k"}}
+
+			var ids []int32
+			for {
+				rows, cursor, err := pgxutil.SelectPage(ctx, tx, sql, nil, opts)
+				require.NoError(t, err, key)
+				for _, row := range rows {
+					assert.NotContains(t, row, "pgxutil_page_key_0")
+					ids = append(ids, row["id"].(int32))
+				}
+				if cursor == "" {
+					break
+				}
+				opts.After = cursor
+			}
+
+			assert.Equal(t, []int32{1, 2, 3, 4, 5, 6, 7}, ids, key)
+		}
+	})
+}
+
+func TestSelectPageUUIDCursor(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	db := &pgxutiltest.FakeQueryer{}
+	id := [16]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+	const idText = "01020304-0506-0708-090a-0b0c0d0e0f10"
+	db.ExpectQuery(`select *, "id"::text as pgxutil_page_key_0 from (select id from widgets) pgxutil_page order by "id" asc limit 2`).
+		ReturnRows(pgxutiltest.NewRows("id", "pgxutil_page_key_0").Types(pgtype.UUIDOID, pgtype.TextOID).AddRow(id, idText).AddRow(id, idText))
+	db.ExpectQuery(`select *, "id"::text as pgxutil_page_key_0 from (select id from widgets) pgxutil_page where ("id") > ($1) order by "id" asc limit 2`).
+		WithArgs(idText).
+		ReturnRows(pgxutiltest.NewRows("id", "pgxutil_page_key_0").Types(pgtype.UUIDOID, pgtype.TextOID))
+
+	// The cursor holds the text of the uuid, not the [16]byte it is selected as, so it can be bound on the next page.
+	opts := pgxutil.PageOptions{Limit: 1, OrderBy: []string{"id"}}
+	rows, cursor, err := pgxutil.SelectPage(ctx, db, "select id from widgets", nil, opts)
+	require.NoError(t, err)
+	assert.Equal(t, []map[string]interface{}{{"id": id}}, rows)
+	require.NotEmpty(t, cursor)
+
+	opts.After = cursor
+	rows, cursor, err = pgxutil.SelectPage(ctx, db, "select id from widgets", nil, opts)
+	require.NoError(t, err)
+	assert.Empty(t, rows)
+	assert.Empty(t, cursor)
+	require.NoError(t, db.ExpectationsWereMet())
+}
+
+func TestSelectPageInvalidCursor(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		_, _, err := pgxutil.SelectPage(ctx, tx, "select 1 as id", nil, pgxutil.PageOptions{After: "!", Limit: 1, OrderBy: []string{"id"}})
+		assert.Error(t, err)
+	})
+}
+
+func TestSelectPageNamedArgs(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	db := &pgxutiltest.FakeQueryer{}
+	db.ExpectQuery(`select *, "id"::text as pgxutil_page_key_0 from (select id from widgets where grp = $1) pgxutil_page order by "id" asc limit 2`).
+		WithArgs(int32(1)).
+		ReturnRows(pgxutiltest.NewRows("id", "pgxutil_page_key_0").Types(pgtype.Int4OID, pgtype.TextOID).AddRow(int32(1), "1").AddRow(int32(2), "2"))
+	db.ExpectQuery(`select *, "id"::text as pgxutil_page_key_0 from (select id from widgets where grp = $1) pgxutil_page where ("id") > ($2) order by "id" asc limit 2`).
+		WithArgs(int32(1), "1").
+		ReturnRows(pgxutiltest.NewRows("id", "pgxutil_page_key_0").Types(pgtype.Int4OID, pgtype.TextOID).AddRow(int32(2), "2"))
+
+	// The placeholder of the cursor follows the rewritten named argument, not the Options and NamedArgs values.
+	sql := "select id from widgets where grp = :grp"
+	args := []interface{}{pgxutil.NamedArgs{"grp": int32(1)}, pgxutil.Options{ExpectedRows: 2}}
+	opts := pgxutil.PageOptions{Limit: 1, OrderBy: []string{"id"}}
+	rows, cursor, err := pgxutil.SelectPage(ctx, db, sql, args, opts)
+	require.NoError(t, err)
+	assert.Equal(t, []map[string]interface{}{{"id": int32(1)}}, rows)
+	require.NotEmpty(t, cursor)
+
+	opts.After = cursor
+	rows, cursor, err = pgxutil.SelectPage(ctx, db, sql, args, opts)
+	require.NoError(t, err)
+	assert.Equal(t, []map[string]interface{}{{"id": int32(2)}}, rows)
+	assert.Empty(t, cursor)
+	require.NoError(t, db.ExpectationsWereMet())
+}
+
+func TestSelectPageQuotedColumns(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	db := &pgxutiltest.FakeQueryer{}
+	db.ExpectQuery(`select *, "a,b"::text as pgxutil_page_key_0, "c"::text as pgxutil_page_key_1 from (select 1 as "a,b", 2 as c) pgxutil_page order by "a,b" desc, "c" desc limit 2`).
+		ReturnRows(pgxutiltest.NewRows("a,b", "c", "pgxutil_page_key_0", "pgxutil_page_key_1").Types(pgtype.Int4OID, pgtype.Int4OID, pgtype.TextOID, pgtype.TextOID).AddRow(int32(1), int32(2), "1", "2"))
+
+	// A comma in a column name is not mistaken for the separator of the order by terms.
+	rows, cursor, err := pgxutil.SelectPage(ctx, db, `select 1 as "a,b", 2 as c`, nil, pgxutil.PageOptions{Limit: 1, OrderBy: []string{"a,b", "c"}, Desc: true})
+	require.NoError(t, err)
+	assert.Equal(t, []map[string]interface{}{{"a,b": int32(1), "c": int32(2)}}, rows)
+	assert.Empty(t, cursor)
+	require.NoError(t, db.ExpectationsWereMet())
+}
@@ -0,0 +1,50 @@
+package pgxutil
+
+import (
+	"errors"
+
+	"github.com/jackc/pgconn"
+)
+
+// IsSQLState returns true if err is or wraps a *pgconn.PgError with the SQLSTATE code.
+func IsSQLState(err error, code string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == code
+}
+
+// IsUniqueViolation returns true if err is a unique_violation (23505) error. ConstraintName returns the name of the
+// violated index or constraint.
+func IsUniqueViolation(err error) bool {
+	return IsSQLState(err, "23505")
+}
+
+// IsForeignKeyViolation returns true if err is a foreign_key_violation (23503) error.
+func IsForeignKeyViolation(err error) bool {
+	return IsSQLState(err, "23503")
+}
+
+// IsCheckViolation returns true if err is a check_violation (23514) error.
+func IsCheckViolation(err error) bool {
+	return IsSQLState(err, "23514")
+}
+
+// IsNotNullViolation returns true if err is a not_null_violation (23502) error. PostgreSQL reports the column in
+// pgconn.PgError.ColumnName rather than a constraint name.
+func IsNotNullViolation(err error) bool {
+	return IsSQLState(err, "23502")
+}
+
+// IsLockNotAvailable returns true if err is a lock_not_available (55P03) error. See also ErrLockNotAvailable.
+func IsLockNotAvailable(err error) bool {
+	return IsSQLState(err, "55P03")
+}
+
+// ConstraintName returns the name of the constraint violated by err. It is empty if err does not wrap a
+// *pgconn.PgError or PostgreSQL did not report a constraint.
+func ConstraintName(err error) string {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.ConstraintName
+	}
+	return ""
+}
@@ -0,0 +1,72 @@
+package pgxutil_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgxutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPgErrorPredicates(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		err := pgxutil.MultiExec(ctx, tx, []string{
+			"create temporary table parents (id int primary key)",
+			`create temporary table children (
+	id int primary key,
+	parent_id int not null constraint children_parent_fk references parents,
+	age int constraint children_age_check check (age >= 0)
+)`,
+			"insert into parents values (1)",
+			"insert into children values (1, 1, 0)",
+		})
+		require.NoError(t, err)
+
+		for _, tt := range []struct {
+			sql        string
+			predicate  func(error) bool
+			constraint string
+		}{
+			{"insert into children values (1, 1, 0)", pgxutil.IsUniqueViolation, "children_pkey"},
+			{"insert into children values (2, 2, 0)", pgxutil.IsForeignKeyViolation, "children_parent_fk"},
+			{"insert into children values (2, 1, -1)", pgxutil.IsCheckViolation, "children_age_check"},
+			{"insert into children values (2, null, 0)", pgxutil.IsNotNullViolation, ""},
+		} {
+			_, err := tx.Exec(ctx, "savepoint s")
+			require.NoError(t, err)
+
+			_, err = pgxutil.Exec(ctx, tx, tt.sql)
+			require.Error(t, err, tt.sql)
+			assert.True(t, tt.predicate(err), tt.sql)
+			assert.Equal(t, tt.constraint, pgxutil.ConstraintName(err), tt.sql)
+
+			_, err = tx.Exec(ctx, "rollback to savepoint s")
+			require.NoError(t, err)
+		}
+	})
+}
+
+func TestIsSQLState(t *testing.T) {
+	t.Parallel()
+
+	err := fmt.Errorf("insert user: %w", &pgconn.PgError{Code: "23505", ConstraintName: "users_email_key"})
+	assert.True(t, pgxutil.IsSQLState(err, "23505"))
+	assert.True(t, pgxutil.IsUniqueViolation(err))
+	assert.False(t, pgxutil.IsForeignKeyViolation(err))
+	assert.False(t, pgxutil.IsCheckViolation(err))
+	assert.False(t, pgxutil.IsNotNullViolation(err))
+	assert.False(t, pgxutil.IsLockNotAvailable(err))
+	assert.Equal(t, "users_email_key", pgxutil.ConstraintName(err))
+
+	err = errors.New("boom")
+	assert.False(t, pgxutil.IsSQLState(err, "23505"))
+	assert.False(t, pgxutil.IsUniqueViolation(err))
+	assert.Equal(t, "", pgxutil.ConstraintName(err))
+	assert.False(t, pgxutil.IsUniqueViolation(nil))
+}
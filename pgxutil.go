@@ -2,38 +2,80 @@ package pgxutil
 
 import (
 	"context"
+	"encoding/json"
+	"encoding/xml"
 	"errors"
 	"fmt"
+	"math/big"
+	"net"
 	"reflect"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gofrs/uuid"
 	"github.com/jackc/pgconn"
-	"github.com/jackc/pgsql"
+	"github.com/jackc/pgproto3/v2"
 	"github.com/jackc/pgtype"
-	gofrs "github.com/jackc/pgtype/ext/gofrs-uuid"
 	"github.com/jackc/pgx/v4"
-	"github.com/shopspring/decimal"
+	"github.com/jackc/pgxutil/build"
 )
 
-var errNullValue = errors.New("value is null")
-var errNotFound = errors.New("no rows in result set")
-var errNoColumns = errors.New("no columns in result set")
-var errMultipleColumns = errors.New("multiple columns in result set")
-var errMultipleRows = errors.New("multiple rows in result set")
+// ErrNoRows is returned when a query that requires a row returns no rows. It is the same error as pgx.ErrNoRows.
+var ErrNoRows = pgx.ErrNoRows
 
+// ErrTooManyRows is returned when a query that requires a single row returns multiple rows.
+var ErrTooManyRows = errors.New("multiple rows in result set")
+
+// ErrNoColumns is returned when a query that requires a single column returns no columns.
+var ErrNoColumns = errors.New("no columns in result set")
+
+// ErrTooManyColumns is returned when a query that requires a single column returns multiple columns.
+var ErrTooManyColumns = errors.New("multiple columns in result set")
+
+// ErrStaleRow is returned by UpdateVersioned and by UpdateStruct for a record with a version field when no row with
+// the expected version was found. The row was changed or deleted since it was read.
+var ErrStaleRow = errors.New("row was changed or deleted since it was read")
+
+// ErrNullValue is returned when a null value is found where a null value is not allowed.
+var ErrNullValue = errors.New("value is null")
+
+var errInfiniteValue = errors.New("value is infinite")
+
+// Queryer is the interface used by the Select functions. It is implemented by *pgx.Conn, pgx.Tx, and *pgxpool.Pool.
 type Queryer interface {
 	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
 }
 
+// Execer is the interface used by functions that execute statements without reading rows. It is implemented by
+// *pgx.Conn, pgx.Tx, and *pgxpool.Pool.
 type Execer interface {
 	Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error)
 }
 
+// Beginner is the interface used by functions that run in a transaction. It is implemented by *pgx.Conn, pgx.Tx, and
+// *pgxpool.Pool.
+type Beginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// CopyFromer is the interface used by functions that use the copy protocol. It is implemented by *pgx.Conn, pgx.Tx,
+// and *pgxpool.Pool.
+type CopyFromer interface {
+	CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error)
+}
+
+// BulkInserter is the interface required by InsertRows.
+type BulkInserter interface {
+	Execer
+	CopyFromer
+}
+
 func selectOneValueNotNull(ctx context.Context, db Queryer, sql string, args []interface{}, rowFn func(pgx.Rows) error) error {
 	return selectOneValue(ctx, db, sql, args, func(rows pgx.Rows) error {
 		if rows.RawValues()[0] == nil {
 			rows.Close()
-			return errNullValue
+			return ErrNullValue
 		}
 
 		return rowFn(rows)
@@ -44,11 +86,11 @@ func selectOneValue(ctx context.Context, db Queryer, sql string, args []interfac
 	return selectOneRow(ctx, db, sql, args, func(rows pgx.Rows) error {
 		if len(rows.RawValues()) == 0 {
 			rows.Close()
-			return errNoColumns
+			return ErrNoColumns
 		}
 		if len(rows.RawValues()) > 1 {
 			rows.Close()
-			return errMultipleColumns
+			return ErrTooManyColumns
 		}
 
 		return rowFn(rows)
@@ -59,7 +101,7 @@ func selectColumnNotNull(ctx context.Context, db Queryer, sql string, args []int
 	return selectRows(ctx, db, sql, args, func(rows pgx.Rows) error {
 		if rows.RawValues()[0] == nil {
 			rows.Close()
-			return errNullValue
+			return ErrNullValue
 		}
 
 		return rowFn(rows)
@@ -70,11 +112,11 @@ func selectColumn(ctx context.Context, db Queryer, sql string, args []interface{
 	return selectRows(ctx, db, sql, args, func(rows pgx.Rows) error {
 		if len(rows.RawValues()) == 0 {
 			rows.Close()
-			return errNoColumns
+			return ErrNoColumns
 		}
 		if len(rows.RawValues()) > 1 {
 			rows.Close()
-			return errMultipleColumns
+			return ErrTooManyColumns
 		}
 
 		return rowFn(rows)
@@ -92,35 +134,171 @@ func selectOneRow(ctx context.Context, db Queryer, sql string, args []interface{
 	}
 
 	if rowCount == 0 {
-		return errNotFound
+		return ErrNoRows
 	}
 	if rowCount > 1 {
-		return errMultipleRows
+		return ErrTooManyRows
 	}
 
 	return nil
 }
 
 func selectRows(ctx context.Context, db Queryer, sql string, args []interface{}, rowFn func(pgx.Rows) error) error {
+	_, err := selectRowsFields(ctx, db, sql, args, rowFn)
+	return err
+}
+
+// selectRowsFields is like selectRows except it also returns the field descriptions of the result. They are returned
+// even if there are no rows.
+func selectRowsFields(ctx context.Context, db Queryer, sql string, args []interface{}, rowFn func(pgx.Rows) error) ([]pgproto3.FieldDescription, error) {
+	ctx, cancel, sql, args, err := prepareQuery(ctx, sql, args)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+	args = applyResultFormat(args, OptionsFromContext(ctx).ResultFormat)
+
+	if timeout := OptionsFromContext(ctx).StatementTimeout; timeout != 0 {
+		var fieldDescriptions []pgproto3.FieldDescription
+		err := withStatementTimeout(ctx, db, timeout, func(tx pgx.Tx) error {
+			var err error
+			fieldDescriptions, err = cancelableQueryRows(ctx, tx, sql, args, rowFn)
+			return err
+		})
+		return fieldDescriptions, err
+	}
+
+	return cancelableQueryRows(ctx, db, sql, args, rowFn)
+}
+
+// cancelableQueryRows is like queryRows except Options.CancelGracePeriod is applied.
+func cancelableQueryRows(ctx context.Context, db Queryer, sql string, args []interface{}, rowFn func(pgx.Rows) error) ([]pgproto3.FieldDescription, error) {
+	grace := OptionsFromContext(ctx).CancelGracePeriod
+	if grace == 0 {
+		return queryRows(ctx, db, sql, args, rowFn)
+	}
+
+	var fieldDescriptions []pgproto3.FieldDescription
+	err := withCancelRequest(ctx, db, grace, func(ctx context.Context) error {
+		var err error
+		fieldDescriptions, err = queryRows(ctx, db, sql, args, rowFn)
+		return err
+	})
+	return fieldDescriptions, err
+}
+
+// queryRows sends sql and args to db as is, calls rowFn for each row, and returns the field descriptions. An error of
+// the query is returned as a *QueryError.
+func queryRows(ctx context.Context, db Queryer, sql string, args []interface{}, rowFn func(pgx.Rows) error) ([]pgproto3.FieldDescription, error) {
 	rows, _ := db.Query(ctx, sql, args...)
+	if rows.Err() != nil {
+		rows.Close()
+		return nil, newQueryError(ctx, sql, args, rows.Err())
+	}
+	fieldDescriptions := copyFieldDescriptions(rows.FieldDescriptions())
 
 	for rows.Next() {
 		err := rowFn(rows)
 		if err != nil {
 			rows.Close()
-			return err
+			return nil, err
 		}
 	}
 
 	if rows.Err() != nil {
-		return rows.Err()
+		return nil, newQueryError(ctx, sql, args, rows.Err())
 	}
 
-	return nil
+	return fieldDescriptions, nil
+}
+
+// copyFieldDescriptions returns a deep copy of fieldDescriptions. The names of the field descriptions returned by pgx
+// may refer to a buffer that is reused for later messages.
+func copyFieldDescriptions(fieldDescriptions []pgproto3.FieldDescription) []pgproto3.FieldDescription {
+	if fieldDescriptions == nil {
+		return nil
+	}
+
+	fds := make([]pgproto3.FieldDescription, len(fieldDescriptions))
+	for i, fd := range fieldDescriptions {
+		fds[i] = fd
+		fds[i].Name = append([]byte(nil), fd.Name...)
+	}
+
+	return fds
+}
+
+// selectOneValuePtr is like selectOneValue except a nil *T is returned for a null value. Otherwise, scanFn is called to
+// read the value.
+func selectOneValuePtr[T any](ctx context.Context, db Queryer, sql string, args []interface{}, scanFn func(pgx.Rows) (T, error)) (*T, error) {
+	var v *T
+	err := selectOneValue(ctx, db, sql, args, func(rows pgx.Rows) error {
+		if rows.RawValues()[0] == nil {
+			return nil
+		}
+
+		t, err := scanFn(rows)
+		if err != nil {
+			return err
+		}
+		v = &t
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// Select selects a single value of type T. Any PostgreSQL value that pgx can scan into a T can be selected. An error
+// will be returned if no rows are found or a null value is found.
+func Select[T any](ctx context.Context, db Queryer, sql string, args ...interface{}) (T, error) {
+	var v T
+	err := selectOneValueNotNull(ctx, db, sql, args, func(rows pgx.Rows) error {
+		return rows.Scan(&v)
+	})
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return v, nil
 }
 
-// SelectString selects a single string. Any PostgreSQL data type can be selected. The text format of the
-// selected values will be returned. An error will be returned if no rows are found or a null value is found.
+// SelectOr is like Select except defaultValue is returned if no rows are found.
+func SelectOr[T any](ctx context.Context, db Queryer, defaultValue T, sql string, args ...interface{}) (T, error) {
+	v, err := Select[T](ctx, db, sql, args...)
+	if errors.Is(err, ErrNoRows) {
+		return defaultValue, nil
+	}
+	return v, err
+}
+
+// SelectAll selects a column of type T. Any PostgreSQL value that pgx can scan into a T can be selected. An error will
+// be returned if a null value is found. Options.ExpectedRows can be used to preallocate the slice.
+func SelectAll[T any](ctx context.Context, db Queryer, sql string, args ...interface{}) ([]T, error) {
+	v := makeRowSlice[T](ctx, args)
+	err := selectColumnNotNull(ctx, db, sql, args, func(rows pgx.Rows) error {
+		var t T
+		err := rows.Scan(&t)
+		if err != nil {
+			return err
+		}
+		v = append(v, t)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// SelectString selects a single string. Any PostgreSQL data type can be selected. The value is requested in the text
+// format so it is returned as formatted by PostgreSQL, e.g. 42 for an integer, \x0102 for a bytea, and {1,2} for an
+// array. The text format of some types depends on settings such as DateStyle, TimeZone, and bytea_output. An error
+// will be returned if no rows are found or a null value is found.
 func SelectString(ctx context.Context, db Queryer, sql string, args ...interface{}) (string, error) {
 	var v string
 	args = append([]interface{}{pgx.QueryResultFormats{pgx.TextFormatCode}}, args...)
@@ -135,6 +313,34 @@ func SelectString(ctx context.Context, db Queryer, sql string, args ...interface
 	return v, nil
 }
 
+// SelectStringPtr is like SelectString except nil is returned if a null value is found.
+func SelectStringPtr(ctx context.Context, db Queryer, sql string, args ...interface{}) (*string, error) {
+	args = append([]interface{}{pgx.QueryResultFormats{pgx.TextFormatCode}}, args...)
+	return selectOneValuePtr(ctx, db, sql, args, func(rows pgx.Rows) (string, error) {
+		return string(rows.RawValues()[0]), nil
+	})
+}
+
+// SelectStringOr is like SelectString except defaultValue is returned if no rows are found.
+func SelectStringOr(ctx context.Context, db Queryer, defaultValue string, sql string, args ...interface{}) (string, error) {
+	v, err := SelectString(ctx, db, sql, args...)
+	if errors.Is(err, ErrNoRows) {
+		return defaultValue, nil
+	}
+	return v, err
+}
+
+// SelectStringAs is like SelectString except the value is cast to typeName by PostgreSQL before it is converted to
+// text. e.g. SelectStringAs(ctx, db, "numeric(10,2)", "select 1.5::float8") returns "1.50". typeName is interpolated
+// into the SQL so it must not come from untrusted input. sql must return a single column and is run as a common table
+// expression so it may be a data-modifying statement with a returning clause.
+func SelectStringAs(ctx context.Context, db Queryer, typeName string, sql string, args ...interface{}) (string, error) {
+	// Like addLockClause, a trailing semicolon is removed and sql ends on its own line so a trailing comment does not
+	// comment out the rest of the statement.
+	sql = strings.TrimRight(sql, " \t\r\n;")
+	return SelectString(ctx, db, fmt.Sprintf("with t(v) as (\n%s\n) select v::%s::text from t", sql, typeName), args...)
+}
+
 // SelectAllString selects a column of strings. Any PostgreSQL data type can be selected. The text format of the
 // selected values will be returned. An error will be returned a null value is found.
 func SelectAllString(ctx context.Context, db Queryer, sql string, args ...interface{}) ([]string, error) {
@@ -152,12 +358,14 @@ func SelectAllString(ctx context.Context, db Queryer, sql string, args ...interf
 }
 
 // SelectByteSlice selects a single byte slice. Any PostgreSQL data type can be selected. The binary format of the
-// selected value will be returned. An error will be returned if no rows are found or a null value is found.
+// selected value will be returned unless Options.ResultFormat is TextResultFormat. The value is copied out of the row
+// buffer unless Options.ZeroCopy is set. An error will be returned if no rows are found or a null value is found.
 func SelectByteSlice(ctx context.Context, db Queryer, sql string, args ...interface{}) ([]byte, error) {
 	var v []byte
-	args = append([]interface{}{pgx.QueryResultFormats{pgx.BinaryFormatCode}}, args...)
+	zeroCopy := queryOptions(ctx, args).ZeroCopy
+	args = append([]interface{}{defaultResultFormats{pgx.BinaryFormatCode}}, args...)
 	err := selectOneValueNotNull(ctx, db, sql, args, func(rows pgx.Rows) error {
-		v = rows.RawValues()[0]
+		v = copyBytes(rows.RawValues()[0], zeroCopy)
 		return nil
 	})
 	if err != nil {
@@ -168,12 +376,13 @@ func SelectByteSlice(ctx context.Context, db Queryer, sql string, args ...interf
 }
 
 // SelectAllByteSlice selects a column byte slice. Any PostgreSQL data type can be selected. The binary format of the
-// selected value will be returned. An error will be returned if a null value is found.
+// selected values will be returned unless Options.ResultFormat is TextResultFormat. The values are always copied out of
+// the row buffer because it is reused for later rows. An error will be returned if a null value is found.
 func SelectAllByteSlice(ctx context.Context, db Queryer, sql string, args ...interface{}) ([][]byte, error) {
 	var v [][]byte
-	args = append([]interface{}{pgx.QueryResultFormats{pgx.BinaryFormatCode}}, args...)
+	args = append([]interface{}{defaultResultFormats{pgx.BinaryFormatCode}}, args...)
 	err := selectColumnNotNull(ctx, db, sql, args, func(rows pgx.Rows) error {
-		v = append(v, rows.RawValues()[0])
+		v = append(v, copyBytes(rows.RawValues()[0], false))
 		return nil
 	})
 	if err != nil {
@@ -183,30 +392,13 @@ func SelectAllByteSlice(ctx context.Context, db Queryer, sql string, args ...int
 	return v, nil
 }
 
-// SelectBool selects a single bool. An error will be returned if no rows are found or a null value is found.
-func SelectBool(ctx context.Context, db Queryer, sql string, args ...interface{}) (bool, error) {
-	var v pgtype.Bool
+// SelectJSON selects a single json.RawMessage. json and jsonb values can be selected. The text format of the selected
+// value will be returned. An error will be returned if no rows are found or a null value is found.
+func SelectJSON(ctx context.Context, db Queryer, sql string, args ...interface{}) (json.RawMessage, error) {
+	var v json.RawMessage
 	args = append([]interface{}{pgx.QueryResultFormats{pgx.TextFormatCode}}, args...)
 	err := selectOneValueNotNull(ctx, db, sql, args, func(rows pgx.Rows) error {
-		return rows.Scan(&v)
-	})
-	if err != nil {
-		return false, err
-	}
-
-	return v.Bool, nil
-}
-
-// SelectAllBool selects a column of bool. An error will be returned if null value is found.
-func SelectAllBool(ctx context.Context, db Queryer, sql string, args ...interface{}) ([]bool, error) {
-	var v []bool
-	err := selectColumnNotNull(ctx, db, sql, args, func(rows pgx.Rows) error {
-		var b pgtype.Bool
-		err := rows.Scan(&b)
-		if err != nil {
-			return err
-		}
-		v = append(v, b.Bool)
+		v = append(json.RawMessage(nil), rows.RawValues()[0]...)
 		return nil
 	})
 	if err != nil {
@@ -216,33 +408,24 @@ func SelectAllBool(ctx context.Context, db Queryer, sql string, args ...interfac
 	return v, nil
 }
 
-// SelectInt64 selects a single int64. Any PostgreSQL value representable as an int64 can be selected. An error will be
-// returned if no rows are found or a null value is found.
-func SelectInt64(ctx context.Context, db Queryer, sql string, args ...interface{}) (int64, error) {
-	var v pgtype.Int8
-	args = append([]interface{}{pgx.QueryResultFormats{pgx.TextFormatCode}}, args...)
-	err := selectOneValueNotNull(ctx, db, sql, args, func(rows pgx.Rows) error {
-		return rows.Scan(&v)
-	})
+// SelectJSONUnmarshal selects a single json or jsonb value and unmarshals it into dst with json.Unmarshal. An error
+// will be returned if no rows are found or a null value is found.
+func SelectJSONUnmarshal(ctx context.Context, db Queryer, dst interface{}, sql string, args ...interface{}) error {
+	buf, err := SelectJSON(ctx, db, sql, args...)
 	if err != nil {
-		return 0, err
+		return err
 	}
 
-	return v.Int, nil
+	return json.Unmarshal(buf, dst)
 }
 
-// SelectAllInt64 selects a column of int64. Any PostgreSQL value representable as an int64 can be selected. An error
-// will be returned if null value is found.
-func SelectAllInt64(ctx context.Context, db Queryer, sql string, args ...interface{}) ([]int64, error) {
-	var v []int64
+// SelectXML selects a single xml value as []byte. The text format of the selected value is returned, so text values
+// can be selected too. An error will be returned if no rows are found or a null value is found.
+func SelectXML(ctx context.Context, db Queryer, sql string, args ...interface{}) ([]byte, error) {
+	var v []byte
 	args = append([]interface{}{pgx.QueryResultFormats{pgx.TextFormatCode}}, args...)
-	err := selectColumnNotNull(ctx, db, sql, args, func(rows pgx.Rows) error {
-		var i8 pgtype.Int8
-		err := rows.Scan(&i8)
-		if err != nil {
-			return err
-		}
-		v = append(v, i8.Int)
+	err := selectOneValueNotNull(ctx, db, sql, args, func(rows pgx.Rows) error {
+		v = append([]byte(nil), rows.RawValues()[0]...)
 		return nil
 	})
 	if err != nil {
@@ -252,114 +435,154 @@ func SelectAllInt64(ctx context.Context, db Queryer, sql string, args ...interfa
 	return v, nil
 }
 
-// SelectFloat64 selects a single float64. Any PostgreSQL value representable as an float64 can be selected. However,
-// precision is not guaranteed when converting formats (e.g. when selecting a numeric with more precision than a float
-// can represent). An error will be returned if no rows are found or a null value is found.
-func SelectFloat64(ctx context.Context, db Queryer, sql string, args ...interface{}) (float64, error) {
-	var v pgtype.Float8
-	args = append([]interface{}{pgx.QueryResultFormats{pgx.TextFormatCode}}, args...)
-	err := selectOneValueNotNull(ctx, db, sql, args, func(rows pgx.Rows) error {
-		return rows.Scan(&v)
-	})
+// SelectXMLUnmarshal selects a single xml value and unmarshals it into dst with xml.Unmarshal. An error will be
+// returned if no rows are found or a null value is found.
+func SelectXMLUnmarshal(ctx context.Context, db Queryer, dst interface{}, sql string, args ...interface{}) error {
+	buf, err := SelectXML(ctx, db, sql, args...)
 	if err != nil {
-		return 0, err
+		return err
 	}
 
-	return v.Float, nil
+	return xml.Unmarshal(buf, dst)
 }
 
-// SelectAllFloat64 selects a single float64. Any PostgreSQL value representable as an float64 can be selected. However,
-// precision is not guaranteed when converting formats (e.g. when selecting a numeric with more precision than a float
-// can represent). An error will be returned if no rows are found or a null value is found.
-func SelectAllFloat64(ctx context.Context, db Queryer, sql string, args ...interface{}) ([]float64, error) {
-	var v []float64
+// SelectBool selects a single bool. An error will be returned if no rows are found or a null value is found.
+func SelectBool(ctx context.Context, db Queryer, sql string, args ...interface{}) (bool, error) {
 	args = append([]interface{}{pgx.QueryResultFormats{pgx.TextFormatCode}}, args...)
-	err := selectColumnNotNull(ctx, db, sql, args, func(rows pgx.Rows) error {
-		var f8 pgtype.Float8
-		err := rows.Scan(&f8)
-		if err != nil {
-			return err
-		}
-		v = append(v, f8.Float)
-		return nil
+	v, err := Select[pgtype.Bool](ctx, db, sql, args...)
+	return v.Bool, err
+}
+
+// SelectBoolPtr is like SelectBool except nil is returned if a null value is found.
+func SelectBoolPtr(ctx context.Context, db Queryer, sql string, args ...interface{}) (*bool, error) {
+	args = append([]interface{}{pgx.QueryResultFormats{pgx.TextFormatCode}}, args...)
+	return selectOneValuePtr(ctx, db, sql, args, func(rows pgx.Rows) (bool, error) {
+		var v pgtype.Bool
+		err := rows.Scan(&v)
+		return v.Bool, err
 	})
+}
+
+// SelectAllBool selects a column of bool. An error will be returned if null value is found.
+func SelectAllBool(ctx context.Context, db Queryer, sql string, args ...interface{}) ([]bool, error) {
+	column, err := SelectAll[pgtype.Bool](ctx, db, sql, args...)
 	if err != nil {
 		return nil, err
 	}
 
+	var v []bool
+	for _, b := range column {
+		v = append(v, b.Bool)
+	}
+
 	return v, nil
 }
 
-// SelectDecimal selects a single decimal.Decimal. Any PostgreSQL value representable as an decimal can be selected.
-// An error will be returned if no rows are found or a null value is found.
-func SelectDecimal(ctx context.Context, db Queryer, sql string, args ...interface{}) (decimal.Decimal, error) {
-	var v pgtype.GenericText
-	args = append([]interface{}{pgx.QueryResultFormats{pgx.TextFormatCode}}, args...)
-	err := selectOneValueNotNull(ctx, db, sql, args, func(rows pgx.Rows) error {
-		return rows.Scan(&v)
-	})
-	if err != nil {
-		return decimal.Decimal{}, err
+// SelectExists returns true if sql returns any rows. sql is wrapped in an exists expression, so it should be a
+// complete select statement such as "select 1 from users where id=$1".
+func SelectExists(ctx context.Context, db Queryer, sql string, args ...interface{}) (bool, error) {
+	// Like SelectStringAs, a trailing semicolon is removed and sql ends on its own line so a trailing comment does not
+	// comment out the closing parenthesis.
+	sql = strings.TrimRight(sql, " \t\r\n;")
+	return SelectBool(ctx, db, "select exists(\n"+sql+"\n)", args...)
+}
+
+// IntConversionError is returned by SelectInt64 and the similar functions when a selected value cannot be represented
+// by the integer type.
+type IntConversionError struct {
+	// Value is the value in the PostgreSQL text format.
+	Value string
+
+	// Type is the Go type the value was converted to, e.g. int64.
+	Type string
+
+	// Overflow is true if the value is an integer outside the range of Type.
+	Overflow bool
+
+	// Fractional is true if the value has a fractional part that would be lost.
+	Fractional bool
+}
+
+func (e *IntConversionError) Error() string {
+	switch {
+	case e.Overflow:
+		return fmt.Sprintf("%s is out of range for %s", e.Value, e.Type)
+	case e.Fractional:
+		return fmt.Sprintf("%s has a fractional part and cannot be converted to %s without truncation", e.Value, e.Type)
+	default:
+		return fmt.Sprintf("cannot convert %s to %s", e.Value, e.Type)
+	}
+}
+
+// parseInt parses the text format of a value as an integer of bitSize bits. Numbers that are not written as an integer,
+// e.g. 3.00 or 1e3, are accepted if they are integral.
+func parseInt(s string, bitSize int) (int64, error) {
+	n, err := strconv.ParseInt(s, 10, bitSize)
+	if err == nil {
+		return n, nil
 	}
 
-	d, err := decimal.NewFromString(v.String)
+	typeName := fmt.Sprintf("int%d", bitSize)
+	i, err := parseIntegral(s, typeName)
 	if err != nil {
-		return decimal.Decimal{}, err
+		return 0, err
 	}
 
-	return d, nil
-}
+	if !i.IsInt64() {
+		return 0, &IntConversionError{Value: s, Type: typeName, Overflow: true}
+	}
+	n = i.Int64()
+	if bitSize < 64 && (n < -1<<(bitSize-1) || n > 1<<(bitSize-1)-1) {
+		return 0, &IntConversionError{Value: s, Type: typeName, Overflow: true}
+	}
 
-// SelectAllDecimal selects a column of decimal.Decimal. Any PostgreSQL value representable as an decimal can be
-// selected. An error will be returned if a null value is found.
-func SelectAllDecimal(ctx context.Context, db Queryer, sql string, args ...interface{}) ([]decimal.Decimal, error) {
-	var v []decimal.Decimal
-	args = append([]interface{}{pgx.QueryResultFormats{pgx.TextFormatCode}}, args...)
-	err := selectColumnNotNull(ctx, db, sql, args, func(rows pgx.Rows) error {
-		var t pgtype.GenericText
-		err := rows.Scan(&t)
-		if err != nil {
-			return err
-		}
+	return n, nil
+}
 
-		d, err := decimal.NewFromString(t.String)
-		if err != nil {
-			return err
-		}
+// parseIntegral parses the text format of an integral number of any size. typeName is used in the error.
+func parseIntegral(s string, typeName string) (*big.Int, error) {
+	i, ok := new(big.Int).SetString(s, 10)
+	if ok {
+		return i, nil
+	}
 
-		v = append(v, d)
-		return nil
-	})
-	if err != nil {
-		return nil, err
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return nil, &IntConversionError{Value: s, Type: typeName}
+	}
+	if !r.IsInt() {
+		return nil, &IntConversionError{Value: s, Type: typeName, Fractional: true}
 	}
 
-	return v, nil
+	return r.Num(), nil
 }
 
-// SelectUUID selects a single uuid.UUID. An error will be returned if no rows are found or a null value is found.
-func SelectUUID(ctx context.Context, db Queryer, sql string, args ...interface{}) (uuid.UUID, error) {
-	var v gofrs.UUID
-	err := selectOneValueNotNull(ctx, db, sql, args, func(rows pgx.Rows) error {
-		return rows.Scan(&v)
-	})
+func selectInt(ctx context.Context, db Queryer, bitSize int, sql string, args []interface{}) (int64, error) {
+	args = append([]interface{}{pgx.QueryResultFormats{pgx.TextFormatCode}}, args...)
+	v, err := Select[pgtype.GenericText](ctx, db, sql, args...)
 	if err != nil {
-		return uuid.Nil, err
+		return 0, err
 	}
 
-	return v.UUID, nil
+	return parseInt(v.String, bitSize)
 }
 
-// SelectUUID selects a column of uuid.UUID. An error will be returned if a null value is found.
-func SelectAllUUID(ctx context.Context, db Queryer, sql string, args ...interface{}) ([]uuid.UUID, error) {
-	var v []uuid.UUID
+func selectIntPtr(ctx context.Context, db Queryer, bitSize int, sql string, args []interface{}) (*int64, error) {
+	args = append([]interface{}{pgx.QueryResultFormats{pgx.TextFormatCode}}, args...)
+	return selectOneValuePtr(ctx, db, sql, args, func(rows pgx.Rows) (int64, error) {
+		return parseInt(string(rows.RawValues()[0]), bitSize)
+	})
+}
+
+func selectAllInt(ctx context.Context, db Queryer, bitSize int, sql string, args []interface{}) ([]int64, error) {
+	args = append([]interface{}{pgx.QueryResultFormats{pgx.TextFormatCode}}, args...)
+	var v []int64
 	err := selectColumnNotNull(ctx, db, sql, args, func(rows pgx.Rows) error {
-		var u gofrs.UUID
-		err := rows.Scan(&u)
+		n, err := parseInt(string(rows.RawValues()[0]), bitSize)
 		if err != nil {
 			return err
 		}
-		v = append(v, u.UUID)
+		v = append(v, n)
 		return nil
 	})
 	if err != nil {
@@ -369,88 +592,962 @@ func SelectAllUUID(ctx context.Context, db Queryer, sql string, args ...interfac
 	return v, nil
 }
 
-// SelectValue selects a single value of unspecified type. An error will be returned if no rows are found.
-func SelectValue(ctx context.Context, db Queryer, sql string, args ...interface{}) (interface{}, error) {
-	var v interface{}
-	err := selectOneValue(ctx, db, sql, args, func(rows pgx.Rows) error {
-		values, err := rows.Values()
-		if err != nil {
-			return err
-		}
-		v = values[0]
-		return err
-	})
-	if err != nil {
-		return nil, err
-	}
+// SelectInt64 selects a single int64. Any PostgreSQL value representable as an int64 can be selected. A number with a
+// fractional part or outside the range of an int64 causes an *IntConversionError. An error will be returned if no rows
+// are found or a null value is found.
+func SelectInt64(ctx context.Context, db Queryer, sql string, args ...interface{}) (int64, error) {
+	return selectInt(ctx, db, 64, sql, args)
+}
 
-	return v, nil
+// SelectInt64Ptr is like SelectInt64 except nil is returned if a null value is found.
+func SelectInt64Ptr(ctx context.Context, db Queryer, sql string, args ...interface{}) (*int64, error) {
+	return selectIntPtr(ctx, db, 64, sql, args)
 }
 
-// SelectAllValue selects a column of unspecified type.
-func SelectAllValue(ctx context.Context, db Queryer, sql string, args ...interface{}) ([]interface{}, error) {
-	var v []interface{}
-	err := selectColumn(ctx, db, sql, args, func(rows pgx.Rows) error {
-		values, err := rows.Values()
-		if err != nil {
-			return err
-		}
-		v = append(v, values[0])
-		return err
-	})
-	if err != nil {
-		return nil, err
+// SelectInt64Or is like SelectInt64 except defaultValue is returned if no rows are found.
+func SelectInt64Or(ctx context.Context, db Queryer, defaultValue int64, sql string, args ...interface{}) (int64, error) {
+	v, err := SelectInt64(ctx, db, sql, args...)
+	if errors.Is(err, ErrNoRows) {
+		return defaultValue, nil
 	}
+	return v, err
+}
 
-	return v, nil
+// SelectCount returns the number of rows in tableName that match whereArgs. If whereArgs is nil all rows are counted.
+func SelectCount(ctx context.Context, db Queryer, tableName string, whereArgs map[string]interface{}) (int64, error) {
+	sql, args := appendWhere("select count(*) from "+tableName, nil, whereArgs)
+	return SelectInt64(ctx, db, sql, args...)
 }
 
-// SelectMap selects a single row into a map. An error will be returned if no rows are found.
-func SelectMap(ctx context.Context, db Queryer, sql string, args ...interface{}) (map[string]interface{}, error) {
-	var v map[string]interface{}
-	err := selectOneRow(ctx, db, sql, args, func(rows pgx.Rows) error {
-		values, err := rows.Values()
-		if err != nil {
-			return err
-		}
+// SelectCountSQL returns the number of rows returned by sql. sql is used as a subquery.
+func SelectCountSQL(ctx context.Context, db Queryer, sql string, args ...interface{}) (int64, error) {
+	return SelectInt64(ctx, db, "select count(*) from ("+sql+") pgxutil_count", args...)
+}
 
-		v = make(map[string]interface{}, len(values))
-		for i := range values {
-			v[string(rows.FieldDescriptions()[i].Name)] = values[i]
-		}
+// SelectAllInt64 selects a column of int64. Any PostgreSQL value representable as an int64 can be selected. A number
+// with a fractional part or outside the range of an int64 causes an *IntConversionError. An error will be returned if
+// null value is found.
+func SelectAllInt64(ctx context.Context, db Queryer, sql string, args ...interface{}) ([]int64, error) {
+	return selectAllInt(ctx, db, 64, sql, args)
+}
 
+// SelectInt32 is like SelectInt64 except it selects an int32.
+func SelectInt32(ctx context.Context, db Queryer, sql string, args ...interface{}) (int32, error) {
+	n, err := selectInt(ctx, db, 32, sql, args)
+	return int32(n), err
+}
+
+// SelectInt32Ptr is like SelectInt32 except nil is returned if a null value is found.
+func SelectInt32Ptr(ctx context.Context, db Queryer, sql string, args ...interface{}) (*int32, error) {
+	n, err := selectIntPtr(ctx, db, 32, sql, args)
+	if n == nil || err != nil {
+		return nil, err
+	}
+	v := int32(*n)
+	return &v, nil
+}
+
+// SelectInt32Or is like SelectInt32 except defaultValue is returned if no rows are found.
+func SelectInt32Or(ctx context.Context, db Queryer, defaultValue int32, sql string, args ...interface{}) (int32, error) {
+	v, err := SelectInt32(ctx, db, sql, args...)
+	if errors.Is(err, ErrNoRows) {
+		return defaultValue, nil
+	}
+	return v, err
+}
+
+// SelectAllInt32 is like SelectAllInt64 except it selects a column of int32.
+func SelectAllInt32(ctx context.Context, db Queryer, sql string, args ...interface{}) ([]int32, error) {
+	column, err := selectAllInt(ctx, db, 32, sql, args)
+	if err != nil {
+		return nil, err
+	}
+
+	var v []int32
+	for _, n := range column {
+		v = append(v, int32(n))
+	}
+
+	return v, nil
+}
+
+// SelectInt16 is like SelectInt64 except it selects an int16.
+func SelectInt16(ctx context.Context, db Queryer, sql string, args ...interface{}) (int16, error) {
+	n, err := selectInt(ctx, db, 16, sql, args)
+	return int16(n), err
+}
+
+// SelectInt16Ptr is like SelectInt16 except nil is returned if a null value is found.
+func SelectInt16Ptr(ctx context.Context, db Queryer, sql string, args ...interface{}) (*int16, error) {
+	n, err := selectIntPtr(ctx, db, 16, sql, args)
+	if n == nil || err != nil {
+		return nil, err
+	}
+	v := int16(*n)
+	return &v, nil
+}
+
+// SelectInt16Or is like SelectInt16 except defaultValue is returned if no rows are found.
+func SelectInt16Or(ctx context.Context, db Queryer, defaultValue int16, sql string, args ...interface{}) (int16, error) {
+	v, err := SelectInt16(ctx, db, sql, args...)
+	if errors.Is(err, ErrNoRows) {
+		return defaultValue, nil
+	}
+	return v, err
+}
+
+// SelectAllInt16 is like SelectAllInt64 except it selects a column of int16.
+func SelectAllInt16(ctx context.Context, db Queryer, sql string, args ...interface{}) ([]int16, error) {
+	column, err := selectAllInt(ctx, db, 16, sql, args)
+	if err != nil {
+		return nil, err
+	}
+
+	var v []int16
+	for _, n := range column {
+		v = append(v, int16(n))
+	}
+
+	return v, nil
+}
+
+// SelectBigInt selects a single *big.Int. Any PostgreSQL integer or integral numeric value can be selected regardless of
+// its size. A number with a fractional part causes an *IntConversionError. An error will be returned if no rows are
+// found or a null value is found.
+func SelectBigInt(ctx context.Context, db Queryer, sql string, args ...interface{}) (*big.Int, error) {
+	args = append([]interface{}{pgx.QueryResultFormats{pgx.TextFormatCode}}, args...)
+	v, err := Select[pgtype.GenericText](ctx, db, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseIntegral(v.String, "*big.Int")
+}
+
+// SelectBigIntPtr is like SelectBigInt except nil is returned if a null value is found.
+func SelectBigIntPtr(ctx context.Context, db Queryer, sql string, args ...interface{}) (*big.Int, error) {
+	args = append([]interface{}{pgx.QueryResultFormats{pgx.TextFormatCode}}, args...)
+	var v *big.Int
+	err := selectOneValue(ctx, db, sql, args, func(rows pgx.Rows) error {
+		raw := rows.RawValues()[0]
+		if raw == nil {
+			return nil
+		}
+
+		var err error
+		v, err = parseIntegral(string(raw), "*big.Int")
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// SelectAllBigInt selects a column of *big.Int. Any PostgreSQL integer or integral numeric value can be selected
+// regardless of its size. A number with a fractional part causes an *IntConversionError. An error will be returned if a
+// null value is found.
+func SelectAllBigInt(ctx context.Context, db Queryer, sql string, args ...interface{}) ([]*big.Int, error) {
+	args = append([]interface{}{pgx.QueryResultFormats{pgx.TextFormatCode}}, args...)
+	var v []*big.Int
+	err := selectColumnNotNull(ctx, db, sql, args, func(rows pgx.Rows) error {
+		i, err := parseIntegral(string(rows.RawValues()[0]), "*big.Int")
+		if err != nil {
+			return err
+		}
+		v = append(v, i)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// SelectFloat64 selects a single float64. Any PostgreSQL value representable as an float64 can be selected. However,
+// precision is not guaranteed when converting formats (e.g. when selecting a numeric with more precision than a float
+// can represent). An error will be returned if no rows are found or a null value is found.
+func SelectFloat64(ctx context.Context, db Queryer, sql string, args ...interface{}) (float64, error) {
+	args = append([]interface{}{pgx.QueryResultFormats{pgx.TextFormatCode}}, args...)
+	v, err := Select[pgtype.Float8](ctx, db, sql, args...)
+	return v.Float, err
+}
+
+// SelectFloat64Ptr is like SelectFloat64 except nil is returned if a null value is found.
+func SelectFloat64Ptr(ctx context.Context, db Queryer, sql string, args ...interface{}) (*float64, error) {
+	args = append([]interface{}{pgx.QueryResultFormats{pgx.TextFormatCode}}, args...)
+	return selectOneValuePtr(ctx, db, sql, args, func(rows pgx.Rows) (float64, error) {
+		var v pgtype.Float8
+		err := rows.Scan(&v)
+		return v.Float, err
+	})
+}
+
+// SelectAllFloat64 selects a single float64. Any PostgreSQL value representable as an float64 can be selected. However,
+// precision is not guaranteed when converting formats (e.g. when selecting a numeric with more precision than a float
+// can represent). An error will be returned if no rows are found or a null value is found.
+func SelectAllFloat64(ctx context.Context, db Queryer, sql string, args ...interface{}) ([]float64, error) {
+	args = append([]interface{}{pgx.QueryResultFormats{pgx.TextFormatCode}}, args...)
+	column, err := SelectAll[pgtype.Float8](ctx, db, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var v []float64
+	for _, f8 := range column {
+		v = append(v, f8.Float)
+	}
+
+	return v, nil
+}
+
+// SelectNumeric selects a single number as a T created by parse from the text format of the value. This allows
+// selecting into any decimal type without pgxutil depending on it. e.g. for github.com/cockroachdb/apd:
+//
+//	d, err := SelectNumeric(ctx, db, func(s string) (*apd.Decimal, error) {
+//		d, _, err := apd.NewFromString(s)
+//		return d, err
+//	}, "select price from products where id = $1", id)
+//
+// Any PostgreSQL value whose text format parse accepts can be selected. An error will be returned if no rows are found
+// or a null value is found. Select[pgtype.Numeric] can be used to select a pgtype.Numeric.
+func SelectNumeric[T any](ctx context.Context, db Queryer, parse func(string) (T, error), sql string, args ...interface{}) (T, error) {
+	args = append([]interface{}{pgx.QueryResultFormats{pgx.TextFormatCode}}, args...)
+	v, err := Select[pgtype.GenericText](ctx, db, sql, args...)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return parse(v.String)
+}
+
+// SelectNumericPtr is like SelectNumeric except nil is returned if a null value is found.
+func SelectNumericPtr[T any](ctx context.Context, db Queryer, parse func(string) (T, error), sql string, args ...interface{}) (*T, error) {
+	args = append([]interface{}{pgx.QueryResultFormats{pgx.TextFormatCode}}, args...)
+	return selectOneValuePtr(ctx, db, sql, args, func(rows pgx.Rows) (T, error) {
+		return parse(string(rows.RawValues()[0]))
+	})
+}
+
+// SelectAllNumeric selects a column of T created by parse from the text format of each value as described by
+// SelectNumeric. An error will be returned if a null value is found.
+func SelectAllNumeric[T any](ctx context.Context, db Queryer, parse func(string) (T, error), sql string, args ...interface{}) ([]T, error) {
+	args = append([]interface{}{pgx.QueryResultFormats{pgx.TextFormatCode}}, args...)
+	var v []T
+	err := selectColumnNotNull(ctx, db, sql, args, func(rows pgx.Rows) error {
+		t, err := parse(string(rows.RawValues()[0]))
+		if err != nil {
+			return err
+		}
+		v = append(v, t)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// SelectUUID selects a single uuid.UUID. An error will be returned if no rows are found or a null value is found.
+func SelectUUID(ctx context.Context, db Queryer, sql string, args ...interface{}) (uuid.UUID, error) {
+	return SelectUUIDAs[uuid.UUID](ctx, db, sql, args...)
+}
+
+// SelectUUIDPtr is like SelectUUID except nil is returned if a null value is found.
+func SelectUUIDPtr(ctx context.Context, db Queryer, sql string, args ...interface{}) (*uuid.UUID, error) {
+	return SelectUUIDAsPtr[uuid.UUID](ctx, db, sql, args...)
+}
+
+// SelectUUID selects a column of uuid.UUID. An error will be returned if a null value is found.
+func SelectAllUUID(ctx context.Context, db Queryer, sql string, args ...interface{}) ([]uuid.UUID, error) {
+	return SelectAllUUIDAs[uuid.UUID](ctx, db, sql, args...)
+}
+
+// SelectUUIDAs selects a single UUID as any type whose underlying type is [16]byte. This includes the UUID types of
+// both github.com/gofrs/uuid and github.com/google/uuid so either can be used without pgxutil depending on it. e.g.
+//
+//	id, err := pgxutil.SelectUUIDAs[uuid.UUID](ctx, db, "select id from widgets where name = $1", name)
+//
+// An error will be returned if no rows are found or a null value is found.
+func SelectUUIDAs[T ~[16]byte](ctx context.Context, db Queryer, sql string, args ...interface{}) (T, error) {
+	var v pgtype.UUID
+	err := selectOneValueNotNull(ctx, db, sql, args, func(rows pgx.Rows) error {
+		return rows.Scan(&v)
+	})
+	if err != nil {
+		return T{}, err
+	}
+
+	return T(v.Bytes), nil
+}
+
+// SelectUUIDAsPtr is like SelectUUIDAs except nil is returned if a null value is found.
+func SelectUUIDAsPtr[T ~[16]byte](ctx context.Context, db Queryer, sql string, args ...interface{}) (*T, error) {
+	return selectOneValuePtr(ctx, db, sql, args, func(rows pgx.Rows) (T, error) {
+		var v pgtype.UUID
+		err := rows.Scan(&v)
+		return T(v.Bytes), err
+	})
+}
+
+// SelectAllUUIDAs selects a column of UUIDs as described by SelectUUIDAs. An error will be returned if a null value is
+// found.
+func SelectAllUUIDAs[T ~[16]byte](ctx context.Context, db Queryer, sql string, args ...interface{}) ([]T, error) {
+	var v []T
+	err := selectColumnNotNull(ctx, db, sql, args, func(rows pgx.Rows) error {
+		var u pgtype.UUID
+		err := rows.Scan(&u)
+		if err != nil {
+			return err
+		}
+		v = append(v, T(u.Bytes))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// SelectInt64Array selects a single one-dimensional array as a []int64. Arrays of any PostgreSQL integer type can be
+// selected. An error will be returned if no rows are found, a null value is found, or the array contains a null element.
+func SelectInt64Array(ctx context.Context, db Queryer, sql string, args ...interface{}) ([]int64, error) {
+	args = append([]interface{}{pgx.QueryResultFormats{pgx.TextFormatCode}}, args...)
+	v, err := Select[pgtype.Int8Array](ctx, db, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(v.Dimensions) > 1 {
+		return nil, fmt.Errorf("expected 1 array dimension, but got %d", len(v.Dimensions))
+	}
+
+	a := make([]int64, len(v.Elements))
+	for i := range v.Elements {
+		if v.Elements[i].Status != pgtype.Present {
+			return nil, ErrNullValue
+		}
+		a[i] = v.Elements[i].Int
+	}
+
+	return a, nil
+}
+
+// SelectStringArray selects a single one-dimensional array as a []string. Arrays of any PostgreSQL data type can be
+// selected. The text format of each element is used. An error will be returned if no rows are found, a null value is
+// found, or the array contains a null element.
+func SelectStringArray(ctx context.Context, db Queryer, sql string, args ...interface{}) ([]string, error) {
+	v, err := selectTextArray(ctx, db, sql, args)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(v.Dimensions) > 1 {
+		return nil, fmt.Errorf("expected 1 array dimension, but got %d", len(v.Dimensions))
+	}
+
+	return textArrayElements(v.Elements)
+}
+
+// SelectStringArray2D is like SelectStringArray except it selects a two-dimensional array as a [][]string.
+func SelectStringArray2D(ctx context.Context, db Queryer, sql string, args ...interface{}) ([][]string, error) {
+	v, err := selectTextArray(ctx, db, sql, args)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(v.Dimensions) == 0 {
+		return [][]string{}, nil
+	}
+	if len(v.Dimensions) != 2 {
+		return nil, fmt.Errorf("expected 2 array dimensions, but got %d", len(v.Dimensions))
+	}
+
+	elements, err := textArrayElements(v.Elements)
+	if err != nil {
+		return nil, err
+	}
+
+	rowLen := int(v.Dimensions[1].Length)
+	a := make([][]string, v.Dimensions[0].Length)
+	for i := range a {
+		a[i] = elements[i*rowLen : (i+1)*rowLen]
+	}
+
+	return a, nil
+}
+
+func selectTextArray(ctx context.Context, db Queryer, sql string, args []interface{}) (pgtype.TextArray, error) {
+	args = append([]interface{}{pgx.QueryResultFormats{pgx.TextFormatCode}}, args...)
+	return Select[pgtype.TextArray](ctx, db, sql, args...)
+}
+
+func textArrayElements(elements []pgtype.Text) ([]string, error) {
+	a := make([]string, len(elements))
+	for i := range elements {
+		if elements[i].Status != pgtype.Present {
+			return nil, ErrNullValue
+		}
+		a[i] = elements[i].String
+	}
+	return a, nil
+}
+
+// SelectHstore selects a single hstore value. Keys with a null value are mapped to nil. An error will be returned if no
+// rows are found or a null value is found.
+func SelectHstore(ctx context.Context, db Queryer, sql string, args ...interface{}) (map[string]*string, error) {
+	args = append([]interface{}{pgx.QueryResultFormats{pgx.TextFormatCode}}, args...)
+	v, err := Select[pgtype.Hstore](ctx, db, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return hstoreMap(v), nil
+}
+
+// SelectAllHstore selects a column of hstore values. Keys with a null value are mapped to nil. An error will be
+// returned if a null value is found.
+func SelectAllHstore(ctx context.Context, db Queryer, sql string, args ...interface{}) ([]map[string]*string, error) {
+	args = append([]interface{}{pgx.QueryResultFormats{pgx.TextFormatCode}}, args...)
+	column, err := SelectAll[pgtype.Hstore](ctx, db, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var v []map[string]*string
+	for _, h := range column {
+		v = append(v, hstoreMap(h))
+	}
+
+	return v, nil
+}
+
+func hstoreMap(h pgtype.Hstore) map[string]*string {
+	m := make(map[string]*string, len(h.Map))
+	for k, t := range h.Map {
+		if t.Status == pgtype.Present {
+			s := t.String
+			m[k] = &s
+		} else {
+			m[k] = nil
+		}
+	}
+	return m
+}
+
+// SelectIPNet selects a single inet or cidr value as a *net.IPNet. An error will be returned if no rows are found or a
+// null value is found.
+func SelectIPNet(ctx context.Context, db Queryer, sql string, args ...interface{}) (*net.IPNet, error) {
+	v, err := Select[pgtype.Inet](ctx, db, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return v.IPNet, nil
+}
+
+// SelectAllIPNet selects a column of inet or cidr values as *net.IPNet. An error will be returned if a null value is
+// found.
+func SelectAllIPNet(ctx context.Context, db Queryer, sql string, args ...interface{}) ([]*net.IPNet, error) {
+	column, err := SelectAll[pgtype.Inet](ctx, db, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var v []*net.IPNet
+	for _, inet := range column {
+		v = append(v, inet.IPNet)
+	}
+
+	return v, nil
+}
+
+// SelectIP selects a single inet or cidr value as a net.IP. Any netmask is discarded. An error will be returned if no
+// rows are found or a null value is found.
+func SelectIP(ctx context.Context, db Queryer, sql string, args ...interface{}) (net.IP, error) {
+	v, err := SelectIPNet(ctx, db, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return v.IP, nil
+}
+
+// SelectAllIP selects a column of inet or cidr values as net.IP. Any netmask is discarded. An error will be returned if
+// a null value is found.
+func SelectAllIP(ctx context.Context, db Queryer, sql string, args ...interface{}) ([]net.IP, error) {
+	column, err := SelectAllIPNet(ctx, db, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var v []net.IP
+	for _, ipNet := range column {
+		v = append(v, ipNet.IP)
+	}
+
+	return v, nil
+}
+
+// Range is a PostgreSQL range value such as int8range, tsrange, or daterange. Lower and Upper are only set when the
+// corresponding bound is not unbounded and the range is not empty.
+type Range[T any] struct {
+	Lower          T
+	Upper          T
+	LowerInclusive bool
+	UpperInclusive bool
+	LowerUnbounded bool
+	UpperUnbounded bool
+	Empty          bool
+}
+
+// rangeElementOIDs maps the built-in range types to the types of their bounds. Bounds of other range types are read
+// as text.
+var rangeElementOIDs = map[uint32]uint32{
+	pgtype.Int4rangeOID: pgtype.Int4OID,
+	pgtype.Int8rangeOID: pgtype.Int8OID,
+	pgtype.NumrangeOID:  pgtype.NumericOID,
+	pgtype.TsrangeOID:   pgtype.TimestampOID,
+	pgtype.TstzrangeOID: pgtype.TimestamptzOID,
+	pgtype.DaterangeOID: pgtype.DateOID,
+}
+
+// SelectRange selects a single range value. Any T that pgx can scan the range bounds into can be used. An error will
+// be returned if no rows are found or a null value is found.
+func SelectRange[T any](ctx context.Context, db Queryer, sql string, args ...interface{}) (Range[T], error) {
+	args = append([]interface{}{pgx.QueryResultFormats{pgx.TextFormatCode}}, args...)
+	ci := pgtype.NewConnInfo()
+	var v Range[T]
+	err := selectOneValueNotNull(ctx, db, sql, args, func(rows pgx.Rows) error {
+		var err error
+		v, err = scanRange[T](ci, rows)
+		return err
+	})
+	if err != nil {
+		return Range[T]{}, err
+	}
+
+	return v, nil
+}
+
+// SelectAllRange selects a column of range values. An error will be returned if a null value is found.
+func SelectAllRange[T any](ctx context.Context, db Queryer, sql string, args ...interface{}) ([]Range[T], error) {
+	args = append([]interface{}{pgx.QueryResultFormats{pgx.TextFormatCode}}, args...)
+	ci := pgtype.NewConnInfo()
+	var v []Range[T]
+	err := selectColumnNotNull(ctx, db, sql, args, func(rows pgx.Rows) error {
+		r, err := scanRange[T](ci, rows)
+		if err != nil {
+			return err
+		}
+		v = append(v, r)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+func scanRange[T any](ci *pgtype.ConnInfo, rows pgx.Rows) (Range[T], error) {
+	var r Range[T]
+	utr, err := pgtype.ParseUntypedTextRange(string(rows.RawValues()[0]))
+	if err != nil {
+		return r, err
+	}
+
+	if utr.LowerType == pgtype.Empty {
+		r.Empty = true
+		return r, nil
+	}
+
+	elementOID, ok := rangeElementOIDs[rows.FieldDescriptions()[0].DataTypeOID]
+	if !ok {
+		elementOID = pgtype.TextOID
+	}
+
+	switch utr.LowerType {
+	case pgtype.Unbounded:
+		r.LowerUnbounded = true
+	default:
+		r.LowerInclusive = utr.LowerType == pgtype.Inclusive
+		err = ci.Scan(elementOID, pgx.TextFormatCode, []byte(utr.Lower), &r.Lower)
+		if err != nil {
+			return r, err
+		}
+	}
+
+	switch utr.UpperType {
+	case pgtype.Unbounded:
+		r.UpperUnbounded = true
+	default:
+		r.UpperInclusive = utr.UpperType == pgtype.Inclusive
+		err = ci.Scan(elementOID, pgx.TextFormatCode, []byte(utr.Upper), &r.Upper)
+		if err != nil {
+			return r, err
+		}
+	}
+
+	return r, nil
+}
+
+// TimeOptions controls how SelectTimeWithOptions and SelectAllTimeWithOptions convert values.
+type TimeOptions struct {
+	// Infinity is returned when infinity is selected. If it is the zero time an error is returned instead.
+	Infinity time.Time
+
+	// NegativeInfinity is returned when -infinity is selected. If it is the zero time an error is returned instead.
+	NegativeInfinity time.Time
+
+	// Location is the time zone the results are converted to. If nil the time zone is not changed.
+	Location *time.Location
+}
+
+func (opts *TimeOptions) convert(value interface{}) (time.Time, error) {
+	switch value := value.(type) {
+	case time.Time:
+		if opts.Location != nil {
+			value = value.In(opts.Location)
+		}
+		return value, nil
+	case pgtype.InfinityModifier:
+		var t time.Time
+		if value == pgtype.Infinity {
+			t = opts.Infinity
+		} else {
+			t = opts.NegativeInfinity
+		}
+		if t.IsZero() {
+			return time.Time{}, errInfiniteValue
+		}
+		return t, nil
+	default:
+		return time.Time{}, fmt.Errorf("cannot convert %T to time.Time", value)
+	}
+}
+
+// SelectTime selects a single time.Time. timestamptz, timestamp, and date values can be selected. An error will be
+// returned if no rows are found, a null value is found, or an infinite value is found.
+func SelectTime(ctx context.Context, db Queryer, sql string, args ...interface{}) (time.Time, error) {
+	return SelectTimeWithOptions(ctx, db, TimeOptions{}, sql, args...)
+}
+
+// SelectTimePtr is like SelectTime except nil is returned if a null value is found.
+func SelectTimePtr(ctx context.Context, db Queryer, sql string, args ...interface{}) (*time.Time, error) {
+	return selectOneValuePtr(ctx, db, sql, args, func(rows pgx.Rows) (time.Time, error) {
+		values, err := rows.Values()
+		if err != nil {
+			return time.Time{}, err
+		}
+		opts := &TimeOptions{}
+		return opts.convert(values[0])
+	})
+}
+
+// SelectAllTime selects a column of time.Time. timestamptz, timestamp, and date values can be selected. An error will
+// be returned if a null value or an infinite value is found.
+func SelectAllTime(ctx context.Context, db Queryer, sql string, args ...interface{}) ([]time.Time, error) {
+	return SelectAllTimeWithOptions(ctx, db, TimeOptions{}, sql, args...)
+}
+
+// SelectTimeWithOptions is like SelectTime except infinite values and time zones are handled according to opts.
+func SelectTimeWithOptions(ctx context.Context, db Queryer, opts TimeOptions, sql string, args ...interface{}) (time.Time, error) {
+	var v time.Time
+	err := selectOneValueNotNull(ctx, db, sql, args, func(rows pgx.Rows) error {
+		values, err := rows.Values()
+		if err != nil {
+			return err
+		}
+		v, err = opts.convert(values[0])
 		return err
 	})
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return v, nil
+}
+
+// SelectAllTimeWithOptions is like SelectAllTime except infinite values and time zones are handled according to opts.
+func SelectAllTimeWithOptions(ctx context.Context, db Queryer, opts TimeOptions, sql string, args ...interface{}) ([]time.Time, error) {
+	var v []time.Time
+	err := selectColumnNotNull(ctx, db, sql, args, func(rows pgx.Rows) error {
+		values, err := rows.Values()
+		if err != nil {
+			return err
+		}
+		t, err := opts.convert(values[0])
+		if err != nil {
+			return err
+		}
+		v = append(v, t)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// SelectInterval selects a single pgtype.Interval. An error will be returned if no rows are found or a null value is
+// found.
+func SelectInterval(ctx context.Context, db Queryer, sql string, args ...interface{}) (pgtype.Interval, error) {
+	return Select[pgtype.Interval](ctx, db, sql, args...)
+}
+
+// SelectAllInterval selects a column of pgtype.Interval. An error will be returned if a null value is found.
+func SelectAllInterval(ctx context.Context, db Queryer, sql string, args ...interface{}) ([]pgtype.Interval, error) {
+	return SelectAll[pgtype.Interval](ctx, db, sql, args...)
+}
+
+// SelectDuration selects a single interval as a time.Duration. A day is treated as 24 hours. An error will be returned
+// if no rows are found, a null value is found, or the interval has a months component as it has no fixed duration.
+func SelectDuration(ctx context.Context, db Queryer, sql string, args ...interface{}) (time.Duration, error) {
+	v, err := SelectInterval(ctx, db, sql, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	return intervalDuration(v)
+}
+
+// SelectAllDuration selects a column of intervals as time.Duration. A day is treated as 24 hours. An error will be
+// returned if a null value is found or an interval has a months component.
+func SelectAllDuration(ctx context.Context, db Queryer, sql string, args ...interface{}) ([]time.Duration, error) {
+	column, err := SelectAllInterval(ctx, db, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var v []time.Duration
+	for _, interval := range column {
+		d, err := intervalDuration(interval)
+		if err != nil {
+			return nil, err
+		}
+		v = append(v, d)
+	}
+
+	return v, nil
+}
+
+func intervalDuration(interval pgtype.Interval) (time.Duration, error) {
+	if interval.Months != 0 {
+		return 0, fmt.Errorf("interval with %d months cannot be converted to time.Duration", interval.Months)
+	}
+
+	return time.Duration(interval.Days)*24*time.Hour + time.Duration(interval.Microseconds)*time.Microsecond, nil
+}
+
+// SelectValue selects a single value of unspecified type. A []byte value, such as a bytea, is copied out of the row
+// buffer unless Options.ZeroCopy is set. An error will be returned if no rows are found.
+func SelectValue(ctx context.Context, db Queryer, sql string, args ...interface{}) (interface{}, error) {
+	var v interface{}
+	zeroCopy := queryOptions(ctx, args).ZeroCopy
+	err := selectOneValue(ctx, db, sql, args, func(rows pgx.Rows) error {
+		values, err := rowValues(rows, zeroCopy)
+		if err != nil {
+			return err
+		}
+		v = values[0]
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// SelectValueOr is like SelectValue except defaultValue is returned if no rows are found.
+func SelectValueOr(ctx context.Context, db Queryer, defaultValue interface{}, sql string, args ...interface{}) (interface{}, error) {
+	v, err := SelectValue(ctx, db, sql, args...)
+	if errors.Is(err, ErrNoRows) {
+		return defaultValue, nil
+	}
+	return v, err
+}
+
+// SelectAllValue selects a column of unspecified type. []byte values are copied out of the row buffer as described by
+// SelectAllByteSlice.
+func SelectAllValue(ctx context.Context, db Queryer, sql string, args ...interface{}) ([]interface{}, error) {
+	v := makeRowSlice[interface{}](ctx, args)
+	err := selectColumn(ctx, db, sql, args, func(rows pgx.Rows) error {
+		values, err := rowValues(rows, false)
+		if err != nil {
+			return err
+		}
+		v = append(v, values[0])
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// SelectMap selects a single row into a map. []byte values are copied out of the row buffer unless Options.ZeroCopy is
+// set. An error will be returned if no rows are found.
+func SelectMap(ctx context.Context, db Queryer, sql string, args ...interface{}) (map[string]interface{}, error) {
+	var v map[string]interface{}
+	scanner := mapRowScanner{zeroCopy: queryOptions(ctx, args).ZeroCopy}
+	err := selectOneRow(ctx, db, sql, args, func(rows pgx.Rows) error {
+		v = make(map[string]interface{}, len(rows.FieldDescriptions()))
+		return scanner.scan(rows, v)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// SelectAllMap selects rows into a map slice. []byte values are copied out of the row buffer as described by
+// SelectAllByteSlice. Options.ExpectedRows can be used to preallocate the slice.
+func SelectAllMap(ctx context.Context, db Queryer, sql string, args ...interface{}) ([]map[string]interface{}, error) {
+	return SelectAppendMap(ctx, db, nil, sql, args...)
+}
+
+// SelectAppendMap selects rows into maps appended to dst and returns the extended slice. Maps in the spare capacity
+// of dst, such as those of an earlier result passed as rows[:0], are cleared and reused so a caller that repeatedly
+// runs a query can avoid allocating new maps. Any other references to those maps must no longer be in use. If
+// Options.ExpectedRows is greater than the spare capacity of dst the slice is grown once before rows are read.
+func SelectAppendMap(ctx context.Context, db Queryer, dst []map[string]interface{}, sql string, args ...interface{}) ([]map[string]interface{}, error) {
+	if n := queryOptions(ctx, args).ExpectedRows; n > cap(dst)-len(dst) {
+		grown := make([]map[string]interface{}, len(dst), len(dst)+n)
+		copy(grown[:cap(dst)], dst[:cap(dst)])
+		dst = grown
+	}
+
+	var scanner mapRowScanner
+	err := selectRows(ctx, db, sql, args, func(rows pgx.Rows) error {
+		var m map[string]interface{}
+		if len(dst) < cap(dst) {
+			m = dst[:len(dst)+1][len(dst)]
+		}
+		if m == nil {
+			m = make(map[string]interface{}, len(rows.FieldDescriptions()))
+		} else {
+			clearMap(m)
+		}
+
+		err := scanner.scan(rows, m)
+		if err != nil {
+			return err
+		}
+		dst = append(dst, m)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return v, nil
+	return dst, nil
 }
 
-// SelectAllMap selects rows into a map slice.
-func SelectAllMap(ctx context.Context, db Queryer, sql string, args ...interface{}) ([]map[string]interface{}, error) {
-	var v []map[string]interface{}
-	err := selectRows(ctx, db, sql, args, func(rows pgx.Rows) error {
-		values, err := rows.Values()
+// SelectMapForEach selects rows and calls fn with each row as a map. Rows are read one at a time so fn can process
+// large result sets with constant memory. If fn returns an error the query is closed and the error is returned.
+//
+// []byte values are copied out of the row buffer unless Options.ZeroCopy is set. With ZeroCopy fn must not retain
+// them after it returns.
+func SelectMapForEach(ctx context.Context, db Queryer, fn func(map[string]interface{}) error, sql string, args ...interface{}) error {
+	scanner := mapRowScanner{zeroCopy: queryOptions(ctx, args).ZeroCopy}
+	return selectRows(ctx, db, sql, args, func(rows pgx.Rows) error {
+		m := make(map[string]interface{}, len(rows.FieldDescriptions()))
+		err := scanner.scan(rows, m)
 		if err != nil {
 			return err
 		}
 
-		m := make(map[string]interface{}, len(values))
-		for i := range values {
-			m[string(rows.FieldDescriptions()[i].Name)] = values[i]
+		return fn(m)
+	})
+}
+
+// SelectMapForEachReuse is like SelectMapForEach except the same map is cleared and refilled for every row so no map
+// is allocated per row. fn must not retain the map or modify it after it returns.
+func SelectMapForEachReuse(ctx context.Context, db Queryer, fn func(map[string]interface{}) error, sql string, args ...interface{}) error {
+	scanner := mapRowScanner{zeroCopy: queryOptions(ctx, args).ZeroCopy}
+	var m map[string]interface{}
+	return selectRows(ctx, db, sql, args, func(rows pgx.Rows) error {
+		if m == nil {
+			m = make(map[string]interface{}, len(rows.FieldDescriptions()))
+		} else {
+			clearMap(m)
 		}
 
-		v = append(v, m)
+		err := scanner.scan(rows, m)
+		if err != nil {
+			return err
+		}
 
-		return nil
+		return fn(m)
 	})
+}
+
+// mapRowScanner stores rows into maps keyed by column name. The names are converted from the field descriptions once
+// per query rather than for every row. Values are read with rowValues.
+type mapRowScanner struct {
+	names    []string
+	zeroCopy bool
+}
+
+func (s *mapRowScanner) scan(rows pgx.Rows, m map[string]interface{}) error {
+	values, err := rowValues(rows, s.zeroCopy)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	return v, nil
+	if len(s.names) != len(values) {
+		s.names = make([]string, len(values))
+		for i, fd := range rows.FieldDescriptions() {
+			s.names[i] = string(fd.Name)
+		}
+	}
+
+	for i := range values {
+		m[s.names[i]] = values[i]
+	}
+
+	return nil
+}
+
+// rowValues is like rows.Values except []byte values are copied unless zeroCopy is true. pgx decodes some values, such
+// as bytea in the binary format, to slices of the row buffer, which is overwritten when the next row is read.
+func rowValues(rows pgx.Rows, zeroCopy bool) ([]interface{}, error) {
+	values, err := rows.Values()
+	if err != nil || zeroCopy {
+		return values, err
+	}
+
+	for i, v := range values {
+		if b, ok := v.([]byte); ok {
+			values[i] = copyBytes(b, false)
+		}
+	}
+
+	return values, nil
+}
+
+// copyBytes returns a copy of b unless zeroCopy is true. nil is preserved.
+func copyBytes(b []byte, zeroCopy bool) []byte {
+	if zeroCopy || b == nil {
+		return b
+	}
+	return append(make([]byte, 0, len(b)), b...)
+}
+
+func clearMap(m map[string]interface{}) {
+	for k := range m {
+		delete(m, k)
+	}
 }
 
 // SelectStringMap selects a single row into a map where all values are strings. An error will be returned if no rows
@@ -475,7 +1572,7 @@ func SelectStringMap(ctx context.Context, db Queryer, sql string, args ...interf
 
 // SelectAllStringMap selects rows into a map slice where all values are strings.
 func SelectAllStringMap(ctx context.Context, db Queryer, sql string, args ...interface{}) ([]map[string]string, error) {
-	var v []map[string]string
+	v := makeRowSlice[map[string]string](ctx, args)
 	args = append([]interface{}{pgx.QueryResultFormats{pgx.TextFormatCode}}, args...)
 	err := selectRows(ctx, db, sql, args, func(rows pgx.Rows) error {
 		values := rows.RawValues()
@@ -495,9 +1592,30 @@ func SelectAllStringMap(ctx context.Context, db Queryer, sql string, args ...int
 	return v, nil
 }
 
-// SelectStruct selects a single row into struct dst. An error will be returned if no rows are found. The values are
-// assigned positionally to the exported struct fields.
+// SelectRowScan selects a single row and scans it into dest like pgx.Row.Scan. Unlike pgx.Row, an error will be
+// returned if no rows or multiple rows are found.
+func SelectRowScan(ctx context.Context, db Queryer, sql string, args []interface{}, dest ...interface{}) error {
+	return selectOneRow(ctx, db, sql, args, func(rows pgx.Rows) error {
+		return rows.Scan(dest...)
+	})
+}
+
+// SelectStruct selects a single row into struct dst. An error will be returned if no rows are found. If any exported
+// field of dst has a db tag the values are assigned by matching the column name to the db tag or, for untagged
+// fields, to the field name ignoring case and underscores. Fields tagged db:"-" are ignored. Otherwise, the values are
+// assigned positionally to the exported struct fields. The fields of embedded structs are treated as fields of the
+// outer struct.
 func SelectStruct(ctx context.Context, db Queryer, dst interface{}, sql string, args ...interface{}) error {
+	return selectStruct(ctx, db, dst, false, sql, args)
+}
+
+// SelectStructStrict is like SelectStruct except an error is also returned if any exported struct field does not
+// receive a column value. This catches differences between the struct and the query such as schema drift.
+func SelectStructStrict(ctx context.Context, db Queryer, dst interface{}, sql string, args ...interface{}) error {
+	return selectStruct(ctx, db, dst, true, sql, args)
+}
+
+func selectStruct(ctx context.Context, db Queryer, dst interface{}, strict bool, sql string, args []interface{}) error {
 	dstValue := reflect.ValueOf(dst)
 	if dstValue.Kind() != reflect.Ptr {
 		return fmt.Errorf("dst not a pointer")
@@ -505,24 +1623,19 @@ func SelectStruct(ctx context.Context, db Queryer, dst interface{}, sql string,
 
 	dstElemValue := dstValue.Elem()
 	dstElemType := dstElemValue.Type()
-
-	exportedFields := make([]int, 0, dstElemType.NumField())
-	for i := 0; i < dstElemType.NumField(); i++ {
-		sf := dstElemType.Field(i)
-		if sf.PkgPath == "" {
-			exportedFields = append(exportedFields, i)
-		}
+	if dstElemType.Kind() != reflect.Struct {
+		return fmt.Errorf("dst not a pointer to struct")
 	}
 
 	err := selectOneRow(ctx, db, sql, args, func(rows pgx.Rows) error {
-		rowFieldCount := len(rows.RawValues())
-		if rowFieldCount > len(exportedFields) {
-			return fmt.Errorf("got %d values, but dst struct has only %d fields", rowFieldCount, len(exportedFields))
+		fieldIndexes, err := structFieldIndexes(dstElemType, rows.FieldDescriptions(), strict)
+		if err != nil {
+			return err
 		}
 
-		scanTargets := make([]interface{}, rowFieldCount)
-		for i := 0; i < rowFieldCount; i++ {
-			scanTargets[i] = dstElemValue.Field(exportedFields[i]).Addr().Interface()
+		scanTargets := make([]interface{}, len(fieldIndexes))
+		for i := range fieldIndexes {
+			scanTargets[i] = structFieldByIndex(dstElemValue, fieldIndexes[i]).Addr().Interface()
 		}
 
 		return rows.Scan(scanTargets...)
@@ -534,9 +1647,20 @@ func SelectStruct(ctx context.Context, db Queryer, dst interface{}, sql string,
 	return nil
 }
 
-// SelectAllStruct selects rows into dst. dst must be a slice of struct or pointer to struct. The values are assigned
-// positionally to the exported struct fields.
+// SelectAllStruct selects rows into dst. dst must be a slice of struct or pointer to struct. The values are mapped to
+// struct fields the same way as SelectStruct. The fields of embedded structs are treated as fields of the outer struct.
+// Pointer fields are set to nil when a null value is selected.
 func SelectAllStruct(ctx context.Context, db Queryer, dst interface{}, sql string, args ...interface{}) error {
+	return selectAllStruct(ctx, db, dst, false, sql, args)
+}
+
+// SelectAllStructStrict is like SelectAllStruct except an error is also returned if any exported struct field does
+// not receive a column value.
+func SelectAllStructStrict(ctx context.Context, db Queryer, dst interface{}, sql string, args ...interface{}) error {
+	return selectAllStruct(ctx, db, dst, true, sql, args)
+}
+
+func selectAllStruct(ctx context.Context, db Queryer, dst interface{}, strict bool, sql string, args []interface{}) error {
 	ptrSliceValue := reflect.ValueOf(dst)
 	if ptrSliceValue.Kind() != reflect.Ptr {
 		return fmt.Errorf("dst not a pointer")
@@ -564,20 +1688,16 @@ func SelectAllStruct(ctx context.Context, db Queryer, dst interface{}, sql strin
 		return fmt.Errorf("dst not a pointer to slice of struct or pointer to struct")
 	}
 
-	exportedFields := make([]int, 0, structType.NumField())
-	for i := 0; i < structType.NumField(); i++ {
-		sf := structType.Field(i)
-		if sf.PkgPath == "" {
-			exportedFields = append(exportedFields, i)
-		}
-	}
-
 	sliceValue := reflect.New(sliceType).Elem()
+	var fieldIndexes [][]int
 
 	err := selectRows(ctx, db, sql, args, func(rows pgx.Rows) error {
-		rowFieldCount := len(rows.RawValues())
-		if rowFieldCount > len(exportedFields) {
-			return fmt.Errorf("got %d values, but dst struct has only %d fields", rowFieldCount, len(exportedFields))
+		if fieldIndexes == nil {
+			var err error
+			fieldIndexes, err = structFieldIndexes(structType, rows.FieldDescriptions(), strict)
+			if err != nil {
+				return err
+			}
 		}
 
 		var appendableValue reflect.Value
@@ -591,9 +1711,9 @@ func SelectAllStruct(ctx context.Context, db Queryer, dst interface{}, sql strin
 			fieldableValue = appendableValue
 		}
 
-		scanTargets := make([]interface{}, rowFieldCount)
-		for i := 0; i < rowFieldCount; i++ {
-			scanTargets[i] = fieldableValue.Field(exportedFields[i]).Addr().Interface()
+		scanTargets := make([]interface{}, len(fieldIndexes))
+		for i := range fieldIndexes {
+			scanTargets[i] = structFieldByIndex(fieldableValue, fieldIndexes[i]).Addr().Interface()
 		}
 
 		err := rows.Scan(scanTargets...)
@@ -614,22 +1734,526 @@ func SelectAllStruct(ctx context.Context, db Queryer, dst interface{}, sql strin
 	return nil
 }
 
-// Insert inserts a row and returns the resulting row.
+// SelectComposite selects a single composite value into dst. If dst is a pointer to a struct the fields of the
+// composite are assigned to the exported fields of the struct by position. Otherwise, or if dst implements
+// pgtype.BinaryDecoder, the value is scanned into dst directly so composite types registered with the connection can be
+// used. An error will be returned if no rows are found or a null value is found.
+func SelectComposite(ctx context.Context, db Queryer, dst interface{}, sql string, args ...interface{}) error {
+	dstValue := reflect.ValueOf(dst)
+	if dstValue.Kind() != reflect.Ptr {
+		return fmt.Errorf("dst not a pointer")
+	}
+
+	_, isDecoder := dst.(pgtype.BinaryDecoder)
+	if isDecoder || dstValue.Elem().Kind() != reflect.Struct {
+		return selectOneValueNotNull(ctx, db, sql, args, func(rows pgx.Rows) error {
+			return rows.Scan(dst)
+		})
+	}
+
+	args = append([]interface{}{pgx.QueryResultFormats{pgx.BinaryFormatCode}}, args...)
+	ci := pgtype.NewConnInfo()
+	return selectOneValueNotNull(ctx, db, sql, args, func(rows pgx.Rows) error {
+		return decodeComposite(ci, rows.RawValues()[0], dstValue.Elem())
+	})
+}
+
+// decodeComposite assigns the fields of the binary encoded composite src to the exported fields of the struct v by
+// position. Fields that are themselves composite values are decoded recursively.
+func decodeComposite(ci *pgtype.ConnInfo, src []byte, v reflect.Value) error {
+	fields := exportedStructFields(v.Type(), nil)
+	scanner := pgtype.NewCompositeBinaryScanner(ci, src)
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	if scanner.FieldCount() != len(fields) {
+		return fmt.Errorf("got %d composite fields, but dst struct has %d fields", scanner.FieldCount(), len(fields))
+	}
+
+	for _, sf := range fields {
+		if !scanner.Next() {
+			break
+		}
+
+		fieldValue := structFieldByIndex(v, sf.index)
+		err := decodeCompositeField(ci, scanner.OID(), scanner.Bytes(), fieldValue)
+		if err != nil {
+			return fmt.Errorf("composite field %s: %w", sf.Name, err)
+		}
+	}
+
+	return scanner.Err()
+}
+
+func decodeCompositeField(ci *pgtype.ConnInfo, oid uint32, src []byte, v reflect.Value) error {
+	if _, ok := ci.DataTypeForOID(oid); !ok {
+		fieldType := v.Type()
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+
+		if fieldType.Kind() == reflect.Struct {
+			if v.Kind() == reflect.Ptr {
+				if src == nil {
+					v.Set(reflect.Zero(v.Type()))
+					return nil
+				}
+				v.Set(reflect.New(fieldType))
+				v = v.Elem()
+			}
+			if src == nil {
+				return ErrNullValue
+			}
+			return decodeComposite(ci, src, v)
+		}
+	}
+
+	return ci.Scan(oid, pgx.BinaryFormatCode, src, v.Addr().Interface())
+}
+
+// Insert inserts a row and returns the resulting row. The keys of values are quoted as column names, so they must match
+// the case of the columns. e.g. the key UserID refers to a column created as "UserID", not to one created as UserID,
+// which PostgreSQL folds to userid. tableName is used as-is so it may be schema qualified. Use build.QuoteIdentifier to
+// quote it if necessary.
 func Insert(ctx context.Context, db Queryer, tableName string, values map[string]interface{}) (map[string]interface{}, error) {
-	stmt := pgsql.Insert(tableName).Data(pgsql.RowMap(values)).Returning("*")
-	sql, args := pgsql.Build(stmt)
+	return InsertReturning(ctx, db, tableName, values, "*")
+}
+
+// InsertReturning inserts a row and returns the columns of the resulting row listed in returning. returning is used
+// as-is as the SQL returning clause (e.g. "id, created_at").
+func InsertReturning(ctx context.Context, db Queryer, tableName string, values map[string]interface{}, returning string) (map[string]interface{}, error) {
+	sql, args := buildInsert(tableName, values)
+	return SelectMap(ctx, db, sql+" returning "+returning, args...)
+}
+
+// InsertReturningStruct inserts a row and selects the resulting row into struct dst. The values are mapped to struct
+// fields the same way as SelectStruct.
+func InsertReturningStruct(ctx context.Context, db Queryer, dst interface{}, tableName string, values map[string]interface{}) error {
+	sql, args := buildInsert(tableName, values)
+	return SelectStruct(ctx, db, dst, sql+" returning *", args...)
+}
+
+func buildInsert(tableName string, values map[string]interface{}) (string, []interface{}) {
+	if len(values) == 0 {
+		return fmt.Sprintf("insert into %s default values", tableName), nil
+	}
+
+	columns, placeholders, args := build.ColumnValues(values, 1)
+	return fmt.Sprintf("insert into %s (%s) values (%s)", tableName, columns, placeholders), args
+}
+
+// Upsert inserts a row or, if the row conflicts with an existing row on conflictColumns, updates the existing row with
+// the values of the non-conflict columns. It returns the resulting row.
+func Upsert(ctx context.Context, db Queryer, tableName string, values map[string]interface{}, conflictColumns []string) (map[string]interface{}, error) {
+	if len(conflictColumns) == 0 {
+		return nil, fmt.Errorf("conflictColumns must not be empty")
+	}
+
+	isConflictColumn := make(map[string]struct{}, len(conflictColumns))
+	for _, c := range conflictColumns {
+		isConflictColumn[c] = struct{}{}
+	}
+
+	var assignments []string
+	for _, k := range sortedKeys(values) {
+		if _, ok := isConflictColumn[k]; !ok {
+			column := build.QuoteIdentifier(k)
+			assignments = append(assignments, fmt.Sprintf("%s = excluded.%s", column, column))
+		}
+	}
+
+	// A no-op assignment is used when every column is a conflict column so the existing row is still returned.
+	if len(assignments) == 0 {
+		column := build.QuoteIdentifier(conflictColumns[0])
+		assignments = append(assignments, fmt.Sprintf("%s = excluded.%s", column, column))
+	}
+
+	sql, args := buildInsert(tableName, values)
+	sql = fmt.Sprintf("%s on conflict (%s) do update set %s returning *", sql, build.QuoteIdentifierList(conflictColumns), strings.Join(assignments, ", "))
 	return SelectMap(ctx, db, sql, args...)
 }
 
-// Update executes an update statement and returns the number of rows updated.
-func Update(ctx context.Context, db Execer, tableName string, setValues, whereArgs map[string]interface{}) (int64, error) {
-	stmt := pgsql.Update(tableName).Set(pgsql.RowMap(setValues))
-	if whereArgs != nil {
-		for k, v := range whereArgs {
-			stmt.Where(fmt.Sprintf("%s = ?", k), v)
+// InsertOnConflictDoNothing inserts a row unless it conflicts with an existing row on conflictColumns. If
+// conflictColumns is empty any conflict causes the row to be skipped. It returns the number of rows inserted.
+func InsertOnConflictDoNothing(ctx context.Context, db Execer, tableName string, values map[string]interface{}, conflictColumns []string) (int64, error) {
+	sql, args := buildInsert(tableName, values)
+	if len(conflictColumns) > 0 {
+		sql = fmt.Sprintf("%s on conflict (%s) do nothing", sql, build.QuoteIdentifierList(conflictColumns))
+	} else {
+		sql = sql + " on conflict do nothing"
+	}
+	ct, err := Exec(ctx, db, sql, args...)
+	return ct.RowsAffected(), err
+}
+
+// insertRowsCopyFromThreshold is the number of rows at which InsertRows switches from a multi-row insert to the copy
+// protocol.
+const insertRowsCopyFromThreshold = 100
+
+// maxQueryParams is the maximum number of parameters PostgreSQL allows in a single statement.
+const maxQueryParams = 65535
+
+// InsertRows inserts rows into tableName and returns the number of rows inserted. All rows must have the same keys and
+// at least one key. Small sets of rows are inserted with a single multi-row insert statement. Larger sets of rows use
+// the copy protocol. tableName may be schema qualified and is parsed as in SQL, so unquoted names are folded to lower
+// case and quoted names are used exactly, e.g. public."Widgets".
+func InsertRows(ctx context.Context, db BulkInserter, tableName string, rows []map[string]interface{}) (int64, error) {
+	if len(rows) == 0 {
+		return 0, nil
+	}
+
+	table, err := parseTableName(tableName)
+	if err != nil {
+		return 0, err
+	}
+
+	columnNames := sortedKeys(rows[0])
+	if len(columnNames) == 0 {
+		return 0, fmt.Errorf("row 0 has no columns")
+	}
+
+	values := make([][]interface{}, len(rows))
+	for i, row := range rows {
+		if len(row) != len(columnNames) {
+			return 0, fmt.Errorf("row %d has %d columns, but row 0 has %d columns", i, len(row), len(columnNames))
+		}
+		values[i] = make([]interface{}, len(columnNames))
+		for j, c := range columnNames {
+			v, ok := row[c]
+			if !ok {
+				return 0, fmt.Errorf("row %d does not have column %s", i, c)
+			}
+			values[i][j] = v
+		}
+	}
+
+	if len(rows) >= insertRowsCopyFromThreshold || len(rows)*len(columnNames) > maxQueryParams {
+		return db.CopyFrom(ctx, table, columnNames, pgx.CopyFromRows(values))
+	}
+
+	sb := &strings.Builder{}
+	sb.WriteString("insert into ")
+	sb.WriteString(table.Sanitize())
+	sb.WriteString(" (")
+	sb.WriteString(string(build.QuoteIdentifierList(columnNames)))
+	sb.WriteString(") values ")
+
+	args := make([]interface{}, 0, len(rows)*len(columnNames))
+	for i, rowValues := range values {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteByte('(')
+		sb.WriteString(string(build.Placeholders(len(args)+1, len(rowValues))))
+		sb.WriteByte(')')
+		args = append(args, rowValues...)
+	}
+
+	ct, err := Exec(ctx, db, sb.String(), args...)
+	if err != nil {
+		return 0, err
+	}
+
+	return ct.RowsAffected(), nil
+}
+
+// parseTableName splits a possibly schema qualified table name on the dots that are not quoted. Like PostgreSQL, ASCII
+// letters of unquoted parts are folded to lower case and quoted parts are unquoted.
+func parseTableName(tableName string) (pgx.Identifier, error) {
+	var ident pgx.Identifier
+	var part strings.Builder
+	quoted := false
+	for i := 0; i < len(tableName); i++ {
+		c := tableName[i]
+		switch {
+		case quoted && c == '"' && i+1 < len(tableName) && tableName[i+1] == '"':
+			part.WriteByte('"')
+			i++
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+			part.WriteByte(c)
+		case c == '.':
+			ident = append(ident, part.String())
+			part.Reset()
+		case 'A' <= c && c <= 'Z':
+			part.WriteByte(c + 'a' - 'A')
+		default:
+			part.WriteByte(c)
+		}
+	}
+	ident = append(ident, part.String())
+
+	if quoted {
+		return nil, fmt.Errorf("invalid table name %s: unterminated quoted identifier", tableName)
+	}
+	for _, p := range ident {
+		if p == "" {
+			return nil, fmt.Errorf("invalid table name %s", tableName)
 		}
 	}
-	sql, args := pgsql.Build(stmt)
-	ct, err := db.Exec(ctx, sql, args...)
+
+	return ident, nil
+}
+
+// Update executes an update statement and returns the number of rows updated. If whereArgs is nil all rows are
+// updated. The keys of setValues and whereArgs are quoted as column names as described by Insert. An error is returned
+// if setValues is empty.
+func Update(ctx context.Context, db Execer, tableName string, setValues, whereArgs map[string]interface{}) (int64, error) {
+	sql, args, err := buildUpdate(tableName, setValues, whereArgs)
+	if err != nil {
+		return 0, err
+	}
+	ct, err := Exec(ctx, db, sql, args...)
+	return ct.RowsAffected(), err
+}
+
+// UpdateReturning executes an update statement and returns the updated rows.
+func UpdateReturning(ctx context.Context, db Queryer, tableName string, setValues, whereArgs map[string]interface{}) ([]map[string]interface{}, error) {
+	sql, args, err := buildUpdate(tableName, setValues, whereArgs)
+	if err != nil {
+		return nil, err
+	}
+	return SelectAllMap(ctx, db, sql+" returning *", args...)
+}
+
+// UpdateVersioned is like Update except it implements optimistic locking with versionColumn. Only rows where
+// versionColumn equals version are updated and versionColumn is incremented. ErrStaleRow is returned if no rows were
+// updated.
+func UpdateVersioned(ctx context.Context, db Execer, tableName string, setValues, whereArgs map[string]interface{}, versionColumn string, version interface{}) (int64, error) {
+	sql, args := buildVersionedUpdate(tableName, setValues, whereArgs, versionColumn, version)
+	ct, err := Exec(ctx, db, sql, args...)
+	if err != nil {
+		return 0, err
+	}
+	if ct.RowsAffected() == 0 {
+		return 0, ErrStaleRow
+	}
+
+	return ct.RowsAffected(), nil
+}
+
+func buildVersionedUpdate(tableName string, setValues, whereArgs map[string]interface{}, versionColumn string, version interface{}) (string, []interface{}) {
+	quotedVersionColumn := build.QuoteIdentifier(versionColumn)
+	increment := fmt.Sprintf("%s = %s + 1", quotedVersionColumn, quotedVersionColumn)
+
+	var sql string
+	var args []interface{}
+	if len(setValues) == 0 {
+		sql = fmt.Sprintf("update %s set %s", tableName, increment)
+	} else {
+		var assignments build.SanitizedSQL
+		assignments, args = build.Assignments(setValues, 1)
+		sql = fmt.Sprintf("update %s set %s, %s", tableName, assignments, increment)
+	}
+
+	versionedWhereArgs := make(map[string]interface{}, len(whereArgs)+1)
+	for k, v := range whereArgs {
+		versionedWhereArgs[k] = v
+	}
+	versionedWhereArgs[versionColumn] = version
+
+	return appendWhere(sql, args, versionedWhereArgs)
+}
+
+func buildUpdate(tableName string, setValues, whereArgs map[string]interface{}) (string, []interface{}, error) {
+	if len(setValues) == 0 {
+		return "", nil, fmt.Errorf("setValues must not be empty")
+	}
+
+	assignments, args := build.Assignments(setValues, 1)
+	sql := fmt.Sprintf("update %s set %s", tableName, assignments)
+	sql, args = appendWhere(sql, args, whereArgs)
+	return sql, args, nil
+}
+
+// appendWhere appends a where clause matching whereArgs to sql. The placeholders follow those already used by args.
+func appendWhere(sql string, args []interface{}, whereArgs map[string]interface{}) (string, []interface{}) {
+	if len(whereArgs) == 0 {
+		return sql, args
+	}
+
+	conditions, whereValues := build.Conditions(whereArgs, len(args)+1)
+	return fmt.Sprintf("%s where %s", sql, conditions), append(args, whereValues...)
+}
+
+// appendScopedWhere is like appendWhere except condition is always included in the where clause.
+func appendScopedWhere(sql string, args []interface{}, whereArgs map[string]interface{}, condition string) (string, []interface{}) {
+	if len(whereArgs) == 0 {
+		return fmt.Sprintf("%s where %s", sql, condition), args
+	}
+
+	conditions, whereValues := build.Conditions(whereArgs, len(args)+1)
+	return fmt.Sprintf("%s where %s and %s", sql, conditions, condition), append(args, whereValues...)
+}
+
+// Delete executes a delete statement and returns the number of rows deleted. If whereArgs is nil all rows are deleted.
+func Delete(ctx context.Context, db Execer, tableName string, whereArgs map[string]interface{}) (int64, error) {
+	sql, args := appendWhere("delete from "+tableName, nil, whereArgs)
+	ct, err := Exec(ctx, db, sql, args...)
 	return ct.RowsAffected(), err
 }
+
+// DeleteOne executes a delete statement in a transaction. If the statement does not delete exactly one row the
+// transaction is rolled back and an error is returned.
+func DeleteOne(ctx context.Context, db Beginner, tableName string, whereArgs map[string]interface{}) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	n, err := Delete(ctx, tx, tableName, whereArgs)
+	if err != nil {
+		return err
+	}
+	if n != 1 {
+		return fmt.Errorf("expected 1 row to be deleted, but %d rows matched", n)
+	}
+
+	return tx.Commit(ctx)
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys, _ := build.SortedColumns(m)
+	return keys
+}
+
+// structField is a field of a struct that can be a scan target. index is suitable for reflect.Value.FieldByIndex.
+type structField struct {
+	reflect.StructField
+	index []int
+}
+
+// exportedStructFields returns the exported fields of structType. The fields of embedded structs without a db tag are
+// included in place of the embedded struct itself.
+func exportedStructFields(structType reflect.Type, parentIndex []int) []structField {
+	fields := make([]structField, 0, structType.NumField())
+	for i := 0; i < structType.NumField(); i++ {
+		sf := structType.Field(i)
+		index := make([]int, len(parentIndex)+1)
+		copy(index, parentIndex)
+		index[len(parentIndex)] = i
+
+		if _, tagged := sf.Tag.Lookup("db"); sf.Anonymous && !tagged {
+			embeddedType := sf.Type
+			if embeddedType.Kind() == reflect.Ptr && sf.PkgPath == "" {
+				embeddedType = embeddedType.Elem()
+			}
+			if embeddedType.Kind() == reflect.Struct {
+				fields = append(fields, exportedStructFields(embeddedType, index)...)
+				continue
+			}
+		}
+
+		if sf.PkgPath == "" {
+			fields = append(fields, structField{StructField: sf, index: index})
+		}
+	}
+
+	return fields
+}
+
+// structFieldByIndex is like reflect.Value.FieldByIndex except it allocates nil embedded struct pointers.
+func structFieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, fieldIndex := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(fieldIndex)
+	}
+
+	return v
+}
+
+// structFieldIndexes returns the index of the field of structType that each column in fieldDescriptions should be
+// scanned into. If strict is true an error is returned if any field would not receive a column value.
+func structFieldIndexes(structType reflect.Type, fieldDescriptions []pgproto3.FieldDescription, strict bool) ([][]int, error) {
+	exportedFields := exportedStructFields(structType, nil)
+	tagged := false
+	for _, sf := range exportedFields {
+		if _, ok := sf.Tag.Lookup("db"); ok {
+			tagged = true
+			break
+		}
+	}
+
+	fieldIndexes := make([][]int, len(fieldDescriptions))
+
+	if !tagged {
+		if len(fieldDescriptions) > len(exportedFields) {
+			return nil, fmt.Errorf("got %d values, but dst struct has only %d fields", len(fieldDescriptions), len(exportedFields))
+		}
+		if strict && len(fieldDescriptions) < len(exportedFields) {
+			return nil, fmt.Errorf("got %d values, but dst struct has %d fields", len(fieldDescriptions), len(exportedFields))
+		}
+		for i := range fieldDescriptions {
+			fieldIndexes[i] = exportedFields[i].index
+		}
+		return fieldIndexes, nil
+	}
+
+	matchedFields := make([]bool, len(exportedFields))
+	for i, fd := range fieldDescriptions {
+		columnName := string(fd.Name)
+		found := false
+		for j, sf := range exportedFields {
+			if structFieldMatchesColumn(sf.StructField, columnName) {
+				fieldIndexes[i] = sf.index
+				matchedFields[j] = true
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("no struct field found for column %s", columnName)
+		}
+	}
+
+	if strict {
+		for i, sf := range exportedFields {
+			if !matchedFields[i] && sf.Tag.Get("db") != "-" {
+				return nil, fmt.Errorf("no column found for struct field %s", sf.Name)
+			}
+		}
+	}
+
+	return fieldIndexes, nil
+}
+
+// structFieldMatchesColumn returns true if sf should receive the value of the column named columnName.
+func structFieldMatchesColumn(sf reflect.StructField, columnName string) bool {
+	if name, _, ok := dbTag(sf); ok && name != "" {
+		return name != "-" && name == columnName
+	}
+
+	return strings.EqualFold(sf.Name, strings.ReplaceAll(columnName, "_", ""))
+}
+
+// dbTag returns the column name and options of the db tag of sf. e.g. db:"id,default" has the name id and the option
+// default. The name may be empty when only options are given. ok is false if sf has no db tag.
+func dbTag(sf reflect.StructField) (name string, options []string, ok bool) {
+	tag, ok := sf.Tag.Lookup("db")
+	if !ok {
+		return "", nil, false
+	}
+
+	parts := strings.Split(tag, ",")
+	return parts[0], parts[1:], true
+}
+
+// hasTagOption returns true if the db tag of sf has option.
+func hasTagOption(sf reflect.StructField, option string) bool {
+	_, options, _ := dbTag(sf)
+	for _, o := range options {
+		if o == option {
+			return true
+		}
+	}
+
+	return false
+}
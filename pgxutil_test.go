@@ -1,20 +1,67 @@
 package pgxutil_test
 
 import (
+	"bytes"
 	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math/big"
+	"net"
 	"os"
+	"reflect"
 	"testing"
 	"time"
 
 	"github.com/gofrs/uuid"
 	"github.com/jackc/pgconn"
+	"github.com/jackc/pgtype"
 	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/jackc/pgxutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
+// Ensure the common pgx database handles can be used with pgxutil.
+var _ pgxutil.Queryer = (*pgx.Conn)(nil)
+var _ pgxutil.Queryer = (pgx.Tx)(nil)
+var _ pgxutil.Queryer = (*pgxpool.Pool)(nil)
+var _ pgxutil.Execer = (*pgx.Conn)(nil)
+var _ pgxutil.Execer = (pgx.Tx)(nil)
+var _ pgxutil.Execer = (*pgxpool.Pool)(nil)
+var _ pgxutil.Beginner = (*pgx.Conn)(nil)
+var _ pgxutil.Beginner = (pgx.Tx)(nil)
+var _ pgxutil.Beginner = (*pgxpool.Pool)(nil)
+var _ pgxutil.BulkInserter = (*pgx.Conn)(nil)
+var _ pgxutil.BulkInserter = (pgx.Tx)(nil)
+var _ pgxutil.BulkInserter = (*pgxpool.Pool)(nil)
+var _ pgxutil.TxBeginner = (*pgx.Conn)(nil)
+var _ pgxutil.TxBeginner = (*pgxpool.Pool)(nil)
+var _ pgxutil.BatchSender = (*pgx.Conn)(nil)
+var _ pgxutil.BatchSender = (pgx.Tx)(nil)
+var _ pgxutil.BatchSender = (*pgxpool.Pool)(nil)
+var _ pgxutil.PgConner = (*pgx.Conn)(nil)
+var _ pgxutil.Handle = (*pgx.Conn)(nil)
+var _ pgxutil.Handle = (pgx.Tx)(nil)
+var _ pgxutil.Handle = (*pgxpool.Pool)(nil)
+var _ pgxutil.Handle = (*pgxutil.HookedDB)(nil)
+var _ pgxutil.TxBeginner = (*pgxutil.HookedDB)(nil)
+var _ pgxutil.Queryer = (*pgxutil.ReadOnlyDB)(nil)
+var _ pgxutil.Handle = (*pgxutil.ReplicaRouter)(nil)
+var _ pgxutil.Execer = (*pgxutil.ReadOnlyDB)(nil)
+var _ pgxutil.Beginner = (*pgxutil.ReadOnlyDB)(nil)
+var _ pgxutil.Handle = (*pgxutil.DB)(nil)
+var _ pgxutil.TxBeginner = (*pgxutil.DB)(nil)
+var _ pgxutil.Handle = (*pgxutil.RetryDB)(nil)
+var _ pgxutil.Queryer = (*pgxutil.SQLDB)(nil)
+var _ pgxutil.Queryer = (*pgxutil.CachedDB)(nil)
+var _ pgxutil.Execer = (*pgxutil.SQLDB)(nil)
+var _ pgxutil.SQLQueryer = (*sql.DB)(nil)
+var _ pgxutil.SQLQueryer = (*sql.Tx)(nil)
+var _ pgxutil.SQLQueryer = (*sql.Conn)(nil)
+
 func withTx(t testing.TB, f func(ctx context.Context, tx pgx.Tx)) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
@@ -72,6 +119,66 @@ func TestSelectOneCommonErrors(t *testing.T) {
 	})
 }
 
+func TestSelect(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		s, err := pgxutil.Select[string](ctx, tx, "select 'Hello, world!'")
+		assert.NoError(t, err)
+		assert.Equal(t, "Hello, world!", s)
+
+		n, err := pgxutil.Select[int32](ctx, tx, "select 42")
+		assert.NoError(t, err)
+		assert.Equal(t, int32(42), n)
+
+		_, err = pgxutil.Select[int32](ctx, tx, "select null::int4")
+		assert.EqualError(t, err, "value is null")
+
+		_, err = pgxutil.Select[int32](ctx, tx, "select 42 where false")
+		assert.EqualError(t, err, "no rows in result set")
+	})
+}
+
+func TestSelectAll(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		tests := []struct {
+			sql    string
+			result []int64
+		}{
+			{"select generate_series(1,2)::int8", []int64{1, 2}},
+			{"select 42::int8 where false", nil},
+		}
+		for i, tt := range tests {
+			v, err := pgxutil.SelectAll[int64](ctx, tx, tt.sql)
+			assert.NoErrorf(t, err, "%d. %s", i, tt.sql)
+			assert.Equalf(t, tt.result, v, "%d. %s", i, tt.sql)
+		}
+	})
+}
+
+func TestSelectErrorsIs(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		tests := []struct {
+			sql string
+			err error
+		}{
+			{"select 42::float8 where 1=0", pgxutil.ErrNoRows},
+			{"select 42::float8 from generate_series(1,2)", pgxutil.ErrTooManyRows},
+			{"select", pgxutil.ErrNoColumns},
+			{"select 1, 2", pgxutil.ErrTooManyColumns},
+			{"select null::int8", pgxutil.ErrNullValue},
+		}
+		for i, tt := range tests {
+			_, err := pgxutil.SelectInt64(ctx, tx, tt.sql)
+			assert.Truef(t, errors.Is(err, tt.err), "%d. %s: %v", i, tt.sql, err)
+		}
+
+		_, err := pgxutil.SelectInt64(ctx, tx, "select 1 where false")
+		assert.True(t, errors.Is(err, pgx.ErrNoRows))
+	})
+}
+
 func TestSelectString(t *testing.T) {
 	t.Parallel()
 	withTx(t, func(ctx context.Context, tx pgx.Tx) {
@@ -81,6 +188,10 @@ func TestSelectString(t *testing.T) {
 		}{
 			{"select 'Hello, world!'", "Hello, world!"},
 			{"select 42", "42"},
+			{"select '\\x0102'::bytea", "\\x0102"},
+			{"select '{1,2,3}'::int4[]", "{1,2,3}"},
+			{"select '2020-01-02 03:04:05+00'::timestamptz at time zone 'UTC'", "2020-01-02 03:04:05"},
+			{"select true", "t"},
 		}
 		for i, tt := range tests {
 			v, err := pgxutil.SelectString(ctx, tx, tt.sql)
@@ -90,6 +201,117 @@ func TestSelectString(t *testing.T) {
 	})
 }
 
+func TestSelectStringAs(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		v, err := pgxutil.SelectStringAs(ctx, tx, "numeric(10,2)", "select 1.5::float8")
+		require.NoError(t, err)
+		assert.Equal(t, "1.50", v)
+
+		v, err = pgxutil.SelectStringAs(ctx, tx, "date", "select :t::timestamptz at time zone 'UTC'", pgxutil.NamedArgs{"t": "2020-01-02 03:04:05+00"})
+		require.NoError(t, err)
+		assert.Equal(t, "2020-01-02", v)
+
+		_, err = tx.Exec(ctx, "create temporary table t (id int)")
+		require.NoError(t, err)
+
+		v, err = pgxutil.SelectStringAs(ctx, tx, "int8", "insert into t values (7) returning id")
+		require.NoError(t, err)
+		assert.Equal(t, "7", v)
+
+		v, err = pgxutil.SelectStringAs(ctx, tx, "int8", "select 8 -- eight\n;\n")
+		require.NoError(t, err)
+		assert.Equal(t, "8", v)
+
+		_, err = pgxutil.SelectStringAs(ctx, tx, "int4", "select 1, 2")
+		require.Error(t, err)
+	})
+}
+
+func TestSelectPtr(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		s, err := pgxutil.SelectStringPtr(ctx, tx, "select 'Hello'")
+		assert.NoError(t, err)
+		if assert.NotNil(t, s) {
+			assert.Equal(t, "Hello", *s)
+		}
+
+		s, err = pgxutil.SelectStringPtr(ctx, tx, "select ''")
+		assert.NoError(t, err)
+		if assert.NotNil(t, s) {
+			assert.Equal(t, "", *s)
+		}
+
+		s, err = pgxutil.SelectStringPtr(ctx, tx, "select null::text")
+		assert.NoError(t, err)
+		assert.Nil(t, s)
+
+		_, err = pgxutil.SelectStringPtr(ctx, tx, "select null::text where false")
+		assert.EqualError(t, err, "no rows in result set")
+
+		b, err := pgxutil.SelectBoolPtr(ctx, tx, "select null::bool")
+		assert.NoError(t, err)
+		assert.Nil(t, b)
+
+		n, err := pgxutil.SelectInt64Ptr(ctx, tx, "select 0")
+		assert.NoError(t, err)
+		if assert.NotNil(t, n) {
+			assert.Equal(t, int64(0), *n)
+		}
+
+		n, err = pgxutil.SelectInt64Ptr(ctx, tx, "select null::int8")
+		assert.NoError(t, err)
+		assert.Nil(t, n)
+
+		f, err := pgxutil.SelectFloat64Ptr(ctx, tx, "select null::float8")
+		assert.NoError(t, err)
+		assert.Nil(t, f)
+
+		u, err := pgxutil.SelectUUIDPtr(ctx, tx, "select null::uuid")
+		assert.NoError(t, err)
+		assert.Nil(t, u)
+
+		tm, err := pgxutil.SelectTimePtr(ctx, tx, "select '2020-01-02'::date")
+		assert.NoError(t, err)
+		if assert.NotNil(t, tm) {
+			assert.Equal(t, time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), *tm)
+		}
+
+		tm, err = pgxutil.SelectTimePtr(ctx, tx, "select null::date")
+		assert.NoError(t, err)
+		assert.Nil(t, tm)
+	})
+}
+
+func TestSelectOr(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		n, err := pgxutil.SelectOr[int32](ctx, tx, -1, "select 42")
+		assert.NoError(t, err)
+		assert.Equal(t, int32(42), n)
+
+		n, err = pgxutil.SelectOr[int32](ctx, tx, -1, "select 42 where false")
+		assert.NoError(t, err)
+		assert.Equal(t, int32(-1), n)
+
+		_, err = pgxutil.SelectOr[int32](ctx, tx, -1, "select 42 from generate_series(1,2)")
+		assert.EqualError(t, err, "multiple rows in result set")
+
+		s, err := pgxutil.SelectStringOr(ctx, tx, "default", "select 'Hello' where false")
+		assert.NoError(t, err)
+		assert.Equal(t, "default", s)
+
+		i, err := pgxutil.SelectInt64Or(ctx, tx, 7, "select max(n) from generate_series(1,2) n group by n % 2 having false")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(7), i)
+
+		v, err := pgxutil.SelectValueOr(ctx, tx, "default", "select 1 where false")
+		assert.NoError(t, err)
+		assert.Equal(t, "default", v)
+	})
+}
+
 func TestSelectAllString(t *testing.T) {
 	t.Parallel()
 	withTx(t, func(ctx context.Context, tx pgx.Tx) {
@@ -144,6 +366,102 @@ func TestSelectAllByteSlice(t *testing.T) {
 	})
 }
 
+func TestSelectAllByteSliceCopiesRowBuffer(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		sql := "select decode(repeat(lpad(to_hex(n), 2, '0'), 1000), 'hex') from generate_series(1, 100) n"
+
+		expected := make([][]byte, 100)
+		for i := range expected {
+			expected[i] = bytes.Repeat([]byte{byte(i + 1)}, 1000)
+		}
+
+		bs, err := pgxutil.SelectAllByteSlice(ctx, tx, sql, pgxutil.Options{ZeroCopy: true})
+		require.NoError(t, err)
+		assert.Equal(t, expected, bs)
+
+		vs, err := pgxutil.SelectAllValue(ctx, tx, sql)
+		require.NoError(t, err)
+		require.Len(t, vs, 100)
+		for i := range vs {
+			assert.Equal(t, expected[i], vs[i])
+		}
+
+		ms, err := pgxutil.SelectAllMap(ctx, tx, "select decode(repeat(lpad(to_hex(n), 2, '0'), 1000), 'hex') as b from generate_series(1, 100) n")
+		require.NoError(t, err)
+		require.Len(t, ms, 100)
+		for i := range ms {
+			assert.Equal(t, expected[i], ms[i]["b"])
+		}
+	})
+}
+
+func TestSelectJSON(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		tests := []struct {
+			sql    string
+			result json.RawMessage
+		}{
+			{`select '{"name": "Adam"}'::json`, json.RawMessage(`{"name": "Adam"}`)},
+			{`select '{"name": "Adam"}'::jsonb`, json.RawMessage(`{"name": "Adam"}`)},
+			{`select '[1, 2]'::jsonb`, json.RawMessage(`[1, 2]`)},
+		}
+		for i, tt := range tests {
+			v, err := pgxutil.SelectJSON(ctx, tx, tt.sql)
+			assert.NoErrorf(t, err, "%d. %s", i, tt.sql)
+			assert.Equalf(t, tt.result, v, "%d. %s", i, tt.sql)
+		}
+	})
+}
+
+func TestSelectJSONUnmarshal(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		type person struct {
+			Name   string `json:"name"`
+			Height int32  `json:"height"`
+		}
+
+		var p person
+		err := pgxutil.SelectJSONUnmarshal(ctx, tx, &p, `select json_build_object('name', 'Adam', 'height', 72)`)
+		require.NoError(t, err)
+		assert.Equal(t, person{Name: "Adam", Height: 72}, p)
+
+		var people []person
+		err = pgxutil.SelectJSONUnmarshal(ctx, tx, &people, `select jsonb_agg(jsonb_build_object('name', name)) from (values ('Adam'), ('Bill')) t(name)`)
+		require.NoError(t, err)
+		assert.Equal(t, []person{{Name: "Adam"}, {Name: "Bill"}}, people)
+	})
+}
+
+func TestSelectXML(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		v, err := pgxutil.SelectXML(ctx, tx, `select xmlelement(name person, xmlattributes('Adam' as name))`)
+		require.NoError(t, err)
+		assert.Equal(t, []byte(`<person name="Adam"/>`), v)
+
+		_, err = pgxutil.SelectXML(ctx, tx, `select null::xml`)
+		assert.Error(t, err)
+	})
+}
+
+func TestSelectXMLUnmarshal(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		type person struct {
+			Name   string `xml:"name,attr"`
+			Height int32  `xml:"height"`
+		}
+
+		var p person
+		err := pgxutil.SelectXMLUnmarshal(ctx, tx, &p, `select '<person name="Adam"><height>72</height></person>'::xml`)
+		require.NoError(t, err)
+		assert.Equal(t, person{Name: "Adam", Height: 72}, p)
+	})
+}
+
 func TestSelectBool(t *testing.T) {
 	t.Parallel()
 	withTx(t, func(ctx context.Context, tx pgx.Tx) {
@@ -180,6 +498,27 @@ func TestSelectAllBool(t *testing.T) {
 	})
 }
 
+func TestSelectExists(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		tests := []struct {
+			sql    string
+			args   []interface{}
+			result bool
+		}{
+			{"select 1 from generate_series(1,10) n where n = $1", []interface{}{5}, true},
+			{"select 1 from generate_series(1,10) n where n = $1", []interface{}{11}, false},
+			{"select 1 from generate_series(1,10) n where n = $1;\n", []interface{}{5}, true},
+			{"select 1 from generate_series(1,10) n where n = $1 -- comment", []interface{}{5}, true},
+		}
+		for i, tt := range tests {
+			v, err := pgxutil.SelectExists(ctx, tx, tt.sql, tt.args...)
+			assert.NoErrorf(t, err, "%d. %s", i, tt.sql)
+			assert.Equalf(t, tt.result, v, "%d. %s", i, tt.sql)
+		}
+	})
+}
+
 func TestSelectInt64(t *testing.T) {
 	t.Parallel()
 	withTx(t, func(ctx context.Context, tx pgx.Tx) {
@@ -189,6 +528,8 @@ func TestSelectInt64(t *testing.T) {
 		}{
 			{"select 99999999999::bigint", 99999999999},
 			{"select 42::smallint", 42},
+			{"select 3.00::numeric", 3},
+			{"select 1e3::float8", 1000},
 		}
 		for i, tt := range tests {
 			v, err := pgxutil.SelectInt64(ctx, tx, tt.sql)
@@ -198,6 +539,110 @@ func TestSelectInt64(t *testing.T) {
 	})
 }
 
+func TestSelectInt64ConversionError(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		_, err := pgxutil.SelectInt64(ctx, tx, "select 1.5::numeric")
+		var convErr *pgxutil.IntConversionError
+		require.ErrorAs(t, err, &convErr)
+		assert.Equal(t, pgxutil.IntConversionError{Value: "1.5", Type: "int64", Fractional: true}, *convErr)
+		assert.EqualError(t, err, "1.5 has a fractional part and cannot be converted to int64 without truncation")
+
+		_, err = pgxutil.SelectInt64(ctx, tx, "select 9223372036854775808::numeric")
+		require.ErrorAs(t, err, &convErr)
+		assert.Equal(t, pgxutil.IntConversionError{Value: "9223372036854775808", Type: "int64", Overflow: true}, *convErr)
+
+		_, err = pgxutil.SelectAllInt64(ctx, tx, "select n::float8 / 2 from generate_series(2, 3) n")
+		require.ErrorAs(t, err, &convErr)
+		assert.Equal(t, "1.5", convErr.Value)
+		assert.True(t, convErr.Fractional)
+
+		_, err = pgxutil.SelectInt64(ctx, tx, "select 'abc'")
+		assert.EqualError(t, err, "cannot convert abc to int64")
+	})
+}
+
+func TestSelectInt32(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		n, err := pgxutil.SelectInt32(ctx, tx, "select 2147483647::int8")
+		require.NoError(t, err)
+		assert.EqualValues(t, 2147483647, n)
+
+		_, err = pgxutil.SelectInt32(ctx, tx, "select 2147483648::int8")
+		assert.EqualError(t, err, "2147483648 is out of range for int32")
+
+		p, err := pgxutil.SelectInt32Ptr(ctx, tx, "select null::int4")
+		require.NoError(t, err)
+		assert.Nil(t, p)
+
+		n, err = pgxutil.SelectInt32Or(ctx, tx, 7, "select 1 where false")
+		require.NoError(t, err)
+		assert.EqualValues(t, 7, n)
+
+		ns, err := pgxutil.SelectAllInt32(ctx, tx, "select generate_series(1,2)")
+		require.NoError(t, err)
+		assert.Equal(t, []int32{1, 2}, ns)
+	})
+}
+
+func TestSelectInt16(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		n, err := pgxutil.SelectInt16(ctx, tx, "select -32768")
+		require.NoError(t, err)
+		assert.EqualValues(t, -32768, n)
+
+		_, err = pgxutil.SelectInt16(ctx, tx, "select 32768")
+		assert.EqualError(t, err, "32768 is out of range for int16")
+
+		p, err := pgxutil.SelectInt16Ptr(ctx, tx, "select 42")
+		require.NoError(t, err)
+		if assert.NotNil(t, p) {
+			assert.EqualValues(t, 42, *p)
+		}
+
+		n, err = pgxutil.SelectInt16Or(ctx, tx, 7, "select 1 where false")
+		require.NoError(t, err)
+		assert.EqualValues(t, 7, n)
+
+		ns, err := pgxutil.SelectAllInt16(ctx, tx, "select generate_series(1,2)")
+		require.NoError(t, err)
+		assert.Equal(t, []int16{1, 2}, ns)
+	})
+}
+
+func TestSelectCount(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		_, err := tx.Exec(ctx, `create temporary table t (id serial primary key, name text, height int)`)
+		require.NoError(t, err)
+
+		n, err := pgxutil.SelectCount(ctx, tx, "t", nil)
+		require.NoError(t, err)
+		assert.EqualValues(t, 0, n)
+
+		_, err = pgxutil.InsertRows(ctx, tx, "t", []map[string]interface{}{
+			{"name": "Adam", "height": 72},
+			{"name": "Bill", "height": 68},
+			{"name": "Charlie", "height": 68},
+		})
+		require.NoError(t, err)
+
+		n, err = pgxutil.SelectCount(ctx, tx, "t", nil)
+		require.NoError(t, err)
+		assert.EqualValues(t, 3, n)
+
+		n, err = pgxutil.SelectCount(ctx, tx, "t", map[string]interface{}{"height": 68})
+		require.NoError(t, err)
+		assert.EqualValues(t, 2, n)
+
+		n, err = pgxutil.SelectCountSQL(ctx, tx, "select * from t where height > $1", 70)
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, n)
+	})
+}
+
 func TestSelectAllInt64(t *testing.T) {
 	t.Parallel()
 	withTx(t, func(ctx context.Context, tx pgx.Tx) {
@@ -216,6 +661,34 @@ func TestSelectAllInt64(t *testing.T) {
 	})
 }
 
+func TestSelectBigInt(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		n, err := pgxutil.SelectBigInt(ctx, tx, "select 340282366920938463463374607431768211455::numeric")
+		require.NoError(t, err)
+		assert.Equal(t, "340282366920938463463374607431768211455", n.String())
+
+		n, err = pgxutil.SelectBigInt(ctx, tx, "select 42")
+		require.NoError(t, err)
+		assert.EqualValues(t, 42, n.Int64())
+
+		_, err = pgxutil.SelectBigInt(ctx, tx, "select 1.5")
+		var convErr *pgxutil.IntConversionError
+		require.ErrorAs(t, err, &convErr)
+		assert.True(t, convErr.Fractional)
+
+		n, err = pgxutil.SelectBigIntPtr(ctx, tx, "select null::numeric")
+		require.NoError(t, err)
+		assert.Nil(t, n)
+
+		ns, err := pgxutil.SelectAllBigInt(ctx, tx, "select 10::numeric ^ n from generate_series(19, 20) n")
+		require.NoError(t, err)
+		require.Len(t, ns, 2)
+		assert.Equal(t, "10000000000000000000", ns[0].String())
+		assert.Equal(t, "100000000000000000000", ns[1].String())
+	})
+}
+
 func TestSelectFloat64(t *testing.T) {
 	t.Parallel()
 	withTx(t, func(ctx context.Context, tx pgx.Tx) {
@@ -255,90 +728,428 @@ func TestSelectAllFloat64(t *testing.T) {
 	})
 }
 
-func TestSelectDecimal(t *testing.T) {
+func TestSelectNumeric(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		parseRat := func(s string) (*big.Rat, error) {
+			r, ok := new(big.Rat).SetString(s)
+			if !ok {
+				return nil, fmt.Errorf("invalid number %s", s)
+			}
+			return r, nil
+		}
+
+		r, err := pgxutil.SelectNumeric(ctx, tx, parseRat, "select 1.2345::numeric")
+		require.NoError(t, err)
+		assert.Equal(t, "2469/2000", r.String())
+
+		_, err = pgxutil.SelectNumeric(ctx, tx, parseRat, "select 'NaN'::numeric")
+		require.EqualError(t, err, "invalid number NaN")
+
+		p, err := pgxutil.SelectNumericPtr(ctx, tx, parseRat, "select null::numeric")
+		require.NoError(t, err)
+		assert.Nil(t, p)
+
+		rs, err := pgxutil.SelectAllNumeric(ctx, tx, parseRat, "select n + 0.5 from generate_series(1,2) n")
+		require.NoError(t, err)
+		require.Len(t, rs, 2)
+		assert.Equal(t, "3/2", rs[0].String())
+		assert.Equal(t, "5/2", rs[1].String())
+	})
+}
+
+func TestSelectUUID(t *testing.T) {
 	t.Parallel()
 	withTx(t, func(ctx context.Context, tx pgx.Tx) {
 		tests := []struct {
 			sql    string
-			result string
+			result uuid.UUID
+		}{
+			{"select '27fd10c1-bccc-4efd-9fea-093f86c95089'::uuid", uuid.FromStringOrNil("27fd10c1-bccc-4efd-9fea-093f86c95089")},
+		}
+		for i, tt := range tests {
+			v, err := pgxutil.SelectUUID(ctx, tx, tt.sql)
+			assert.NoErrorf(t, err, "%d. %s", i, tt.sql)
+			assert.Equalf(t, tt.result, v, "%d. %s", i, tt.sql)
+		}
+	})
+}
+
+func TestSelectAllUUID(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		tests := []struct {
+			sql    string
+			result []uuid.UUID
+		}{
+			{
+				sql: "select format('27fd10c1-bccc-4efd-9fea-093f86c9508%s', n)::uuid from generate_series(1,2) n",
+				result: []uuid.UUID{
+					uuid.FromStringOrNil("27fd10c1-bccc-4efd-9fea-093f86c95081"),
+					uuid.FromStringOrNil("27fd10c1-bccc-4efd-9fea-093f86c95082"),
+				},
+			},
+			{
+				sql:    "select '27fd10c1-bccc-4efd-9fea-093f86c95089'::uuid where false",
+				result: nil,
+			},
+		}
+		for i, tt := range tests {
+			v, err := pgxutil.SelectAllUUID(ctx, tx, tt.sql)
+			assert.NoErrorf(t, err, "%d. %s", i, tt.sql)
+			assert.Equalf(t, tt.result, v, "%d. %s", i, tt.sql)
+		}
+	})
+}
+
+// googleUUID has the same definition as the UUID type of github.com/google/uuid.
+type googleUUID [16]byte
+
+func TestSelectUUIDAs(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		want := googleUUID(uuid.FromStringOrNil("27fd10c1-bccc-4efd-9fea-093f86c95089"))
+
+		v, err := pgxutil.SelectUUIDAs[googleUUID](ctx, tx, "select '27fd10c1-bccc-4efd-9fea-093f86c95089'::uuid")
+		require.NoError(t, err)
+		assert.Equal(t, want, v)
+
+		v, err = pgxutil.SelectUUIDAs[googleUUID](ctx, tx, "select $1::uuid", pgxutil.Options{ResultFormat: pgxutil.TextResultFormat}, want)
+		require.NoError(t, err)
+		assert.Equal(t, want, v)
+
+		_, err = pgxutil.SelectUUIDAs[googleUUID](ctx, tx, "select null::uuid")
+		require.Error(t, err)
+
+		p, err := pgxutil.SelectUUIDAsPtr[googleUUID](ctx, tx, "select null::uuid")
+		require.NoError(t, err)
+		assert.Nil(t, p)
+
+		p, err = pgxutil.SelectUUIDAsPtr[googleUUID](ctx, tx, "select '27fd10c1-bccc-4efd-9fea-093f86c95089'::uuid")
+		require.NoError(t, err)
+		require.NotNil(t, p)
+		assert.Equal(t, want, *p)
+
+		vs, err := pgxutil.SelectAllUUIDAs[googleUUID](ctx, tx, "select format('27fd10c1-bccc-4efd-9fea-093f86c9508%s', n)::uuid from generate_series(1,2) n")
+		require.NoError(t, err)
+		assert.Equal(t, []googleUUID{
+			googleUUID(uuid.FromStringOrNil("27fd10c1-bccc-4efd-9fea-093f86c95081")),
+			googleUUID(uuid.FromStringOrNil("27fd10c1-bccc-4efd-9fea-093f86c95082")),
+		}, vs)
+	})
+}
+
+func TestSelectTime(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		tests := []struct {
+			sql    string
+			result time.Time
+		}{
+			{"select '2020-01-02 03:04:05Z'::timestamptz", time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)},
+			{"select '2020-01-02 03:04:05'::timestamp", time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)},
+			{"select '2020-01-02'::date", time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)},
+		}
+		for i, tt := range tests {
+			v, err := pgxutil.SelectTime(ctx, tx, tt.sql)
+			assert.NoErrorf(t, err, "%d. %s", i, tt.sql)
+			assert.Truef(t, tt.result.Equal(v), "%d. %s", i, tt.sql)
+		}
+
+		_, err := pgxutil.SelectTime(ctx, tx, "select 'infinity'::timestamptz")
+		assert.EqualError(t, err, "value is infinite")
+	})
+}
+
+func TestSelectTimeWithOptions(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		loc := time.FixedZone("", -5*60*60)
+		opts := pgxutil.TimeOptions{
+			Infinity:         time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC),
+			NegativeInfinity: time.Date(1, 1, 1, 0, 0, 0, 1, time.UTC),
+			Location:         loc,
+		}
+
+		v, err := pgxutil.SelectTimeWithOptions(ctx, tx, opts, "select 'infinity'::timestamptz")
+		assert.NoError(t, err)
+		assert.Equal(t, opts.Infinity, v)
+
+		v, err = pgxutil.SelectTimeWithOptions(ctx, tx, opts, "select '-infinity'::date")
+		assert.NoError(t, err)
+		assert.Equal(t, opts.NegativeInfinity, v)
+
+		v, err = pgxutil.SelectTimeWithOptions(ctx, tx, opts, "select '2020-01-02 03:04:05Z'::timestamptz")
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2020, 1, 1, 22, 4, 5, 0, loc), v)
+	})
+}
+
+func TestSelectAllTime(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		tests := []struct {
+			sql    string
+			result []time.Time
+		}{
+			{"select '2020-01-01'::date + n from generate_series(0,1) n", []time.Time{
+				time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+				time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC),
+			}},
+			{"select '2020-01-01'::date where false", nil},
+		}
+		for i, tt := range tests {
+			v, err := pgxutil.SelectAllTime(ctx, tx, tt.sql)
+			assert.NoErrorf(t, err, "%d. %s", i, tt.sql)
+			assert.Equalf(t, tt.result, v, "%d. %s", i, tt.sql)
+		}
+	})
+}
+
+func TestSelectHstore(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		_, err := tx.Exec(ctx, "create extension if not exists hstore")
+		require.NoError(t, err)
+
+		v, err := pgxutil.SelectHstore(ctx, tx, `select 'a=>1, b=>NULL'::hstore`)
+		require.NoError(t, err)
+		one := "1"
+		assert.Equal(t, map[string]*string{"a": &one, "b": nil}, v)
+
+		_, err = pgxutil.SelectHstore(ctx, tx, `select null::hstore`)
+		assert.EqualError(t, err, "value is null")
+	})
+}
+
+func TestSelectAllHstore(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		_, err := tx.Exec(ctx, "create extension if not exists hstore")
+		require.NoError(t, err)
+
+		v, err := pgxutil.SelectAllHstore(ctx, tx, `select hstore('n', n::text) from generate_series(1,2) n`)
+		require.NoError(t, err)
+		one, two := "1", "2"
+		assert.Equal(t, []map[string]*string{{"n": &one}, {"n": &two}}, v)
+	})
+}
+
+func TestSelectInt64Array(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		tests := []struct {
+			sql    string
+			result []int64
 		}{
-			{"select 1.2345::numeric", "1.2345"},
-			{"select 1.2345::float8", "1.2345"},
-			{"select 1.23::float4", "1.23"},
-			{"select 99999999999::bigint", "99999999999"},
-			{"select 42::smallint", "42"},
+			{"select array[1,2,3]::int8[]", []int64{1, 2, 3}},
+			{"select array[4,5]::int4[]", []int64{4, 5}},
+			{"select array_agg(n) from generate_series(1,3) n", []int64{1, 2, 3}},
+			{"select '{}'::int8[]", []int64{}},
 		}
 		for i, tt := range tests {
-			v, err := pgxutil.SelectDecimal(ctx, tx, tt.sql)
+			v, err := pgxutil.SelectInt64Array(ctx, tx, tt.sql)
 			assert.NoErrorf(t, err, "%d. %s", i, tt.sql)
-			assert.Equalf(t, tt.result, v.String(), "%d. %s", i, tt.sql)
+			assert.Equalf(t, tt.result, v, "%d. %s", i, tt.sql)
+		}
+
+		errTests := []struct {
+			sql string
+			err string
+		}{
+			{"select null::int8[]", "value is null"},
+			{"select array[1,null]::int8[]", "value is null"},
+			{"select '{{1,2},{3,4}}'::int8[]", "expected 1 array dimension, but got 2"},
+		}
+		for i, tt := range errTests {
+			_, err := pgxutil.SelectInt64Array(ctx, tx, tt.sql)
+			assert.EqualErrorf(t, err, tt.err, "%d. %s", i, tt.sql)
 		}
 	})
 }
 
-func TestSelectAllDecimal(t *testing.T) {
+func TestSelectStringArray(t *testing.T) {
 	t.Parallel()
 	withTx(t, func(ctx context.Context, tx pgx.Tx) {
 		tests := []struct {
 			sql    string
 			result []string
 		}{
-			{"select n + 0.5 from generate_series(1,2) n", []string{"1.5", "2.5"}},
-			{"select 42.0 where false", nil},
+			{"select array['foo','bar']", []string{"foo", "bar"}},
+			{`select array['a,b', 'c"d', '{}']`, []string{"a,b", `c"d`, "{}"}},
+			{"select array[1,2]", []string{"1", "2"}},
+			{"select '{}'::text[]", []string{}},
 		}
 		for i, tt := range tests {
-			v, err := pgxutil.SelectAllDecimal(ctx, tx, tt.sql)
+			v, err := pgxutil.SelectStringArray(ctx, tx, tt.sql)
 			assert.NoErrorf(t, err, "%d. %s", i, tt.sql)
-			if assert.Equalf(t, len(tt.result), len(v), "%d. %s", i, tt.sql) {
-				for j := range v {
-					assert.Equalf(t, tt.result[j], v[j].String(), "%d. %s - %d", i, tt.sql, j)
-				}
+			assert.Equalf(t, tt.result, v, "%d. %s", i, tt.sql)
+		}
+
+		_, err := pgxutil.SelectStringArray(ctx, tx, "select array['foo',null]")
+		assert.Equal(t, pgxutil.ErrNullValue, err)
+
+		_, err = pgxutil.SelectStringArray(ctx, tx, "select '{{a,b}}'::text[]")
+		assert.EqualError(t, err, "expected 1 array dimension, but got 2")
+	})
+}
+
+func TestSelectStringArray2D(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		tests := []struct {
+			sql    string
+			result [][]string
+		}{
+			{"select '{{a,b,c},{d,e,f}}'::text[]", [][]string{{"a", "b", "c"}, {"d", "e", "f"}}},
+			{"select array[array[1,2],array[3,4]]", [][]string{{"1", "2"}, {"3", "4"}}},
+			{"select '{}'::text[]", [][]string{}},
+		}
+		for i, tt := range tests {
+			v, err := pgxutil.SelectStringArray2D(ctx, tx, tt.sql)
+			assert.NoErrorf(t, err, "%d. %s", i, tt.sql)
+			assert.Equalf(t, tt.result, v, "%d. %s", i, tt.sql)
+		}
+
+		_, err := pgxutil.SelectStringArray2D(ctx, tx, "select '{a,b}'::text[]")
+		assert.EqualError(t, err, "expected 2 array dimensions, but got 1")
+	})
+}
+
+func TestSelectIPNet(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		tests := []struct {
+			sql    string
+			result string
+		}{
+			{"select '192.168.1.5/24'::inet", "192.168.1.5/24"},
+			{"select '10.0.0.0/8'::cidr", "10.0.0.0/8"},
+			{"select '::1'::inet", "::1/128"},
+		}
+		for i, tt := range tests {
+			v, err := pgxutil.SelectIPNet(ctx, tx, tt.sql)
+			assert.NoErrorf(t, err, "%d. %s", i, tt.sql)
+			if assert.NotNilf(t, v, "%d. %s", i, tt.sql) {
+				assert.Equalf(t, tt.result, v.String(), "%d. %s", i, tt.sql)
 			}
 		}
+
+		_, err := pgxutil.SelectIPNet(ctx, tx, "select null::inet")
+		assert.Error(t, err)
+
+		column, err := pgxutil.SelectAllIPNet(ctx, tx, "select ('10.0.0.' || n)::inet from generate_series(1,2) n")
+		require.NoError(t, err)
+		require.Len(t, column, 2)
+		assert.Equal(t, "10.0.0.1/32", column[0].String())
+		assert.Equal(t, "10.0.0.2/32", column[1].String())
 	})
 }
 
-func TestSelectUUID(t *testing.T) {
+func TestSelectIP(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		v, err := pgxutil.SelectIP(ctx, tx, "select '192.168.1.5/24'::inet")
+		require.NoError(t, err)
+		assert.True(t, net.ParseIP("192.168.1.5").Equal(v))
+
+		column, err := pgxutil.SelectAllIP(ctx, tx, "select ('10.0.0.' || n)::inet from generate_series(1,2) n")
+		require.NoError(t, err)
+		require.Len(t, column, 2)
+		assert.True(t, net.ParseIP("10.0.0.1").Equal(column[0]))
+		assert.True(t, net.ParseIP("10.0.0.2").Equal(column[1]))
+	})
+}
+
+func TestSelectRange(t *testing.T) {
 	t.Parallel()
 	withTx(t, func(ctx context.Context, tx pgx.Tx) {
 		tests := []struct {
 			sql    string
-			result uuid.UUID
+			result pgxutil.Range[int64]
 		}{
-			{"select '27fd10c1-bccc-4efd-9fea-093f86c95089'::uuid", uuid.FromStringOrNil("27fd10c1-bccc-4efd-9fea-093f86c95089")},
+			{"select '[1,10)'::int8range", pgxutil.Range[int64]{Lower: 1, Upper: 10, LowerInclusive: true}},
+			{"select '[1,10]'::int4range", pgxutil.Range[int64]{Lower: 1, Upper: 11, LowerInclusive: true}},
+			{"select '(,5)'::int8range", pgxutil.Range[int64]{Upper: 5, LowerUnbounded: true}},
+			{"select '[3,)'::int8range", pgxutil.Range[int64]{Lower: 3, LowerInclusive: true, UpperUnbounded: true}},
+			{"select 'empty'::int8range", pgxutil.Range[int64]{Empty: true}},
 		}
 		for i, tt := range tests {
-			v, err := pgxutil.SelectUUID(ctx, tx, tt.sql)
+			v, err := pgxutil.SelectRange[int64](ctx, tx, tt.sql)
 			assert.NoErrorf(t, err, "%d. %s", i, tt.sql)
 			assert.Equalf(t, tt.result, v, "%d. %s", i, tt.sql)
 		}
+
+		dates, err := pgxutil.SelectRange[time.Time](ctx, tx, "select '[2020-01-01,2020-02-01)'::daterange")
+		require.NoError(t, err)
+		assert.Equal(t, time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), dates.Lower)
+		assert.Equal(t, time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC), dates.Upper)
+		assert.True(t, dates.LowerInclusive)
+		assert.False(t, dates.UpperInclusive)
+
+		timestamps, err := pgxutil.SelectRange[time.Time](ctx, tx, "select '[2020-01-01 12:00:00,2020-01-02 12:00:00]'::tsrange")
+		require.NoError(t, err)
+		assert.Equal(t, time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC), timestamps.Lower)
+		assert.Equal(t, time.Date(2020, 1, 2, 12, 0, 0, 0, time.UTC), timestamps.Upper)
+		assert.True(t, timestamps.UpperInclusive)
+
+		numbers, err := pgxutil.SelectRange[float64](ctx, tx, "select '(1.5,2.5]'::numrange")
+		require.NoError(t, err)
+		assert.Equal(t, pgxutil.Range[float64]{Lower: 1.5, Upper: 2.5, UpperInclusive: true}, numbers)
+
+		_, err = pgxutil.SelectRange[int64](ctx, tx, "select null::int8range")
+		assert.Error(t, err)
 	})
 }
 
-func TestSelectAllUUID(t *testing.T) {
+func TestSelectAllRange(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		v, err := pgxutil.SelectAllRange[int64](ctx, tx, "select int8range(n, n+1) from generate_series(1,2) n")
+		require.NoError(t, err)
+		assert.Equal(t, []pgxutil.Range[int64]{
+			{Lower: 1, Upper: 2, LowerInclusive: true},
+			{Lower: 2, Upper: 3, LowerInclusive: true},
+		}, v)
+	})
+}
+
+func TestSelectInterval(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		v, err := pgxutil.SelectInterval(ctx, tx, "select '1 year 2 days 3 seconds'::interval")
+		require.NoError(t, err)
+		assert.Equal(t, pgtype.Interval{Microseconds: 3000000, Days: 2, Months: 12, Status: pgtype.Present}, v)
+
+		column, err := pgxutil.SelectAllInterval(ctx, tx, "select n * '1 day'::interval from generate_series(1,2) n")
+		require.NoError(t, err)
+		assert.Equal(t, []pgtype.Interval{{Days: 1, Status: pgtype.Present}, {Days: 2, Status: pgtype.Present}}, column)
+	})
+}
+
+func TestSelectDuration(t *testing.T) {
 	t.Parallel()
 	withTx(t, func(ctx context.Context, tx pgx.Tx) {
 		tests := []struct {
 			sql    string
-			result []uuid.UUID
+			result time.Duration
 		}{
-			{
-				sql: "select format('27fd10c1-bccc-4efd-9fea-093f86c9508%s', n)::uuid from generate_series(1,2) n",
-				result: []uuid.UUID{
-					uuid.FromStringOrNil("27fd10c1-bccc-4efd-9fea-093f86c95081"),
-					uuid.FromStringOrNil("27fd10c1-bccc-4efd-9fea-093f86c95082"),
-				},
-			},
-			{
-				sql:    "select '27fd10c1-bccc-4efd-9fea-093f86c95089'::uuid where false",
-				result: nil,
-			},
+			{"select '1.5 seconds'::interval", 1500 * time.Millisecond},
+			{"select '1 day 2 hours'::interval", 26 * time.Hour},
+			{"select '-3 minutes'::interval", -3 * time.Minute},
 		}
 		for i, tt := range tests {
-			v, err := pgxutil.SelectAllUUID(ctx, tx, tt.sql)
+			v, err := pgxutil.SelectDuration(ctx, tx, tt.sql)
 			assert.NoErrorf(t, err, "%d. %s", i, tt.sql)
 			assert.Equalf(t, tt.result, v, "%d. %s", i, tt.sql)
 		}
+
+		_, err := pgxutil.SelectDuration(ctx, tx, "select '1 month'::interval")
+		assert.EqualError(t, err, "interval with 1 months cannot be converted to time.Duration")
+
+		column, err := pgxutil.SelectAllDuration(ctx, tx, "select n * '1 second'::interval from generate_series(1,2) n")
+		require.NoError(t, err)
+		assert.Equal(t, []time.Duration{time.Second, 2 * time.Second}, column)
 	})
 }
 
@@ -419,6 +1230,76 @@ func TestSelectAllMap(t *testing.T) {
 	})
 }
 
+func TestSelectMapForEach(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		var rows []map[string]interface{}
+		err := pgxutil.SelectMapForEach(ctx, tx, func(m map[string]interface{}) error {
+			rows = append(rows, m)
+			return nil
+		}, "select n as a, n+1 as b from generate_series(1,2) n")
+		assert.NoError(t, err)
+		assert.Equal(t, []map[string]interface{}{
+			{"a": int32(1), "b": int32(2)},
+			{"a": int32(2), "b": int32(3)},
+		}, rows)
+
+		errStop := errors.New("stop")
+		count := 0
+		err = pgxutil.SelectMapForEach(ctx, tx, func(m map[string]interface{}) error {
+			count++
+			return errStop
+		}, "select n from generate_series(1,10) n")
+		assert.Equal(t, errStop, err)
+		assert.Equal(t, 1, count)
+	})
+}
+
+func TestSelectMapForEachReuse(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		var rows []map[string]interface{}
+		var maps []map[string]interface{}
+		err := pgxutil.SelectMapForEachReuse(ctx, tx, func(m map[string]interface{}) error {
+			row := make(map[string]interface{}, len(m))
+			for k, v := range m {
+				row[k] = v
+			}
+			rows = append(rows, row)
+			maps = append(maps, m)
+			return nil
+		}, "select n as a, case when n = 1 then n end as b from generate_series(1,2) n")
+		assert.NoError(t, err)
+		assert.Equal(t, []map[string]interface{}{
+			{"a": int32(1), "b": int32(1)},
+			{"a": int32(2), "b": nil},
+		}, rows)
+		require.Len(t, maps, 2)
+		assert.Equal(t, reflect.ValueOf(maps[0]).Pointer(), reflect.ValueOf(maps[1]).Pointer())
+	})
+}
+
+func TestSelectAppendMap(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		rows, err := pgxutil.SelectAppendMap(ctx, tx, nil, "select n as a from generate_series(1,2) n")
+		require.NoError(t, err)
+		assert.Equal(t, []map[string]interface{}{{"a": int32(1)}, {"a": int32(2)}}, rows)
+		first := reflect.ValueOf(rows[0]).Pointer()
+
+		rows, err = pgxutil.SelectAppendMap(ctx, tx, rows[:0], "select n as b from generate_series(3,5) n")
+		require.NoError(t, err)
+		assert.Equal(t, []map[string]interface{}{{"b": int32(3)}, {"b": int32(4)}, {"b": int32(5)}}, rows)
+		assert.Equal(t, first, reflect.ValueOf(rows[0]).Pointer())
+
+		rows, err = pgxutil.SelectAppendMap(ctx, tx, rows, "select 6 as c", pgxutil.Options{ExpectedRows: 10})
+		require.NoError(t, err)
+		assert.Len(t, rows, 4)
+		assert.Equal(t, map[string]interface{}{"c": int32(6)}, rows[3])
+		assert.GreaterOrEqual(t, cap(rows), 13)
+	})
+}
+
 func TestSelectStringMap(t *testing.T) {
 	t.Parallel()
 	withTx(t, func(ctx context.Context, tx pgx.Tx) {
@@ -459,6 +1340,24 @@ func TestSelectAllStringMap(t *testing.T) {
 	})
 }
 
+func TestSelectRowScan(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		var name string
+		var height int32
+		err := pgxutil.SelectRowScan(ctx, tx, "select $1::text, $2::int4", []interface{}{"Adam", 72}, &name, &height)
+		assert.NoError(t, err)
+		assert.Equal(t, "Adam", name)
+		assert.Equal(t, int32(72), height)
+
+		err = pgxutil.SelectRowScan(ctx, tx, "select 'Adam', 72 where false", nil, &name, &height)
+		assert.EqualError(t, err, "no rows in result set")
+
+		err = pgxutil.SelectRowScan(ctx, tx, "select 'Adam', 72 from generate_series(1,2)", nil, &name, &height)
+		assert.EqualError(t, err, "multiple rows in result set")
+	})
+}
+
 func TestSelectStructPositionalMapping(t *testing.T) {
 	t.Parallel()
 	withTx(t, func(ctx context.Context, tx pgx.Tx) {
@@ -482,6 +1381,47 @@ func TestSelectStructPositionalMapping(t *testing.T) {
 	})
 }
 
+func TestSelectStructNameMapping(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		type person struct {
+			FirstName string
+			Surname   string `db:"last_name"`
+			Height    int32
+			Ignored   string `db:"-"`
+		}
+
+		tests := []struct {
+			sql      string
+			expected person
+		}{
+			{"select 72 as height, 'Smith' as last_name, 'Adam' as first_name", person{FirstName: "Adam", Surname: "Smith", Height: 72}},
+			{"select 'Adam' as firstname", person{FirstName: "Adam"}},
+		}
+		for i, tt := range tests {
+			var actual person
+			err := pgxutil.SelectStruct(ctx, tx, &actual, tt.sql)
+			assert.NoErrorf(t, err, "%d. %s", i, tt.sql)
+			assert.Equalf(t, tt.expected, actual, "%d. %s", i, tt.sql)
+		}
+
+		errTests := []struct {
+			sql string
+			err string
+		}{
+			{"select 'Adam' as first_name where false", "no rows in result set"},
+			{"select 'Adam' as first_name from generate_series(1,2)", "multiple rows in result set"},
+			{"select 'x' as ignored", "no struct field found for column ignored"},
+			{"select 'x' as missing", "no struct field found for column missing"},
+		}
+		for i, tt := range errTests {
+			var actual person
+			err := pgxutil.SelectStruct(ctx, tx, &actual, tt.sql)
+			assert.EqualErrorf(t, err, tt.err, "%d. %s", i, tt.sql)
+		}
+	})
+}
+
 func TestSelectAllStructPointerPositionalMapping(t *testing.T) {
 	t.Parallel()
 	withTx(t, func(ctx context.Context, tx pgx.Tx) {
@@ -534,6 +1474,88 @@ func TestSelectAllStructPositionalMapping(t *testing.T) {
 	})
 }
 
+func TestSelectAllStructNameMapping(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		type timestamps struct {
+			CreatedAt time.Time `db:"created_at"`
+		}
+
+		type person struct {
+			timestamps
+			Name   string `db:"name"`
+			Height *int32 `db:"height"`
+		}
+
+		createdAt := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		height := int32(72)
+
+		tests := []struct {
+			sql      string
+			expected []person
+		}{
+			{
+				sql: "select 'Adam' as name, 72 as height, '2020-01-01 00:00:00Z'::timestamptz as created_at union all select 'Bill', null, '2020-01-01 00:00:00Z'::timestamptz",
+				expected: []person{
+					{timestamps: timestamps{CreatedAt: createdAt}, Name: "Adam", Height: &height},
+					{timestamps: timestamps{CreatedAt: createdAt}, Name: "Bill", Height: nil},
+				},
+			},
+			{
+				sql:      "select 'Adam' as name where false",
+				expected: nil,
+			},
+		}
+		for i, tt := range tests {
+			var actual []person
+			err := pgxutil.SelectAllStruct(ctx, tx, &actual, tt.sql)
+			assert.NoErrorf(t, err, "%d. %s", i, tt.sql)
+			if assert.Equalf(t, len(tt.expected), len(actual), "%d. %s", i, tt.sql) {
+				for j := range actual {
+					assert.Truef(t, tt.expected[j].CreatedAt.Equal(actual[j].CreatedAt), "%d. %s - %d", i, tt.sql, j)
+					assert.Equalf(t, tt.expected[j].Name, actual[j].Name, "%d. %s - %d", i, tt.sql, j)
+					assert.Equalf(t, tt.expected[j].Height, actual[j].Height, "%d. %s - %d", i, tt.sql, j)
+				}
+			}
+		}
+	})
+}
+
+func TestSelectStructStrict(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		type person struct {
+			Name    string `db:"name"`
+			Height  int32  `db:"height"`
+			Ignored string `db:"-"`
+		}
+
+		var p person
+		err := pgxutil.SelectStructStrict(ctx, tx, &p, "select 'Adam' as name, 72 as height")
+		assert.NoError(t, err)
+		assert.Equal(t, person{Name: "Adam", Height: 72}, p)
+
+		err = pgxutil.SelectStructStrict(ctx, tx, &p, "select 'Adam' as name")
+		assert.EqualError(t, err, "no column found for struct field Height")
+
+		err = pgxutil.SelectStructStrict(ctx, tx, &p, "select 'Adam' as name, 72 as height, 'x' as extra")
+		assert.EqualError(t, err, "no struct field found for column extra")
+
+		type positionalPerson struct {
+			Name   string
+			Height int32
+		}
+
+		var pp positionalPerson
+		err = pgxutil.SelectStructStrict(ctx, tx, &pp, "select 'Adam'")
+		assert.EqualError(t, err, "got 1 values, but dst struct has 2 fields")
+
+		var people []person
+		err = pgxutil.SelectAllStructStrict(ctx, tx, &people, "select 'Adam' as name union all select 'Bill'")
+		assert.EqualError(t, err, "no column found for struct field Height")
+	})
+}
+
 func BenchmarkSelectRow(b *testing.B) {
 	ctx := context.Background()
 	conn := connectPG(b, ctx)
@@ -746,6 +1768,45 @@ func BenchmarkSelectAllRows(b *testing.B) {
 	})
 }
 
+func TestSelectComposite(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		_, err := tx.Exec(ctx, `create type pgxutil_point as (x int, y int);
+create type pgxutil_place as (name text, note text, location pgxutil_point);`)
+		require.NoError(t, err)
+
+		type point struct {
+			X int32
+			Y int32
+		}
+
+		type place struct {
+			Name     string
+			Note     *string
+			Location *point
+		}
+
+		var p point
+		err = pgxutil.SelectComposite(ctx, tx, &p, "select row(1, 2)::pgxutil_point")
+		require.NoError(t, err)
+		assert.Equal(t, point{X: 1, Y: 2}, p)
+
+		var pl place
+		err = pgxutil.SelectComposite(ctx, tx, &pl, "select row('home', null, row(3, 4))::pgxutil_place")
+		require.NoError(t, err)
+		assert.Equal(t, place{Name: "home", Location: &point{X: 3, Y: 4}}, pl)
+
+		err = pgxutil.SelectComposite(ctx, tx, &struct{ X int32 }{}, "select row(1, 2)::pgxutil_point")
+		assert.EqualError(t, err, "got 2 composite fields, but dst struct has 1 fields")
+
+		err = pgxutil.SelectComposite(ctx, tx, &p, "select null::pgxutil_point")
+		assert.Error(t, err)
+
+		err = pgxutil.SelectComposite(ctx, tx, &p, "select row(1, 2)::pgxutil_point where false")
+		assert.Equal(t, pgxutil.ErrNoRows, err)
+	})
+}
+
 func TestInsert(t *testing.T) {
 	t.Parallel()
 	withTx(t, func(ctx context.Context, tx pgx.Tx) {
@@ -764,6 +1825,138 @@ func TestInsert(t *testing.T) {
 	})
 }
 
+func TestInsertReturning(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		_, err := tx.Exec(ctx, `create temporary table t (id serial primary key, name text, height int)`)
+		require.NoError(t, err)
+		returningRow, err := pgxutil.InsertReturning(ctx, tx, "t", map[string]interface{}{"name": "Adam", "height": 72}, "id")
+		require.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"id": int32(1)}, returningRow)
+	})
+}
+
+func TestInsertReturningStruct(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		_, err := tx.Exec(ctx, `create temporary table t (id serial primary key, name text, height int)`)
+		require.NoError(t, err)
+
+		type person struct {
+			ID     int32  `db:"id"`
+			Name   string `db:"name"`
+			Height int32  `db:"height"`
+		}
+
+		var p person
+		err = pgxutil.InsertReturningStruct(ctx, tx, &p, "t", map[string]interface{}{"name": "Adam", "height": 72})
+		require.NoError(t, err)
+		assert.Equal(t, person{ID: 1, Name: "Adam", Height: 72}, p)
+	})
+}
+
+func TestInsertRows(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		_, err := tx.Exec(ctx, `create temporary table t (id serial primary key, name text, height int)`)
+		require.NoError(t, err)
+
+		for _, rowCount := range []int{0, 2, 250} {
+			_, err = tx.Exec(ctx, `truncate t`)
+			require.NoError(t, err)
+
+			rows := make([]map[string]interface{}, rowCount)
+			for i := range rows {
+				rows[i] = map[string]interface{}{"name": fmt.Sprintf("Person %d", i), "height": i}
+			}
+
+			insertCount, err := pgxutil.InsertRows(ctx, tx, "t", rows)
+			require.NoErrorf(t, err, "%d", rowCount)
+			assert.EqualValuesf(t, rowCount, insertCount, "%d", rowCount)
+
+			n, err := pgxutil.SelectInt64(ctx, tx, "select count(*) from t where name = 'Person ' || height")
+			require.NoErrorf(t, err, "%d", rowCount)
+			assert.EqualValuesf(t, rowCount, n, "%d", rowCount)
+		}
+
+		_, err = pgxutil.InsertRows(ctx, tx, "t", []map[string]interface{}{{"name": "Adam"}, {"height": 72}})
+		assert.EqualError(t, err, "row 1 does not have column name")
+
+		_, err = pgxutil.InsertRows(ctx, tx, "t", []map[string]interface{}{{}})
+		assert.EqualError(t, err, "row 0 has no columns")
+	})
+}
+
+func TestInsertRowsTableName(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		_, err := tx.Exec(ctx, `create temporary table "Widgets.v2" (name text)`)
+		require.NoError(t, err)
+
+		// Both the insert and the copy path use the same quoted, schema qualified name.
+		for _, rowCount := range []int{1, 200} {
+			rows := make([]map[string]interface{}, rowCount)
+			for i := range rows {
+				rows[i] = map[string]interface{}{"name": fmt.Sprintf("widget %d", i)}
+			}
+			insertCount, err := pgxutil.InsertRows(ctx, tx, `PG_TEMP."Widgets.v2"`, rows)
+			require.NoErrorf(t, err, "%d", rowCount)
+			assert.EqualValuesf(t, rowCount, insertCount, "%d", rowCount)
+		}
+
+		_, err = pgxutil.InsertRows(ctx, tx, `pg_temp."Widgets`, []map[string]interface{}{{"name": "widget"}})
+		assert.Error(t, err)
+	})
+}
+
+func TestUpsert(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		_, err := tx.Exec(ctx, `create temporary table t (id int primary key, name text, height int)`)
+		require.NoError(t, err)
+
+		row, err := pgxutil.Upsert(ctx, tx, "t", map[string]interface{}{"id": 1, "name": "Adam", "height": 72}, []string{"id"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"id": int32(1), "name": "Adam", "height": int32(72)}, row)
+
+		row, err = pgxutil.Upsert(ctx, tx, "t", map[string]interface{}{"id": 1, "name": "Adam", "height": 74}, []string{"id"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"id": int32(1), "name": "Adam", "height": int32(74)}, row)
+
+		row, err = pgxutil.Upsert(ctx, tx, "t", map[string]interface{}{"id": 1}, []string{"id"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"id": int32(1), "name": "Adam", "height": int32(74)}, row)
+
+		n, err := pgxutil.SelectInt64(ctx, tx, "select count(*) from t")
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, n)
+	})
+}
+
+func TestInsertOnConflictDoNothing(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		_, err := tx.Exec(ctx, `create temporary table t (id int primary key, name text, height int)`)
+		require.NoError(t, err)
+
+		insertCount, err := pgxutil.InsertOnConflictDoNothing(ctx, tx, "t", map[string]interface{}{"id": 1, "name": "Adam", "height": 72}, []string{"id"})
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, insertCount)
+
+		insertCount, err = pgxutil.InsertOnConflictDoNothing(ctx, tx, "t", map[string]interface{}{"id": 1, "name": "Bill", "height": 68}, []string{"id"})
+		require.NoError(t, err)
+		assert.EqualValues(t, 0, insertCount)
+
+		insertCount, err = pgxutil.InsertOnConflictDoNothing(ctx, tx, "t", map[string]interface{}{"id": 1, "name": "Bill", "height": 68}, nil)
+		require.NoError(t, err)
+		assert.EqualValues(t, 0, insertCount)
+
+		name, err := pgxutil.SelectString(ctx, tx, "select name from t where id = 1")
+		require.NoError(t, err)
+		assert.Equal(t, "Adam", name)
+	})
+}
+
 func TestUpdateWithoutWhere(t *testing.T) {
 	t.Parallel()
 	withTx(t, func(ctx context.Context, tx pgx.Tx) {
@@ -812,3 +2005,120 @@ func TestUpdateWithWhere(t *testing.T) {
 		assert.Equal(t, row2, freshRow2)
 	})
 }
+
+func TestUpdateReturning(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		_, err := tx.Exec(ctx, `create temporary table t (id serial primary key, name text, height int)`)
+		require.NoError(t, err)
+		row1, err := pgxutil.Insert(ctx, tx, "t", map[string]interface{}{"name": "Adam", "height": 72})
+		require.NoError(t, err)
+		_, err = pgxutil.Insert(ctx, tx, "t", map[string]interface{}{"name": "Bill", "height": 68})
+		require.NoError(t, err)
+
+		updatedRows, err := pgxutil.UpdateReturning(ctx, tx, "t", map[string]interface{}{"height": 99}, map[string]interface{}{"id": row1["id"]})
+		require.NoError(t, err)
+		assert.Equal(t, []map[string]interface{}{{"id": row1["id"], "name": "Adam", "height": int32(99)}}, updatedRows)
+	})
+}
+
+func TestUpdateNoValues(t *testing.T) {
+	t.Parallel()
+
+	db := &flakyDB{}
+	_, err := pgxutil.Update(context.Background(), db, "t", nil, map[string]interface{}{"id": 1})
+	assert.EqualError(t, err, "setValues must not be empty")
+	_, err = pgxutil.UpdateReturning(context.Background(), db, "t", map[string]interface{}{}, nil)
+	assert.EqualError(t, err, "setValues must not be empty")
+	assert.Equal(t, 0, db.attempts)
+}
+
+func TestInsertMixedCaseKeys(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		_, err := tx.Exec(ctx, `create temporary table t (id serial primary key, "UserID" int, username text)`)
+		require.NoError(t, err)
+
+		// Keys are quoted, so they match the case of the columns exactly.
+		row, err := pgxutil.Insert(ctx, tx, "t", map[string]interface{}{"UserID": 1, "username": "adam"})
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, row["UserID"])
+
+		_, err = pgxutil.Insert(ctx, tx, "t", map[string]interface{}{"UserName": "bill"})
+		assert.Error(t, err)
+	})
+}
+
+func TestUpdateVersioned(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		_, err := tx.Exec(ctx, `create temporary table t (id serial primary key, name text, version int not null default 1)`)
+		require.NoError(t, err)
+		row, err := pgxutil.Insert(ctx, tx, "t", map[string]interface{}{"name": "Adam"})
+		require.NoError(t, err)
+
+		updateCount, err := pgxutil.UpdateVersioned(ctx, tx, "t", map[string]interface{}{"name": "Bill"}, map[string]interface{}{"id": row["id"]}, "version", 1)
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, updateCount)
+
+		freshRow, err := pgxutil.SelectMap(ctx, tx, "select * from t where id=$1", row["id"])
+		require.NoError(t, err)
+		assert.Equal(t, "Bill", freshRow["name"])
+		assert.Equal(t, int32(2), freshRow["version"])
+
+		_, err = pgxutil.UpdateVersioned(ctx, tx, "t", map[string]interface{}{"name": "Charlie"}, map[string]interface{}{"id": row["id"]}, "version", 1)
+		assert.ErrorIs(t, err, pgxutil.ErrStaleRow)
+
+		_, err = pgxutil.UpdateVersioned(ctx, tx, "t", nil, map[string]interface{}{"id": row["id"]}, "version", 2)
+		require.NoError(t, err)
+		version, err := pgxutil.SelectInt64(ctx, tx, "select version from t where id=$1", row["id"])
+		require.NoError(t, err)
+		assert.EqualValues(t, 3, version)
+	})
+}
+
+func TestDelete(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		_, err := tx.Exec(ctx, `create temporary table t (id serial primary key, name text, height int)`)
+		require.NoError(t, err)
+		row1, err := pgxutil.Insert(ctx, tx, "t", map[string]interface{}{"name": "Adam", "height": 72})
+		require.NoError(t, err)
+		_, err = pgxutil.Insert(ctx, tx, "t", map[string]interface{}{"name": "Bill", "height": 68})
+		require.NoError(t, err)
+		_, err = pgxutil.Insert(ctx, tx, "t", map[string]interface{}{"name": "Charlie", "height": 68})
+		require.NoError(t, err)
+
+		deleteCount, err := pgxutil.Delete(ctx, tx, "t", map[string]interface{}{"id": row1["id"]})
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, deleteCount)
+
+		deleteCount, err = pgxutil.Delete(ctx, tx, "t", nil)
+		require.NoError(t, err)
+		assert.EqualValues(t, 2, deleteCount)
+	})
+}
+
+func TestDeleteOne(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		_, err := tx.Exec(ctx, `create temporary table t (id serial primary key, name text, height int)`)
+		require.NoError(t, err)
+		row1, err := pgxutil.Insert(ctx, tx, "t", map[string]interface{}{"name": "Adam", "height": 72})
+		require.NoError(t, err)
+		_, err = pgxutil.Insert(ctx, tx, "t", map[string]interface{}{"name": "Bill", "height": 68})
+		require.NoError(t, err)
+		_, err = pgxutil.Insert(ctx, tx, "t", map[string]interface{}{"name": "Charlie", "height": 68})
+		require.NoError(t, err)
+
+		err = pgxutil.DeleteOne(ctx, tx, "t", map[string]interface{}{"height": 68})
+		assert.EqualError(t, err, "expected 1 row to be deleted, but 2 rows matched")
+
+		err = pgxutil.DeleteOne(ctx, tx, "t", map[string]interface{}{"id": row1["id"]})
+		require.NoError(t, err)
+
+		n, err := pgxutil.SelectInt64(ctx, tx, "select count(*) from t")
+		require.NoError(t, err)
+		assert.EqualValues(t, 2, n)
+	})
+}
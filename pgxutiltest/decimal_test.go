@@ -0,0 +1,27 @@
+//go:build !pgxutil_nodecimal
+
+package pgxutiltest_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgxutil"
+	"github.com/jackc/pgxutil/pgxutiltest"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFakeQueryerTypes(t *testing.T) {
+	t.Parallel()
+
+	db := &pgxutiltest.FakeQueryer{}
+	db.ExpectQuery("select price from products").
+		ReturnRows(pgxutiltest.NewRows("price").Types(pgtype.NumericOID).AddRow("12.34"))
+
+	price, err := pgxutil.SelectDecimal(context.Background(), db, "select price from products")
+	require.NoError(t, err)
+	assert.True(t, decimal.RequireFromString("12.34").Equal(price))
+}
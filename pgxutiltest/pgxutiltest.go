@@ -0,0 +1,410 @@
+// Package pgxutiltest provides helpers for testing code that uses pgxutil.
+package pgxutiltest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgproto3/v2"
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+)
+
+// FakeQueryer is a pgxutil.Queryer that returns canned results instead of sending queries to PostgreSQL. It allows
+// unit testing code that uses the pgxutil Select functions without a database. Each query must match the next
+// expectation added with ExpectQuery. Queries that do not match return an error.
+//
+// The zero value is ready to use. A FakeQueryer is safe for concurrent use.
+type FakeQueryer struct {
+	mu           sync.Mutex
+	expectations []*Expectation
+	next         int
+}
+
+// Expectation is an expected query and the result returned for it.
+type Expectation struct {
+	sql       string
+	args      []interface{}
+	checkArgs bool
+	rows      *Rows
+	err       error
+}
+
+// ExpectQuery adds an expectation for a query with sql. sql is compared with the query after NamedArgs are rewritten so
+// it must use positional parameters. Differences in whitespace are ignored. By default the query returns no rows.
+func (f *FakeQueryer) ExpectQuery(sql string) *Expectation {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	e := &Expectation{sql: sql}
+	f.expectations = append(f.expectations, e)
+	return e
+}
+
+// WithArgs requires the query to have args. They are compared with reflect.DeepEqual so their types must match exactly.
+// pgx options such as pgx.QueryResultFormats are not compared. If WithArgs is not called the args are not checked.
+func (e *Expectation) WithArgs(args ...interface{}) *Expectation {
+	e.args = args
+	e.checkArgs = true
+	return e
+}
+
+// ReturnRows makes the query return rows.
+func (e *Expectation) ReturnRows(rows *Rows) *Expectation {
+	e.rows = rows
+	return e
+}
+
+// ReturnError makes the query fail with err.
+func (e *Expectation) ReturnError(err error) *Expectation {
+	e.err = err
+	return e
+}
+
+// Query implements pgxutil.Queryer.
+func (f *FakeQueryer) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	rows, err := f.query(sql, args)
+	if err != nil {
+		return &fakeRows{err: err, closed: true}, err
+	}
+
+	return rows, nil
+}
+
+func (f *FakeQueryer) query(sql string, args []interface{}) (*fakeRows, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.next >= len(f.expectations) {
+		return nil, fmt.Errorf("unexpected query %q", sql)
+	}
+
+	e := f.expectations[f.next]
+	if normalizeSQL(sql) != normalizeSQL(e.sql) {
+		return nil, fmt.Errorf("unexpected query %q, expected %q", sql, e.sql)
+	}
+
+	queryArgs, formats := splitArgs(args)
+	if e.checkArgs && !reflect.DeepEqual(queryArgs, e.args) && !(len(queryArgs) == 0 && len(e.args) == 0) {
+		return nil, fmt.Errorf("query %q has args %v, expected %v", sql, queryArgs, e.args)
+	}
+	f.next++
+
+	if e.err != nil {
+		return nil, e.err
+	}
+
+	rows := e.rows
+	if rows == nil {
+		rows = NewRows()
+	}
+	return rows.encode(formats)
+}
+
+// ExpectationsWereMet returns an error if any expected query has not been sent.
+func (f *FakeQueryer) ExpectationsWereMet() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.next < len(f.expectations) {
+		return fmt.Errorf("%d expected queries were not sent, the next is %q", len(f.expectations)-f.next, f.expectations[f.next].sql)
+	}
+
+	return nil
+}
+
+func normalizeSQL(sql string) string {
+	return strings.Join(strings.Fields(sql), " ")
+}
+
+// resultFormats describes the result formats requested by the pgx options in the args of a query.
+type resultFormats struct {
+	formats      pgx.QueryResultFormats
+	formatsByOID pgx.QueryResultFormatsByOID
+	simple       bool
+}
+
+func (rf resultFormats) formatFor(ci *pgtype.ConnInfo, i int, oid uint32) int16 {
+	switch {
+	case rf.simple:
+		return pgx.TextFormatCode
+	case len(rf.formats) == 1:
+		return rf.formats[0]
+	case rf.formats != nil:
+		if i < len(rf.formats) {
+			return rf.formats[i]
+		}
+		return pgx.TextFormatCode
+	case rf.formatsByOID != nil:
+		return rf.formatsByOID[oid]
+	default:
+		return ci.ResultFormatCodeForOID(oid)
+	}
+}
+
+// splitArgs separates the pgx options in args from the query arguments in the same way as pgx.
+func splitArgs(args []interface{}) ([]interface{}, resultFormats) {
+	var rf resultFormats
+	for len(args) > 0 {
+		switch arg := args[0].(type) {
+		case pgx.QueryResultFormats:
+			rf.formats = arg
+		case pgx.QueryResultFormatsByOID:
+			rf.formatsByOID = arg
+		case pgx.QuerySimpleProtocol:
+			rf.simple = bool(arg)
+		default:
+			return args, rf
+		}
+		args = args[1:]
+	}
+
+	return args, rf
+}
+
+// Rows is the result of a query returned by FakeQueryer.
+type Rows struct {
+	columns []string
+	oids    []uint32
+	values  [][]interface{}
+}
+
+// NewRows returns a result with columns and no rows.
+func NewRows(columns ...string) *Rows {
+	return &Rows{columns: columns, oids: make([]uint32, len(columns))}
+}
+
+// AddRow adds a row with values. There must be a value for each column. A nil value is a null.
+func (r *Rows) AddRow(values ...interface{}) *Rows {
+	if len(values) != len(r.columns) {
+		panic(fmt.Sprintf("pgxutiltest: row has %d values but there are %d columns", len(values), len(r.columns)))
+	}
+
+	r.values = append(r.values, values)
+	return r
+}
+
+// Types sets the data type OIDs of the columns, e.g. pgtype.NumericOID. The type of a column with a zero OID is
+// determined from its first non-null value in the same way pgx chooses the type of an argument, e.g. text for a
+// string. It is text if every value is null. Types is needed for values such as strings that are sent as another
+// type.
+func (r *Rows) Types(oids ...uint32) *Rows {
+	if len(oids) != len(r.columns) {
+		panic(fmt.Sprintf("pgxutiltest: %d types given but there are %d columns", len(oids), len(r.columns)))
+	}
+
+	r.oids = oids
+	return r
+}
+
+// encode returns the rows encoded in the wire format as PostgreSQL would send them.
+func (r *Rows) encode(rf resultFormats) (*fakeRows, error) {
+	ci := pgtype.NewConnInfo()
+
+	fields := make([]pgproto3.FieldDescription, len(r.columns))
+	dataTypes := make([]*pgtype.DataType, len(r.columns))
+	for i, name := range r.columns {
+		oid := r.oids[i]
+		if oid == 0 {
+			oid = pgtype.TextOID
+			for _, row := range r.values {
+				if row[i] == nil {
+					continue
+				}
+
+				dt, ok := ci.DataTypeForValue(row[i])
+				if !ok {
+					return nil, fmt.Errorf("cannot determine the type of column %s from %T", name, row[i])
+				}
+				oid = dt.OID
+				break
+			}
+		}
+
+		dt, ok := ci.DataTypeForOID(oid)
+		if !ok {
+			return nil, fmt.Errorf("column %s has unknown type %d", name, oid)
+		}
+		dataTypes[i] = dt
+		fields[i] = pgproto3.FieldDescription{
+			Name:         []byte(name),
+			DataTypeOID:  oid,
+			DataTypeSize: -1,
+			TypeModifier: -1,
+			Format:       rf.formatFor(ci, i, oid),
+		}
+	}
+
+	values := make([][][]byte, len(r.values))
+	for n, row := range r.values {
+		values[n] = make([][]byte, len(row))
+		for i, v := range row {
+			if v == nil {
+				continue
+			}
+
+			buf, err := encodeValue(ci, dataTypes[i], fields[i].Format, v)
+			if err != nil {
+				return nil, fmt.Errorf("row %d column %s: %w", n, r.columns[i], err)
+			}
+			values[n][i] = buf
+		}
+	}
+
+	return &fakeRows{ci: ci, fields: fields, values: values, row: -1}, nil
+}
+
+func encodeValue(ci *pgtype.ConnInfo, dt *pgtype.DataType, format int16, v interface{}) ([]byte, error) {
+	value := pgtype.NewValue(dt.Value)
+	err := value.Set(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf []byte
+	if format == pgx.BinaryFormatCode {
+		encoder, ok := value.(pgtype.BinaryEncoder)
+		if !ok {
+			return nil, fmt.Errorf("%s cannot be encoded in the binary format", dt.Name)
+		}
+		buf, err = encoder.EncodeBinary(ci, nil)
+	} else {
+		encoder, ok := value.(pgtype.TextEncoder)
+		if !ok {
+			return nil, fmt.Errorf("%s cannot be encoded in the text format", dt.Name)
+		}
+		buf, err = encoder.EncodeText(ci, nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if buf == nil {
+		// A value such as pgtype.Null that encodes to nothing is a null. Otherwise an empty value must not be mistaken
+		// for a null.
+		if value.Get() == nil {
+			return nil, nil
+		}
+		buf = []byte{}
+	}
+
+	return buf, nil
+}
+
+// fakeRows implements pgx.Rows for the encoded values of Rows.
+type fakeRows struct {
+	ci     *pgtype.ConnInfo
+	fields []pgproto3.FieldDescription
+	values [][][]byte
+	row    int
+	err    error
+	closed bool
+}
+
+func (r *fakeRows) Close() {
+	r.closed = true
+}
+
+func (r *fakeRows) Err() error {
+	return r.err
+}
+
+func (r *fakeRows) CommandTag() pgconn.CommandTag {
+	return pgconn.CommandTag(fmt.Sprintf("SELECT %d", len(r.values)))
+}
+
+func (r *fakeRows) FieldDescriptions() []pgproto3.FieldDescription {
+	return r.fields
+}
+
+func (r *fakeRows) Next() bool {
+	if r.closed {
+		return false
+	}
+
+	r.row++
+	if r.row >= len(r.values) {
+		r.Close()
+		return false
+	}
+
+	return true
+}
+
+func (r *fakeRows) Scan(dest ...interface{}) error {
+	if len(dest) != len(r.fields) {
+		err := fmt.Errorf("number of field descriptions must equal number of destinations, got %d and %d", len(r.fields), len(dest))
+		r.fatal(err)
+		return err
+	}
+
+	for i, dst := range dest {
+		if dst == nil {
+			continue
+		}
+
+		err := r.ci.Scan(r.fields[i].DataTypeOID, r.fields[i].Format, r.values[r.row][i], dst)
+		if err != nil {
+			err = fmt.Errorf("can't scan into dest[%d]: %w", i, err)
+			r.fatal(err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *fakeRows) Values() ([]interface{}, error) {
+	if r.closed {
+		return nil, errors.New("rows is closed")
+	}
+
+	values := make([]interface{}, len(r.fields))
+	for i, buf := range r.values[r.row] {
+		if buf == nil {
+			continue
+		}
+
+		dt, _ := r.ci.DataTypeForOID(r.fields[i].DataTypeOID)
+		value := pgtype.NewValue(dt.Value)
+		err := decodeValue(r.ci, value, r.fields[i].Format, buf)
+		if err != nil {
+			r.fatal(err)
+			return nil, err
+		}
+		values[i] = value.Get()
+	}
+
+	return values, nil
+}
+
+func decodeValue(ci *pgtype.ConnInfo, value pgtype.Value, format int16, buf []byte) error {
+	if format == pgx.BinaryFormatCode {
+		decoder, ok := value.(pgtype.BinaryDecoder)
+		if !ok {
+			return fmt.Errorf("%T cannot be decoded from the binary format", value)
+		}
+		return decoder.DecodeBinary(ci, buf)
+	}
+
+	decoder, ok := value.(pgtype.TextDecoder)
+	if !ok {
+		return fmt.Errorf("%T cannot be decoded from the text format", value)
+	}
+	return decoder.DecodeText(ci, buf)
+}
+
+func (r *fakeRows) RawValues() [][]byte {
+	return r.values[r.row]
+}
+
+func (r *fakeRows) fatal(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+	r.Close()
+}
@@ -0,0 +1,85 @@
+package pgxutiltest_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgxutil"
+	"github.com/jackc/pgxutil/pgxutiltest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _ pgxutil.Queryer = (*pgxutiltest.FakeQueryer)(nil)
+
+func TestFakeQueryer(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	db := &pgxutiltest.FakeQueryer{}
+	db.ExpectQuery("select name from users where id = $1").
+		WithArgs(int64(1)).
+		ReturnRows(pgxutiltest.NewRows("name").AddRow("Adam"))
+	db.ExpectQuery("select id, name from users order by id").
+		ReturnRows(pgxutiltest.NewRows("id", "name").AddRow(int32(1), "Adam").AddRow(int32(2), nil))
+
+	name, err := pgxutil.SelectString(ctx, db, "select name from users where id = :id", pgxutil.NamedArgs{"id": int64(1)})
+	require.NoError(t, err)
+	assert.Equal(t, "Adam", name)
+
+	users, err := pgxutil.SelectAllMap(ctx, db, `select id, name
+from users
+order by id`)
+	require.NoError(t, err)
+	assert.Equal(t, []map[string]interface{}{{"id": int32(1), "name": "Adam"}, {"id": int32(2), "name": nil}}, users)
+
+	require.NoError(t, db.ExpectationsWereMet())
+}
+
+func TestFakeQueryerStruct(t *testing.T) {
+	t.Parallel()
+
+	type user struct {
+		ID        int64
+		Name      string
+		CreatedAt time.Time
+	}
+
+	createdAt := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	db := &pgxutiltest.FakeQueryer{}
+	db.ExpectQuery("select * from users").
+		ReturnRows(pgxutiltest.NewRows("id", "name", "created_at").AddRow(1, "Adam", createdAt))
+
+	var u user
+	err := pgxutil.SelectStruct(context.Background(), db, &u, "select * from users")
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, u.ID)
+	assert.Equal(t, "Adam", u.Name)
+	assert.True(t, createdAt.Equal(u.CreatedAt))
+}
+
+func TestFakeQueryerErrors(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	db := &pgxutiltest.FakeQueryer{}
+	db.ExpectQuery("select 1").ReturnError(errors.New("boom"))
+	db.ExpectQuery("select $1::int8").WithArgs(int64(2))
+	db.ExpectQuery("select 3")
+
+	_, err := pgxutil.SelectInt64(ctx, db, "select 1")
+	require.EqualError(t, err, `query "select 1": boom`)
+
+	_, err = pgxutil.SelectInt64(ctx, db, "select $1::int8", 3)
+	require.EqualError(t, err, `query "select $1::int8": query "select $1::int8" has args [3], expected [2]`)
+
+	_, err = pgxutil.SelectInt64(ctx, db, "select $1::int8", int64(2))
+	require.ErrorIs(t, err, pgxutil.ErrNoRows)
+
+	_, err = pgxutil.SelectInt64(ctx, db, "select 4")
+	require.EqualError(t, err, `query "select 4": unexpected query "select 4", expected "select 3"`)
+
+	require.EqualError(t, db.ExpectationsWereMet(), `1 expected queries were not sent, the next is "select 3"`)
+}
@@ -0,0 +1,65 @@
+package pgxutiltest
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// WithSchema creates a uniquely named schema and calls fn with a pool whose connections have the schema first in their
+// search_path, followed by public. Tables and other objects created without a schema name are created in the new
+// schema. The pool is closed and the schema is dropped with everything in it when fn returns, even if the test fails.
+// This allows integration tests that need real DDL or commits to run in parallel without interfering with each other.
+//
+// The new pool uses the configuration of pool. Any error setting up the schema fails the test immediately.
+func WithSchema(t testing.TB, pool *pgxpool.Pool, fn func(db *pgxpool.Pool)) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	name, err := uniqueName("pgxutiltest")
+	if err != nil {
+		t.Fatalf("pgxutiltest: generate schema name: %v", err)
+	}
+	schema := pgx.Identifier{name}.Sanitize()
+
+	_, err = pool.Exec(ctx, fmt.Sprintf("create schema %s", schema))
+	if err != nil {
+		t.Fatalf("pgxutiltest: create schema: %v", err)
+	}
+	defer func() {
+		_, err := pool.Exec(ctx, fmt.Sprintf("drop schema %s cascade", schema))
+		if err != nil {
+			t.Errorf("pgxutiltest: drop schema: %v", err)
+		}
+	}()
+
+	config := pool.Config()
+	if config.ConnConfig.RuntimeParams == nil {
+		config.ConnConfig.RuntimeParams = make(map[string]string)
+	}
+	config.ConnConfig.RuntimeParams["search_path"] = schema + ", public"
+	db, err := pgxpool.ConnectConfig(ctx, config)
+	if err != nil {
+		t.Fatalf("pgxutiltest: connect: %v", err)
+	}
+	defer db.Close()
+
+	fn(db)
+}
+
+// uniqueName returns prefix followed by a random suffix.
+func uniqueName(prefix string) (string, error) {
+	b := make([]byte, 8)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	return prefix + "_" + hex.EncodeToString(b), nil
+}
@@ -0,0 +1,74 @@
+package pgxutiltest_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/jackc/pgxutil"
+	"github.com/jackc/pgxutil/pgxutiltest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func connectPool(t testing.TB, ctx context.Context) *pgxpool.Pool {
+	pool, err := pgxpool.Connect(ctx, fmt.Sprintf("database=%s", os.Getenv("TEST_DATABASE")))
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+	return pool
+}
+
+func TestWithSchema(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	pool := connectPool(t, ctx)
+
+	var schema string
+	pgxutiltest.WithSchema(t, pool, func(db *pgxpool.Pool) {
+		var err error
+		schema, err = pgxutil.SelectString(ctx, db, "select current_schema()")
+		require.NoError(t, err)
+		assert.Regexp(t, "^pgxutiltest_", schema)
+
+		_, err = db.Exec(ctx, "create table widgets (id int primary key)")
+		require.NoError(t, err)
+
+		_, err = pgxutil.Insert(ctx, db, "widgets", map[string]interface{}{"id": 1})
+		require.NoError(t, err)
+
+		n, err := pgxutil.SelectInt64(ctx, db, "select count(*) from widgets")
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, n)
+	})
+
+	exists, err := pgxutil.SelectExists(ctx, pool, "select 1 from pg_namespace where nspname = $1", schema)
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestWithSchemaIsolation(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	pool := connectPool(t, ctx)
+
+	pgxutiltest.WithSchema(t, pool, func(db1 *pgxpool.Pool) {
+		pgxutiltest.WithSchema(t, pool, func(db2 *pgxpool.Pool) {
+			_, err := db1.Exec(ctx, "create table widgets (id int)")
+			require.NoError(t, err)
+
+			_, err = db2.Exec(ctx, "create table widgets (id int)")
+			require.NoError(t, err)
+
+			_, err = db1.Exec(ctx, "insert into widgets values (1)")
+			require.NoError(t, err)
+
+			n, err := pgxutil.SelectInt64(ctx, db2, "select count(*) from widgets")
+			require.NoError(t, err)
+			assert.EqualValues(t, 0, n)
+		})
+	})
+}
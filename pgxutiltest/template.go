@@ -0,0 +1,101 @@
+package pgxutiltest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// TemplateDatabase is a database prepared once, e.g. by running migrations and loading fixtures, that is cloned for
+// each test with CREATE DATABASE ... TEMPLATE. Cloning a database is much faster than preparing it again. It is
+// typically created in TestMain.
+type TemplateDatabase struct {
+	// Name is the name of the template database.
+	Name string
+
+	pool *pgxpool.Pool
+}
+
+// CreateTemplateDatabase creates the database name, replacing any existing database with that name, and calls setup
+// with a pool connected to it. pool is used to create and drop databases so it must be connected to another database
+// as a user that can create databases. The pool passed to setup is closed when setup returns as a template cannot be
+// cloned while anyone is connected to it. If setup fails the database is dropped.
+func CreateTemplateDatabase(ctx context.Context, pool *pgxpool.Pool, name string, setup func(ctx context.Context, db *pgxpool.Pool) error) (*TemplateDatabase, error) {
+	td := &TemplateDatabase{Name: name, pool: pool}
+
+	_, err := pool.Exec(ctx, fmt.Sprintf("drop database if exists %s", pgx.Identifier{name}.Sanitize()))
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = pool.Exec(ctx, fmt.Sprintf("create database %s", pgx.Identifier{name}.Sanitize()))
+	if err != nil {
+		return nil, err
+	}
+
+	err = td.setup(ctx, setup)
+	if err != nil {
+		td.Drop(ctx)
+		return nil, err
+	}
+
+	return td, nil
+}
+
+func (td *TemplateDatabase) setup(ctx context.Context, setup func(ctx context.Context, db *pgxpool.Pool) error) error {
+	db, err := td.connect(ctx, td.Name)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return setup(ctx, db)
+}
+
+func (td *TemplateDatabase) connect(ctx context.Context, database string) (*pgxpool.Pool, error) {
+	config := td.pool.Config()
+	config.ConnConfig.Database = database
+	return pgxpool.ConnectConfig(ctx, config)
+}
+
+// Clone creates a uniquely named copy of the template database and calls fn with a pool connected to it. The pool is
+// closed and the copy is dropped when fn returns, even if the test fails. Any error creating the copy fails the test
+// immediately. Clones may be used by parallel tests.
+func (td *TemplateDatabase) Clone(t testing.TB, fn func(db *pgxpool.Pool)) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	name, err := uniqueName(td.Name)
+	if err != nil {
+		t.Fatalf("pgxutiltest: generate database name: %v", err)
+	}
+
+	_, err = td.pool.Exec(ctx, fmt.Sprintf("create database %s template %s", pgx.Identifier{name}.Sanitize(), pgx.Identifier{td.Name}.Sanitize()))
+	if err != nil {
+		t.Fatalf("pgxutiltest: clone database: %v", err)
+	}
+	defer func() {
+		_, err := td.pool.Exec(ctx, fmt.Sprintf("drop database %s", pgx.Identifier{name}.Sanitize()))
+		if err != nil {
+			t.Errorf("pgxutiltest: drop database: %v", err)
+		}
+	}()
+
+	db, err := td.connect(ctx, name)
+	if err != nil {
+		t.Fatalf("pgxutiltest: connect: %v", err)
+	}
+	defer db.Close()
+
+	fn(db)
+}
+
+// Drop drops the template database. Clones that have not been dropped are not affected.
+func (td *TemplateDatabase) Drop(ctx context.Context) error {
+	_, err := td.pool.Exec(ctx, fmt.Sprintf("drop database if exists %s", pgx.Identifier{td.Name}.Sanitize()))
+	return err
+}
@@ -0,0 +1,45 @@
+package pgxutiltest_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/jackc/pgxutil"
+	"github.com/jackc/pgxutil/pgxutiltest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplateDatabase(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	pool := connectPool(t, ctx)
+
+	td, err := pgxutiltest.CreateTemplateDatabase(ctx, pool, "pgxutiltest_template", func(ctx context.Context, db *pgxpool.Pool) error {
+		return pgxutil.MultiExec(ctx, db, []string{
+			"create table widgets (id int primary key, name text not null)",
+			"insert into widgets values (1, 'foo')",
+		})
+	})
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, td.Drop(ctx))
+	}()
+
+	for i := 0; i < 2; i++ {
+		td.Clone(t, func(db *pgxpool.Pool) {
+			name, err := pgxutil.SelectString(ctx, db, "select name from widgets where id = 1")
+			require.NoError(t, err)
+			assert.Equal(t, "foo", name)
+
+			_, err = db.Exec(ctx, "insert into widgets values (2, 'bar')")
+			require.NoError(t, err)
+		})
+	}
+
+	exists, err := pgxutil.SelectExists(ctx, pool, "select 1 from pg_database where datname like 'pgxutiltest_template_%'")
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
@@ -0,0 +1,194 @@
+// Package pgxv5 ports the select, exec, and transaction functions of pgxutil to github.com/jackc/pgx/v5. It is a
+// separate module so the main pgxutil package can keep supporting pgx v4 without requiring pgx v5 or the newer Go
+// version it needs.
+//
+// Only Select, SelectOr, SelectAll, SelectStruct, SelectAllStruct, SelectMap, SelectAllMap, SelectRow, SelectAllRows,
+// Exec, and WithTx are ported. Everything else in pgxutil, such as Insert, Update, batches, caching, and the queue,
+// outbox, ratelimit, and kv packages, is only available for pgx v4.
+//
+// The functions mirror their pgxutil counterparts but are built on the generic row functions of pgx v5. Select, for
+// example, is like pgxutil.Select, and SelectStruct uses pgx.RowToStructByName, which maps columns to fields the same
+// way as pgxutil.SelectStruct: by db tag or else by field name ignoring case and underscores. SelectRow and
+// SelectAllRows accept any pgx.RowToFunc for everything else.
+package pgxv5
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ErrNoRows is returned when a query that requires a row returns no rows. It is the same error as pgx.ErrNoRows.
+var ErrNoRows = pgx.ErrNoRows
+
+// ErrTooManyRows is returned when a query that requires exactly one row returns more than one row.
+var ErrTooManyRows = errors.New("multiple rows in result set")
+
+// ErrNoColumns is returned when a query that requires one column returns no columns.
+var ErrNoColumns = errors.New("no columns in result set")
+
+// ErrTooManyColumns is returned when a query that requires one column returns more than one column.
+var ErrTooManyColumns = errors.New("multiple columns in result set")
+
+// ErrNullValue is returned when a null value is found where one is not allowed.
+var ErrNullValue = errors.New("value is null")
+
+// Queryer is the interface used by functions that read rows. It is implemented by *pgx.Conn, pgx.Tx, and
+// *pgxpool.Pool.
+type Queryer interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// Execer is the interface used by functions that execute statements without reading rows. It is implemented by
+// *pgx.Conn, pgx.Tx, and *pgxpool.Pool.
+type Execer interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+}
+
+// Beginner is the interface used by functions that run in a transaction. It is implemented by *pgx.Conn, pgx.Tx, and
+// *pgxpool.Pool.
+type Beginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// SelectRow selects a single row and converts it to a T with fn. Any pgx.RowToFunc such as pgx.RowToAddrOfStructByPos
+// can be used. An error will be returned if no rows or more than one row are found.
+func SelectRow[T any](ctx context.Context, db Queryer, fn pgx.RowToFunc[T], sql string, args ...any) (T, error) {
+	rows, err := db.Query(ctx, sql, args...)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	defer rows.Close()
+
+	var v T
+	rowCount := 0
+	for rows.Next() {
+		rowCount++
+		if rowCount > 1 {
+			break
+		}
+
+		v, err = fn(rows)
+		if err != nil {
+			var zero T
+			return zero, err
+		}
+	}
+	rows.Close()
+
+	var zero T
+	if err := rows.Err(); err != nil {
+		return zero, err
+	}
+	if rowCount == 0 {
+		return zero, ErrNoRows
+	}
+	if rowCount > 1 {
+		return zero, ErrTooManyRows
+	}
+
+	return v, nil
+}
+
+// SelectAllRows selects all rows and converts each to a T with fn.
+func SelectAllRows[T any](ctx context.Context, db Queryer, fn pgx.RowToFunc[T], sql string, args ...any) ([]T, error) {
+	rows, err := db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, fn)
+}
+
+// Select selects a single value of type T. Any PostgreSQL value that pgx can scan into a T can be selected. An error
+// will be returned if no rows are found or a null value is found.
+func Select[T any](ctx context.Context, db Queryer, sql string, args ...any) (T, error) {
+	return SelectRow(ctx, db, rowToValueNotNull[T], sql, args...)
+}
+
+// SelectOr is like Select except defaultValue is returned if no rows are found.
+func SelectOr[T any](ctx context.Context, db Queryer, defaultValue T, sql string, args ...any) (T, error) {
+	v, err := Select[T](ctx, db, sql, args...)
+	if errors.Is(err, ErrNoRows) {
+		return defaultValue, nil
+	}
+	return v, err
+}
+
+// SelectAll selects a column of type T. Any PostgreSQL value that pgx can scan into a T can be selected. An error will
+// be returned if a null value is found.
+func SelectAll[T any](ctx context.Context, db Queryer, sql string, args ...any) ([]T, error) {
+	return SelectAllRows(ctx, db, rowToValueNotNull[T], sql, args...)
+}
+
+// SelectStruct selects a single row into a T, which must be a struct. Columns are mapped to fields by
+// pgx.RowToStructByName. An error will be returned if no rows are found.
+func SelectStruct[T any](ctx context.Context, db Queryer, sql string, args ...any) (T, error) {
+	return SelectRow(ctx, db, pgx.RowToStructByName[T], sql, args...)
+}
+
+// SelectAllStruct selects rows into a []T. Columns are mapped to fields as described by SelectStruct.
+func SelectAllStruct[T any](ctx context.Context, db Queryer, sql string, args ...any) ([]T, error) {
+	return SelectAllRows(ctx, db, pgx.RowToStructByName[T], sql, args...)
+}
+
+// SelectMap selects a single row into a map. An error will be returned if no rows are found.
+func SelectMap(ctx context.Context, db Queryer, sql string, args ...any) (map[string]any, error) {
+	return SelectRow(ctx, db, pgx.RowToMap, sql, args...)
+}
+
+// SelectAllMap selects rows into maps.
+func SelectAllMap(ctx context.Context, db Queryer, sql string, args ...any) ([]map[string]any, error) {
+	return SelectAllRows(ctx, db, pgx.RowToMap, sql, args...)
+}
+
+// Exec executes sql with args and returns the command tag.
+func Exec(ctx context.Context, db Execer, sql string, args ...any) (pgconn.CommandTag, error) {
+	return db.Exec(ctx, sql, args...)
+}
+
+// WithTx begins a transaction on db and calls fn with it. If fn returns nil the transaction is committed. If fn
+// returns an error or panics the transaction is rolled back. A panic is re-raised after the rollback. If db is a pgx.Tx
+// a savepoint is used instead of a new transaction.
+func WithTx(ctx context.Context, db Beginner, fn func(pgx.Tx) error) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback(ctx)
+			panic(p)
+		}
+	}()
+
+	err = fn(tx)
+	if err != nil {
+		tx.Rollback(ctx)
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// rowToValueNotNull is a pgx.RowToFunc that scans the only column of row into a T. It returns ErrNullValue for a null
+// value regardless of T so the behavior matches pgxutil.
+func rowToValueNotNull[T any](row pgx.CollectableRow) (T, error) {
+	var v T
+	switch n := len(row.FieldDescriptions()); {
+	case n == 0:
+		return v, ErrNoColumns
+	case n > 1:
+		return v, ErrTooManyColumns
+	}
+	if row.RawValues()[0] == nil {
+		return v, ErrNullValue
+	}
+
+	err := row.Scan(&v)
+	return v, err
+}
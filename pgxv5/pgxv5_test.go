@@ -0,0 +1,137 @@
+package pgxv5_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgxutil/pgxv5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func withTx(t testing.TB, f func(ctx context.Context, tx pgx.Tx)) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	conn, err := pgx.Connect(ctx, fmt.Sprintf("database=%s", os.Getenv("TEST_DATABASE")))
+	require.NoError(t, err)
+	defer conn.Close(ctx)
+
+	tx, err := conn.Begin(ctx)
+	require.NoError(t, err)
+	defer tx.Rollback(ctx)
+
+	f(ctx, tx)
+}
+
+func TestSelect(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		n, err := pgxv5.Select[int64](ctx, tx, "select 42")
+		require.NoError(t, err)
+		assert.EqualValues(t, 42, n)
+
+		_, err = pgxv5.Select[int64](ctx, tx, "select 42 where false")
+		assert.True(t, errors.Is(err, pgxv5.ErrNoRows))
+
+		_, err = pgxv5.Select[int64](ctx, tx, "select n from generate_series(1, 2) n")
+		assert.True(t, errors.Is(err, pgxv5.ErrTooManyRows))
+
+		_, err = pgxv5.Select[*int64](ctx, tx, "select null::int8")
+		assert.True(t, errors.Is(err, pgxv5.ErrNullValue))
+
+		_, err = pgxv5.Select[int64](ctx, tx, "select 1, 2")
+		assert.True(t, errors.Is(err, pgxv5.ErrTooManyColumns))
+
+		n, err = pgxv5.SelectOr[int64](ctx, tx, 7, "select 42 where false")
+		require.NoError(t, err)
+		assert.EqualValues(t, 7, n)
+	})
+}
+
+func TestSelectAll(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		v, err := pgxv5.SelectAll[string](ctx, tx, "select n::text from generate_series(1, 3) n")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"1", "2", "3"}, v)
+
+		_, err = pgxv5.SelectAll[string](ctx, tx, "select null::text")
+		assert.True(t, errors.Is(err, pgxv5.ErrNullValue))
+	})
+}
+
+func TestSelectStruct(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		type person struct {
+			FirstName string
+			Age       int32 `db:"years"`
+		}
+
+		p, err := pgxv5.SelectStruct[person](ctx, tx, "select 'Adam' as first_name, 72 as years")
+		require.NoError(t, err)
+		assert.Equal(t, person{FirstName: "Adam", Age: 72}, p)
+
+		people, err := pgxv5.SelectAllStruct[person](ctx, tx, "select name as first_name, 1 as years from unnest(array['Adam', 'Bill']) name")
+		require.NoError(t, err)
+		assert.Equal(t, []person{{FirstName: "Adam", Age: 1}, {FirstName: "Bill", Age: 1}}, people)
+	})
+}
+
+func TestSelectMap(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		m, err := pgxv5.SelectMap(ctx, tx, "select 'Adam' as name, 72::int4 as age")
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"name": "Adam", "age": int32(72)}, m)
+
+		ms, err := pgxv5.SelectAllMap(ctx, tx, "select n::int4 as n from generate_series(1, 2) n")
+		require.NoError(t, err)
+		assert.Equal(t, []map[string]any{{"n": int32(1)}, {"n": int32(2)}}, ms)
+	})
+}
+
+func TestSelectRow(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		type pair struct {
+			A int32
+			B int32
+		}
+
+		p, err := pgxv5.SelectRow(ctx, tx, pgx.RowToAddrOfStructByPos[pair], "select 1, 2")
+		require.NoError(t, err)
+		assert.Equal(t, &pair{A: 1, B: 2}, p)
+	})
+}
+
+func TestWithTx(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		_, err := pgxv5.Exec(ctx, tx, "create temporary table t (id int)")
+		require.NoError(t, err)
+
+		err = pgxv5.WithTx(ctx, tx, func(tx pgx.Tx) error {
+			_, err := pgxv5.Exec(ctx, tx, "insert into t values (1)")
+			require.NoError(t, err)
+			return errors.New("rollback")
+		})
+		require.EqualError(t, err, "rollback")
+
+		err = pgxv5.WithTx(ctx, tx, func(tx pgx.Tx) error {
+			_, err := pgxv5.Exec(ctx, tx, "insert into t values (2)")
+			return err
+		})
+		require.NoError(t, err)
+
+		ids, err := pgxv5.SelectAll[int32](ctx, tx, "select id from t")
+		require.NoError(t, err)
+		assert.Equal(t, []int32{2}, ids)
+	})
+}
@@ -0,0 +1,110 @@
+// Package prompgxutil provides Prometheus metrics for pgxutil. It is a separate module so the main pgxutil package does
+// not require the Prometheus client.
+package prompgxutil
+
+import (
+	"context"
+
+	"github.com/jackc/pgxutil"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// CollectorOpts configures a Collector.
+type CollectorOpts struct {
+	// Namespace is prepended to the metric names. e.g. myapp_pgxutil_queries_total.
+	Namespace string
+
+	// ConstLabels are added to every metric.
+	ConstLabels prometheus.Labels
+
+	// Buckets are the query duration histogram buckets in seconds. If nil prometheus.DefBuckets is used.
+	Buckets []float64
+}
+
+// Collector is a pgxutil.QueryHook that records query counts, errors, durations, and rows returned. Each metric has a
+// function label with the name of the pgxutil function that sent the query, e.g. SelectInt64, or "other" for queries
+// sent directly through the pgxutil.HookedDB. Collector implements prometheus.Collector so it can be registered with
+// a prometheus.Registry.
+type Collector struct {
+	queries  *prometheus.CounterVec
+	errors   *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	rows     *prometheus.CounterVec
+}
+
+// NewCollector returns a new Collector configured by opts.
+func NewCollector(opts CollectorOpts) *Collector {
+	labels := []string{"function"}
+	return &Collector{
+		queries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   opts.Namespace,
+			Subsystem:   "pgxutil",
+			Name:        "queries_total",
+			Help:        "Number of queries sent.",
+			ConstLabels: opts.ConstLabels,
+		}, labels),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   opts.Namespace,
+			Subsystem:   "pgxutil",
+			Name:        "query_errors_total",
+			Help:        "Number of queries that failed.",
+			ConstLabels: opts.ConstLabels,
+		}, labels),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   opts.Namespace,
+			Subsystem:   "pgxutil",
+			Name:        "query_duration_seconds",
+			Help:        "Duration of queries including reading their rows.",
+			ConstLabels: opts.ConstLabels,
+			Buckets:     opts.Buckets,
+		}, labels),
+		rows: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   opts.Namespace,
+			Subsystem:   "pgxutil",
+			Name:        "query_rows_total",
+			Help:        "Number of rows returned by queries.",
+			ConstLabels: opts.ConstLabels,
+		}, labels),
+	}
+}
+
+// Wrap returns db wrapped so c records metrics for each query sent through it.
+func Wrap(db pgxutil.Handle, c *Collector) *pgxutil.HookedDB {
+	return pgxutil.NewHookedDB(db, c)
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.queries.Describe(ch)
+	c.errors.Describe(ch)
+	c.duration.Describe(ch)
+	c.rows.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.queries.Collect(ch)
+	c.errors.Collect(ch)
+	c.duration.Collect(ch)
+	c.rows.Collect(ch)
+}
+
+// BeforeQuery implements pgxutil.QueryHook.
+func (c *Collector) BeforeQuery(ctx context.Context, data *pgxutil.QueryHookData) context.Context {
+	return ctx
+}
+
+// AfterQuery implements pgxutil.QueryHook.
+func (c *Collector) AfterQuery(ctx context.Context, data *pgxutil.QueryHookData) {
+	function := data.Function
+	if function == "" {
+		function = "other"
+	}
+
+	c.queries.WithLabelValues(function).Inc()
+	if data.Err != nil {
+		c.errors.WithLabelValues(function).Inc()
+	}
+	c.duration.WithLabelValues(function).Observe(data.Duration.Seconds())
+	c.rows.WithLabelValues(function).Add(float64(data.Rows))
+}
@@ -0,0 +1,60 @@
+package prompgxutil_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgxutil"
+	"github.com/jackc/pgxutil/prompgxutil"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollector(t *testing.T) {
+	t.Parallel()
+
+	c := prompgxutil.NewCollector(prompgxutil.CollectorOpts{Namespace: "test", Buckets: []float64{0.1, 1}})
+
+	registry := prometheus.NewRegistry()
+	require.NoError(t, registry.Register(c))
+
+	queries := []*pgxutil.QueryHookData{
+		{SQL: "select 1", Function: "SelectInt64", Duration: 50 * time.Millisecond, Rows: 1},
+		{SQL: "select 2", Function: "SelectInt64", Duration: 500 * time.Millisecond, Rows: 1},
+		{SQL: "select n from generate_series(1,3) n", Function: "SelectAllInt64", Duration: time.Millisecond, Rows: 3},
+		{SQL: "select 1/0", Function: "SelectInt64", Err: errors.New("division by zero")},
+		{SQL: "vacuum"},
+	}
+	for _, data := range queries {
+		ctx := c.BeforeQuery(context.Background(), data)
+		c.AfterQuery(ctx, data)
+	}
+
+	expected := `
+# HELP test_pgxutil_queries_total Number of queries sent.
+# TYPE test_pgxutil_queries_total counter
+test_pgxutil_queries_total{function="SelectAllInt64"} 1
+test_pgxutil_queries_total{function="SelectInt64"} 3
+test_pgxutil_queries_total{function="other"} 1
+# HELP test_pgxutil_query_errors_total Number of queries that failed.
+# TYPE test_pgxutil_query_errors_total counter
+test_pgxutil_query_errors_total{function="SelectInt64"} 1
+# HELP test_pgxutil_query_rows_total Number of rows returned by queries.
+# TYPE test_pgxutil_query_rows_total counter
+test_pgxutil_query_rows_total{function="SelectAllInt64"} 3
+test_pgxutil_query_rows_total{function="SelectInt64"} 2
+test_pgxutil_query_rows_total{function="other"} 0
+`
+	err := testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		"test_pgxutil_queries_total", "test_pgxutil_query_errors_total", "test_pgxutil_query_rows_total")
+	assert.NoError(t, err)
+
+	count, err := testutil.GatherAndCount(registry, "test_pgxutil_query_duration_seconds")
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+}
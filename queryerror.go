@@ -0,0 +1,82 @@
+package pgxutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+)
+
+// QueryError is returned by the Select functions, Exec, and the functions built on them when a query fails. It
+// records the query so the error identifies it wherever it is logged. Errors found by pgxutil while reading the
+// result such as ErrNoRows and errors returned by callbacks are not wrapped. Use errors.As to get the
+// *pgconn.PgError it wraps, if any.
+type QueryError struct {
+	// SQL is the query as sent to PostgreSQL. NamedArgs have already been rewritten to positional arguments.
+	SQL string
+
+	// Args are the arguments of the query. They are nil if Options.RedactArgs is set.
+	Args []interface{}
+
+	// Err is the error the query failed with.
+	Err error
+}
+
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("query %q: %v", e.SQL, e.Err)
+}
+
+func (e *QueryError) Unwrap() error {
+	return e.Err
+}
+
+// Is makes errors.Is(err, ErrLockNotAvailable) true if the query failed with lock_not_available.
+func (e *QueryError) Is(target error) bool {
+	return target == ErrLockNotAvailable && IsLockNotAvailable(e.Err)
+}
+
+// Position returns the line and column, both starting at 1, in SQL of the error position reported by PostgreSQL. ok is
+// false if Err is not a *pgconn.PgError with a position.
+func (e *QueryError) Position() (line, column int, ok bool) {
+	var pgErr *pgconn.PgError
+	if !errors.As(e.Err, &pgErr) || pgErr.Position <= 0 {
+		return 0, 0, false
+	}
+
+	// Position counts characters, not bytes.
+	line, column = 1, 1
+	n := int32(1)
+	for _, r := range e.SQL {
+		if n == pgErr.Position {
+			return line, column, true
+		}
+		n++
+		if r == '\n' {
+			line++
+			column = 1
+		} else {
+			column++
+		}
+	}
+
+	return line, column, n == pgErr.Position
+}
+
+// newQueryError returns err wrapped in a *QueryError for sql and args as sent to db. pgx query options are removed
+// from args.
+func newQueryError(ctx context.Context, sql string, args []interface{}, err error) error {
+	qe := &QueryError{SQL: sql, Err: err}
+	if !OptionsFromContext(ctx).RedactArgs {
+		for _, arg := range args {
+			switch arg.(type) {
+			case pgx.QueryResultFormats, pgx.QueryResultFormatsByOID, pgx.QuerySimpleProtocol:
+			default:
+				qe.Args = append(qe.Args, arg)
+			}
+		}
+	}
+
+	return qe
+}
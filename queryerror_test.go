@@ -0,0 +1,86 @@
+package pgxutil_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgxutil"
+	"github.com/jackc/pgxutil/pgxutiltest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryError(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		_, err := tx.Exec(ctx, "savepoint s")
+		require.NoError(t, err)
+
+		_, err = pgxutil.SelectString(ctx, tx, "select :name::text,\n  missing", pgxutil.NamedArgs{"name": "Adam"})
+		var queryErr *pgxutil.QueryError
+		require.True(t, errors.As(err, &queryErr))
+		assert.Equal(t, "select $1::text,\n  missing", queryErr.SQL)
+		assert.Equal(t, []interface{}{"Adam"}, queryErr.Args)
+		line, column, ok := queryErr.Position()
+		require.True(t, ok)
+		assert.Equal(t, 2, line)
+		assert.Equal(t, 3, column)
+
+		var pgErr *pgconn.PgError
+		require.True(t, errors.As(err, &pgErr))
+		assert.Equal(t, "42703", pgErr.Code)
+
+		_, err = tx.Exec(ctx, "rollback to savepoint s")
+		require.NoError(t, err)
+
+		_, err = pgxutil.Exec(ctx, tx, "insert into missing values ($1)", 42, pgxutil.Options{RedactArgs: true})
+		require.True(t, errors.As(err, &queryErr))
+		assert.Equal(t, "insert into missing values ($1)", queryErr.SQL)
+		assert.Nil(t, queryErr.Args)
+		assert.Contains(t, err.Error(), `query "insert into missing values ($1)": `)
+
+		_, err = tx.Exec(ctx, "rollback to savepoint s")
+		require.NoError(t, err)
+
+		_, err = pgxutil.SelectString(ctx, tx, "select 'x' where false")
+		assert.Equal(t, pgxutil.ErrNoRows, err)
+	})
+}
+
+func TestQueryErrorPosition(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		sql      string
+		position int32
+		line     int
+		column   int
+		ok       bool
+	}{
+		{sql: "select x", position: 8, line: 1, column: 8, ok: true},
+		{sql: "select 'é', x", position: 13, line: 1, column: 13, ok: true},
+		{sql: "select 1,\nx", position: 11, line: 2, column: 1, ok: true},
+		{sql: "select", position: 7, line: 1, column: 7, ok: true},
+		{sql: "select", position: 0, ok: false},
+		{sql: "select", position: 20, ok: false},
+	} {
+		db := &pgxutiltest.FakeQueryer{}
+		db.ExpectQuery(tt.sql).WithArgs(int64(1)).ReturnError(&pgconn.PgError{Code: "42703", Position: tt.position})
+
+		_, err := pgxutil.SelectString(context.Background(), db, tt.sql, int64(1))
+		var queryErr *pgxutil.QueryError
+		require.True(t, errors.As(err, &queryErr), tt.sql)
+		assert.Equal(t, tt.sql, queryErr.SQL)
+		assert.Equal(t, []interface{}{int64(1)}, queryErr.Args)
+
+		line, column, ok := queryErr.Position()
+		assert.Equalf(t, tt.ok, ok, "%s at %d", tt.sql, tt.position)
+		if tt.ok {
+			assert.Equalf(t, tt.line, line, "%s at %d", tt.sql, tt.position)
+			assert.Equalf(t, tt.column, column, "%s at %d", tt.sql, tt.position)
+		}
+	}
+}
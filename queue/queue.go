@@ -0,0 +1,292 @@
+// Package queue is a minimal job queue stored in a PostgreSQL table.
+//
+// Jobs are added with Enqueue, usually in the transaction that creates the work so the job exists if and only if the
+// transaction commits. A Worker claims jobs with select for update skip locked so concurrent workers never claim the
+// same job. A claimed job is hidden from other workers for the visibility timeout. If the handler succeeds the job is
+// deleted. If it fails the job is retried later with backoff. A job that fails MaxAttempts times is marked dead and
+// kept in the table with its last error for inspection. A worker that stops while handling a job does not lose it. It
+// becomes visible again when the visibility timeout expires, so handlers must tolerate running a job more than once.
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgxutil"
+	"github.com/jackc/pgxutil/internal/pgtable"
+)
+
+// DefaultTableName is the table used when a table name is not set.
+const DefaultTableName = "queue_jobs"
+
+// Job is a unit of work claimed by a Worker.
+type Job struct {
+	ID    int64  `db:"id"`
+	Queue string `db:"queue"`
+
+	// Payload is the JSON the job was enqueued with.
+	Payload json.RawMessage `db:"payload"`
+
+	// Attempts is the number of times the job has been claimed including the current attempt.
+	Attempts int32 `db:"attempts"`
+
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// CreateTable creates the table of jobs if it does not exist. If tableName is empty DefaultTableName is used.
+func CreateTable(ctx context.Context, db pgxutil.Execer, tableName string) error {
+	tableName = pgtable.Name(tableName, DefaultTableName)
+	return pgtable.Create(ctx, db, tableName, `
+	id bigserial primary key,
+	queue text not null,
+	payload jsonb not null,
+	attempts int not null default 0,
+	run_at timestamptz not null default now(),
+	dead boolean not null default false,
+	last_error text,
+	created_at timestamptz not null default now()
+`,
+		fmt.Sprintf("create index if not exists %s on %s (queue, run_at) where not dead", pgtable.IndexName(tableName, "run_at_idx"), tableName),
+	)
+}
+
+// EnqueueOptions configures EnqueueWithOptions.
+type EnqueueOptions struct {
+	// TableName is the table of jobs. If empty DefaultTableName is used.
+	TableName string
+
+	// RunAt delays the job until the given time. If zero the job can run immediately.
+	RunAt time.Time
+}
+
+// Enqueue adds a job with payload marshaled to JSON to queue and returns its ID.
+func Enqueue(ctx context.Context, db pgxutil.Queryer, queue string, payload interface{}) (int64, error) {
+	return EnqueueWithOptions(ctx, db, queue, payload, EnqueueOptions{})
+}
+
+// EnqueueWithOptions is like Enqueue except the job is added according to opts.
+func EnqueueWithOptions(ctx context.Context, db pgxutil.Queryer, queue string, payload interface{}, opts EnqueueOptions) (int64, error) {
+	buf, err := json.Marshal(payload)
+	if err != nil {
+		return 0, err
+	}
+
+	runAt := opts.RunAt
+	if runAt.IsZero() {
+		runAt = time.Now()
+	}
+
+	return pgxutil.SelectInt64(ctx, db,
+		fmt.Sprintf("insert into %s (queue, payload, run_at) values ($1, $2::jsonb, $3) returning id", pgtable.Name(opts.TableName, DefaultTableName)),
+		queue, string(buf), runAt,
+	)
+}
+
+// Worker claims and handles the jobs of a queue. The zero value is not usable. DB, Queue, and Handler must be set. The
+// fields must not be changed while the Worker is running.
+type Worker struct {
+	// DB is used to claim and complete jobs. It is usually a *pgxpool.Pool.
+	DB pgxutil.Handle
+
+	// Queue is the name of the queue to take jobs from.
+	Queue string
+
+	// TableName is the table of jobs. If empty DefaultTableName is used.
+	TableName string
+
+	// Handler is called with each claimed job. The context is canceled when the visibility timeout expires. If Handler
+	// returns an error or panics the job is retried.
+	Handler func(ctx context.Context, job *Job) error
+
+	// BatchSize is the maximum number of jobs claimed at once. If zero 10 is used.
+	BatchSize int
+
+	// PollInterval is how long Run waits before looking for jobs when the queue was empty. If zero 1 second is used.
+	PollInterval time.Duration
+
+	// VisibilityTimeout is how long a claimed batch of jobs is hidden from other workers. It should be longer than
+	// handling BatchSize jobs ever takes. If zero 5 minutes is used.
+	VisibilityTimeout time.Duration
+
+	// MaxAttempts is the number of failed attempts after which a job is marked dead. If zero 5 is used.
+	MaxAttempts int
+
+	// Backoff returns the delay before a job is retried after its attempt failed. If nil the delay is 2^(attempt-1)
+	// seconds up to 1 hour.
+	Backoff func(attempt int) time.Duration
+}
+
+// Run handles jobs until ctx is canceled. It returns ctx.Err() or the first error claiming or completing jobs.
+func (w *Worker) Run(ctx context.Context) error {
+	for {
+		n, err := w.Work(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+
+		if n > 0 {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(w.pollInterval()):
+		}
+	}
+}
+
+// Work claims one batch of jobs, handles them in order, and returns the number of jobs claimed. All the jobs of a batch
+// are claimed for the same visibility timeout, so they must all be handled within it. Jobs whose visibility timeout
+// expired before they were handled are left for the next claim.
+func (w *Worker) Work(ctx context.Context) (int, error) {
+	jobs, deadline, err := w.claim(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range jobs {
+		if !time.Now().Before(deadline) {
+			break
+		}
+
+		err := w.handle(ctx, &jobs[i], deadline)
+		if err != nil {
+			return i + 1, err
+		}
+	}
+
+	return len(jobs), nil
+}
+
+// claim locks up to BatchSize runnable jobs, moves their run_at past the visibility timeout, and returns them with the
+// time their visibility timeout expires. Runnable jobs that already used MaxAttempts attempts, because a worker stopped
+// while handling their last attempt, are marked dead instead.
+func (w *Worker) claim(ctx context.Context) ([]Job, time.Time, error) {
+	// The deadline is taken before run_at is set so it never ends after the job becomes visible again.
+	deadline := time.Now().Add(w.visibilityTimeout())
+
+	var jobs []Job
+	err := pgxutil.WithTx(ctx, w.DB, func(tx pgx.Tx) error {
+		_, err := pgxutil.Exec(ctx, tx,
+			fmt.Sprintf(`update %s
+set dead = true, last_error = coalesce(last_error, 'visibility timeout expired')
+where queue = $1 and not dead and run_at <= now() and attempts >= $2`, w.tableName()),
+			w.Queue, w.maxAttempts(),
+		)
+		if err != nil {
+			return err
+		}
+
+		ids, err := pgxutil.SelectAllInt64(ctx, tx,
+			fmt.Sprintf("select id from %s where queue = $1 and not dead and run_at <= now() and attempts < $3 order by run_at, id limit $2", w.tableName()),
+			w.Queue, w.batchSize(), w.maxAttempts(), pgxutil.Options{Lock: pgxutil.LockForUpdate, LockWait: pgxutil.LockSkipLocked},
+		)
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+
+		return pgxutil.SelectAllStruct(ctx, tx, &jobs,
+			fmt.Sprintf(`update %s
+set attempts = attempts + 1, run_at = now() + $2::interval
+where id = any($1)
+returning id, queue, payload, attempts, created_at`, w.tableName()),
+			ids, w.visibilityTimeout(),
+		)
+	})
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	return jobs, deadline, nil
+}
+
+// handle calls Handler with job and then deletes the job or schedules its retry. The writes only apply if the job was
+// not claimed again after its visibility timeout expired.
+func (w *Worker) handle(ctx context.Context, job *Job, deadline time.Time) error {
+	handlerErr := w.callHandler(ctx, job, deadline)
+	if handlerErr == nil {
+		_, err := pgxutil.Exec(ctx, w.DB, fmt.Sprintf("delete from %s where id = $1 and attempts = $2", w.tableName()), job.ID, job.Attempts)
+		return err
+	}
+
+	if int(job.Attempts) >= w.maxAttempts() {
+		_, err := pgxutil.Exec(ctx, w.DB,
+			fmt.Sprintf("update %s set dead = true, last_error = $3 where id = $1 and attempts = $2", w.tableName()),
+			job.ID, job.Attempts, handlerErr.Error(),
+		)
+		return err
+	}
+
+	_, err := pgxutil.Exec(ctx, w.DB,
+		fmt.Sprintf("update %s set run_at = now() + $3::interval, last_error = $4 where id = $1 and attempts = $2", w.tableName()),
+		job.ID, job.Attempts, w.backoff(int(job.Attempts)), handlerErr.Error(),
+	)
+	return err
+}
+
+func (w *Worker) callHandler(ctx context.Context, job *Job, deadline time.Time) (err error) {
+	ctx, cancel := context.WithDeadline(ctx, deadline)
+	defer cancel()
+
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+
+	return w.Handler(ctx, job)
+}
+
+func (w *Worker) tableName() string {
+	return pgtable.Name(w.TableName, DefaultTableName)
+}
+
+func (w *Worker) batchSize() int {
+	if w.BatchSize == 0 {
+		return 10
+	}
+	return w.BatchSize
+}
+
+func (w *Worker) pollInterval() time.Duration {
+	if w.PollInterval == 0 {
+		return time.Second
+	}
+	return w.PollInterval
+}
+
+func (w *Worker) visibilityTimeout() time.Duration {
+	if w.VisibilityTimeout == 0 {
+		return 5 * time.Minute
+	}
+	return w.VisibilityTimeout
+}
+
+func (w *Worker) maxAttempts() int {
+	if w.MaxAttempts == 0 {
+		return 5
+	}
+	return w.MaxAttempts
+}
+
+func (w *Worker) backoff(attempt int) time.Duration {
+	if w.Backoff != nil {
+		return w.Backoff(attempt)
+	}
+
+	delay := time.Second
+	for i := 1; i < attempt && delay < time.Hour; i++ {
+		delay *= 2
+	}
+	if delay > time.Hour {
+		delay = time.Hour
+	}
+	return delay
+}
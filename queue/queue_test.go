@@ -0,0 +1,242 @@
+package queue_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgxutil"
+	"github.com/jackc/pgxutil/internal/testdb"
+	"github.com/jackc/pgxutil/queue"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorker(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	conn := testdb.Connect(t, ctx)
+
+	tableName := "pg_temp.jobs"
+	require.NoError(t, queue.CreateTable(ctx, conn, tableName))
+
+	for _, n := range []int{1, 2, 3} {
+		_, err := queue.EnqueueWithOptions(ctx, conn, "emails", map[string]int{"n": n}, queue.EnqueueOptions{TableName: tableName})
+		require.NoError(t, err)
+	}
+	_, err := queue.EnqueueWithOptions(ctx, conn, "other", map[string]int{"n": 4}, queue.EnqueueOptions{TableName: tableName})
+	require.NoError(t, err)
+
+	var handled []int
+	w := &queue.Worker{
+		DB:        conn,
+		Queue:     "emails",
+		TableName: tableName,
+		Handler: func(ctx context.Context, job *queue.Job) error {
+			var payload struct{ N int }
+			err := json.Unmarshal(job.Payload, &payload)
+			if err != nil {
+				return err
+			}
+			handled = append(handled, payload.N)
+			return nil
+		},
+	}
+
+	n, err := w.Work(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+	assert.ElementsMatch(t, []int{1, 2, 3}, handled)
+
+	n, err = w.Work(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	remaining, err := pgxutil.SelectAllString(ctx, conn, "select queue from pg_temp.jobs")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"other"}, remaining)
+}
+
+func TestWorkerRetriesAndDeadLetters(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	conn := testdb.Connect(t, ctx)
+
+	tableName := "pg_temp.jobs"
+	require.NoError(t, queue.CreateTable(ctx, conn, tableName))
+
+	id, err := queue.EnqueueWithOptions(ctx, conn, "emails", "hello", queue.EnqueueOptions{TableName: tableName})
+	require.NoError(t, err)
+
+	attempts := 0
+	w := &queue.Worker{
+		DB:          conn,
+		Queue:       "emails",
+		TableName:   tableName,
+		MaxAttempts: 2,
+		Backoff:     func(int) time.Duration { return 0 },
+		Handler: func(ctx context.Context, job *queue.Job) error {
+			attempts++
+			assert.EqualValues(t, attempts, job.Attempts)
+			if attempts == 2 {
+				panic("boom")
+			}
+			return errors.New("smtp unavailable")
+		},
+	}
+
+	n, err := w.Work(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	lastError, err := pgxutil.SelectString(ctx, conn, "select last_error from pg_temp.jobs where id = $1 and not dead", id)
+	require.NoError(t, err)
+	assert.Equal(t, "smtp unavailable", lastError)
+
+	n, err = w.Work(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	lastError, err = pgxutil.SelectString(ctx, conn, "select last_error from pg_temp.jobs where id = $1 and dead", id)
+	require.NoError(t, err)
+	assert.Equal(t, "panic: boom", lastError)
+
+	n, err = w.Work(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+	assert.Equal(t, 2, attempts)
+}
+
+func TestWorkerVisibilityTimeout(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	conn := testdb.Connect(t, ctx)
+
+	tableName := "pg_temp.jobs"
+	require.NoError(t, queue.CreateTable(ctx, conn, tableName))
+
+	_, err := queue.EnqueueWithOptions(ctx, conn, "emails", "hello", queue.EnqueueOptions{TableName: tableName})
+	require.NoError(t, err)
+
+	// Simulate a worker that claimed the job and stopped without completing it.
+	_, err = pgxutil.Exec(ctx, conn, "update pg_temp.jobs set attempts = 1, run_at = now() - '1 second'::interval")
+	require.NoError(t, err)
+
+	var job *queue.Job
+	w := &queue.Worker{
+		DB:        conn,
+		Queue:     "emails",
+		TableName: tableName,
+		Handler: func(ctx context.Context, j *queue.Job) error {
+			job = j
+			return nil
+		},
+	}
+
+	n, err := w.Work(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	require.NotNil(t, job)
+	assert.EqualValues(t, 2, job.Attempts)
+	assert.JSONEq(t, `"hello"`, string(job.Payload))
+}
+
+func TestWorkerMaxAttemptsExpired(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	conn := testdb.Connect(t, ctx)
+
+	tableName := "pg_temp.jobs"
+	require.NoError(t, queue.CreateTable(ctx, conn, tableName))
+
+	id, err := queue.EnqueueWithOptions(ctx, conn, "emails", "hello", queue.EnqueueOptions{TableName: tableName})
+	require.NoError(t, err)
+
+	// Simulate a worker that claimed the last attempt of the job and stopped without completing it.
+	_, err = pgxutil.Exec(ctx, conn, "update pg_temp.jobs set attempts = 2, run_at = now() - '1 second'::interval")
+	require.NoError(t, err)
+
+	w := &queue.Worker{
+		DB:          conn,
+		Queue:       "emails",
+		TableName:   tableName,
+		MaxAttempts: 2,
+		Handler: func(ctx context.Context, j *queue.Job) error {
+			t.Error("handler called for job without attempts left")
+			return nil
+		},
+	}
+
+	n, err := w.Work(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	attempts, err := pgxutil.SelectInt64(ctx, conn, "select attempts from pg_temp.jobs where id = $1 and dead", id)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, attempts)
+}
+
+func TestWorkerSkipsCompletionOfReclaimedJob(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	conn := testdb.Connect(t, ctx)
+
+	tableName := "pg_temp.jobs"
+	require.NoError(t, queue.CreateTable(ctx, conn, tableName))
+
+	id, err := queue.EnqueueWithOptions(ctx, conn, "emails", "hello", queue.EnqueueOptions{TableName: tableName})
+	require.NoError(t, err)
+
+	w := &queue.Worker{
+		DB:        conn,
+		Queue:     "emails",
+		TableName: tableName,
+		Handler: func(ctx context.Context, j *queue.Job) error {
+			// Simulate another worker claiming the job after the visibility timeout expired.
+			_, err := pgxutil.Exec(ctx, conn, "update pg_temp.jobs set attempts = attempts + 1")
+			return err
+		},
+	}
+
+	n, err := w.Work(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	attempts, err := pgxutil.SelectInt64(ctx, conn, "select attempts from pg_temp.jobs where id = $1", id)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, attempts)
+}
+
+func TestEnqueueRunAt(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	conn := testdb.Connect(t, ctx)
+
+	tableName := "pg_temp.jobs"
+	require.NoError(t, queue.CreateTable(ctx, conn, tableName))
+
+	_, err := queue.EnqueueWithOptions(ctx, conn, "emails", "later", queue.EnqueueOptions{TableName: tableName, RunAt: time.Now().Add(time.Hour)})
+	require.NoError(t, err)
+
+	w := &queue.Worker{
+		DB:        conn,
+		Queue:     "emails",
+		TableName: tableName,
+		Handler: func(ctx context.Context, job *queue.Job) error {
+			t.Error("job ran before RunAt")
+			return nil
+		},
+	}
+
+	n, err := w.Work(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+}
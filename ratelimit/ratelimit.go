@@ -0,0 +1,70 @@
+// Package ratelimit is a fixed window rate limiter stored in a PostgreSQL table. It lets processes that already share a
+// database enforce a limit together without another service.
+//
+// Each key has a counter for the current window. Windows are aligned to multiples of the window duration since the Unix
+// epoch according to the database clock, so all processes agree on them regardless of their own clocks. A fixed window
+// can allow up to twice the limit in a window's duration that spans the boundary between two windows.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgxutil"
+	"github.com/jackc/pgxutil/internal/pgtable"
+)
+
+// DefaultTableName is the table used when a table name is not set.
+const DefaultTableName = "rate_limits"
+
+// CreateTable creates the table of counters if it does not exist. If tableName is empty DefaultTableName is used.
+func CreateTable(ctx context.Context, db pgxutil.Execer, tableName string) error {
+	return pgtable.Create(ctx, db, pgtable.Name(tableName, DefaultTableName), `
+	key text primary key,
+	window_start timestamptz not null,
+	count bigint not null
+`)
+}
+
+// Allow is like Limiter.Allow with the zero Limiter.
+func Allow(ctx context.Context, db pgxutil.Queryer, key string, limit int, window time.Duration) (bool, error) {
+	var l Limiter
+	return l.Allow(ctx, db, key, limit, window)
+}
+
+// Limiter configures where counters are stored. The zero value uses DefaultTableName.
+type Limiter struct {
+	// TableName is the table of counters. If empty DefaultTableName is used.
+	TableName string
+}
+
+// Allow counts an event for key and returns true if at most limit events, including this one, have been counted for
+// key in the current window. The counter is updated with a single statement so concurrent callers never exceed limit.
+// Denied events are counted too. Keys are independent so any string such as a user ID or an IP address can be used.
+func (l *Limiter) Allow(ctx context.Context, db pgxutil.Queryer, key string, limit int, window time.Duration) (bool, error) {
+	count, err := l.Count(ctx, db, key, window)
+	if err != nil {
+		return false, err
+	}
+
+	return count <= int64(limit), nil
+}
+
+// Count counts an event for key and returns the number of events counted for key in the current window including this
+// one.
+func (l *Limiter) Count(ctx context.Context, db pgxutil.Queryer, key string, window time.Duration) (int64, error) {
+	if window < time.Microsecond {
+		return 0, fmt.Errorf("window must be at least 1 microsecond")
+	}
+
+	return pgxutil.SelectInt64(ctx, db,
+		fmt.Sprintf(`insert into %[1]s as t (key, window_start, count)
+values ($1, to_timestamp(floor(extract(epoch from now()) * 1000000 / $2) * $2 / 1000000), 1)
+on conflict (key) do update set
+	window_start = excluded.window_start,
+	count = case when t.window_start = excluded.window_start then t.count + 1 else 1 end
+returning count`, pgtable.Name(l.TableName, DefaultTableName)),
+		key, window.Microseconds(),
+	)
+}
@@ -0,0 +1,52 @@
+package ratelimit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgxutil"
+	"github.com/jackc/pgxutil/internal/testdb"
+	"github.com/jackc/pgxutil/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiterAllow(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	conn := testdb.Connect(t, ctx)
+
+	l := &ratelimit.Limiter{TableName: "pg_temp.rate_limits"}
+	require.NoError(t, ratelimit.CreateTable(ctx, conn, l.TableName))
+
+	for i := 0; i < 3; i++ {
+		allowed, err := l.Allow(ctx, conn, "user:1", 3, time.Hour)
+		require.NoError(t, err)
+		assert.True(t, allowed, i)
+	}
+
+	allowed, err := l.Allow(ctx, conn, "user:1", 3, time.Hour)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+
+	allowed, err = l.Allow(ctx, conn, "user:2", 3, time.Hour)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	// Move the counter into a past window.
+	_, err = pgxutil.Exec(ctx, conn, "update pg_temp.rate_limits set window_start = window_start - '1 hour'::interval where key = 'user:1'")
+	require.NoError(t, err)
+
+	count, err := l.Count(ctx, conn, "user:1", time.Hour)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, count)
+}
+
+func TestLimiterInvalidWindow(t *testing.T) {
+	t.Parallel()
+
+	_, err := ratelimit.Allow(context.Background(), nil, "user:1", 3, 0)
+	require.EqualError(t, err, "window must be at least 1 microsecond")
+}
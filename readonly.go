@@ -0,0 +1,130 @@
+package pgxutil
+
+import (
+	"context"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgproto3/v2"
+	"github.com/jackc/pgx/v4"
+)
+
+// ReadOnlyDB wraps a database handle so every query and statement sent through it runs in a read only transaction.
+// PostgreSQL rejects writes with a read_only_sql_transaction (25006) error. If the wrapped handle is a pgx.Tx a
+// savepoint is used instead. The transaction or savepoint is always rolled back as it cannot have made any changes.
+// This is useful for running analytics queries safely, e.g. before routing them to a replica.
+type ReadOnlyDB struct {
+	db Beginner
+}
+
+// NewReadOnlyDB returns db wrapped so all queries sent through it are read only.
+func NewReadOnlyDB(db Beginner) *ReadOnlyDB {
+	return &ReadOnlyDB{db: db}
+}
+
+// Query implements Queryer. The read only transaction ends when the rows are closed or all rows have been read. Like
+// pgx, it returns rows that carry the error even if the query fails.
+func (r *ReadOnlyDB) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	tx, err := r.Begin(ctx)
+	if err != nil {
+		return errRows{err: err}, err
+	}
+
+	rows, err := tx.Query(ctx, sql, args...)
+	if err != nil {
+		tx.Rollback(ctx)
+		if rows == nil {
+			rows = errRows{err: err}
+		}
+		return rows, err
+	}
+
+	return &readOnlyRows{Rows: rows, ctx: ctx, tx: tx}, nil
+}
+
+// Exec implements Execer.
+func (r *ReadOnlyDB) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	var ct pgconn.CommandTag
+	err := WithReadOnlyTx(ctx, r.db, func(tx pgx.Tx) error {
+		var err error
+		ct, err = tx.Exec(ctx, sql, args...)
+		return err
+	})
+	return ct, err
+}
+
+// Begin implements Beginner. The returned transaction is read only.
+func (r *ReadOnlyDB) Begin(ctx context.Context) (pgx.Tx, error) {
+	return beginReadOnly(ctx, r.db)
+}
+
+// WithReadOnlyTx calls fn in a read only transaction on db. If db is a pgx.Tx a savepoint is used instead. The
+// transaction or savepoint is rolled back when fn returns.
+func WithReadOnlyTx(ctx context.Context, db Beginner, fn func(pgx.Tx) error) error {
+	tx, err := beginReadOnly(ctx, db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	return fn(tx)
+}
+
+func beginReadOnly(ctx context.Context, db Beginner) (pgx.Tx, error) {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(ctx, "set transaction read only")
+	if err != nil {
+		tx.Rollback(ctx)
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+// readOnlyRows rolls back tx when the rows are closed or all rows have been read.
+type readOnlyRows struct {
+	pgx.Rows
+	ctx      context.Context
+	tx       pgx.Tx
+	finished bool
+}
+
+func (r *readOnlyRows) Next() bool {
+	if r.Rows.Next() {
+		return true
+	}
+
+	r.finish()
+	return false
+}
+
+func (r *readOnlyRows) Close() {
+	r.Rows.Close()
+	r.finish()
+}
+
+func (r *readOnlyRows) finish() {
+	if r.finished {
+		return
+	}
+	r.finished = true
+
+	r.tx.Rollback(r.ctx)
+}
+
+// errRows is the pgx.Rows returned with an error by a query that could not be sent.
+type errRows struct {
+	err error
+}
+
+func (r errRows) Close()                                         {}
+func (r errRows) Err() error                                     { return r.err }
+func (r errRows) CommandTag() pgconn.CommandTag                  { return nil }
+func (r errRows) FieldDescriptions() []pgproto3.FieldDescription { return nil }
+func (r errRows) Next() bool                                     { return false }
+func (r errRows) Scan(dest ...interface{}) error                 { return r.err }
+func (r errRows) Values() ([]interface{}, error)                 { return nil, r.err }
+func (r errRows) RawValues() [][]byte                            { return nil }
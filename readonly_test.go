@@ -0,0 +1,102 @@
+package pgxutil_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgxutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func requireReadOnlyError(t *testing.T, err error) {
+	t.Helper()
+	var pgErr *pgconn.PgError
+	require.Truef(t, errors.As(err, &pgErr), "%v", err)
+	assert.Equal(t, "25006", pgErr.Code)
+}
+
+func TestReadOnlyDB(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn := connectPG(t, ctx)
+	defer closeConn(t, conn)
+
+	db := pgxutil.NewReadOnlyDB(conn)
+
+	n, err := pgxutil.SelectInt64(ctx, db, "select 42")
+	require.NoError(t, err)
+	assert.EqualValues(t, 42, n)
+
+	_, err = pgxutil.Exec(ctx, db, "create temporary table t (id int)")
+	requireReadOnlyError(t, err)
+
+	// The connection is usable and not read only after the wrapped queries.
+	_, err = conn.Exec(ctx, "create temporary table t (id int)")
+	require.NoError(t, err)
+}
+
+func TestReadOnlyDBInTx(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		_, err := tx.Exec(ctx, `create temporary table t (id int)`)
+		require.NoError(t, err)
+
+		db := pgxutil.NewReadOnlyDB(tx)
+
+		n, err := pgxutil.SelectInt64(ctx, db, "select count(*) from t")
+		require.NoError(t, err)
+		assert.EqualValues(t, 0, n)
+
+		_, err = pgxutil.Exec(ctx, db, "insert into t values (1)")
+		requireReadOnlyError(t, err)
+
+		_, err = tx.Exec(ctx, "insert into t values (1)")
+		require.NoError(t, err)
+	})
+}
+
+func TestWithReadOnlyTx(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		_, err := tx.Exec(ctx, `create temporary table t (id int)`)
+		require.NoError(t, err)
+
+		err = pgxutil.WithReadOnlyTx(ctx, tx, func(tx pgx.Tx) error {
+			_, err := tx.Exec(ctx, "insert into t values (1)")
+			return err
+		})
+		requireReadOnlyError(t, err)
+
+		_, err = tx.Exec(ctx, "insert into t values (1)")
+		require.NoError(t, err)
+	})
+}
+
+type failingBeginner struct {
+	err error
+}
+
+func (f failingBeginner) Begin(ctx context.Context) (pgx.Tx, error) {
+	return nil, f.err
+}
+
+func TestReadOnlyDBBeginError(t *testing.T) {
+	t.Parallel()
+
+	errFailed := errors.New("failed to connect")
+	db := pgxutil.NewReadOnlyDB(failingBeginner{err: errFailed})
+
+	_, err := pgxutil.SelectInt64(context.Background(), db, "select 42")
+	assert.ErrorIs(t, err, errFailed)
+
+	_, err = pgxutil.SelectAllMap(context.Background(), db, "select 42")
+	assert.ErrorIs(t, err, errFailed)
+}
@@ -0,0 +1,256 @@
+package pgxutil
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgxutil/build"
+)
+
+// recordValue returns the struct record points to. name is the name of the argument used in the error.
+func recordValue(name string, record interface{}) (reflect.Value, error) {
+	v := reflect.ValueOf(record)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("%s not a pointer to a struct", name)
+	}
+
+	return v.Elem(), nil
+}
+
+// recordColumn is a column of a struct used as a record.
+type recordColumn struct {
+	name  string
+	value interface{}
+
+	// defaultZero is true if the field has the default tag option and its zero value.
+	defaultZero bool
+
+	// pk is true if the field has the pk tag option.
+	pk bool
+
+	// version is true if the field has the version tag option. field is the field itself so it can be incremented. It
+	// is invalid if the field is in a nil embedded struct.
+	version bool
+	field   reflect.Value
+}
+
+// recordColumns returns the columns of the struct v as described by InsertStruct.
+func recordColumns(v reflect.Value) []recordColumn {
+	fields := exportedStructFields(v.Type(), nil)
+	columns := make([]recordColumn, 0, len(fields))
+	for _, sf := range fields {
+		name, ok := structFieldColumnName(sf.StructField)
+		if !ok {
+			continue
+		}
+
+		c := recordColumn{name: name}
+		fv, ok := fieldByIndexNoAlloc(v, sf.index)
+		if ok {
+			c.value = fv.Interface()
+			c.field = fv
+		}
+		c.defaultZero = hasTagOption(sf.StructField, "default") && (!ok || fv.IsZero())
+		c.pk = hasTagOption(sf.StructField, "pk")
+		c.version = hasTagOption(sf.StructField, "version")
+		columns = append(columns, c)
+	}
+
+	return columns
+}
+
+// InsertStruct inserts record, a pointer to a struct, into tableName. Each exported field is inserted into the column
+// named by its db tag or, for untagged fields, the field name converted to snake case. Fields tagged db:"-" are
+// skipped. Fields with the default tag option, e.g. db:"id,default", are omitted when they have their zero value so
+// the column default is used. Every column is returned and scanned back into record so generated values such as serial
+// IDs and timestamps are filled in.
+func InsertStruct(ctx context.Context, db Queryer, tableName string, record interface{}) error {
+	v, err := recordValue("record", record)
+	if err != nil {
+		return err
+	}
+
+	columns := recordColumns(v)
+	if len(columns) == 0 {
+		return fmt.Errorf("%v has no fields to insert", v.Type())
+	}
+
+	values := make(map[string]interface{}, len(columns))
+	returning := make([]string, 0, len(columns))
+	for _, c := range columns {
+		returning = append(returning, c.name)
+		if !c.defaultZero {
+			values[c.name] = c.value
+		}
+	}
+
+	sql, args := buildInsert(tableName, values)
+	return SelectStruct(ctx, db, record, fmt.Sprintf("%s returning %s", sql, build.QuoteIdentifierList(returning)), args...)
+}
+
+// UpdateStruct updates the rows of tableName whose whereColumns are equal to the fields of record, a pointer to a
+// struct, and sets every other column to the value of its field. Columns are named as described by InsertStruct. It
+// returns the number of rows updated.
+//
+// If a field has the version tag option, e.g. db:"version,version", optimistic locking is used as described by
+// UpdateVersioned. Only a row that still has the version of record is updated, the version column is incremented, and
+// ErrStaleRow is returned if no row was updated. On success the version field of record is incremented to match.
+func UpdateStruct(ctx context.Context, db Execer, tableName string, record interface{}, whereColumns []string) (int64, error) {
+	v, err := recordValue("record", record)
+	if err != nil {
+		return 0, err
+	}
+
+	columns := recordColumns(v)
+	whereArgs, err := recordWhereArgs(v.Type(), columns, whereColumns)
+	if err != nil {
+		return 0, err
+	}
+
+	setValues := make(map[string]interface{})
+	for _, c := range columns {
+		if _, ok := whereArgs[c.name]; !ok && !c.version {
+			setValues[c.name] = c.value
+		}
+	}
+	if len(setValues) == 0 {
+		return 0, fmt.Errorf("%v has no fields to update", v.Type())
+	}
+
+	return updateRecord(ctx, db, tableName, v.Type(), columns, setValues, whereArgs)
+}
+
+// UpdateStructChanges is like UpdateStruct except only the columns in changes are set. record is only used to find the
+// rows to update and is not modified except for its version field. Each column in changes must be a column of record.
+// This allows updating just the fields that were changed without overwriting concurrent changes to the other columns.
+func UpdateStructChanges(ctx context.Context, db Execer, tableName string, record interface{}, whereColumns []string, changes map[string]interface{}) (int64, error) {
+	v, err := recordValue("record", record)
+	if err != nil {
+		return 0, err
+	}
+
+	columns := recordColumns(v)
+	whereArgs, err := recordWhereArgs(v.Type(), columns, whereColumns)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(changes) == 0 {
+		return 0, fmt.Errorf("changes is empty")
+	}
+	for name := range changes {
+		c, ok := findRecordColumn(columns, name)
+		if !ok {
+			return 0, fmt.Errorf("%v has no column %s", v.Type(), name)
+		}
+		if c.version {
+			return 0, fmt.Errorf("changes includes the version column %s", name)
+		}
+	}
+
+	return updateRecord(ctx, db, tableName, v.Type(), columns, changes, whereArgs)
+}
+
+// updateRecord updates the rows matching whereArgs with setValues. If columns has a version column the update is
+// versioned and the version field is incremented.
+func updateRecord(ctx context.Context, db Execer, tableName string, recordType reflect.Type, columns []recordColumn, setValues, whereArgs map[string]interface{}) (int64, error) {
+	for _, c := range columns {
+		if !c.version {
+			continue
+		}
+
+		if !c.field.IsValid() || !isIntegerKind(c.field.Kind()) {
+			return 0, fmt.Errorf("version field %s of %v is not an integer", c.name, recordType)
+		}
+
+		n, err := UpdateVersioned(ctx, db, tableName, setValues, whereArgs, c.name, c.value)
+		if err != nil {
+			return n, err
+		}
+
+		if c.field.CanInt() {
+			c.field.SetInt(c.field.Int() + 1)
+		} else {
+			c.field.SetUint(c.field.Uint() + 1)
+		}
+
+		return n, nil
+	}
+
+	return Update(ctx, db, tableName, setValues, whereArgs)
+}
+
+func isIntegerKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	default:
+		return false
+	}
+}
+
+// recordWhereArgs returns the whereArgs matching the whereColumns of a record of recordType.
+func recordWhereArgs(recordType reflect.Type, columns []recordColumn, whereColumns []string) (map[string]interface{}, error) {
+	if len(whereColumns) == 0 {
+		return nil, fmt.Errorf("whereColumns is empty")
+	}
+
+	whereArgs := make(map[string]interface{}, len(whereColumns))
+	for _, name := range whereColumns {
+		c, ok := findRecordColumn(columns, name)
+		if !ok {
+			return nil, fmt.Errorf("%v has no column %s", recordType, name)
+		}
+		whereArgs[name] = c.value
+	}
+
+	return whereArgs, nil
+}
+
+func findRecordColumn(columns []recordColumn, name string) (recordColumn, bool) {
+	for _, c := range columns {
+		if c.name == name {
+			return c, true
+		}
+	}
+
+	return recordColumn{}, false
+}
+
+// Get selects the row of tableName whose primary key is pk into dest, a pointer to a struct. The primary key column is
+// the column of the field with the pk tag option, e.g. db:"user_id,pk", or id if no field has it. The columns of dest
+// are selected as named by InsertStruct. ErrNoRows is returned if there is no such row.
+func Get(ctx context.Context, db Queryer, tableName string, dest interface{}, pk interface{}) error {
+	return getRecord(ctx, db, tableName, dest, pk, "")
+}
+
+// getRecord implements Get. If condition is not empty it is also required to match.
+func getRecord(ctx context.Context, db Queryer, tableName string, dest interface{}, pk interface{}, condition string) error {
+	v, err := recordValue("dest", dest)
+	if err != nil {
+		return err
+	}
+
+	columns := recordColumns(v)
+	if len(columns) == 0 {
+		return fmt.Errorf("%v has no fields to select", v.Type())
+	}
+
+	names := make([]string, len(columns))
+	pkColumn := "id"
+	for i, c := range columns {
+		names[i] = c.name
+		if c.pk {
+			pkColumn = c.name
+		}
+	}
+
+	sql := fmt.Sprintf("select %s from %s where %s = $1", build.QuoteIdentifierList(names), tableName, pgx.Identifier{pkColumn}.Sanitize())
+	if condition != "" {
+		sql += " and " + condition
+	}
+	return SelectStruct(ctx, db, dest, sql, pk)
+}
@@ -0,0 +1,226 @@
+package pgxutil_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgxutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type insertStructUser struct {
+	ID        int64     `db:"id,default"`
+	Name      string    `db:"name"`
+	Height    int32     `db:",default"`
+	Nickname  *string   `db:"nickname"`
+	CreatedAt time.Time `db:"created_at,default"`
+	Ignored   string    `db:"-"`
+}
+
+func TestInsertStruct(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		_, err := tx.Exec(ctx, `create temporary table users (
+	id bigserial primary key,
+	name text not null,
+	height int not null default 70,
+	nickname text,
+	created_at timestamptz not null default now()
+)`)
+		require.NoError(t, err)
+
+		user := &insertStructUser{Name: "Adam", Ignored: "ignored"}
+		err = pgxutil.InsertStruct(ctx, tx, "users", user)
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, user.ID)
+		assert.Equal(t, "Adam", user.Name)
+		assert.EqualValues(t, 70, user.Height)
+		assert.Nil(t, user.Nickname)
+		assert.False(t, user.CreatedAt.IsZero())
+		assert.Equal(t, "ignored", user.Ignored)
+
+		nickname := "B"
+		user = &insertStructUser{ID: 10, Name: "Bill", Height: 68, Nickname: &nickname}
+		err = pgxutil.InsertStruct(ctx, tx, "users", user)
+		require.NoError(t, err)
+		assert.EqualValues(t, 10, user.ID)
+		assert.EqualValues(t, 68, user.Height)
+		require.NotNil(t, user.Nickname)
+		assert.Equal(t, "B", *user.Nickname)
+
+		type untagged struct {
+			ID   int64
+			Name string
+		}
+		_, err = tx.Exec(ctx, `create temporary table widgets (id int8 primary key, name text)`)
+		require.NoError(t, err)
+		widget := &untagged{ID: 1, Name: "foo"}
+		err = pgxutil.InsertStruct(ctx, tx, "widgets", widget)
+		require.NoError(t, err)
+		assert.Equal(t, untagged{ID: 1, Name: "foo"}, *widget)
+
+		err = pgxutil.InsertStruct(ctx, tx, "users", insertStructUser{})
+		assert.EqualError(t, err, "record not a pointer to a struct")
+	})
+}
+
+func TestUpdateStruct(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		_, err := tx.Exec(ctx, `create temporary table users (
+	id bigserial primary key,
+	name text not null,
+	height int not null default 70,
+	nickname text,
+	created_at timestamptz not null default now()
+)`)
+		require.NoError(t, err)
+
+		user := &insertStructUser{Name: "Adam"}
+		err = pgxutil.InsertStruct(ctx, tx, "users", user)
+		require.NoError(t, err)
+
+		user.Name = "Adam Smith"
+		user.Height = 72
+		n, err := pgxutil.UpdateStruct(ctx, tx, "users", user, []string{"id"})
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, n)
+
+		var updated insertStructUser
+		err = pgxutil.SelectStruct(ctx, tx, &updated, "select * from users where id = $1", user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Adam Smith", updated.Name)
+		assert.EqualValues(t, 72, updated.Height)
+
+		_, err = pgxutil.UpdateStruct(ctx, tx, "users", user, nil)
+		assert.EqualError(t, err, "whereColumns is empty")
+
+		_, err = pgxutil.UpdateStruct(ctx, tx, "users", user, []string{"missing"})
+		assert.EqualError(t, err, "pgxutil_test.insertStructUser has no column missing")
+	})
+}
+
+func TestUpdateStructChanges(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		_, err := tx.Exec(ctx, `create temporary table users (
+	id bigserial primary key,
+	name text not null,
+	height int not null default 70,
+	nickname text,
+	created_at timestamptz not null default now()
+)`)
+		require.NoError(t, err)
+
+		user := &insertStructUser{Name: "Adam"}
+		err = pgxutil.InsertStruct(ctx, tx, "users", user)
+		require.NoError(t, err)
+
+		// A concurrent change to a column not in changes is preserved.
+		_, err = tx.Exec(ctx, "update users set name = 'Adam Smith' where id = $1", user.ID)
+		require.NoError(t, err)
+
+		n, err := pgxutil.UpdateStructChanges(ctx, tx, "users", user, []string{"id"}, map[string]interface{}{"height": 72})
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, n)
+
+		var updated insertStructUser
+		err = pgxutil.SelectStruct(ctx, tx, &updated, "select * from users where id = $1", user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Adam Smith", updated.Name)
+		assert.EqualValues(t, 72, updated.Height)
+
+		_, err = pgxutil.UpdateStructChanges(ctx, tx, "users", user, []string{"id"}, map[string]interface{}{"missing": 1})
+		assert.EqualError(t, err, "pgxutil_test.insertStructUser has no column missing")
+	})
+}
+
+func TestGet(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		_, err := tx.Exec(ctx, `create temporary table users (
+	id bigserial primary key,
+	name text not null,
+	height int not null default 70,
+	nickname text,
+	created_at timestamptz not null default now(),
+	extra text
+)`)
+		require.NoError(t, err)
+
+		user := &insertStructUser{Name: "Adam"}
+		err = pgxutil.InsertStruct(ctx, tx, "users", user)
+		require.NoError(t, err)
+
+		var got insertStructUser
+		err = pgxutil.Get(ctx, tx, "users", &got, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, *user, got)
+
+		err = pgxutil.Get(ctx, tx, "users", &got, user.ID+1)
+		assert.ErrorIs(t, err, pgxutil.ErrNoRows)
+
+		type account struct {
+			Code string `db:"code,pk"`
+			Name string `db:"name"`
+		}
+		_, err = tx.Exec(ctx, `create temporary table accounts (code text primary key, name text)`)
+		require.NoError(t, err)
+		_, err = tx.Exec(ctx, `insert into accounts values ('a', 'Alpha')`)
+		require.NoError(t, err)
+
+		var a account
+		err = pgxutil.Get(ctx, tx, "accounts", &a, "a")
+		require.NoError(t, err)
+		assert.Equal(t, account{Code: "a", Name: "Alpha"}, a)
+
+		err = pgxutil.Get(ctx, tx, "accounts", a, "a")
+		assert.EqualError(t, err, "dest not a pointer to a struct")
+	})
+}
+
+func TestUpdateStructVersion(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		_, err := tx.Exec(ctx, `create temporary table documents (id bigserial primary key, body text, version int not null default 1)`)
+		require.NoError(t, err)
+
+		type document struct {
+			ID      int64  `db:"id,default"`
+			Body    string `db:"body"`
+			Version int32  `db:"version,default,version"`
+		}
+
+		doc := &document{Body: "first"}
+		err = pgxutil.InsertStruct(ctx, tx, "documents", doc)
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, doc.Version)
+
+		stale := *doc
+
+		doc.Body = "second"
+		_, err = pgxutil.UpdateStruct(ctx, tx, "documents", doc, []string{"id"})
+		require.NoError(t, err)
+		assert.EqualValues(t, 2, doc.Version)
+
+		stale.Body = "conflict"
+		_, err = pgxutil.UpdateStruct(ctx, tx, "documents", &stale, []string{"id"})
+		assert.ErrorIs(t, err, pgxutil.ErrStaleRow)
+		assert.EqualValues(t, 1, stale.Version)
+
+		_, err = pgxutil.UpdateStructChanges(ctx, tx, "documents", &stale, []string{"id"}, map[string]interface{}{"body": "conflict"})
+		assert.ErrorIs(t, err, pgxutil.ErrStaleRow)
+
+		_, err = pgxutil.UpdateStructChanges(ctx, tx, "documents", doc, []string{"id"}, map[string]interface{}{"body": "third"})
+		require.NoError(t, err)
+		assert.EqualValues(t, 3, doc.Version)
+
+		var got document
+		err = pgxutil.Get(ctx, tx, "documents", &got, doc.ID)
+		require.NoError(t, err)
+		assert.Equal(t, document{ID: doc.ID, Body: "third", Version: 3}, got)
+	})
+}
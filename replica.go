@@ -0,0 +1,230 @@
+package pgxutil
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+)
+
+// Route is how ReplicaRouter chooses between the primary and the replicas for a query. See Options.Route.
+type Route int8
+
+const (
+	// RouteAuto sends read-only queries to a replica and other queries to the primary.
+	RouteAuto Route = iota
+
+	// RoutePrimary sends the query to the primary.
+	RoutePrimary
+
+	// RouteReplica sends the query to a replica even if it does not appear to be read-only, e.g. a select of a function
+	// known not to write. It falls back to the primary like a read-only query. Exec is not affected.
+	RouteReplica
+)
+
+// ReplicaRouter is a Handle that sends read-only queries to replicas and everything else to the primary. Passing a
+// ReplicaRouter to the Select functions reads from a replica. A query is read-only if it is accepted by the check
+// described by Options.ReadOnly. Options.Route overrides the choice for a query. Replicas are used in turn. If a
+// replica fails with a connection error before returning any rows the query is retried on the next replica and finally
+// on the primary. Transactions, batches, and copies always use the primary.
+//
+// The zero value is not usable. Primary must be set. The fields must not be changed after the ReplicaRouter is first
+// used.
+type ReplicaRouter struct {
+	// Primary receives all writes and the read-only queries no replica could handle.
+	Primary Handle
+
+	// Replicas receive read-only queries.
+	Replicas []Queryer
+
+	// MaxLagBytes excludes a replica whose replay position, pg_last_wal_replay_lsn(), is more than MaxLagBytes behind
+	// the primary's pg_current_wal_lsn(). Zero disables the check. A replica that cannot be checked is also excluded.
+	MaxLagBytes int64
+
+	// LagCheckInterval is how often the lag of each replica is checked. If zero 5 seconds is used.
+	LagCheckInterval time.Duration
+
+	mu       sync.Mutex
+	next     int
+	replicas []*routedReplica
+}
+
+type routedReplica struct {
+	db        Queryer
+	checkedAt time.Time
+	stale     bool
+}
+
+// Query implements Queryer.
+func (r *ReplicaRouter) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	switch OptionsFromContext(ctx).Route {
+	case RoutePrimary:
+		return r.Primary.Query(ctx, sql, args...)
+	case RouteAuto:
+		if !isReadOnlySQL(sql) {
+			return r.Primary.Query(ctx, sql, args...)
+		}
+	}
+
+	for _, replica := range r.availableReplicas(ctx) {
+		rows, err := peekQuery(ctx, replica, sql, args)
+		if err == nil || !isConnectionError(err) || ctx.Err() != nil {
+			return rows, err
+		}
+	}
+
+	return r.Primary.Query(ctx, sql, args...)
+}
+
+// Exec implements Execer. The statement is always sent to the primary.
+func (r *ReplicaRouter) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	return r.Primary.Exec(ctx, sql, args...)
+}
+
+// Begin implements Beginner. The transaction is always begun on the primary.
+func (r *ReplicaRouter) Begin(ctx context.Context) (pgx.Tx, error) {
+	return r.Primary.Begin(ctx)
+}
+
+// CopyFrom implements CopyFromer. The copy is always sent to the primary.
+func (r *ReplicaRouter) CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error) {
+	return r.Primary.CopyFrom(ctx, tableName, columnNames, rowSrc)
+}
+
+// SendBatch implements BatchSender. The batch is always sent to the primary.
+func (r *ReplicaRouter) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults {
+	return r.Primary.SendBatch(ctx, b)
+}
+
+// availableReplicas returns the replicas that are not stale starting with the next one in turn. The lag of the replicas
+// whose last check is older than LagCheckInterval is checked first. The checks run without holding r.mu so a slow
+// replica does not block the queries that do not need a check.
+func (r *ReplicaRouter) availableReplicas(ctx context.Context) []Queryer {
+	r.mu.Lock()
+	if r.replicas == nil {
+		r.replicas = make([]*routedReplica, len(r.Replicas))
+		for i, db := range r.Replicas {
+			r.replicas[i] = &routedReplica{db: db}
+		}
+	}
+
+	ordered := make([]*routedReplica, len(r.replicas))
+	var due []*routedReplica
+	for i := range r.replicas {
+		replica := r.replicas[(r.next+i)%len(r.replicas)]
+		ordered[i] = replica
+		if r.MaxLagBytes > 0 && time.Since(replica.checkedAt) >= r.lagCheckInterval() {
+			// Setting checkedAt now keeps concurrent queries from checking the same replica.
+			replica.checkedAt = time.Now()
+			due = append(due, replica)
+		}
+	}
+	if len(r.replicas) > 0 {
+		r.next = (r.next + 1) % len(r.replicas)
+	}
+	r.mu.Unlock()
+
+	for _, replica := range due {
+		stale, err := r.checkLag(ctx, replica.db)
+		r.mu.Lock()
+		if err != nil && ctx.Err() != nil {
+			// The check was interrupted by the caller, not failed by the replica. Check it again next time.
+			replica.checkedAt = time.Time{}
+		} else {
+			replica.stale = stale
+		}
+		r.mu.Unlock()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	available := make([]Queryer, 0, len(ordered))
+	for _, replica := range ordered {
+		if r.MaxLagBytes > 0 && replica.stale {
+			continue
+		}
+		available = append(available, replica.db)
+	}
+
+	return available
+}
+
+func (r *ReplicaRouter) lagCheckInterval() time.Duration {
+	if r.LagCheckInterval == 0 {
+		return 5 * time.Second
+	}
+	return r.LagCheckInterval
+}
+
+// checkLag returns whether db is more than MaxLagBytes behind the primary. A replica that cannot be checked within
+// LagCheckInterval is stale. err is the error of the check if it failed.
+func (r *ReplicaRouter) checkLag(ctx context.Context, db Queryer) (stale bool, err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.lagCheckInterval())
+	defer cancel()
+
+	var replayLSN pgtype.Text
+	_, err = queryRows(ctx, db, "select pg_last_wal_replay_lsn()::text", nil, func(rows pgx.Rows) error {
+		return rows.Scan(&replayLSN)
+	})
+	if err != nil {
+		return true, err
+	}
+
+	// A null replay position means the server is not in recovery and is not behind.
+	var lag pgtype.Float8
+	_, err = queryRows(ctx, r.Primary, "select pg_wal_lsn_diff(pg_current_wal_lsn(), $1::text::pg_lsn)", []interface{}{replayLSN}, func(rows pgx.Rows) error {
+		return rows.Scan(&lag)
+	})
+	if err != nil {
+		return true, err
+	}
+
+	return lag.Status == pgtype.Present && lag.Float > float64(r.MaxLagBytes), nil
+}
+
+// isConnectionError reports whether err is an error other than one returned by PostgreSQL or a canceled context.
+func isConnectionError(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return false
+	}
+
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+// peekQuery sends the query to db and reads the first row so errors that occur before any rows are received are
+// returned immediately.
+func peekQuery(ctx context.Context, db Queryer, sql string, args []interface{}) (pgx.Rows, error) {
+	rows, err := db.Query(ctx, sql, args...)
+	if err != nil {
+		return rows, err
+	}
+
+	pr := &peekedRows{Rows: rows, peeked: true}
+	pr.hasRow = rows.Next()
+	if !pr.hasRow && rows.Err() != nil {
+		return pr, rows.Err()
+	}
+
+	return pr, nil
+}
+
+// peekedRows replays the result of the first call to Next made by peekQuery.
+type peekedRows struct {
+	pgx.Rows
+	peeked bool
+	hasRow bool
+}
+
+func (r *peekedRows) Next() bool {
+	if r.peeked {
+		r.peeked = false
+		return r.hasRow
+	}
+
+	return r.Rows.Next()
+}
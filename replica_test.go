@@ -0,0 +1,175 @@
+package pgxutil_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgxutil"
+	"github.com/jackc/pgxutil/pgxutiltest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplicaRouter(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	primary := connectPG(t, ctx)
+	defer closeConn(t, primary)
+
+	replica := connectPG(t, ctx)
+	defer closeConn(t, replica)
+
+	router := &pgxutil.ReplicaRouter{Primary: primary, Replicas: []pgxutil.Queryer{replica}, MaxLagBytes: 1024}
+
+	primaryPID, err := pgxutil.SelectInt64(ctx, primary, "select pg_backend_pid()")
+	require.NoError(t, err)
+	replicaPID, err := pgxutil.SelectInt64(ctx, replica, "select pg_backend_pid()")
+	require.NoError(t, err)
+
+	pid, err := pgxutil.SelectInt64(ctx, router, "select pg_backend_pid()")
+	require.NoError(t, err)
+	assert.Equal(t, replicaPID, pid)
+
+	pids, err := pgxutil.SelectAllInt64(ctx, router, "select pg_backend_pid() from generate_series(1, 3)")
+	require.NoError(t, err)
+	assert.Equal(t, []int64{replicaPID, replicaPID, replicaPID}, pids)
+
+	_, err = pgxutil.SelectInt64(ctx, router, "select 1/0")
+	require.Error(t, err)
+
+	// Writes go to the primary. The temporary table is not visible on the replica connection.
+	_, err = pgxutil.Exec(ctx, router, "create temporary table t (id int)")
+	require.NoError(t, err)
+	row, err := pgxutil.Insert(ctx, router, "t", map[string]interface{}{"id": 1})
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, row["id"])
+	_, err = pgxutil.SelectInt64(ctx, router, "select count(*) from t")
+	require.Error(t, err)
+
+	tx, err := router.Begin(ctx)
+	require.NoError(t, err)
+	pid, err = pgxutil.SelectInt64(ctx, tx, "select pg_backend_pid()")
+	require.NoError(t, err)
+	assert.Equal(t, primaryPID, pid)
+	require.NoError(t, tx.Rollback(ctx))
+}
+
+func TestReplicaRouterFallback(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	primary := connectPG(t, ctx)
+	defer closeConn(t, primary)
+
+	replica := connectPG(t, ctx)
+	closeConn(t, replica)
+
+	router := &pgxutil.ReplicaRouter{Primary: primary, Replicas: []pgxutil.Queryer{replica}}
+
+	primaryPID, err := pgxutil.SelectInt64(ctx, primary, "select pg_backend_pid()")
+	require.NoError(t, err)
+
+	pid, err := pgxutil.SelectInt64(ctx, router, "select pg_backend_pid()")
+	require.NoError(t, err)
+	assert.Equal(t, primaryPID, pid)
+}
+
+func TestReplicaRouterRoute(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	primary := connectPG(t, ctx)
+	defer closeConn(t, primary)
+
+	replica := connectPG(t, ctx)
+	defer closeConn(t, replica)
+
+	router := &pgxutil.ReplicaRouter{Primary: primary, Replicas: []pgxutil.Queryer{replica}}
+
+	primaryPID, err := pgxutil.SelectInt64(ctx, primary, "select pg_backend_pid()")
+	require.NoError(t, err)
+	replicaPID, err := pgxutil.SelectInt64(ctx, replica, "select pg_backend_pid()")
+	require.NoError(t, err)
+
+	pid, err := pgxutil.SelectInt64(ctx, router, "select pg_backend_pid()", pgxutil.Options{Route: pgxutil.RoutePrimary})
+	require.NoError(t, err)
+	assert.Equal(t, primaryPID, pid)
+
+	primaryCtx := pgxutil.WithOptions(ctx, pgxutil.Options{Route: pgxutil.RoutePrimary})
+	pid, err = pgxutil.SelectInt64(primaryCtx, router, "select pg_backend_pid()")
+	require.NoError(t, err)
+	assert.Equal(t, primaryPID, pid)
+
+	// An argument takes precedence over the context.
+	pid, err = pgxutil.SelectInt64(primaryCtx, router, "select pg_backend_pid()", pgxutil.Options{Route: pgxutil.RouteReplica})
+	require.NoError(t, err)
+	assert.Equal(t, replicaPID, pid)
+
+	// The word update makes the query look like it writes.
+	sql := "with t as (select 'update' as word) select pg_backend_pid() from t"
+	pid, err = pgxutil.SelectInt64(ctx, router, sql)
+	require.NoError(t, err)
+	assert.Equal(t, primaryPID, pid)
+
+	pid, err = pgxutil.SelectInt64(ctx, router, sql, pgxutil.Options{Route: pgxutil.RouteReplica})
+	require.NoError(t, err)
+	assert.Equal(t, replicaPID, pid)
+}
+
+func TestReplicaRouterLagCheckCanceled(t *testing.T) {
+	t.Parallel()
+
+	primary := &flakyDB{}
+	primary.ExpectQuery("select pg_wal_lsn_diff(pg_current_wal_lsn(), $1::text::pg_lsn)").
+		ReturnRows(pgxutiltest.NewRows("pg_wal_lsn_diff").Types(pgtype.Float8OID).AddRow(0.0))
+
+	replica := &pgxutiltest.FakeQueryer{}
+	replica.ExpectQuery("select pg_last_wal_replay_lsn()::text").ReturnError(context.Canceled)
+	replica.ExpectQuery("select 1").ReturnError(context.Canceled)
+	replica.ExpectQuery("select pg_last_wal_replay_lsn()::text").
+		ReturnRows(pgxutiltest.NewRows("pg_last_wal_replay_lsn").Types(pgtype.TextOID).AddRow("0/0"))
+	replica.ExpectQuery("select 1").ReturnRows(pgxutiltest.NewRows("n").Types(pgtype.Int8OID).AddRow(int64(1)))
+
+	router := &pgxutil.ReplicaRouter{Primary: primary, Replicas: []pgxutil.Queryer{replica}, MaxLagBytes: 1024}
+
+	// A check interrupted by the caller does not make the replica stale.
+	canceledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := pgxutil.SelectInt64(canceledCtx, router, "select 1")
+	require.ErrorIs(t, err, context.Canceled)
+
+	n, err := pgxutil.SelectInt64(context.Background(), router, "select 1")
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, n)
+	require.NoError(t, replica.ExpectationsWereMet())
+	require.NoError(t, primary.ExpectationsWereMet())
+}
+
+func TestReplicaRouterLock(t *testing.T) {
+	t.Parallel()
+
+	primary := &flakyDB{}
+	primary.ExpectQuery("select id from jobs\nfor update skip locked").
+		ReturnRows(pgxutiltest.NewRows("id").Types(pgtype.Int8OID).AddRow(int64(1)))
+	replica := &pgxutiltest.FakeQueryer{}
+	replica.ExpectQuery("select id from jobs\nfor update skip locked").
+		ReturnRows(pgxutiltest.NewRows("id").Types(pgtype.Int8OID).AddRow(int64(2)))
+
+	router := &pgxutil.ReplicaRouter{Primary: primary, Replicas: []pgxutil.Queryer{replica}}
+
+	// A locking select cannot run in the read-only transaction of a replica.
+	id, err := pgxutil.SelectInt64(context.Background(), router, "select id from jobs", pgxutil.Options{Lock: pgxutil.LockForUpdate, LockWait: pgxutil.LockSkipLocked})
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, id)
+	require.NoError(t, primary.ExpectationsWereMet())
+	assert.Error(t, replica.ExpectationsWereMet())
+}
@@ -0,0 +1,116 @@
+package pgxutil
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+)
+
+// RetryDB is a Handle that retries queries and statements that fail because of a transient connection problem. It
+// should wrap a *pgxpool.Pool so each attempt can use a different connection. Pass it to the Select functions and Exec
+// to retry them.
+//
+// Errors that are guaranteed to have occurred before anything was sent to the server, e.g. a connection that was
+// found to be closed, and cannot_connect_now (57P03) while a server is starting are always retried. Other connection
+// errors, admin_shutdown (57P01), and crash_shutdown (57P02) may occur after PostgreSQL received and even ran the query.
+// They are only retried for queries marked with Options.RetrySafe. A query is only retried if it fails before any
+// rows are returned. Transactions, copies, and batches are passed through without retries.
+//
+// The zero value is not usable. DB must be set.
+type RetryDB struct {
+	// DB is the wrapped handle.
+	DB Handle
+
+	// MaxAttempts is the maximum number of times a query is sent. If zero 3 is used.
+	MaxAttempts int
+}
+
+// Query implements Queryer.
+func (r *RetryDB) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	var rows pgx.Rows
+	err := r.retry(ctx, func() error {
+		var err error
+		rows, err = peekQuery(ctx, r.DB, sql, args)
+		return err
+	})
+	return rows, err
+}
+
+// Exec implements Execer.
+func (r *RetryDB) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	var ct pgconn.CommandTag
+	err := r.retry(ctx, func() error {
+		var err error
+		ct, err = r.DB.Exec(ctx, sql, args...)
+		return err
+	})
+	return ct, err
+}
+
+// Begin implements Beginner. The transaction is not retried.
+func (r *RetryDB) Begin(ctx context.Context) (pgx.Tx, error) {
+	return r.DB.Begin(ctx)
+}
+
+// CopyFrom implements CopyFromer. The copy is not retried.
+func (r *RetryDB) CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error) {
+	return r.DB.CopyFrom(ctx, tableName, columnNames, rowSrc)
+}
+
+// SendBatch implements BatchSender. The batch is not retried.
+func (r *RetryDB) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults {
+	return r.DB.SendBatch(ctx, b)
+}
+
+// retry calls fn until it succeeds, fails with an error that must not be retried, or MaxAttempts is reached. There is
+// a randomized, increasing delay between attempts.
+func (r *RetryDB) retry(ctx context.Context, fn func() error) error {
+	maxAttempts := r.MaxAttempts
+	if maxAttempts == 0 {
+		maxAttempts = 3
+	}
+	retrySafe := OptionsFromContext(ctx).RetrySafe
+
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= maxAttempts || ctx.Err() != nil {
+			return err
+		}
+
+		safe, transient := classifyConnError(err)
+		if !safe && !(transient && retrySafe) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(retryDelay(attempt)):
+		}
+	}
+}
+
+// classifyConnError reports whether err is a connection error that is safe to retry because it occurred before the
+// query was sent, and whether err is a transient connection error after which the query may or may not have run.
+func classifyConnError(err error) (safe bool, transient bool) {
+	var retryable interface{ SafeToRetry() bool }
+	if errors.As(err, &retryable) && retryable.SafeToRetry() {
+		return true, true
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case "57P03": // cannot_connect_now
+			return true, true
+		case "57P01", "57P02": // admin_shutdown, crash_shutdown
+			return false, true
+		}
+		return false, false
+	}
+
+	return false, isConnectionError(err)
+}
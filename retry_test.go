@@ -0,0 +1,105 @@
+package pgxutil_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgxutil"
+	"github.com/jackc/pgxutil/pgxutiltest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyDB counts attempts. Queries are sent to FakeQueryer. The first failures statements fail with err.
+type flakyDB struct {
+	pgxutiltest.FakeQueryer
+	failures int
+	err      error
+	attempts int
+}
+
+func (f *flakyDB) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	f.attempts++
+	return f.FakeQueryer.Query(ctx, sql, args...)
+}
+
+func (f *flakyDB) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	f.attempts++
+	if f.attempts <= f.failures {
+		return nil, f.err
+	}
+	return pgconn.CommandTag("UPDATE 1"), nil
+}
+
+func (f *flakyDB) Begin(ctx context.Context) (pgx.Tx, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (f *flakyDB) CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error) {
+	return 0, errors.New("not implemented")
+}
+
+func (f *flakyDB) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults {
+	return nil
+}
+
+func TestRetryDB(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name      string
+		err       error
+		failures  int
+		retrySafe bool
+		attempts  int
+		ok        bool
+	}{
+		{name: "cannot_connect_now", err: &pgconn.PgError{Code: "57P03"}, failures: 2, attempts: 3, ok: true},
+		{name: "too many failures", err: &pgconn.PgError{Code: "57P03"}, failures: 3, attempts: 3, ok: false},
+		{name: "admin_shutdown", err: &pgconn.PgError{Code: "57P01"}, failures: 1, attempts: 1, ok: false},
+		{name: "admin_shutdown retry safe", err: &pgconn.PgError{Code: "57P01"}, failures: 1, retrySafe: true, attempts: 2, ok: true},
+		{name: "connection error", err: errors.New("connection reset"), failures: 1, attempts: 1, ok: false},
+		{name: "connection error retry safe", err: errors.New("connection reset"), failures: 1, retrySafe: true, attempts: 2, ok: true},
+		{name: "unique_violation", err: &pgconn.PgError{Code: "23505"}, failures: 1, retrySafe: true, attempts: 1, ok: false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			db := &flakyDB{}
+			for i := 0; i < tt.failures; i++ {
+				db.ExpectQuery("select n").ReturnError(tt.err)
+			}
+			db.ExpectQuery("select n").ReturnRows(pgxutiltest.NewRows("n").AddRow(int64(42)))
+			n, err := pgxutil.SelectInt64(ctx, &pgxutil.RetryDB{DB: db}, "select n", pgxutil.Options{RetrySafe: tt.retrySafe})
+			assert.Equal(t, tt.attempts, db.attempts)
+			if tt.ok {
+				require.NoError(t, err)
+				assert.EqualValues(t, 42, n)
+			} else {
+				require.ErrorIs(t, err, tt.err)
+			}
+
+			db = &flakyDB{failures: tt.failures, err: tt.err}
+			ctx = pgxutil.WithOptions(ctx, pgxutil.Options{RetrySafe: tt.retrySafe})
+			_, err = pgxutil.Exec(ctx, &pgxutil.RetryDB{DB: db}, "update t set n = 1")
+			assert.Equal(t, tt.attempts, db.attempts)
+			if tt.ok {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, tt.err)
+			}
+		})
+	}
+}
+
+func TestRetryDBMaxAttempts(t *testing.T) {
+	t.Parallel()
+
+	db := &flakyDB{failures: 10, err: &pgconn.PgError{Code: "57P03"}}
+	_, err := pgxutil.Exec(context.Background(), &pgxutil.RetryDB{DB: db, MaxAttempts: 5}, "update t set n = 1")
+	require.Error(t, err)
+	assert.Equal(t, 5, db.attempts)
+}
@@ -0,0 +1,160 @@
+package pgxutil
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// Table describes a table or table-like relation.
+type Table struct {
+	Schema string
+	Name   string
+
+	// Kind is one of table, partitioned table, view, materialized view, or foreign table.
+	Kind string
+}
+
+// Column describes a column of a table.
+type Column struct {
+	Name string
+
+	// DataType is the name of the data type including any modifier as formatted by PostgreSQL. e.g. integer or
+	// character varying(20).
+	DataType string
+
+	// Nullable is false if the column has a not null constraint.
+	Nullable bool
+
+	// Default is the default expression of the column or nil if it has none.
+	Default *string
+
+	// Position is the number of the column in the table starting at 1. Dropped columns leave gaps.
+	Position int
+}
+
+// Index describes an index of a table.
+type Index struct {
+	Name string
+
+	// Columns are the indexed columns in order. Expressions in the index are omitted.
+	Columns []string
+
+	Unique  bool
+	Primary bool
+
+	// Definition is the create index statement for the index.
+	Definition string
+}
+
+// ListTables returns the tables, views, materialized views, and foreign tables of all schemas other than the system
+// schemas pg_catalog, information_schema, pg_toast, and the temporary schemas. They are ordered by schema and name.
+func ListTables(ctx context.Context, db Queryer) ([]Table, error) {
+	var tables []Table
+	err := selectRows(ctx, db, `select n.nspname, c.relname,
+	case c.relkind
+		when 'r' then 'table'
+		when 'p' then 'partitioned table'
+		when 'v' then 'view'
+		when 'm' then 'materialized view'
+		when 'f' then 'foreign table'
+	end
+from pg_class c
+	join pg_namespace n on n.oid = c.relnamespace
+where c.relkind in ('r', 'p', 'v', 'm', 'f')
+	and n.nspname not like 'pg\_%'
+	and n.nspname <> 'information_schema'
+order by n.nspname, c.relname`,
+		nil,
+		func(rows pgx.Rows) error {
+			var t Table
+			err := rows.Scan(&t.Schema, &t.Name, &t.Kind)
+			tables = append(tables, t)
+			return err
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return tables, nil
+}
+
+// TableExists returns true if tableName exists. tableName is resolved like a table name in a query so it may be schema
+// qualified and otherwise is found through the search_path. It must be quoted if it requires quoting in SQL.
+func TableExists(ctx context.Context, db Queryer, tableName string) (bool, error) {
+	return SelectBool(ctx, db, "select to_regclass($1) is not null", tableName)
+}
+
+// ListColumns returns the columns of tableName in order. tableName is resolved as described by TableExists. An error is
+// returned if it does not exist.
+func ListColumns(ctx context.Context, db Queryer, tableName string) ([]Column, error) {
+	var columns []Column
+	err := selectRows(ctx, db, `select a.attname, format_type(a.atttypid, a.atttypmod), not a.attnotnull,
+	pg_get_expr(d.adbin, d.adrelid), a.attnum::int4
+from pg_attribute a
+	left join pg_attrdef d on d.adrelid = a.attrelid and d.adnum = a.attnum
+where a.attrelid = $1::regclass
+	and a.attnum > 0
+	and not a.attisdropped
+order by a.attnum`,
+		[]interface{}{tableName},
+		func(rows pgx.Rows) error {
+			var c Column
+			var position int32
+			err := rows.Scan(&c.Name, &c.DataType, &c.Nullable, &c.Default, &position)
+			c.Position = int(position)
+			columns = append(columns, c)
+			return err
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return columns, nil
+}
+
+// ColumnExists returns true if tableName exists and has the column columnName. tableName is resolved as described by
+// TableExists. columnName is not resolved so it must not be quoted.
+func ColumnExists(ctx context.Context, db Queryer, tableName, columnName string) (bool, error) {
+	return SelectBool(ctx, db, `select exists(
+	select 1
+	from pg_attribute
+	where attrelid = to_regclass($1)
+		and attname = $2
+		and attnum > 0
+		and not attisdropped
+)`, tableName, columnName)
+}
+
+// ListIndexes returns the indexes of tableName ordered by name. tableName is resolved as described by TableExists. An
+// error is returned if it does not exist.
+func ListIndexes(ctx context.Context, db Queryer, tableName string) ([]Index, error) {
+	var indexes []Index
+	err := selectRows(ctx, db, `select i.relname,
+	array(
+		select a.attname
+		from unnest(ix.indkey::int2[]) with ordinality as k(attnum, n)
+			join pg_attribute a on a.attrelid = ix.indrelid and a.attnum = k.attnum
+		order by k.n
+	)::text[],
+	ix.indisunique, ix.indisprimary, pg_get_indexdef(ix.indexrelid)
+from pg_index ix
+	join pg_class i on i.oid = ix.indexrelid
+where ix.indrelid = $1::regclass
+order by i.relname`,
+		[]interface{}{tableName},
+		func(rows pgx.Rows) error {
+			var i Index
+			err := rows.Scan(&i.Name, &i.Columns, &i.Unique, &i.Primary, &i.Definition)
+			indexes = append(indexes, i)
+			return err
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return indexes, nil
+}
@@ -0,0 +1,121 @@
+package pgxutil_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgxutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createIntrospectionTables(t testing.TB, ctx context.Context, tx pgx.Tx) {
+	err := pgxutil.MultiExec(ctx, tx, []string{
+		"create schema pgxutil_introspection",
+		`create table pgxutil_introspection.widgets (
+	id serial primary key,
+	name varchar(20) not null,
+	dropped int,
+	description text
+)`,
+		"alter table pgxutil_introspection.widgets drop column dropped",
+		"create unique index widgets_name_lower_idx on pgxutil_introspection.widgets (lower(name), description)",
+		"create view pgxutil_introspection.widget_names as select name from pgxutil_introspection.widgets",
+	})
+	require.NoError(t, err)
+}
+
+func TestListTables(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		createIntrospectionTables(t, ctx, tx)
+
+		tables, err := pgxutil.ListTables(ctx, tx)
+		require.NoError(t, err)
+
+		var found []pgxutil.Table
+		for _, table := range tables {
+			assert.NotEqual(t, "pg_catalog", table.Schema)
+			if table.Schema == "pgxutil_introspection" {
+				found = append(found, table)
+			}
+		}
+		assert.Equal(t, []pgxutil.Table{
+			{Schema: "pgxutil_introspection", Name: "widget_names", Kind: "view"},
+			{Schema: "pgxutil_introspection", Name: "widgets", Kind: "table"},
+		}, found)
+	})
+}
+
+func TestTableExists(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		createIntrospectionTables(t, ctx, tx)
+
+		exists, err := pgxutil.TableExists(ctx, tx, "pgxutil_introspection.widgets")
+		require.NoError(t, err)
+		assert.True(t, exists)
+
+		exists, err = pgxutil.TableExists(ctx, tx, "pgxutil_introspection.missing")
+		require.NoError(t, err)
+		assert.False(t, exists)
+
+		exists, err = pgxutil.ColumnExists(ctx, tx, "pgxutil_introspection.widgets", "name")
+		require.NoError(t, err)
+		assert.True(t, exists)
+
+		exists, err = pgxutil.ColumnExists(ctx, tx, "pgxutil_introspection.widgets", "dropped")
+		require.NoError(t, err)
+		assert.False(t, exists)
+
+		exists, err = pgxutil.ColumnExists(ctx, tx, "pgxutil_introspection.missing", "name")
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+}
+
+func TestListColumns(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		createIntrospectionTables(t, ctx, tx)
+
+		columns, err := pgxutil.ListColumns(ctx, tx, "pgxutil_introspection.widgets")
+		require.NoError(t, err)
+		require.Len(t, columns, 3)
+
+		require.NotNil(t, columns[0].Default)
+		assert.Contains(t, *columns[0].Default, "nextval")
+		columns[0].Default = nil
+		assert.Equal(t, []pgxutil.Column{
+			{Name: "id", DataType: "integer", Nullable: false, Position: 1},
+			{Name: "name", DataType: "character varying(20)", Nullable: false, Position: 2},
+			{Name: "description", DataType: "text", Nullable: true, Position: 4},
+		}, columns)
+
+		_, err = pgxutil.ListColumns(ctx, tx, "pgxutil_introspection.missing")
+		require.Error(t, err)
+	})
+}
+
+func TestListIndexes(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		createIntrospectionTables(t, ctx, tx)
+
+		indexes, err := pgxutil.ListIndexes(ctx, tx, "pgxutil_introspection.widgets")
+		require.NoError(t, err)
+		require.Len(t, indexes, 2)
+
+		assert.Equal(t, "widgets_name_lower_idx", indexes[0].Name)
+		assert.Equal(t, []string{"description"}, indexes[0].Columns)
+		assert.True(t, indexes[0].Unique)
+		assert.False(t, indexes[0].Primary)
+		assert.Contains(t, indexes[0].Definition, "CREATE UNIQUE INDEX widgets_name_lower_idx")
+
+		assert.Equal(t, "widgets_pkey", indexes[1].Name)
+		assert.Equal(t, []string{"id"}, indexes[1].Columns)
+		assert.True(t, indexes[1].Unique)
+		assert.True(t, indexes[1].Primary)
+	})
+}
@@ -0,0 +1,79 @@
+package pgxutil
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgxutil/build"
+)
+
+// SoftDeleteTable provides helpers for a table whose rows are soft deleted by setting a timestamp column instead of
+// being removed. A row is deleted when the column is not null. Delete sets the column and Get, SelectCount, and Update
+// ignore deleted rows.
+//
+// The package level functions called with Name are unscoped. e.g. pgxutil.Get(ctx, db, t.Name, &dst, id) returns a
+// deleted row and pgxutil.Delete(ctx, db, t.Name, whereArgs) permanently deletes rows. The Select functions take
+// arbitrary SQL, so they cannot be scoped automatically. Use NotDeleted in the SQL passed to them instead.
+type SoftDeleteTable struct {
+	// Name is the table name. Like the tableName argument of Update it is used as is.
+	Name string
+
+	// Column is the timestamp column that is set when a row is deleted. If empty deleted_at is used.
+	Column string
+}
+
+func (t SoftDeleteTable) column() string {
+	if t.Column == "" {
+		return "deleted_at"
+	}
+	return t.Column
+}
+
+// NotDeleted returns the condition that matches rows that have not been deleted. e.g. "deleted_at" is null.
+func (t SoftDeleteTable) NotDeleted() string {
+	return fmt.Sprintf("%s is null", build.QuoteIdentifier(t.column()))
+}
+
+// Delete soft deletes the rows that match whereArgs and have not already been deleted by setting Column to the current
+// time. If whereArgs is nil all rows are deleted. It returns the number of rows deleted.
+func (t SoftDeleteTable) Delete(ctx context.Context, db Execer, whereArgs map[string]interface{}) (int64, error) {
+	sql := fmt.Sprintf("update %s set %s = now()", t.Name, build.QuoteIdentifier(t.column()))
+	sql, args := appendScopedWhere(sql, nil, whereArgs, t.NotDeleted())
+	ct, err := Exec(ctx, db, sql, args...)
+	return ct.RowsAffected(), err
+}
+
+// Restore undeletes the deleted rows that match whereArgs by setting Column to null. If whereArgs is nil all rows are
+// restored. It returns the number of rows restored.
+func (t SoftDeleteTable) Restore(ctx context.Context, db Execer, whereArgs map[string]interface{}) (int64, error) {
+	quotedColumn := build.QuoteIdentifier(t.column())
+	sql := fmt.Sprintf("update %s set %s = null", t.Name, quotedColumn)
+	sql, args := appendScopedWhere(sql, nil, whereArgs, fmt.Sprintf("%s is not null", quotedColumn))
+	ct, err := Exec(ctx, db, sql, args...)
+	return ct.RowsAffected(), err
+}
+
+// Get is like the package level Get except ErrNoRows is returned if the row has been deleted.
+func (t SoftDeleteTable) Get(ctx context.Context, db Queryer, dest interface{}, pk interface{}) error {
+	return getRecord(ctx, db, t.Name, dest, pk, t.NotDeleted())
+}
+
+// SelectCount is like the package level SelectCount except deleted rows are not counted.
+func (t SoftDeleteTable) SelectCount(ctx context.Context, db Queryer, whereArgs map[string]interface{}) (int64, error) {
+	sql, args := appendScopedWhere("select count(*) from "+t.Name, nil, whereArgs, t.NotDeleted())
+	return SelectInt64(ctx, db, sql, args...)
+}
+
+// Update is like the package level Update except deleted rows are not updated. An error is returned if setValues is
+// empty.
+func (t SoftDeleteTable) Update(ctx context.Context, db Execer, setValues, whereArgs map[string]interface{}) (int64, error) {
+	if len(setValues) == 0 {
+		return 0, fmt.Errorf("setValues must not be empty")
+	}
+
+	assignments, args := build.Assignments(setValues, 1)
+	sql := fmt.Sprintf("update %s set %s", t.Name, assignments)
+	sql, args = appendScopedWhere(sql, args, whereArgs, t.NotDeleted())
+	ct, err := Exec(ctx, db, sql, args...)
+	return ct.RowsAffected(), err
+}
@@ -0,0 +1,96 @@
+package pgxutil_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgxutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSoftDeleteTable(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		_, err := tx.Exec(ctx, `create temporary table t (id serial primary key, name text, height int, deleted_at timestamptz)`)
+		require.NoError(t, err)
+		_, err = tx.Exec(ctx, `insert into t (name, height) values ('Adam', 72), ('Bill', 68), ('Charlie', 68)`)
+		require.NoError(t, err)
+
+		table := pgxutil.SoftDeleteTable{Name: "t"}
+		assert.Equal(t, `"deleted_at" is null`, table.NotDeleted())
+
+		n, err := table.Delete(ctx, tx, map[string]interface{}{"name": "Adam"})
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, n)
+
+		n, err = table.Delete(ctx, tx, map[string]interface{}{"name": "Adam"})
+		require.NoError(t, err)
+		assert.EqualValues(t, 0, n)
+
+		n, err = table.SelectCount(ctx, tx, nil)
+		require.NoError(t, err)
+		assert.EqualValues(t, 2, n)
+
+		n, err = pgxutil.SelectCount(ctx, tx, "t", nil)
+		require.NoError(t, err)
+		assert.EqualValues(t, 3, n)
+
+		type person struct {
+			ID   int32  `db:"id"`
+			Name string `db:"name"`
+		}
+		var p person
+		err = table.Get(ctx, tx, &p, 1)
+		assert.ErrorIs(t, err, pgxutil.ErrNoRows)
+		err = pgxutil.Get(ctx, tx, "t", &p, 1)
+		require.NoError(t, err)
+		assert.Equal(t, "Adam", p.Name)
+
+		n, err = table.Update(ctx, tx, map[string]interface{}{"height": 70}, nil)
+		require.NoError(t, err)
+		assert.EqualValues(t, 2, n)
+
+		names, err := pgxutil.SelectAllString(ctx, tx, "select name from t where "+table.NotDeleted()+" order by id")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Bill", "Charlie"}, names)
+
+		n, err = table.Restore(ctx, tx, map[string]interface{}{"name": "Adam"})
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, n)
+
+		err = table.Get(ctx, tx, &p, 1)
+		require.NoError(t, err)
+		assert.Equal(t, "Adam", p.Name)
+	})
+}
+
+func TestSoftDeleteTableColumn(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		_, err := tx.Exec(ctx, `create temporary table t (id serial primary key, name text, removed_at timestamptz)`)
+		require.NoError(t, err)
+		_, err = tx.Exec(ctx, `insert into t (name) values ('Adam'), ('Bill')`)
+		require.NoError(t, err)
+
+		table := pgxutil.SoftDeleteTable{Name: "t", Column: "removed_at"}
+		n, err := table.Delete(ctx, tx, nil)
+		require.NoError(t, err)
+		assert.EqualValues(t, 2, n)
+
+		n, err = pgxutil.SelectCountSQL(ctx, tx, "select count(*) from t where removed_at is not null")
+		require.NoError(t, err)
+		assert.EqualValues(t, 2, n)
+	})
+}
+
+func TestSoftDeleteTableUpdateNoValues(t *testing.T) {
+	t.Parallel()
+
+	db := &flakyDB{}
+	table := pgxutil.SoftDeleteTable{Name: "t"}
+	_, err := table.Update(context.Background(), db, nil, map[string]interface{}{"id": 1})
+	assert.EqualError(t, err, "setValues must not be empty")
+	assert.Equal(t, 0, db.attempts)
+}
@@ -0,0 +1,212 @@
+package pgxutil
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgproto3/v2"
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+)
+
+// SQLQueryer is the database/sql interface wrapped by SQLDB. It is implemented by *sql.DB, *sql.Tx, and *sql.Conn.
+type SQLQueryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// SQLDB adapts a database/sql handle to Queryer and Execer so the Select functions and Exec can be used with code
+// that still uses database/sql, with any PostgreSQL driver such as github.com/jackc/pgx/v4/stdlib or
+// github.com/lib/pq. NamedArgs work because they are rewritten to positional arguments before the query reaches db.
+//
+// Values are converted by database/sql, so a few functions behave differently than with pgx. Values are always read
+// in the text format. Functions that return the raw value like SelectString see the value as the driver returned it,
+// e.g. a time.Time is formatted by Go rather than PostgreSQL. Values and the maps of SelectMap contain the values of
+// the driver, e.g. an int64 for an int4. Functions that need more than Queryer and Execer, e.g. ones that use a
+// transaction or Options.StatementTimeout, are not supported.
+//
+// The zero value is not usable. DB must be set.
+type SQLDB struct {
+	// DB is the wrapped handle.
+	DB SQLQueryer
+}
+
+// Query implements Queryer. Like pgx, it returns rows that carry the error even if the query fails.
+func (s *SQLDB) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	rows, err := s.DB.QueryContext(ctx, sql, sqlArgs(args)...)
+	if err != nil {
+		return &sqlRows{err: err, closed: true}, err
+	}
+
+	fields, err := sqlFieldDescriptions(rows)
+	if err != nil {
+		rows.Close()
+		return &sqlRows{err: err, closed: true}, err
+	}
+
+	return &sqlRows{rows: rows, fields: fields}, nil
+}
+
+// Exec implements Execer. The command tag is built from the first keyword of sql and the number of rows affected, so
+// only RowsAffected is meaningful.
+func (s *SQLDB) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	result, err := s.DB.ExecContext(ctx, sql, sqlArgs(args)...)
+	if err != nil {
+		return nil, err
+	}
+
+	command := strings.ToUpper(leadingKeyword(sql))
+	n, err := result.RowsAffected()
+	if err != nil {
+		return pgconn.CommandTag(command), nil
+	}
+	if command == "INSERT" {
+		command += " 0"
+	}
+
+	return pgconn.CommandTag(fmt.Sprintf("%s %d", command, n)), nil
+}
+
+// sqlArgs returns args without the pgx query options that database/sql drivers do not accept.
+func sqlArgs(args []interface{}) []interface{} {
+	filtered := make([]interface{}, 0, len(args))
+	for _, arg := range args {
+		switch arg.(type) {
+		case pgx.QueryResultFormats, pgx.QueryResultFormatsByOID, pgx.QuerySimpleProtocol:
+		default:
+			filtered = append(filtered, arg)
+		}
+	}
+	return filtered
+}
+
+// sqlConnInfo is only used to look up the OIDs of the built-in types by name.
+var sqlConnInfo = pgtype.NewConnInfo()
+
+func sqlFieldDescriptions(rows *sql.Rows) ([]pgproto3.FieldDescription, error) {
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make([]pgproto3.FieldDescription, len(columnTypes))
+	for i, ct := range columnTypes {
+		fields[i] = pgproto3.FieldDescription{Name: []byte(ct.Name()), DataTypeSize: -1, Format: pgx.TextFormatCode}
+		if dt, ok := sqlConnInfo.DataTypeForName(strings.ToLower(ct.DatabaseTypeName())); ok {
+			fields[i].DataTypeOID = dt.OID
+		}
+	}
+
+	return fields, nil
+}
+
+// sqlRows implements pgx.Rows for *sql.Rows. Scan is delegated to database/sql. RawValues are derived from the values
+// returned by the driver.
+type sqlRows struct {
+	rows   *sql.Rows
+	fields []pgproto3.FieldDescription
+	values []interface{}
+	raw    [][]byte
+	err    error
+	closed bool
+}
+
+func (r *sqlRows) Close() {
+	if r.closed {
+		return
+	}
+	r.closed = true
+
+	err := r.rows.Close()
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+func (r *sqlRows) Err() error {
+	return r.err
+}
+
+func (r *sqlRows) CommandTag() pgconn.CommandTag {
+	return nil
+}
+
+func (r *sqlRows) FieldDescriptions() []pgproto3.FieldDescription {
+	return r.fields
+}
+
+func (r *sqlRows) Next() bool {
+	if r.closed {
+		return false
+	}
+
+	if !r.rows.Next() {
+		r.err = r.rows.Err()
+		r.Close()
+		return false
+	}
+
+	r.values = make([]interface{}, len(r.fields))
+	dest := make([]interface{}, len(r.fields))
+	for i := range dest {
+		dest[i] = &r.values[i]
+	}
+	err := r.rows.Scan(dest...)
+	if err != nil {
+		r.err = err
+		r.Close()
+		return false
+	}
+
+	r.raw = make([][]byte, len(r.values))
+	for i, v := range r.values {
+		r.raw[i] = sqlRawValue(v)
+	}
+
+	return true
+}
+
+func (r *sqlRows) Scan(dest ...interface{}) error {
+	if r.closed {
+		return r.err
+	}
+	return r.rows.Scan(dest...)
+}
+
+func (r *sqlRows) Values() ([]interface{}, error) {
+	return r.values, nil
+}
+
+func (r *sqlRows) RawValues() [][]byte {
+	return r.raw
+}
+
+// sqlRawValue returns the text format of a value returned by a database/sql driver.
+func sqlRawValue(v interface{}) []byte {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return v
+	case string:
+		return []byte(v)
+	case int64:
+		return strconv.AppendInt(nil, v, 10)
+	case float64:
+		return strconv.AppendFloat(nil, v, 'g', -1, 64)
+	case bool:
+		if v {
+			return []byte("t")
+		}
+		return []byte("f")
+	case time.Time:
+		return []byte(v.Format("2006-01-02 15:04:05.999999999Z07:00"))
+	default:
+		return []byte(fmt.Sprint(v))
+	}
+}
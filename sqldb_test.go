@@ -0,0 +1,95 @@
+package pgxutil_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	_ "github.com/jackc/pgx/v4/stdlib"
+	"github.com/jackc/pgxutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLDB(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	sqlDB, err := sql.Open("pgx", fmt.Sprintf("database=%s", os.Getenv("TEST_DATABASE")))
+	require.NoError(t, err)
+	defer sqlDB.Close()
+
+	sqlTx, err := sqlDB.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	defer sqlTx.Rollback()
+
+	db := &pgxutil.SQLDB{DB: sqlTx}
+
+	_, err = pgxutil.Exec(ctx, db, "create temporary table people (id int primary key, name text not null)")
+	require.NoError(t, err)
+
+	n, err := pgxutil.InsertOnConflictDoNothing(ctx, db, "people", map[string]interface{}{"id": 1, "name": "Adam"}, []string{"id"})
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, n)
+
+	n, err = pgxutil.Update(ctx, db, "people", map[string]interface{}{"name": "Bill"}, map[string]interface{}{"id": 1})
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, n)
+
+	name, err := pgxutil.SelectString(ctx, db, "select name from people where id = :id", pgxutil.NamedArgs{"id": 1})
+	require.NoError(t, err)
+	assert.Equal(t, "Bill", name)
+
+	ids, err := pgxutil.SelectAllInt64(ctx, db, "select n from generate_series(1, 3) n")
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1, 2, 3}, ids)
+
+	type person struct {
+		ID   int32
+		Name string
+	}
+	var p person
+	err = pgxutil.SelectStruct(ctx, db, &p, "select id, name from people")
+	require.NoError(t, err)
+	assert.Equal(t, person{ID: 1, Name: "Bill"}, p)
+
+	_, err = pgxutil.SelectInt64(ctx, db, "select null::int8")
+	assert.ErrorIs(t, err, pgxutil.ErrNullValue)
+
+	_, err = pgxutil.SelectInt64(ctx, db, "select 1 where false")
+	assert.ErrorIs(t, err, pgxutil.ErrNoRows)
+}
+
+type failingSQLQueryer struct {
+	err error
+}
+
+func (f failingSQLQueryer) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return nil, f.err
+}
+
+func (f failingSQLQueryer) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return nil, f.err
+}
+
+func TestSQLDBQueryError(t *testing.T) {
+	t.Parallel()
+
+	errFailed := errors.New("failed")
+	db := &pgxutil.SQLDB{DB: failingSQLQueryer{err: errFailed}}
+
+	_, err := pgxutil.SelectInt64(context.Background(), db, "select nope")
+	assert.ErrorIs(t, err, errFailed)
+
+	_, err = pgxutil.SelectAllMap(context.Background(), db, "select nope")
+	assert.ErrorIs(t, err, errFailed)
+
+	_, err = pgxutil.Exec(context.Background(), db, "delete from nope")
+	assert.ErrorIs(t, err, errFailed)
+}
@@ -0,0 +1,87 @@
+package pgxutil
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// TableStats describes the size and maintenance state of a table as reported by pg_stat_user_tables and the object
+// size functions.
+type TableStats struct {
+	Schema string
+	Name   string
+
+	// TableSize is the size in bytes of the table including its TOAST table but not its indexes, pg_table_size.
+	TableSize int64
+
+	// IndexSize is the size in bytes of the indexes of the table, pg_indexes_size.
+	IndexSize int64
+
+	// TotalSize is TableSize plus IndexSize, pg_total_relation_size.
+	TotalSize int64
+
+	// LiveTuples and DeadTuples are the estimated numbers of live and dead rows. Dead rows are removed by vacuum.
+	LiveTuples int64
+	DeadTuples int64
+
+	// LastVacuum, LastAutovacuum, LastAnalyze, and LastAutoanalyze are when the table was last vacuumed or analyzed
+	// manually or by the autovacuum daemon. They are nil if it never was since the statistics were last reset.
+	LastVacuum      *time.Time
+	LastAutovacuum  *time.Time
+	LastAnalyze     *time.Time
+	LastAutoanalyze *time.Time
+}
+
+const tableStatsSQL = `select schemaname, relname,
+	pg_table_size(relid), pg_indexes_size(relid), pg_total_relation_size(relid),
+	n_live_tup, n_dead_tup,
+	last_vacuum, last_autovacuum, last_analyze, last_autoanalyze
+from pg_stat_user_tables`
+
+// ListTableStats returns the statistics of the tables and materialized views of all schemas other than the system
+// schemas pg_catalog, information_schema, and pg_toast. They are ordered by schema and name.
+func ListTableStats(ctx context.Context, db Queryer) ([]TableStats, error) {
+	var stats []TableStats
+	err := selectRows(ctx, db, tableStatsSQL+"\norder by schemaname, relname", nil, func(rows pgx.Rows) error {
+		s, err := scanTableStats(rows)
+		stats = append(stats, s)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// GetTableStats returns the statistics of tableName. tableName is resolved as described by TableExists. An error is
+// returned if it does not exist and ErrNoRows is returned if it is not a table, e.g. a view or a system catalog.
+func GetTableStats(ctx context.Context, db Queryer, tableName string) (*TableStats, error) {
+	var stats *TableStats
+	err := selectRows(ctx, db, tableStatsSQL+"\nwhere relid = $1::regclass", []interface{}{tableName}, func(rows pgx.Rows) error {
+		s, err := scanTableStats(rows)
+		stats = &s
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if stats == nil {
+		return nil, ErrNoRows
+	}
+
+	return stats, nil
+}
+
+func scanTableStats(rows pgx.Rows) (TableStats, error) {
+	var s TableStats
+	err := rows.Scan(
+		&s.Schema, &s.Name,
+		&s.TableSize, &s.IndexSize, &s.TotalSize,
+		&s.LiveTuples, &s.DeadTuples,
+		&s.LastVacuum, &s.LastAutovacuum, &s.LastAnalyze, &s.LastAutoanalyze,
+	)
+	return s, err
+}
@@ -0,0 +1,56 @@
+package pgxutil_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgxutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListTableStats(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		createIntrospectionTables(t, ctx, tx)
+
+		stats, err := pgxutil.ListTableStats(ctx, tx)
+		require.NoError(t, err)
+
+		var found []string
+		for _, s := range stats {
+			assert.NotEqual(t, "pg_catalog", s.Schema)
+			if s.Schema == "pgxutil_introspection" {
+				found = append(found, s.Name)
+			}
+		}
+		assert.Equal(t, []string{"widgets"}, found)
+	})
+}
+
+func TestGetTableStats(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		createIntrospectionTables(t, ctx, tx)
+
+		_, err := tx.Exec(ctx, "insert into pgxutil_introspection.widgets (name) select n::text from generate_series(1, 100) n")
+		require.NoError(t, err)
+
+		stats, err := pgxutil.GetTableStats(ctx, tx, "pgxutil_introspection.widgets")
+		require.NoError(t, err)
+		assert.Equal(t, "pgxutil_introspection", stats.Schema)
+		assert.Equal(t, "widgets", stats.Name)
+		assert.Greater(t, stats.TableSize, int64(0))
+		assert.Greater(t, stats.IndexSize, int64(0))
+		assert.Equal(t, stats.TableSize+stats.IndexSize, stats.TotalSize)
+		assert.Nil(t, stats.LastVacuum)
+		assert.Nil(t, stats.LastAnalyze)
+
+		_, err = pgxutil.GetTableStats(ctx, tx, "pgxutil_introspection.widget_names")
+		assert.ErrorIs(t, err, pgxutil.ErrNoRows)
+
+		_, err = pgxutil.GetTableStats(ctx, tx, "pgxutil_introspection.missing")
+		assert.Error(t, err)
+	})
+}
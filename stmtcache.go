@@ -0,0 +1,142 @@
+package pgxutil
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgconn/stmtcache"
+	"github.com/jackc/pgx/v4"
+)
+
+// StatementCacheStats records the hits and misses of statement caches built by BuildStatementCache. It is safe for
+// concurrent use so a single StatementCacheStats can be shared by all the connections in a pool.
+type StatementCacheStats struct {
+	hits   int64
+	misses int64
+}
+
+// Hits returns the number of times a statement was found in the cache.
+func (s *StatementCacheStats) Hits() int64 {
+	return atomic.LoadInt64(&s.hits)
+}
+
+// Misses returns the number of times a statement was not found in the cache and had to be prepared.
+func (s *StatementCacheStats) Misses() int64 {
+	return atomic.LoadInt64(&s.misses)
+}
+
+// BuildStatementCache returns a function that builds a least recently used statement cache of capacity statements for
+// each connection and records its hits and misses in stats. mode is stmtcache.ModePrepare or stmtcache.ModeDescribe.
+// An error is returned if mode is neither, capacity is less than 1, or stats is nil. Assign the result to
+// pgx.ConnConfig.BuildStatementCache.
+//
+// pgx caches prepared statements by SQL text by default, so the Select functions already reuse prepared statements
+// when called repeatedly with the same SQL. BuildStatementCache is only needed to observe the cache.
+func BuildStatementCache(mode int, capacity int, stats *StatementCacheStats) (pgx.BuildStatementCacheFunc, error) {
+	if mode != stmtcache.ModePrepare && mode != stmtcache.ModeDescribe {
+		return nil, fmt.Errorf("mode must be stmtcache.ModePrepare or stmtcache.ModeDescribe")
+	}
+	if capacity < 1 {
+		return nil, fmt.Errorf("capacity must be at least 1")
+	}
+	if stats == nil {
+		return nil, fmt.Errorf("stats must not be nil")
+	}
+
+	return func(conn *pgconn.PgConn) stmtcache.Cache {
+		return &statementCache{
+			conn:     conn,
+			mode:     mode,
+			capacity: capacity,
+			stats:    stats,
+			m:        make(map[string]*list.Element),
+			l:        list.New(),
+		}
+	}, nil
+}
+
+var statementCacheCount uint64
+
+// statementCache is a least recently used stmtcache.Cache that records hits and misses.
+type statementCache struct {
+	conn         *pgconn.PgConn
+	mode         int
+	capacity     int
+	stats        *StatementCacheStats
+	m            map[string]*list.Element
+	l            *list.List
+	namePrefix   string
+	prepareCount int
+}
+
+func (c *statementCache) Get(ctx context.Context, sql string) (*pgconn.StatementDescription, error) {
+	if el, ok := c.m[sql]; ok {
+		atomic.AddInt64(&c.stats.hits, 1)
+		c.l.MoveToFront(el)
+		return el.Value.(*pgconn.StatementDescription), nil
+	}
+
+	atomic.AddInt64(&c.stats.misses, 1)
+
+	if c.l.Len() == c.capacity {
+		err := c.removeOldest(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var name string
+	if c.mode == stmtcache.ModePrepare {
+		if c.namePrefix == "" {
+			c.namePrefix = fmt.Sprintf("pgxutil_psc_%d", atomic.AddUint64(&statementCacheCount, 1))
+		}
+		name = fmt.Sprintf("%s_%d", c.namePrefix, c.prepareCount)
+		c.prepareCount++
+	}
+
+	psd, err := c.conn.Prepare(ctx, name, sql, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	c.m[sql] = c.l.PushFront(psd)
+
+	return psd, nil
+}
+
+func (c *statementCache) Clear(ctx context.Context) error {
+	for c.l.Len() > 0 {
+		err := c.removeOldest(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *statementCache) Len() int {
+	return c.l.Len()
+}
+
+func (c *statementCache) Cap() int {
+	return c.capacity
+}
+
+func (c *statementCache) Mode() int {
+	return c.mode
+}
+
+func (c *statementCache) removeOldest(ctx context.Context) error {
+	oldest := c.l.Back()
+	c.l.Remove(oldest)
+	psd := oldest.Value.(*pgconn.StatementDescription)
+	delete(c.m, psd.SQL)
+	if c.mode == stmtcache.ModePrepare {
+		return c.conn.Exec(ctx, "deallocate "+pgx.Identifier{psd.Name}.Sanitize()).Close()
+	}
+	return nil
+}
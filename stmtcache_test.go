@@ -0,0 +1,68 @@
+package pgxutil_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgconn/stmtcache"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgxutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildStatementCache(t *testing.T) {
+	t.Parallel()
+
+	for _, mode := range []int{stmtcache.ModePrepare, stmtcache.ModeDescribe} {
+		func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			config, err := pgx.ParseConfig(fmt.Sprintf("database=%s", os.Getenv("TEST_DATABASE")))
+			require.NoError(t, err)
+
+			stats := &pgxutil.StatementCacheStats{}
+			config.BuildStatementCache, err = pgxutil.BuildStatementCache(mode, 2, stats)
+			require.NoError(t, err)
+
+			conn, err := pgx.ConnectConfig(ctx, config)
+			require.NoError(t, err)
+			defer closeConn(t, conn)
+
+			for i := 0; i < 3; i++ {
+				n, err := pgxutil.SelectInt64(ctx, conn, "select $1::int8", i)
+				require.NoErrorf(t, err, "mode %d", mode)
+				assert.EqualValuesf(t, i, n, "mode %d", mode)
+			}
+			assert.EqualValuesf(t, 1, stats.Misses(), "mode %d", mode)
+			assert.EqualValuesf(t, 2, stats.Hits(), "mode %d", mode)
+
+			for _, sql := range []string{"select 1::int8", "select 2::int8", "select 3::int8"} {
+				_, err := pgxutil.SelectInt64(ctx, conn, sql)
+				require.NoErrorf(t, err, "mode %d", mode)
+			}
+			_, err = pgxutil.SelectInt64(ctx, conn, "select $1::int8", 0)
+			require.NoErrorf(t, err, "mode %d", mode)
+
+			assert.EqualValuesf(t, 5, stats.Misses(), "mode %d", mode)
+			assert.EqualValuesf(t, 2, stats.Hits(), "mode %d", mode)
+			assert.Equalf(t, 2, conn.StatementCache().Len(), "mode %d", mode)
+		}()
+	}
+}
+
+func TestBuildStatementCacheInvalid(t *testing.T) {
+	t.Parallel()
+
+	stats := &pgxutil.StatementCacheStats{}
+	_, err := pgxutil.BuildStatementCache(42, 2, stats)
+	assert.EqualError(t, err, "mode must be stmtcache.ModePrepare or stmtcache.ModeDescribe")
+	_, err = pgxutil.BuildStatementCache(stmtcache.ModePrepare, 0, stats)
+	assert.EqualError(t, err, "capacity must be at least 1")
+	_, err = pgxutil.BuildStatementCache(stmtcache.ModePrepare, 2, nil)
+	assert.EqualError(t, err, "stats must not be nil")
+}
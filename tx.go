@@ -0,0 +1,115 @@
+package pgxutil
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+)
+
+// TxBeginner is the interface used by functions that start a transaction with specific options. It is implemented by
+// *pgx.Conn and *pgxpool.Pool.
+type TxBeginner interface {
+	BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error)
+}
+
+// WithTx begins a transaction on db and calls fn with it. If fn returns nil the transaction is committed. If fn
+// returns an error or panics the transaction is rolled back. A panic is re-raised after the rollback.
+//
+// If db is a pgx.Tx a savepoint is used instead of a new transaction. This allows functions that need transactional
+// semantics to call WithTx regardless of whether their caller has already started a transaction. Rolling back the
+// savepoint only undoes the changes made by fn.
+func WithTx(ctx context.Context, db Beginner, fn func(pgx.Tx) error) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	return finishTx(ctx, tx, fn)
+}
+
+// WithSavepoint calls fn in a savepoint of tx. If fn returns nil the savepoint is released. If fn returns an error or
+// panics only the changes made by fn are rolled back and tx remains usable. The error is returned so the caller can
+// decide whether to continue, e.g. ignoring a unique violation of a best-effort insert:
+//
+//	err := pgxutil.WithSavepoint(ctx, tx, func(tx pgx.Tx) error {
+//		_, err := pgxutil.Insert(ctx, tx, "audit_log", entry)
+//		return err
+//	})
+//	if err != nil && !pgxutil.IsUniqueViolation(err) {
+//		return err
+//	}
+//
+// This is the same as WithTx when it is called with a pgx.Tx. WithSavepoint makes the intent explicit and cannot start
+// a new transaction by mistake.
+func WithSavepoint(ctx context.Context, tx pgx.Tx, fn func(pgx.Tx) error) error {
+	savepoint, err := tx.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	return finishTx(ctx, savepoint, fn)
+}
+
+// finishTx calls fn with tx and then commits or rolls back tx as described by WithTx.
+func finishTx(ctx context.Context, tx pgx.Tx, fn func(pgx.Tx) error) error {
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback(ctx)
+			panic(p)
+		}
+	}()
+
+	err := fn(tx)
+	if err != nil {
+		tx.Rollback(ctx)
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// WithTxRetry is like WithTx except the transaction is started with txOptions and the entire transaction is retried
+// when PostgreSQL reports a serialization failure (40001) or a deadlock (40P01). fn is called at most maxAttempts
+// times. There is a randomized, increasing delay between attempts. fn must be safe to call multiple times.
+func WithTxRetry(ctx context.Context, db TxBeginner, txOptions pgx.TxOptions, maxAttempts int, fn func(pgx.Tx) error) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		var tx pgx.Tx
+		tx, err = db.BeginTx(ctx, txOptions)
+		if err != nil {
+			return err
+		}
+
+		err = finishTx(ctx, tx, fn)
+		if err == nil || !isRetryableTxError(err) || attempt >= maxAttempts {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(retryDelay(attempt)):
+		}
+	}
+}
+
+func isRetryableTxError(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == "40001" || pgErr.Code == "40P01"
+	}
+	return false
+}
+
+// retryDelay returns a random duration between 0 and an exponentially increasing maximum delay for attempt.
+func retryDelay(attempt int) time.Duration {
+	maxDelay := 10 * time.Millisecond
+	for i := 1; i < attempt && maxDelay < time.Second; i++ {
+		maxDelay *= 2
+	}
+	return time.Duration(rand.Int63n(int64(maxDelay)))
+}
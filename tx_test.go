@@ -0,0 +1,157 @@
+package pgxutil_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgxutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithTx(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		_, err := tx.Exec(ctx, `create temporary table t (id serial primary key, name text)`)
+		require.NoError(t, err)
+
+		err = pgxutil.WithTx(ctx, tx, func(tx pgx.Tx) error {
+			_, err := pgxutil.Insert(ctx, tx, "t", map[string]interface{}{"name": "Adam"})
+			return err
+		})
+		require.NoError(t, err)
+
+		errRollback := errors.New("rollback")
+		err = pgxutil.WithTx(ctx, tx, func(tx pgx.Tx) error {
+			_, err := pgxutil.Insert(ctx, tx, "t", map[string]interface{}{"name": "Bill"})
+			require.NoError(t, err)
+			return errRollback
+		})
+		assert.Equal(t, errRollback, err)
+
+		assert.PanicsWithValue(t, "boom", func() {
+			pgxutil.WithTx(ctx, tx, func(tx pgx.Tx) error {
+				_, err := pgxutil.Insert(ctx, tx, "t", map[string]interface{}{"name": "Charlie"})
+				require.NoError(t, err)
+				panic("boom")
+			})
+		})
+
+		names, err := pgxutil.SelectAllString(ctx, tx, "select name from t order by id")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Adam"}, names)
+	})
+}
+
+func TestWithSavepoint(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		_, err := tx.Exec(ctx, `create temporary table t (id int primary key, name text)`)
+		require.NoError(t, err)
+
+		err = pgxutil.WithSavepoint(ctx, tx, func(tx pgx.Tx) error {
+			_, err := pgxutil.Insert(ctx, tx, "t", map[string]interface{}{"id": 1, "name": "Adam"})
+			return err
+		})
+		require.NoError(t, err)
+
+		err = pgxutil.WithSavepoint(ctx, tx, func(tx pgx.Tx) error {
+			_, err := pgxutil.Insert(ctx, tx, "t", map[string]interface{}{"id": 2, "name": "Bill"})
+			require.NoError(t, err)
+			_, err = pgxutil.Insert(ctx, tx, "t", map[string]interface{}{"id": 1, "name": "Charlie"})
+			return err
+		})
+		assert.True(t, pgxutil.IsUniqueViolation(err))
+
+		assert.PanicsWithValue(t, "boom", func() {
+			pgxutil.WithSavepoint(ctx, tx, func(tx pgx.Tx) error {
+				_, err := pgxutil.Insert(ctx, tx, "t", map[string]interface{}{"id": 3, "name": "Dave"})
+				require.NoError(t, err)
+				panic("boom")
+			})
+		})
+
+		_, err = pgxutil.Insert(ctx, tx, "t", map[string]interface{}{"id": 4, "name": "Eve"})
+		require.NoError(t, err)
+
+		names, err := pgxutil.SelectAllString(ctx, tx, "select name from t order by id")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Adam", "Eve"}, names)
+	})
+}
+
+func TestWithTxNested(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		_, err := tx.Exec(ctx, `create temporary table t (id serial primary key, name text)`)
+		require.NoError(t, err)
+
+		insertName := func(db pgxutil.Beginner, name string, fail bool) error {
+			return pgxutil.WithTx(ctx, db, func(tx pgx.Tx) error {
+				_, err := pgxutil.Insert(ctx, tx, "t", map[string]interface{}{"name": name})
+				if err != nil {
+					return err
+				}
+				if fail {
+					return errors.New("fail")
+				}
+				return nil
+			})
+		}
+
+		err = pgxutil.WithTx(ctx, tx, func(tx pgx.Tx) error {
+			require.NoError(t, insertName(tx, "Adam", false))
+			require.Error(t, insertName(tx, "Bill", true))
+			return insertName(tx, "Charlie", false)
+		})
+		require.NoError(t, err)
+
+		names, err := pgxutil.SelectAllString(ctx, tx, "select name from t order by id")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Adam", "Charlie"}, names)
+	})
+}
+
+func TestWithTxRetry(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	conn := connectPG(t, ctx)
+	defer closeConn(t, conn)
+
+	attempts := 0
+	err := pgxutil.WithTxRetry(ctx, conn, pgx.TxOptions{IsoLevel: pgx.Serializable}, 3, func(tx pgx.Tx) error {
+		attempts++
+		if attempts < 3 {
+			_, err := tx.Exec(ctx, `do $$ begin raise exception 'serialization failure' using errcode = 'serialization_failure'; end $$`)
+			return err
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, attempts)
+
+	attempts = 0
+	err = pgxutil.WithTxRetry(ctx, conn, pgx.TxOptions{}, 2, func(tx pgx.Tx) error {
+		attempts++
+		_, err := tx.Exec(ctx, `do $$ begin raise exception 'deadlock' using errcode = 'deadlock_detected'; end $$`)
+		return err
+	})
+	var pgErr *pgconn.PgError
+	require.True(t, errors.As(err, &pgErr))
+	assert.Equal(t, "40P01", pgErr.Code)
+	assert.Equal(t, 2, attempts)
+
+	attempts = 0
+	err = pgxutil.WithTxRetry(ctx, conn, pgx.TxOptions{}, 3, func(tx pgx.Tx) error {
+		attempts++
+		return errors.New("not retryable")
+	})
+	assert.EqualError(t, err, "not retryable")
+	assert.Equal(t, 1, attempts)
+}
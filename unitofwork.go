@@ -0,0 +1,129 @@
+package pgxutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// ErrUnitOfWorkRolledBack is returned by the Commit of the outermost UnitOfWork when a nested UnitOfWork was rolled
+// back. The transaction is rolled back instead of committed.
+var ErrUnitOfWorkRolledBack = errors.New("unit of work was rolled back by a nested unit of work")
+
+type unitOfWorkContextKey struct{}
+
+// unitOfWorkState is shared by a UnitOfWork and the nested units of work that joined it.
+type unitOfWorkState struct {
+	tx           pgx.Tx
+	rollbackOnly bool
+}
+
+// UnitOfWork is a transaction shared by all the code that runs with its context. Unlike WithContextTx, nested units
+// of work do not use savepoints. They join the outermost unit of work, whose Commit or Rollback decides the outcome
+// for all of them. The usual pattern is:
+//
+//	ctx, uow, err := pgxutil.BeginUnitOfWork(ctx)
+//	if err != nil {
+//		return err
+//	}
+//	defer uow.Rollback(ctx)
+//
+//	// ... call repositories that use pgxutil.FromContext(ctx) or begin their own units of work ...
+//
+//	return uow.Commit(ctx)
+//
+// A UnitOfWork must not be used concurrently.
+type UnitOfWork struct {
+	state  *unitOfWorkState
+	nested bool
+	done   bool
+}
+
+// BeginUnitOfWork begins a unit of work and returns a copy of ctx that carries it. If ctx already carries a unit of
+// work the new one joins it. Otherwise, a transaction is begun on the handle attached to ctx with NewContext and the
+// returned context carries the transaction for FromContext.
+func BeginUnitOfWork(ctx context.Context) (context.Context, *UnitOfWork, error) {
+	if state, ok := ctx.Value(unitOfWorkContextKey{}).(*unitOfWorkState); ok {
+		return ctx, &UnitOfWork{state: state, nested: true}, nil
+	}
+
+	db, ok := FromContext(ctx)
+	if !ok {
+		return nil, nil, fmt.Errorf("context does not carry a Handle, which is required for BeginUnitOfWork")
+	}
+
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	state := &unitOfWorkState{tx: tx}
+	ctx = context.WithValue(NewContext(ctx, tx), unitOfWorkContextKey{}, state)
+	return ctx, &UnitOfWork{state: state}, nil
+}
+
+// Commit commits the unit of work. For a nested unit of work it does nothing. For the outermost unit of work it
+// commits the transaction, unless a nested unit of work was rolled back. Then the transaction is rolled back and
+// ErrUnitOfWorkRolledBack is returned.
+func (u *UnitOfWork) Commit(ctx context.Context) error {
+	if u.done {
+		return fmt.Errorf("unit of work is already finished")
+	}
+	u.done = true
+
+	if u.nested {
+		return nil
+	}
+
+	if u.state.rollbackOnly {
+		err := u.state.tx.Rollback(ctx)
+		if err != nil {
+			return err
+		}
+		return ErrUnitOfWorkRolledBack
+	}
+
+	return u.state.tx.Commit(ctx)
+}
+
+// Rollback rolls back the unit of work. For a nested unit of work it marks the outermost unit of work so that it is
+// rolled back when it finishes. Rollback does nothing if Commit or Rollback was already called, so it can be deferred.
+func (u *UnitOfWork) Rollback(ctx context.Context) error {
+	if u.done {
+		return nil
+	}
+	u.done = true
+
+	u.state.rollbackOnly = true
+	if u.nested {
+		return nil
+	}
+
+	return u.state.tx.Rollback(ctx)
+}
+
+// RunUnitOfWork calls fn in a unit of work as described by BeginUnitOfWork. If fn returns nil the unit of work is
+// committed. If fn returns an error or panics it is rolled back. A panic is re-raised after the rollback.
+func RunUnitOfWork(ctx context.Context, fn func(ctx context.Context) error) error {
+	ctx, uow, err := BeginUnitOfWork(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			uow.Rollback(ctx)
+			panic(p)
+		}
+	}()
+
+	err = fn(ctx)
+	if err != nil {
+		uow.Rollback(ctx)
+		return err
+	}
+
+	return uow.Commit(ctx)
+}
@@ -0,0 +1,73 @@
+package pgxutil_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgxutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnitOfWork(t *testing.T) {
+	t.Parallel()
+	withTx(t, func(ctx context.Context, tx pgx.Tx) {
+		_, err := tx.Exec(ctx, "create temporary table t (id int primary key)")
+		require.NoError(t, err)
+
+		ctx = pgxutil.NewContext(ctx, tx)
+
+		insert := func(ctx context.Context, id int) error {
+			return pgxutil.RunUnitOfWork(ctx, func(ctx context.Context) error {
+				db, _ := pgxutil.FromContext(ctx)
+				_, err := db.Exec(ctx, "insert into t values ($1)", id)
+				return err
+			})
+		}
+
+		selectIDs := func() []int64 {
+			ids, err := pgxutil.SelectAllInt64(ctx, tx, "select id from t order by id")
+			require.NoError(t, err)
+			return ids
+		}
+
+		// Nested units of work join the outer one.
+		uowCtx, uow, err := pgxutil.BeginUnitOfWork(ctx)
+		require.NoError(t, err)
+		require.NoError(t, insert(uowCtx, 1))
+		require.NoError(t, insert(uowCtx, 2))
+		require.NoError(t, uow.Commit(uowCtx))
+		require.NoError(t, uow.Rollback(uowCtx))
+		assert.Equal(t, []int64{1, 2}, selectIDs())
+
+		require.EqualError(t, uow.Commit(uowCtx), "unit of work is already finished")
+
+		// A failed nested unit of work rolls back the outer one.
+		uowCtx, uow, err = pgxutil.BeginUnitOfWork(ctx)
+		require.NoError(t, err)
+		require.NoError(t, insert(uowCtx, 3))
+		err = pgxutil.RunUnitOfWork(uowCtx, func(ctx context.Context) error {
+			return errors.New("failed")
+		})
+		require.EqualError(t, err, "failed")
+		require.NoError(t, insert(uowCtx, 4))
+		assert.ErrorIs(t, uow.Commit(uowCtx), pgxutil.ErrUnitOfWorkRolledBack)
+		assert.Equal(t, []int64{1, 2}, selectIDs())
+
+		// Rollback of the outer unit of work.
+		uowCtx, uow, err = pgxutil.BeginUnitOfWork(ctx)
+		require.NoError(t, err)
+		require.NoError(t, insert(uowCtx, 5))
+		require.NoError(t, uow.Rollback(uowCtx))
+		assert.Equal(t, []int64{1, 2}, selectIDs())
+	})
+}
+
+func TestUnitOfWorkRequiresHandle(t *testing.T) {
+	t.Parallel()
+
+	_, _, err := pgxutil.BeginUnitOfWork(context.Background())
+	require.EqualError(t, err, "context does not carry a Handle, which is required for BeginUnitOfWork")
+}
@@ -0,0 +1,59 @@
+package pgxutil
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// WarmUp opens n connections of pool and prepares statements on each of them so the first queries after a deploy do
+// not pay for connecting and planning. If n is zero or more than the MaxConns of pool MaxConns is used. The n
+// connections are held at the same time so each one is a separate connection, which leaves no connections for other
+// queries while WarmUp runs if n is MaxConns. They are released when WarmUp returns, including when it fails.
+// statements may be nil to only open the connections.
+//
+// See PrepareStatements for how the statements are used. Connections opened later, e.g. to replace ones closed by
+// MaxConnLifetime, do not have them prepared unless PrepareStatements is also called from pgxpool.Config.AfterConnect.
+func WarmUp(ctx context.Context, pool *pgxpool.Pool, n int, statements []string) error {
+	if maxConns := int(pool.Config().MaxConns); n == 0 || n > maxConns {
+		n = maxConns
+	}
+
+	conns := make([]*pgxpool.Conn, 0, n)
+	defer func() {
+		for _, c := range conns {
+			c.Release()
+		}
+	}()
+
+	for i := 0; i < n; i++ {
+		c, err := pool.Acquire(ctx)
+		if err != nil {
+			return err
+		}
+		conns = append(conns, c)
+
+		err = PrepareStatements(ctx, c.Conn(), statements)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// PrepareStatements prepares statements on conn using the SQL text as the name. pgx uses a prepared statement whose
+// name is the SQL text of a query instead of its statement cache, so the Select functions, Exec, and every other
+// function that sends exactly that SQL use them regardless of the statement cache settings of the connection.
+// Statements that are already prepared are skipped.
+func PrepareStatements(ctx context.Context, conn *pgx.Conn, statements []string) error {
+	for _, sql := range statements {
+		_, err := conn.Prepare(ctx, sql, sql)
+		if err != nil {
+			return newQueryError(ctx, sql, nil, err)
+		}
+	}
+
+	return nil
+}
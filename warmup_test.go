@@ -0,0 +1,55 @@
+package pgxutil_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/jackc/pgxutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWarmUp(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	config, err := pgxpool.ParseConfig(fmt.Sprintf("database=%s", os.Getenv("TEST_DATABASE")))
+	require.NoError(t, err)
+	config.MaxConns = 3
+
+	pool, err := pgxpool.ConnectConfig(ctx, config)
+	require.NoError(t, err)
+	defer pool.Close()
+
+	const sql = "select $1::int8 + 1"
+	err = pgxutil.WarmUp(ctx, pool, 2, []string{sql})
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, pool.Stat().TotalConns())
+
+	err = pgxutil.WarmUp(ctx, pool, 0, []string{sql})
+	require.NoError(t, err)
+
+	conns := pool.AcquireAllIdle(ctx)
+	require.Len(t, conns, 3)
+	for _, c := range conns {
+		prepared, err := pgxutil.SelectBool(ctx, c, "select exists(select 1 from pg_prepared_statements where name = $1)", sql)
+		c.Release()
+		require.NoError(t, err)
+		assert.True(t, prepared)
+	}
+
+	n, err := pgxutil.SelectInt64(ctx, pool, sql, 41)
+	require.NoError(t, err)
+	assert.EqualValues(t, 42, n)
+
+	err = pgxutil.WarmUp(ctx, pool, 10, []string{"selec 1"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `query "selec 1"`)
+	assert.EqualValues(t, 0, pool.Stat().AcquiredConns())
+}